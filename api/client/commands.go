@@ -601,14 +601,16 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 }
 
 func (cli *DockerCli) CmdStop(args ...string) error {
-	cmd := cli.Subcmd("stop", "CONTAINER [CONTAINER...]", "Stop a running container by sending SIGTERM and then SIGKILL after a grace period", true)
-	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Number of seconds to wait for the container to stop before killing it. Default is 10 seconds.")
+	cmd := cli.Subcmd("stop", "CONTAINER [CONTAINER...]", "Stop a running container by sending its stop signal (SIGTERM by default) and then SIGKILL after a grace period", true)
+	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Number of seconds to wait for the container to stop before killing it. Default is the container's --stop-timeout, 10 seconds unless set.")
 	cmd.Require(flag.Min, 1)
 
 	utils.ParseFlags(cmd, args, true)
 
 	v := url.Values{}
-	v.Set("t", strconv.Itoa(*nSeconds))
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		v.Set("t", strconv.Itoa(*nSeconds))
+	}
 
 	var encounteredError error
 	for _, name := range cmd.Args() {
@@ -625,13 +627,15 @@ func (cli *DockerCli) CmdStop(args ...string) error {
 
 func (cli *DockerCli) CmdRestart(args ...string) error {
 	cmd := cli.Subcmd("restart", "CONTAINER [CONTAINER...]", "Restart a running container", true)
-	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Number of seconds to try to stop for before killing the container. Once killed it will then be restarted. Default is 10 seconds.")
+	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Number of seconds to try to stop for before killing the container. Once killed it will then be restarted. Default is the container's --stop-timeout, 10 seconds unless set.")
 	cmd.Require(flag.Min, 1)
 
 	utils.ParseFlags(cmd, args, true)
 
 	v := url.Values{}
-	v.Set("t", strconv.Itoa(*nSeconds))
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		v.Set("t", strconv.Itoa(*nSeconds))
+	}
 
 	var encounteredError error
 	for _, name := range cmd.Args() {
@@ -838,8 +842,103 @@ func (cli *DockerCli) CmdRename(args ...string) error {
 	return nil
 }
 
+func (cli *DockerCli) CmdUpdate(args ...string) error {
+	cmd := cli.Subcmd("update", "CONTAINER", "Update the configuration of a container, right away if it is running", true)
+	flExtraHosts := opts.NewListOpts(opts.ValidateExtraHost)
+	cmd.Var(&flExtraHosts, []string{"-add-host"}, "Add a custom host-to-IP mapping (host:ip), replacing the mapping of the same host")
+	var (
+		flRestartPolicy   = cmd.String([]string{"-restart"}, "", "Restart policy to apply when the container exits (no, on-failure[:max-retry], always, unless-stopped)")
+		flRestartDelay    = cmd.Duration([]string{"-restart-delay"}, 0, "Delay before the first restart of the container (e.g. 500ms), 0 for the default of the daemon")
+		flRestartMaxDelay = cmd.Duration([]string{"-restart-max-delay"}, 0, "Maximum delay between two restarts of the container (e.g. 1m), 0 for the default of the daemon")
+		flRestartFactor   = cmd.Float64([]string{"-restart-multiplier"}, 0, "Factor the restart delay is multiplied by after each restart, 0 for the default of the daemon")
+		flRestartJitter   = cmd.Float64([]string{"-restart-jitter"}, 0, "Fraction of the restart delay randomly added or removed, from 0 to 1, 0 for the default of the daemon")
+		flRestartWindow   = cmd.Duration([]string{"-restart-reset-window"}, 0, "Run time after which the failures counted by on-failure start again from 0 (e.g. 24h)")
+		flRestartSettle   = cmd.Duration([]string{"-restart-stabilize-delay"}, 0, "Fixed delay added before each restart of the container, on top of the restart delay (e.g. 5s)")
+	)
+	cmd.Require(flag.Exact, 1)
+
+	utils.ParseFlags(cmd, args, true)
+
+	var updateRestart bool
+	for _, name := range []string{"-restart", "-restart-delay", "-restart-max-delay", "-restart-multiplier", "-restart-jitter", "-restart-reset-window", "-restart-stabilize-delay"} {
+		updateRestart = updateRestart || cmd.IsSet(name)
+	}
+	if flExtraHosts.Len() == 0 && !updateRestart {
+		cmd.Usage()
+		return nil
+	}
+	name := cmd.Arg(0)
+
+	stream, _, err := cli.call("GET", "/containers/"+name+"/json", nil, false)
+	if err != nil {
+		return err
+	}
+	env := engine.Env{}
+	if err := env.Decode(stream); err != nil {
+		return err
+	}
+
+	// the settings of the restart policy which aren't given are kept
+	if updateRestart {
+		var policy runconfig.RestartPolicy
+		if err := env.GetSubEnv("HostConfig").GetJson("RestartPolicy", &policy); err != nil {
+			return err
+		}
+		if cmd.IsSet("-restart") {
+			p, err := runconfig.ParseRestartPolicy(*flRestartPolicy)
+			if err != nil {
+				return err
+			}
+			policy.Name, policy.MaximumRetryCount = p.Name, p.MaximumRetryCount
+		}
+		if cmd.IsSet("-restart-delay") {
+			policy.Delay = int(*flRestartDelay / time.Millisecond)
+		}
+		if cmd.IsSet("-restart-max-delay") {
+			policy.MaxDelay = int(*flRestartMaxDelay / time.Millisecond)
+		}
+		if cmd.IsSet("-restart-multiplier") {
+			policy.Multiplier = *flRestartFactor
+		}
+		if cmd.IsSet("-restart-jitter") {
+			policy.Jitter = *flRestartJitter
+		}
+		if cmd.IsSet("-restart-reset-window") {
+			policy.ResetWindow = int(*flRestartWindow / time.Millisecond)
+		}
+		if cmd.IsSet("-restart-stabilize-delay") {
+			policy.StabilizeDelay = int(*flRestartSettle / time.Millisecond)
+		}
+		if _, _, err := readBody(cli.call("POST", "/containers/"+name+"/update", map[string]interface{}{"RestartPolicy": policy}, false)); err != nil {
+			return err
+		}
+	}
+	if flExtraHosts.Len() == 0 {
+		return nil
+	}
+
+	var (
+		extraHosts []string
+		added      = make(map[string]bool)
+	)
+	for _, extraHost := range flExtraHosts.GetAll() {
+		added[strings.Split(extraHost, ":")[0]] = true
+	}
+	for _, extraHost := range env.GetSubEnv("HostConfig").GetList("ExtraHosts") {
+		if !added[strings.Split(extraHost, ":")[0]] {
+			extraHosts = append(extraHosts, extraHost)
+		}
+	}
+	extraHosts = append(extraHosts, flExtraHosts.GetAll()...)
+
+	if _, _, err := readBody(cli.call("PUT", "/containers/"+name+"/hosts", map[string][]string{"ExtraHosts": extraHosts}, false)); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (cli *DockerCli) CmdInspect(args ...string) error {
-	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE [CONTAINER|IMAGE...]", "Return low-level information on a container or image", true)
+	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE|EXEC [CONTAINER|IMAGE|EXEC...]", "Return low-level information on a container, image or exec command", true)
 	tmplStr := cmd.String([]string{"f", "#format", "-format"}, "", "Format the output using the given go template.")
 	cmd.Require(flag.Min, 1)
 
@@ -863,6 +962,9 @@ func (cli *DockerCli) CmdInspect(args ...string) error {
 		obj, _, err := readBody(cli.call("GET", "/containers/"+name+"/json", nil, false))
 		if err != nil {
 			obj, _, err = readBody(cli.call("GET", "/images/"+name+"/json", nil, false))
+			if err != nil {
+				obj, _, err = readBody(cli.call("GET", "/exec/"+name+"/json", nil, false))
+			}
 			if err != nil {
 				if strings.Contains(err.Error(), "No such") {
 					fmt.Fprintf(cli.err, "Error: No such image or container: %s\n", name)
@@ -933,13 +1035,30 @@ func (cli *DockerCli) CmdTop(args ...string) error {
 	if err := procs.Decode(stream); err != nil {
 		return err
 	}
-	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-	fmt.Fprintln(w, strings.Join(procs.GetList("Titles"), "\t"))
 	processes := [][]string{}
 	if err := procs.GetJson("Processes", &processes); err != nil {
 		return err
 	}
-	for _, proc := range processes {
+	titles := procs.GetList("Titles")
+	// the exec column is only shown when an exec runs, older daemons don't
+	// send the exec IDs
+	execIDs := procs.GetList("ExecIDs")
+	showExecs := false
+	for _, id := range execIDs {
+		if id != "" {
+			showExecs = len(execIDs) == len(processes)
+			break
+		}
+	}
+	if showExecs {
+		titles = append([]string{"EXEC"}, titles...)
+	}
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+	for i, proc := range processes {
+		if showExecs {
+			proc = append([]string{utils.TruncateID(execIDs[i])}, proc...)
+		}
 		fmt.Fprintln(w, strings.Join(proc, "\t"))
 	}
 	w.Flush()
@@ -2274,7 +2393,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		}()
 	}
 
-	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "on-failure") {
+	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "unless-stopped" || hostConfig.RestartPolicy.Name == "on-failure") {
 		return ErrConflictRestartPolicyAndAutoRemove
 	}
 
@@ -2494,6 +2613,9 @@ func (cli *DockerCli) CmdLoad(args ...string) error {
 }
 
 func (cli *DockerCli) CmdExec(args ...string) error {
+	if len(args) > 0 && args[0] == "attach" {
+		return cli.execAttach(args[1:]...)
+	}
 	cmd := cli.Subcmd("exec", "CONTAINER COMMAND [ARG...]", "Run a command in a running container", true)
 
 	execConfig, err := runconfig.ParseExec(cmd, args)
@@ -2527,8 +2649,7 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 		if _, _, err := readBody(cli.call("POST", "/exec/"+execID+"/start", execConfig, false)); err != nil {
 			return err
 		}
-		// For now don't print this - wait for when we support exec wait()
-		// fmt.Fprintf(cli.out, "%s\n", execID)
+		fmt.Fprintf(cli.out, "%s\n", execID)
 		return nil
 	}
 
@@ -2580,7 +2701,7 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 		}
 	}
 
-	if execConfig.Tty && cli.isTerminalIn {
+	if execConfig.Tty && cli.isTerminalOut {
 		if err := cli.monitorTtySize(execID, true); err != nil {
 			log.Errorf("Error monitoring TTY size: %s", err)
 		}
@@ -2591,11 +2712,17 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 		return err
 	}
 
-	var status int
-	if _, status, err = getExecExitCode(cli, execID); err != nil {
+	running, status, err := getExecExitCode(cli, execID)
+	if err != nil {
 		return err
 	}
 
+	if running {
+		// detached, the ID lets the user attach again with `docker exec attach`
+		fmt.Fprintf(cli.out, "%s\n", execID)
+		return nil
+	}
+
 	if status != 0 {
 		return &utils.StatusError{StatusCode: status}
 	}
@@ -2603,6 +2730,77 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 	return nil
 }
 
+// execAttach reattaches to the streams of a running exec command
+func (cli *DockerCli) execAttach(args ...string) error {
+	var (
+		cmd     = cli.Subcmd("exec attach", "EXEC", "Attach to a running exec command", true)
+		noStdin = cmd.Bool([]string{"-no-stdin"}, false, "Do not attach STDIN")
+	)
+	cmd.Require(flag.Exact, 1)
+
+	utils.ParseFlags(cmd, args, true)
+	execID := cmd.Arg(0)
+
+	stream, _, err := cli.call("GET", "/exec/"+execID+"/json", nil, false)
+	if err != nil {
+		return err
+	}
+
+	env := engine.Env{}
+	if err := env.Decode(stream); err != nil {
+		return err
+	}
+
+	if !env.GetBool("Running") {
+		return fmt.Errorf("You cannot attach to an exec command which is not running")
+	}
+
+	var (
+		processConfig = env.GetSubEnv("ProcessConfig")
+		tty           = processConfig.GetBool("tty")
+		attachStdin   = !*noStdin && env.GetBool("OpenStdin")
+	)
+
+	if err := cli.CheckTtyInput(attachStdin, tty); err != nil {
+		return err
+	}
+
+	if tty && cli.isTerminalOut {
+		if err := cli.monitorTtySize(execID, true); err != nil {
+			log.Debugf("Error monitoring TTY size: %s", err)
+		}
+	}
+
+	var in io.ReadCloser
+
+	v := url.Values{}
+	if attachStdin {
+		v.Set("stdin", "1")
+		in = cli.in
+	}
+	v.Set("stdout", "1")
+	v.Set("stderr", "1")
+
+	stderr := cli.err
+	if tty {
+		stderr = cli.out
+	}
+
+	if err := cli.hijack("POST", "/exec/"+execID+"/attach?"+v.Encode(), tty, in, cli.out, stderr, nil, nil); err != nil {
+		return err
+	}
+
+	running, status, err := getExecExitCode(cli, execID)
+	if err != nil {
+		return err
+	}
+	if !running && status != 0 {
+		return &utils.StatusError{StatusCode: status}
+	}
+
+	return nil
+}
+
 type containerStats struct {
 	Name             string
 	CpuPercentage    float64
@@ -2762,3 +2960,163 @@ func calcuateCpuPercent(previousCpu, previousSystem uint64, v *stats.Stats) floa
 	}
 	return cpuPercent
 }
+
+func (cli *DockerCli) CmdNetwork(args ...string) error {
+	cmd := cli.Subcmd("network", "COMMAND", "Manage the user-defined networks\n\nCommands:\n    create       Create a network\n    ls           List the networks\n    rm           Remove one or more networks\n    connect      Connect a stopped container to a network\n    disconnect   Disconnect a stopped container from a network", true)
+	if len(args) == 0 {
+		cmd.Usage()
+		return nil
+	}
+	switch args[0] {
+	case "create":
+		return cli.networkCreate(args[1:]...)
+	case "ls":
+		return cli.networkList(args[1:]...)
+	case "rm":
+		return cli.networkRm(args[1:]...)
+	case "connect":
+		return cli.networkConnect("connect", args[1:]...)
+	case "disconnect":
+		return cli.networkConnect("disconnect", args[1:]...)
+	}
+	utils.ParseFlags(cmd, args, false)
+	cmd.Usage()
+	return nil
+}
+
+func (cli *DockerCli) networkCreate(args ...string) error {
+	cmd := cli.Subcmd("network create", "NAME", "Create a network, with its own bridge and subnet", true)
+	subnet := cmd.String([]string{"-subnet"}, "", "Subnet of the network in CIDR format (e.g. 10.1.0.0/24), a free one by default")
+	cmd.Require(flag.Exact, 1)
+
+	utils.ParseFlags(cmd, args, true)
+
+	config := engine.Env{}
+	config.Set("Name", cmd.Arg(0))
+	config.Set("Subnet", *subnet)
+	stream, _, err := cli.call("POST", "/networks/create", config, false)
+	if err != nil {
+		return err
+	}
+	var result engine.Env
+	if err := result.Decode(stream); err != nil {
+		return err
+	}
+	fmt.Fprintf(cli.out, "%s\n", result.Get("Id"))
+	return nil
+}
+
+func (cli *DockerCli) networkList(args ...string) error {
+	cmd := cli.Subcmd("network ls", "", "List the networks", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display numeric IDs")
+	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
+	cmd.Require(flag.Exact, 0)
+
+	utils.ParseFlags(cmd, args, true)
+
+	body, _, err := readBody(cli.call("GET", "/networks", nil, false))
+	if err != nil {
+		return err
+	}
+	outs := engine.NewTable("", 0)
+	if _, err := outs.ReadListFrom(body); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NETWORK ID\tNAME\tSUBNET\tBRIDGE\tCONTAINERS")
+	}
+	for _, out := range outs.Data {
+		id := out.Get("Id")
+		if !*noTrunc {
+			id = utils.TruncateID(id)
+		}
+		if *quiet {
+			fmt.Fprintln(w, id)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, out.Get("Name"), out.Get("Subnet"), out.Get("Bridge"), strings.Join(out.GetList("Containers"), ","))
+	}
+	w.Flush()
+	return nil
+}
+
+func (cli *DockerCli) networkRm(args ...string) error {
+	cmd := cli.Subcmd("network rm", "NETWORK [NETWORK...]", "Remove one or more networks, which no container uses", true)
+	cmd.Require(flag.Min, 1)
+
+	utils.ParseFlags(cmd, args, true)
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/networks/"+name, nil, false)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to remove one or more networks")
+		} else {
+			fmt.Fprintf(cli.out, "%s\n", name)
+		}
+	}
+	return encounteredError
+}
+
+// networkConnect connects or disconnects, depending on action, a container to
+// a network
+func (cli *DockerCli) networkConnect(action string, args ...string) error {
+	description := "Connect a stopped container to a network, instead of its current one"
+	if action == "disconnect" {
+		description = "Disconnect a stopped container from a network, back to the default bridge"
+	}
+	cmd := cli.Subcmd("network "+action, "NETWORK CONTAINER", description, true)
+	cmd.Require(flag.Exact, 2)
+
+	utils.ParseFlags(cmd, args, true)
+
+	config := engine.Env{}
+	config.Set("Container", cmd.Arg(1))
+	if _, _, err := readBody(cli.call("POST", "/networks/"+cmd.Arg(0)+"/"+action, config, false)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cli *DockerCli) CmdLink(args ...string) error {
+	cmd := cli.Subcmd("link", "COMMAND", "Manage the links of a container\n\nCommands:\n    add    Link a container to another one\n    rm     Remove a link of a container", true)
+	if len(args) == 0 {
+		cmd.Usage()
+		return nil
+	}
+	switch args[0] {
+	case "add":
+		return cli.linkContainer("add", "link", args[1:]...)
+	case "rm":
+		return cli.linkContainer("rm", "unlink", args[1:]...)
+	}
+	utils.ParseFlags(cmd, args, false)
+	cmd.Usage()
+	return nil
+}
+
+// linkContainer adds or removes, with the API endpoint, a link of a container
+func (cli *DockerCli) linkContainer(action, endpoint string, args ...string) error {
+	usage := "CONTAINER NAME:ALIAS[,ALIAS...][:hide]"
+	description := "Link a container to another one as alias, right away if it is running.\nWith :hide, the alias is hidden while the other container is stopped"
+	if action == "rm" {
+		usage = "CONTAINER NAME:ALIAS[,ALIAS...]"
+		description = "Remove the link of a container to another one as alias, right away if it is running"
+	}
+	cmd := cli.Subcmd("link "+action, usage, description, true)
+	cmd.Require(flag.Exact, 2)
+
+	utils.ParseFlags(cmd, args, true)
+
+	if _, err := opts.ValidateLink(cmd.Arg(1)); err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("link", cmd.Arg(1))
+	if _, _, err := readBody(cli.call("POST", "/containers/"+cmd.Arg(0)+"/"+endpoint+"?"+v.Encode(), nil, false)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -96,7 +96,7 @@ func checkForJson(r *http.Request) error {
 	return fmt.Errorf("Content-Type specified (%s) must be 'application/json'", ct)
 }
 
-//If we don't do this, POST method without Content-type (even with empty body) will fail
+// If we don't do this, POST method without Content-type (even with empty body) will fail
 func parseForm(r *http.Request) error {
 	if r == nil {
 		return nil
@@ -228,6 +228,36 @@ func postContainersPause(eng *engine.Engine, version version.Version, w http.Res
 	return nil
 }
 
+func postContainersCheckpoint(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	job := eng.Job("checkpoint", vars["name"])
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func postContainersRestore(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	job := eng.Job("restore", vars["name"])
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func postContainersUnpause(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -744,7 +774,9 @@ func postContainersRestart(eng *engine.Engine, version version.Version, w http.R
 		return fmt.Errorf("Missing parameter")
 	}
 	job := eng.Job("restart", vars["name"])
-	job.Setenv("t", r.Form.Get("t"))
+	if t := r.Form.Get("t"); t != "" {
+		job.Setenv("t", t)
+	}
 	if err := job.Run(); err != nil {
 		return err
 	}
@@ -770,6 +802,71 @@ func postContainerRename(eng *engine.Engine, version version.Version, w http.Res
 	return nil
 }
 
+func postContainerLink(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return linkContainers(eng, "container_link", w, r, vars)
+}
+
+func postContainerUnlink(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return linkContainers(eng, "container_unlink", w, r, vars)
+}
+
+func linkContainers(eng *engine.Engine, name string, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	child, alias, hide, err := parsers.ParseLink(r.Form.Get("link"))
+	if err != nil {
+		return err
+	}
+	job := eng.Job(name, vars["name"], child, alias)
+	job.SetenvBool("Hide", hide)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func putContainersHosts(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	job := eng.Job("container_hosts", vars["name"])
+	if err := job.DecodeEnv(r.Body); err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func postContainersUpdate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	job := eng.Job("container_update", vars["name"])
+	if err := job.DecodeEnv(r.Body); err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func deleteContainers(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -849,7 +946,9 @@ func postContainersStop(eng *engine.Engine, version version.Version, w http.Resp
 		return fmt.Errorf("Missing parameter")
 	}
 	job := eng.Job("stop", vars["name"])
-	job.Setenv("t", r.Form.Get("t"))
+	if t := r.Form.Get("t"); t != "" {
+		job.Setenv("t", t)
+	}
 	if err := job.Run(); err != nil {
 		if err.Error() == "Container already stopped" {
 			w.WriteHeader(http.StatusNotModified)
@@ -1215,6 +1314,159 @@ func postContainerExecResize(eng *engine.Engine, version version.Version, w http
 	return nil
 }
 
+func postContainerExecWait(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	var (
+		env          engine.Env
+		stdoutBuffer = bytes.NewBuffer(nil)
+		job          = eng.Job("execWait", vars["name"])
+	)
+	job.Stdout.Add(stdoutBuffer)
+	if err := job.Run(); err != nil {
+		return err
+	}
+
+	env.Set("StatusCode", engine.Tail(stdoutBuffer, 1))
+	return writeJSON(w, http.StatusOK, env)
+}
+
+func postContainerExecAttach(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	var (
+		exec         engine.Env
+		stdoutBuffer = bytes.NewBuffer(nil)
+		job          = eng.Job("execInspect", vars["name"])
+	)
+	job.Stdout.Add(stdoutBuffer)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	if err := exec.Decode(stdoutBuffer); err != nil {
+		return err
+	}
+	// fail before hijacking, the status code can't be sent afterwards
+	if !exec.GetBool("Running") {
+		return fmt.Errorf("Conflict, cannot attach to exec %s, it is not running", vars["name"])
+	}
+
+	inStream, outStream, err := hijackServer(w)
+	if err != nil {
+		return err
+	}
+	defer closeStreams(inStream, outStream)
+
+	var errStream io.Writer
+
+	if _, ok := r.Header["Upgrade"]; ok {
+		fmt.Fprintf(outStream, "HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	} else {
+		fmt.Fprintf(outStream, "HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n")
+	}
+
+	if !exec.GetSubEnv("ProcessConfig").GetBool("tty") {
+		errStream = stdcopy.NewStdWriter(outStream, stdcopy.Stderr)
+		outStream = stdcopy.NewStdWriter(outStream, stdcopy.Stdout)
+	} else {
+		errStream = outStream
+	}
+
+	job = eng.Job("execAttach", vars["name"])
+	job.Setenv("stdin", r.Form.Get("stdin"))
+	job.Setenv("stdout", r.Form.Get("stdout"))
+	job.Setenv("stderr", r.Form.Get("stderr"))
+	job.Stdin.Add(inStream)
+	job.Stdout.Add(outStream)
+	job.Stderr.Set(errStream)
+	if err := job.Run(); err != nil {
+		fmt.Fprintf(outStream, "Error attaching: %s\n", err)
+	}
+	return nil
+}
+
+func deleteExec(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := eng.Job("execRm", vars["name"]).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func getNetworksJSON(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	job := eng.Job("networkList")
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func postNetworksCreate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var (
+		config       engine.Env
+		out          engine.Env
+		stdoutBuffer = bytes.NewBuffer(nil)
+	)
+	if err := config.Decode(r.Body); err != nil {
+		return err
+	}
+	job := eng.Job("networkCreate", config.Get("Name"))
+	job.Setenv("Subnet", config.Get("Subnet"))
+	job.Stdout.Add(stdoutBuffer)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	out.Set("Id", engine.Tail(stdoutBuffer, 1))
+	return writeJSON(w, http.StatusCreated, out)
+}
+
+func postNetworksConnect(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return connectNetwork(eng, "networkConnect", w, r, vars)
+}
+
+func postNetworksDisconnect(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	return connectNetwork(eng, "networkDisconnect", w, r, vars)
+}
+
+func connectNetwork(eng *engine.Engine, name string, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var config engine.Env
+	if err := config.Decode(r.Body); err != nil {
+		return err
+	}
+	if err := eng.Job(name, vars["name"], config.Get("Container")).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func deleteNetworks(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := eng.Job("networkRm", vars["name"]).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func optionsHandler(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	w.WriteHeader(http.StatusOK)
 	return nil
@@ -1320,34 +1572,50 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, dockerVersion st
 			"/containers/{name:.*}/stats":     getContainersStats,
 			"/containers/{name:.*}/attach/ws": wsContainersAttach,
 			"/exec/{id:.*}/json":              getExecByID,
+			"/networks":                       getNetworksJSON,
 		},
 		"POST": {
-			"/auth":                         postAuth,
-			"/commit":                       postCommit,
-			"/build":                        postBuild,
-			"/images/create":                postImagesCreate,
-			"/images/load":                  postImagesLoad,
-			"/images/{name:.*}/push":        postImagesPush,
-			"/images/{name:.*}/tag":         postImagesTag,
-			"/containers/create":            postContainersCreate,
-			"/containers/{name:.*}/kill":    postContainersKill,
-			"/containers/{name:.*}/pause":   postContainersPause,
-			"/containers/{name:.*}/unpause": postContainersUnpause,
-			"/containers/{name:.*}/restart": postContainersRestart,
-			"/containers/{name:.*}/start":   postContainersStart,
-			"/containers/{name:.*}/stop":    postContainersStop,
-			"/containers/{name:.*}/wait":    postContainersWait,
-			"/containers/{name:.*}/resize":  postContainersResize,
-			"/containers/{name:.*}/attach":  postContainersAttach,
-			"/containers/{name:.*}/copy":    postContainersCopy,
-			"/containers/{name:.*}/exec":    postContainerExecCreate,
-			"/exec/{name:.*}/start":         postContainerExecStart,
-			"/exec/{name:.*}/resize":        postContainerExecResize,
-			"/containers/{name:.*}/rename":  postContainerRename,
+			"/auth":                            postAuth,
+			"/commit":                          postCommit,
+			"/build":                           postBuild,
+			"/images/create":                   postImagesCreate,
+			"/images/load":                     postImagesLoad,
+			"/images/{name:.*}/push":           postImagesPush,
+			"/images/{name:.*}/tag":            postImagesTag,
+			"/containers/create":               postContainersCreate,
+			"/containers/{name:.*}/kill":       postContainersKill,
+			"/containers/{name:.*}/pause":      postContainersPause,
+			"/containers/{name:.*}/unpause":    postContainersUnpause,
+			"/containers/{name:.*}/checkpoint": postContainersCheckpoint,
+			"/containers/{name:.*}/restore":    postContainersRestore,
+			"/containers/{name:.*}/restart":    postContainersRestart,
+			"/containers/{name:.*}/start":      postContainersStart,
+			"/containers/{name:.*}/stop":       postContainersStop,
+			"/containers/{name:.*}/wait":       postContainersWait,
+			"/containers/{name:.*}/resize":     postContainersResize,
+			"/containers/{name:.*}/attach":     postContainersAttach,
+			"/containers/{name:.*}/copy":       postContainersCopy,
+			"/containers/{name:.*}/exec":       postContainerExecCreate,
+			"/exec/{name:.*}/start":            postContainerExecStart,
+			"/exec/{name:.*}/resize":           postContainerExecResize,
+			"/exec/{name:.*}/wait":             postContainerExecWait,
+			"/exec/{name:.*}/attach":           postContainerExecAttach,
+			"/containers/{name:.*}/rename":     postContainerRename,
+			"/containers/{name:.*}/link":       postContainerLink,
+			"/containers/{name:.*}/unlink":     postContainerUnlink,
+			"/containers/{name:.*}/update":     postContainersUpdate,
+			"/networks/create":                 postNetworksCreate,
+			"/networks/{name:.*}/connect":      postNetworksConnect,
+			"/networks/{name:.*}/disconnect":   postNetworksDisconnect,
+		},
+		"PUT": {
+			"/containers/{name:.*}/hosts": putContainersHosts,
 		},
 		"DELETE": {
 			"/containers/{name:.*}": deleteContainers,
 			"/images/{name:.*}":     deleteImages,
+			"/exec/{name:.*}":       deleteExec,
+			"/networks/{name:.*}":   deleteNetworks,
 		},
 		"OPTIONS": {
 			"": optionsHandler,
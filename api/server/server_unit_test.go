@@ -14,6 +14,7 @@ import (
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/version"
+	"github.com/docker/docker/runconfig"
 )
 
 func TestGetBoolParam(t *testing.T) {
@@ -474,6 +475,69 @@ func TestDeleteContainers(t *testing.T) {
 	}
 }
 
+func TestPutContainersHosts(t *testing.T) {
+	eng := engine.New()
+	name := "foo"
+	var called bool
+	eng.Register("container_hosts", func(job *engine.Job) engine.Status {
+		called = true
+		if len(job.Args) != 1 || job.Args[0] != name {
+			t.Fatalf("name != '%s': %#v", name, job.Args)
+		}
+		if hosts := job.GetenvList("ExtraHosts"); len(hosts) != 1 || hosts[0] != "db:10.0.0.2" {
+			t.Fatalf("Unexpected extra hosts: %#v", hosts)
+		}
+		return engine.StatusOK
+	})
+	req, err := http.NewRequest("PUT", "/containers/"+name+"/hosts", toJson(map[string][]string{"ExtraHosts": {"db:10.0.0.2"}}, t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r := httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	if !called {
+		t.Fatalf("handler was not called")
+	}
+	if r.Code != http.StatusNoContent {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusNoContent)
+	}
+}
+
+func TestPostContainersUpdate(t *testing.T) {
+	eng := engine.New()
+	name := "foo"
+	var called bool
+	eng.Register("container_update", func(job *engine.Job) engine.Status {
+		called = true
+		if len(job.Args) != 1 || job.Args[0] != name {
+			t.Fatalf("name != '%s': %#v", name, job.Args)
+		}
+		var policy runconfig.RestartPolicy
+		if err := job.GetenvJson("RestartPolicy", &policy); err != nil {
+			t.Fatal(err)
+		}
+		if policy.Name != "on-failure" || policy.MaximumRetryCount != 3 {
+			t.Fatalf("Unexpected restart policy: %#v", policy)
+		}
+		return engine.StatusOK
+	})
+	body := map[string]interface{}{"RestartPolicy": runconfig.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}}
+	req, err := http.NewRequest("POST", "/containers/"+name+"/update", toJson(body, t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r := httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	if !called {
+		t.Fatalf("handler was not called")
+	}
+	if r.Code != http.StatusNoContent {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusNoContent)
+	}
+}
+
 func serveRequest(method, target string, body io.Reader, eng *engine.Engine, t *testing.T) *httptest.ResponseRecorder {
 	return serveRequestUsingVersion(method, target, api.APIVERSION, body, eng, t)
 }
@@ -133,6 +133,7 @@ complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l ipc -d 'Defa
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l link -d 'Add link to another container in the form of <name|id>:alias'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l lxc-conf -d '(lxc exec-driver only) Add custom lxc options --lxc-conf="lxc.cgroup.cpuset.cpus = 0,1"'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -s m -l memory -d 'Memory limit (format: <number><optional unit>, where unit = b, k, m or g)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l ip -d 'Container IPv4 address (e.g. 172.17.0.42), in the subnet of its network'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l mac-address -d 'Container MAC address (e.g. 92:d0:c6:0a:29:33)'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l memory-swap -d "Total memory usage (memory + swap), set '-1' to disable swap (format: <number><optional unit>, where unit = b, k, m or g)"
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l name -d 'Assign a name to the container'
@@ -142,7 +143,13 @@ complete -c docker -A -f -n '__fish_seen_subcommand_from create' -s p -l publish
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l pid -d 'Default is to create a private PID namespace for the container'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l privileged -d 'Give extended privileges to this container'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l read-only -d "Mount the container's root filesystem as read only"
-complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart -d 'Restart policy to apply when a container exits (no, on-failure[:max-retry], always)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart -d 'Restart policy to apply when a container exits (no, on-failure[:max-retry], always, unless-stopped)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-delay -d 'Delay before the first restart of the container (e.g. 500ms), 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-jitter -d 'Fraction of the restart delay randomly added or removed, from 0 to 1, 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-max-delay -d 'Maximum delay between two restarts of the container (e.g. 1m), 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-multiplier -d 'Factor the restart delay is multiplied by after each restart, 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-reset-window -d 'Run time after which the failures counted by on-failure start again from 0 (e.g. 24h)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l restart-stabilize-delay -d 'Fixed delay added before each restart of the container, on top of the restart delay (e.g. 5s)'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -l security-opt -d 'Security Options'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -s t -l tty -d 'Allocate a pseudo-TTY'
 complete -c docker -A -f -n '__fish_seen_subcommand_from create' -s u -l user -d 'Username or UID'
@@ -318,6 +325,7 @@ complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l ipc -d 'Default
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l link -d 'Add link to another container in the form of <name|id>:alias'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l lxc-conf -d '(lxc exec-driver only) Add custom lxc options --lxc-conf="lxc.cgroup.cpuset.cpus = 0,1"'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -s m -l memory -d 'Memory limit (format: <number><optional unit>, where unit = b, k, m or g)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l ip -d 'Container IPv4 address (e.g. 172.17.0.42), in the subnet of its network'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l mac-address -d 'Container MAC address (e.g. 92:d0:c6:0a:29:33)'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l memory-swap -d "Total memory usage (memory + swap), set '-1' to disable swap (format: <number><optional unit>, where unit = b, k, m or g)"
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l name -d 'Assign a name to the container'
@@ -327,7 +335,13 @@ complete -c docker -A -f -n '__fish_seen_subcommand_from run' -s p -l publish -d
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l pid -d 'Default is to create a private PID namespace for the container'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l privileged -d 'Give extended privileges to this container'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l read-only -d "Mount the container's root filesystem as read only"
-complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart -d 'Restart policy to apply when a container exits (no, on-failure[:max-retry], always)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart -d 'Restart policy to apply when a container exits (no, on-failure[:max-retry], always, unless-stopped)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-delay -d 'Delay before the first restart of the container (e.g. 500ms), 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-jitter -d 'Fraction of the restart delay randomly added or removed, from 0 to 1, 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-max-delay -d 'Maximum delay between two restarts of the container (e.g. 1m), 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-multiplier -d 'Factor the restart delay is multiplied by after each restart, 0 for the default of the daemon'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-reset-window -d 'Run time after which the failures counted by on-failure start again from 0 (e.g. 24h)'
+complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l restart-stabilize-delay -d 'Fixed delay added before each restart of the container, on top of the restart delay (e.g. 5s)'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l rm -d 'Automatically remove the container when it exits (incompatible with -d)'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l security-opt -d 'Security Options'
 complete -c docker -A -f -n '__fish_seen_subcommand_from run' -l sig-proxy -d 'Proxy received signals to the process (non-TTY mode only). SIGCHLD, SIGSTOP, and SIGKILL are not proxied.'
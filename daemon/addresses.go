@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/links"
+)
+
+// subscribeAddresses registers watcher to be called each time the addresses
+// of a container change, as it gets a new network at start, or become
+// unreachable or reachable again, as it stops or waits to be restarted and is
+// restarted. It is only called at the creation of the daemon.
+func (daemon *Daemon) subscribeAddresses(watcher func(*Container)) {
+	daemon.addressWatchers = append(daemon.addressWatchers, watcher)
+}
+
+// publishAddresses calls the watchers with container, and with the containers
+// sharing its network with --net=container:, directly or through other ones,
+// whose addresses are the ones of container. They are called synchronously,
+// so that the dependents of container are up to date once it is started.
+func (daemon *Daemon) publishAddresses(container *Container) {
+	changed := append([]*Container{container}, daemon.networkSharers(container)...)
+	for _, c := range changed {
+		for _, watcher := range daemon.addressWatchers {
+			watcher(c)
+		}
+	}
+}
+
+// networkSharers returns the containers using the network of container
+func (daemon *Daemon) networkSharers(container *Container) []*Container {
+	var sharers []*Container
+	for _, c := range daemon.List() {
+		if c != container && c.hostConfig != nil && c.hostConfig.NetworkMode.IsContainer() && c.networkOwner() == container {
+			sharers = append(sharers, c)
+		}
+	}
+	return sharers
+}
+
+// updateParentsHosts refreshes the /etc/hosts records of the links to
+// container with its current addresses. Once it is unreachable, only the
+// records of the hidden links are refreshed, the other ones keep its last
+// addresses. The containers resolving their links with the embedded DNS
+// always get the current addresses.
+func (daemon *Daemon) updateParentsHosts(container *Container) {
+	if daemon.config.DisableNetwork || !container.networkOwner().hostConfig.NetworkMode.IsPrivate() {
+		return
+	}
+	for _, ref := range daemon.ContainerGraph().RefPaths(container.ID) {
+		if ref.ParentID == "0" {
+			continue
+		}
+		c := daemon.Get(ref.ParentID)
+		if c == nil || c.usesEmbeddedDns() || c.HostsPath == "" {
+			continue
+		}
+		if !container.reachable() && !c.HiddenLinks[ref.Name] {
+			continue
+		}
+		alias := links.Alias(ref.Name)
+		log.Debugf("Update /etc/hosts of %s for alias %s of %s", c.ID, alias, container.ID)
+		// the records of both IP versions, and of the containers sharing
+		// the alias, are replaced
+		if err := c.updateLinkHosts(alias); err != nil {
+			log.Errorf("Failed to update /etc/hosts in parent container %s for alias %s: %v", c.ID, alias, err)
+		}
+	}
+}
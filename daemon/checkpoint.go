@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"github.com/docker/docker/engine"
+)
+
+func (daemon *Daemon) ContainerCheckpoint(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container := daemon.Get(name)
+	if container == nil {
+		return job.Errorf("No such container: %s", name)
+	}
+	if err := container.Checkpoint(); err != nil {
+		return job.Errorf("Cannot checkpoint container %s: %s", name, err)
+	}
+	container.LogEvent("checkpoint")
+	return engine.StatusOK
+}
+
+func (daemon *Daemon) ContainerRestore(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container := daemon.Get(name)
+	if container == nil {
+		return job.Errorf("No such container: %s", name)
+	}
+	if container.IsRunning() {
+		return job.Errorf("Container %s is already running", name)
+	}
+	if err := container.Restore(); err != nil {
+		return job.Errorf("Cannot restore container %s: %s", name, err)
+	}
+	container.LogEvent("restore")
+	return engine.StatusOK
+}
@@ -2,10 +2,12 @@ package daemon
 
 import (
 	"net"
+	"time"
 
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/ulimit"
 )
 
 const (
@@ -27,6 +29,7 @@ type Config struct {
 	EnableIptables              bool
 	EnableIpForward             bool
 	EnableIpMasq                bool
+	FirewallDriver              string
 	DefaultIp                   net.IP
 	BridgeIface                 string
 	BridgeIP                    string
@@ -36,12 +39,25 @@ type Config struct {
 	GraphDriver                 string
 	GraphOptions                []string
 	ExecDriver                  string
+	CgroupDriver                string
 	Mtu                         int
 	DisableNetwork              bool
 	EnableSelinuxSupport        bool
 	Context                     map[string][]string
 	TrustKeyPath                string
 	Labels                      []string
+	Ulimits                     map[string]*ulimit.Ulimit
+	MaxConcurrentExecs          int
+	EmbeddedDns                 bool
+	LinkEnv                     bool
+	LinkReject                  bool
+	RestartDelay                time.Duration
+	RestartMaxDelay             time.Duration
+	RestartMultiplier           float64
+	RestartJitter               float64
+	RestartHook                 string
+	LiveRestore                 bool
+	DefaultRestartPolicy        string
 }
 
 // InstallFlags adds command-line options to the top-level flag parser for
@@ -55,6 +71,7 @@ func (config *Config) InstallFlags() {
 	flag.BoolVar(&config.EnableIptables, []string{"#iptables", "-iptables"}, true, "Enable Docker's addition of iptables rules")
 	flag.BoolVar(&config.EnableIpForward, []string{"#ip-forward", "-ip-forward"}, true, "Enable net.ipv4.ip_forward and IPv6 forwarding if --fixed-cidr-v6 is defined. IPv6 forwarding may interfere with your existing IPv6 configuration when using Router Advertisement.")
 	flag.BoolVar(&config.EnableIpMasq, []string{"-ip-masq"}, true, "Enable IP masquerading for bridge's IP range")
+	flag.StringVar(&config.FirewallDriver, []string{"-firewall-driver"}, "iptables", "Set up the firewall rules of the networks with 'iptables' or 'nftables'")
 	flag.BoolVar(&config.EnableIPv6, []string{"-ipv6"}, false, "Enable IPv6 networking")
 	flag.StringVar(&config.BridgeIP, []string{"#bip", "-bip"}, "", "Use this CIDR notation address for the network bridge's IP, not compatible with -b")
 	flag.StringVar(&config.BridgeIface, []string{"b", "-bridge"}, "", "Attach containers to a pre-existing network bridge\nuse 'none' to disable container networking")
@@ -63,7 +80,19 @@ func (config *Config) InstallFlags() {
 	flag.BoolVar(&config.InterContainerCommunication, []string{"#icc", "-icc"}, true, "Allow unrestricted inter-container and Docker daemon host communication")
 	flag.StringVar(&config.GraphDriver, []string{"s", "-storage-driver"}, "", "Force the Docker runtime to use a specific storage driver")
 	flag.StringVar(&config.ExecDriver, []string{"e", "-exec-driver"}, "native", "Force the Docker runtime to use a specific exec driver")
+	flag.StringVar(&config.CgroupDriver, []string{"-cgroup-driver"}, "cgroupfs", "(lxc exec-driver only) Manage container cgroups with 'cgroupfs' or 'systemd'")
 	flag.BoolVar(&config.EnableSelinuxSupport, []string{"-selinux-enabled"}, false, "Enable selinux support. SELinux does not presently support the BTRFS storage driver")
+	flag.BoolVar(&config.EmbeddedDns, []string{"-embedded-dns"}, false, "Resolve links and container names with a DNS server of the daemon instead of /etc/hosts")
+	flag.BoolVar(&config.LinkReject, []string{"-link-reject"}, false, "Reject the connections between linked containers to the ports which are not exposed, instead of dropping them with --icc=false")
+	flag.BoolVar(&config.LinkEnv, []string{"-link-env"}, true, "Set the environment variables of the links (ALIAS_PORT_*, ALIAS_ENV_*...) in the containers")
+	flag.IntVar(&config.MaxConcurrentExecs, []string{"-max-concurrent-execs"}, 0, "Maximum number of exec instances running at once in a container, 0 for no limit")
+	flag.DurationVar(&config.RestartDelay, []string{"-restart-delay"}, defaultRestartDelay, "Default delay before the first restart of the containers with a restart policy")
+	flag.DurationVar(&config.RestartMaxDelay, []string{"-restart-max-delay"}, 0, "Default maximum delay between two restarts of the containers, 0 for no maximum")
+	flag.Float64Var(&config.RestartMultiplier, []string{"-restart-multiplier"}, defaultRestartMultiplier, "Default factor the restart delay of the containers is multiplied by after each restart")
+	flag.Float64Var(&config.RestartJitter, []string{"-restart-jitter"}, 0, "Default fraction of the restart delay of the containers randomly added or removed, from 0 to 1")
+	flag.StringVar(&config.RestartHook, []string{"-restart-hook"}, "", "Path of a program run on the host before each restart of the containers by their restart policy")
+	flag.StringVar(&config.DefaultRestartPolicy, []string{"-default-restart-policy"}, "", "Restart policy of the containers created without --restart (no, on-failure[:max-retry], always, unless-stopped)")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Leave the containers running when the daemon stops, and reattach to them when it starts again")
 	flag.IntVar(&config.Mtu, []string{"#mtu", "-mtu"}, 0, "Set the containers network MTU\nif no value is provided: default to the default route MTU or 1500 if no default route is available")
 	opts.IPVar(&config.DefaultIp, []string{"#ip", "-ip"}, "0.0.0.0", "Default IP address to use when binding container ports")
 	opts.ListVar(&config.GraphOptions, []string{"-storage-opt"}, "Set storage driver options")
@@ -71,6 +100,8 @@ func (config *Config) InstallFlags() {
 	opts.IPListVar(&config.Dns, []string{"#dns", "-dns"}, "Force Docker to use specific DNS servers")
 	opts.DnsSearchListVar(&config.DnsSearch, []string{"-dns-search"}, "Force Docker to use specific DNS search domains")
 	opts.LabelListVar(&config.Labels, []string{"-label"}, "Set key=value labels to the daemon (displayed in `docker info`)")
+	config.Ulimits = make(map[string]*ulimit.Ulimit)
+	flag.Var(opts.NewUlimitOpt(config.Ulimits), []string{"-default-ulimit"}, "Set default ulimits for containers (e.g. --default-ulimit=nofile=1024:2048)")
 }
 
 func getDefaultNetworkMtu() int {
@@ -10,7 +10,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -23,13 +26,18 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/links"
 	"github.com/docker/docker/nat"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/broadcastwriter"
+	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/networkfs/etchosts"
 	"github.com/docker/docker/pkg/networkfs/resolvconf"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/promise"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
@@ -80,7 +88,9 @@ type Container struct {
 	daemon                   *Daemon
 	MountLabel, ProcessLabel string
 	AppArmorProfile          string
+	NoNewPrivileges          bool
 	RestartCount             int
+	FailureCount             int // failures counted by the on-failure restart policy
 	UpdateDns                bool
 
 	// Maps container paths to volume paths.  The key in this is the path to which
@@ -92,9 +102,14 @@ type Container struct {
 	VolumesRW  map[string]bool
 	hostConfig *runconfig.HostConfig
 
+	// Names of the links whose alias is hidden while the linked container
+	// is stopped or waiting to be restarted
+	HiddenLinks map[string]bool
+
 	activeLinks        map[string]*links.Link
 	monitor            *containerMonitor
 	execCommands       *execStore
+	runningExecs       int32 // accessed atomically
 	AppliedVolumesFrom map[string]struct{}
 }
 
@@ -214,7 +229,18 @@ func populateCommand(c *Container, env []string) error {
 	case "none":
 	case "host":
 		en.HostNetworking = true
-	case "bridge", "": // empty string to support existing containers
+	case "container":
+		nc, err := c.getNetworkedContainer()
+		if err != nil {
+			return err
+		}
+		en.ContainerID = nc.ID
+	default:
+		// the bridge, the empty string to support existing containers, and
+		// the user-defined networks
+		if !c.hostConfig.NetworkMode.IsPrivate() {
+			return fmt.Errorf("invalid network mode: %s", c.hostConfig.NetworkMode)
+		}
 		if !c.Config.NetworkDisabled {
 			network := c.NetworkSettings
 			en.Interface = &execdriver.NetworkInterface{
@@ -229,14 +255,6 @@ func populateCommand(c *Container, env []string) error {
 				IPv6Gateway:          network.IPv6Gateway,
 			}
 		}
-	case "container":
-		nc, err := c.getNetworkedContainer()
-		if err != nil {
-			return err
-		}
-		en.ContainerID = nc.ID
-	default:
-		return fmt.Errorf("invalid network mode: %s", c.hostConfig.NetworkMode)
 	}
 
 	ipc := &execdriver.Ipc{}
@@ -254,17 +272,27 @@ func populateCommand(c *Container, env []string) error {
 	pid := &execdriver.Pid{}
 	pid.HostPid = c.hostConfig.PidMode.IsHost()
 
+	uts := &execdriver.UTS{
+		HostUTS: c.hostConfig.UTSMode.IsHost(),
+	}
+
 	// Build lists of devices allowed and created within the container.
-	userSpecifiedDevices := make([]*devices.Device, len(c.hostConfig.Devices))
-	for i, deviceMapping := range c.hostConfig.Devices {
-		device, err := devices.GetDevice(deviceMapping.PathOnHost, deviceMapping.CgroupPermissions)
+	var userSpecifiedDevices []*devices.Device
+	for _, deviceMapping := range c.hostConfig.Devices {
+		devs, err := getDevicesFromPath(deviceMapping)
 		if err != nil {
-			return fmt.Errorf("error gathering device information while adding custom device %q: %s", deviceMapping.PathOnHost, err)
+			return err
 		}
-		device.Path = deviceMapping.PathInContainer
-		userSpecifiedDevices[i] = device
+		userSpecifiedDevices = append(userSpecifiedDevices, devs...)
 	}
 	allowedDevices := append(devices.DefaultAllowedDevices, userSpecifiedDevices...)
+	for _, rule := range c.hostConfig.DeviceCgroupRules {
+		dev, err := getDeviceFromCgroupRule(rule)
+		if err != nil {
+			return err
+		}
+		allowedDevices = append(allowedDevices, dev)
+	}
 
 	autoCreatedDevices := append(devices.DefaultAutoCreatedDevices, userSpecifiedDevices...)
 
@@ -274,11 +302,22 @@ func populateCommand(c *Container, env []string) error {
 		return err
 	}
 
+	var rlimits []*ulimit.Rlimit
+	for _, ul := range mergeUlimits(c.hostConfig.Ulimits, c.daemon.config.Ulimits) {
+		rl, err := ul.GetRlimit()
+		if err != nil {
+			return err
+		}
+		rlimits = append(rlimits, rl)
+	}
+
 	resources := &execdriver.Resources{
 		Memory:     c.Config.Memory,
 		MemorySwap: c.Config.MemorySwap,
 		CpuShares:  c.Config.CpuShares,
 		Cpuset:     c.Config.Cpuset,
+		CpusetMems: c.Config.CpusetMems,
+		Rlimits:    rlimits,
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -295,12 +334,14 @@ func populateCommand(c *Container, env []string) error {
 	c.command = &execdriver.Command{
 		ID:                 c.ID,
 		Rootfs:             c.RootfsPath(),
+		Hostname:           c.fullHostname(),
 		ReadonlyRootfs:     c.hostConfig.ReadonlyRootfs,
 		InitPath:           "/.dockerinit",
 		WorkingDir:         c.Config.WorkingDir,
 		Network:            en,
 		Ipc:                ipc,
 		Pid:                pid,
+		UTS:                uts,
 		Resources:          resources,
 		AllowedDevices:     allowedDevices,
 		AutoCreatedDevices: autoCreatedDevices,
@@ -311,18 +352,126 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		NoNewPrivileges:    c.NoNewPrivileges,
+		StopSignal:         c.stopSignal(),
+		Gpus:               c.hostConfig.Gpus,
+		LiveRestore:        c.daemon.liveRestore(c),
 	}
 
 	return nil
 }
 
-func (container *Container) Start() (err error) {
+// mergeUlimits returns the container's ulimits completed with the daemon's
+// defaults for the resources the container doesn't limit itself
+func mergeUlimits(ulimits []*ulimit.Ulimit, defaults map[string]*ulimit.Ulimit) []*ulimit.Ulimit {
+	merged := append([]*ulimit.Ulimit{}, ulimits...)
+	ulIdx := make(map[string]*ulimit.Ulimit)
+	for _, ul := range ulimits {
+		ulIdx[ul.Name] = ul
+	}
+	for name, ul := range defaults {
+		if _, exists := ulIdx[name]; !exists {
+			merged = append(merged, ul)
+		}
+	}
+	return merged
+}
+
+// getDeviceFromCgroupRule returns the device allowed by a --device-cgroup-rule
+// such as "c 189:* rwm", the device node itself isn't created in the container
+func getDeviceFromCgroupRule(rule string) (*devices.Device, error) {
+	matches := opts.DeviceCgroupRuleRegexp.FindStringSubmatch(rule)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid device cgroup rule format: '%s'", rule)
+	}
+	dev := &devices.Device{
+		Type:              rune(matches[1][0]),
+		MajorNumber:       devices.Wildcard,
+		MinorNumber:       devices.Wildcard,
+		CgroupPermissions: matches[4],
+	}
+	if matches[2] != "*" {
+		major, err := strconv.ParseInt(matches[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		dev.MajorNumber = major
+	}
+	if matches[3] != "*" {
+		minor, err := strconv.ParseInt(matches[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		dev.MinorNumber = minor
+	}
+	return dev, nil
+}
+
+// getDevicesFromPath returns the devices for a --device mapping. If the host
+// path is a directory (e.g. /dev/snd) every device node found below it is
+// passed through, keeping its location relative to PathInContainer.
+func getDevicesFromPath(deviceMapping runconfig.DeviceMapping) ([]*devices.Device, error) {
+	device, err := devices.GetDevice(deviceMapping.PathOnHost, deviceMapping.CgroupPermissions)
+	if err == nil {
+		device.Path = deviceMapping.PathInContainer
+		return []*devices.Device{device}, nil
+	}
+	if err != devices.ErrNotADeviceNode {
+		return nil, fmt.Errorf("error gathering device information while adding custom device %q: %s", deviceMapping.PathOnHost, err)
+	}
+
+	var devs []*devices.Device
+	walkErr := filepath.Walk(deviceMapping.PathOnHost, func(p string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		device, err := devices.GetDevice(p, deviceMapping.CgroupPermissions)
+		if err != nil {
+			if err == devices.ErrNotADeviceNode {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(deviceMapping.PathOnHost, p)
+		if err != nil {
+			return err
+		}
+		device.Path = filepath.Join(deviceMapping.PathInContainer, rel)
+		devs = append(devs, device)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("error gathering device information while adding custom device %q: %s", deviceMapping.PathOnHost, walkErr)
+	}
+	if len(devs) == 0 {
+		return nil, fmt.Errorf("error gathering device information while adding custom device %q: %s", deviceMapping.PathOnHost, devices.ErrNotADeviceNode)
+	}
+	return devs, nil
+}
+
+func (container *Container) Start() error {
+	return container.start("")
+}
+
+// Restore starts the container again from the images of its last checkpoint
+func (container *Container) Restore() error {
+	imagesDir := container.checkpointPath()
+	if _, err := os.Stat(imagesDir); err != nil {
+		return fmt.Errorf("Container %s has no checkpoint to restore", container.ID)
+	}
+	return container.start(imagesDir)
+}
+
+// start sets up and starts the container, restoring it from the checkpoint
+// images in imagesDir instead of running its command if imagesDir is set
+func (container *Container) start(imagesDir string) (err error) {
 	container.Lock()
 	defer container.Unlock()
 
 	if container.Running {
 		return nil
 	}
+	container.StoppedByUser = false
 
 	// if we encounter and error during start we need to ensure that any other
 	// setup has been cleaned up properly
@@ -347,9 +496,7 @@ func (container *Container) Start() (err error) {
 	if err := container.initializeNetworking(); err != nil {
 		return err
 	}
-	if err := container.updateParentsHosts(); err != nil {
-		return err
-	}
+	container.daemon.publishAddresses(container)
 	container.verifyDaemonSettings()
 	if err := container.prepareVolumes(); err != nil {
 		return err
@@ -369,7 +516,65 @@ func (container *Container) Start() (err error) {
 		return err
 	}
 
-	return container.waitForStart()
+	return container.waitForStart(imagesDir, false)
+}
+
+// reattach sets the container up again around its process left running by
+// the previous daemon, and monitors the process again
+func (container *Container) reattach() (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	pid := container.Pid
+	defer func() {
+		if err != nil {
+			// the process is still running, restore would start a second
+			// one next to it
+			if pid != 0 {
+				container.daemon.killOldProcess(container, pid)
+			}
+			container.setError(err)
+			container.setStopped(&execdriver.ExitStatus{ExitCode: -1})
+			container.toDisk()
+			container.cleanup()
+		}
+	}()
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.RestoreNetwork(); err != nil {
+		return err
+	}
+	if container.usesEmbeddedDns() {
+		if err := container.setupEmbeddedDns(); err != nil {
+			return err
+		}
+	}
+	container.daemon.publishAddresses(container)
+	linkedEnv, err := container.setupLinkedContainers()
+	if err != nil {
+		return err
+	}
+	env := container.createDaemonEnvironment(linkedEnv)
+	if err := populateCommand(container, env); err != nil {
+		return err
+	}
+	if err := container.setupMounts(); err != nil {
+		return err
+	}
+
+	startedAt, paused := container.StartedAt, container.Paused
+	if err := container.waitForStart("", true); err != nil {
+		return err
+	}
+	// the process has been running, and maybe paused, since the previous
+	// daemon started it
+	container.StartedAt, container.Paused = startedAt, paused
+	if err := container.daemon.loadExecs(container); err != nil {
+		log.Errorf("Error loading the execs of %s: %s", container.ID, err)
+	}
+	return container.toDisk()
 }
 
 func (container *Container) Run() error {
@@ -404,27 +609,79 @@ func (streamConfig *StreamConfig) StdinPipe() io.WriteCloser {
 }
 
 func (streamConfig *StreamConfig) StdoutPipe() io.ReadCloser {
-	reader, writer := io.Pipe()
-	streamConfig.stdout.AddWriter(writer, "")
-	return ioutils.NewBufReader(reader)
+	return outputPipe(streamConfig.stdout, "")
 }
 
 func (streamConfig *StreamConfig) StderrPipe() io.ReadCloser {
-	reader, writer := io.Pipe()
-	streamConfig.stderr.AddWriter(writer, "")
-	return ioutils.NewBufReader(reader)
+	return outputPipe(streamConfig.stderr, "")
 }
 
 func (streamConfig *StreamConfig) StdoutLogPipe() io.ReadCloser {
-	reader, writer := io.Pipe()
-	streamConfig.stdout.AddWriter(writer, "stdout")
-	return ioutils.NewBufReader(reader)
+	return outputPipe(streamConfig.stdout, "stdout")
 }
 
 func (streamConfig *StreamConfig) StderrLogPipe() io.ReadCloser {
+	return outputPipe(streamConfig.stderr, "stderr")
+}
+
+// flushOutput flushes stdout and stderr at the same time, waiting up to
+// logFlushTimeout for their writers. It is called without any lock held, a
+// slow consumer must only hold back the output.
+func (streamConfig *StreamConfig) flushOutput(id string) {
+	var wg sync.WaitGroup
+	flush := func(w *broadcastwriter.BroadcastWriter, name string) {
+		defer wg.Done()
+		if err := w.Flush(logFlushTimeout); err != nil {
+			log.Errorf("%s: Error flushing %s: %s", id, name, err)
+		}
+	}
+	wg.Add(2)
+	go flush(streamConfig.stdout, "stdout")
+	go flush(streamConfig.stderr, "stderr")
+	wg.Wait()
+}
+
+// outputPipe returns a pipe reading the output of src for stream, which is
+// detached from src when it is closed. A consumer which doesn't keep up is
+// disconnected, so that it doesn't hold back the log or the process.
+func outputPipe(src *broadcastwriter.BroadcastWriter, stream string) io.ReadCloser {
 	reader, writer := io.Pipe()
-	streamConfig.stderr.AddWriter(writer, "stderr")
-	return ioutils.NewBufReader(reader)
+	src.AddWriter(writer, stream, broadcastwriter.DisconnectWhenFull)
+	return &detachingReader{
+		ReadCloser: ioutils.NewBufReader(reader),
+		detach:     func() { src.RemoveWriter(writer, stream) },
+	}
+}
+
+// detachingReader calls detach when it is closed
+type detachingReader struct {
+	io.ReadCloser
+	detach func()
+}
+
+func (r *detachingReader) Close() error {
+	r.detach()
+	return r.ReadCloser.Close()
+}
+
+// partialLineTimeout is how long the output of a process may hold a line
+// without a newline, such as a progress bar, before it is logged
+const partialLineTimeout = time.Second
+
+// newOutputWriter returns the broadcaster of the output named name of the
+// process id, which logs the writers it evicts. Writers of consumers who went
+// away are only logged in debug mode.
+func newOutputWriter(id, name string) *broadcastwriter.BroadcastWriter {
+	w := broadcastwriter.New()
+	w.SetPartialTimeout(partialLineTimeout)
+	w.SetEvictHandler(func(writer io.WriteCloser, stream string, err error) {
+		if err == io.ErrClosedPipe {
+			log.Debugf("%s: Detached a consumer of %s: %s", id, name, err)
+			return
+		}
+		log.Warnf("%s: Evicted a writer of %s: %s", id, name, err)
+	})
+	return w
 }
 
 func (container *Container) buildHostnameFile() error {
@@ -455,19 +712,26 @@ func (container *Container) buildHostsFiles(IP string) error {
 		return err
 	}
 
-	for linkAlias, child := range children {
-		_, alias := path.Split(linkAlias)
-		extraContent = append(extraContent, etchosts.Record{Hosts: alias, IP: child.NetworkSettings.IPAddress})
+	// with the embedded DNS, the links are resolved with their current address
+	if !container.usesEmbeddedDns() {
+		extraContent = append(extraContent, linksHostsRecords(children, "", container.HiddenLinks)...)
 	}
 
-	for _, extraHost := range container.hostConfig.ExtraHosts {
-		parts := strings.Split(extraHost, ":")
-		extraContent = append(extraContent, etchosts.Record{Hosts: parts[0], IP: parts[1]})
-	}
+	extraContent = append(extraContent, extraHostsRecords(container.hostConfig.ExtraHosts)...)
 
 	return etchosts.Build(container.HostsPath, IP, container.Config.Hostname, container.Config.Domainname, extraContent)
 }
 
+// extraHostsRecords returns the hosts records of the host:ip extra hosts
+func extraHostsRecords(extraHosts []string) []etchosts.Record {
+	records := make([]etchosts.Record, 0, len(extraHosts))
+	for _, extraHost := range extraHosts {
+		parts := strings.Split(extraHost, ":")
+		records = append(records, etchosts.Record{Hosts: parts[0], IP: parts[1]})
+	}
+	return records
+}
+
 func (container *Container) buildHostnameAndHostsFiles(IP string) error {
 	if err := container.buildHostnameFile(); err != nil {
 		return err
@@ -490,6 +754,13 @@ func (container *Container) AllocateNetwork() error {
 
 	job := eng.Job("allocate_interface", container.ID)
 	job.Setenv("RequestedMac", container.Config.MacAddress)
+	job.Setenv("RequestedIP", container.Config.IPAddress)
+	if container.Config.IPAddress == "" {
+		job.SetenvList("ReservedIPs", container.daemon.staticAddresses(container))
+	}
+	if err = container.setNetworkID(job); err != nil {
+		return err
+	}
 	if env, err = job.Stdout.AddEnv(); err != nil {
 		return err
 	}
@@ -537,11 +808,19 @@ func (container *Container) AllocateNetwork() error {
 	container.NetworkSettings.PortMapping = nil
 
 	for port := range portSpecs {
+		// the ports of a range published as a whole are mapped with it
+		if _, exists := bindings[port]; !exists && inPortRange(port, bindings) {
+			continue
+		}
 		if err = container.allocatePort(eng, port, bindings); err != nil {
 			eng.Job("release_interface", container.ID).Run()
 			return err
 		}
 	}
+	if err = container.allocatePortRanges(eng, bindings); err != nil {
+		eng.Job("release_interface", container.ID).Run()
+		return err
+	}
 	container.WriteHostConfig()
 
 	container.NetworkSettings.Ports = bindings
@@ -559,6 +838,38 @@ func (container *Container) AllocateNetwork() error {
 	return nil
 }
 
+// setNetworkID sets the ID of the user-defined network of the container, if
+// any, in the environment of the allocate_interface job
+func (container *Container) setNetworkID(job *engine.Job) error {
+	mode := container.hostConfig.NetworkMode
+	if !mode.IsUserDefined() {
+		return nil
+	}
+	network := container.daemon.networks.Get(string(mode))
+	if network == nil {
+		return fmt.Errorf("No such network: %s", mode)
+	}
+	job.Setenv("NetworkID", network.ID)
+	return nil
+}
+
+// staticAddresses returns the static addresses (--ip) of the other containers
+// on the network of container, which are not allocated to the containers
+// without one, even while they are stopped
+func (daemon *Daemon) staticAddresses(container *Container) []string {
+	var addresses []string
+	mode := container.hostConfig.NetworkMode
+	for _, c := range daemon.List() {
+		if c == container || c.Config.IPAddress == "" || c.hostConfig == nil {
+			continue
+		}
+		if c.hostConfig.NetworkMode == mode || (c.hostConfig.NetworkMode.IsBridge() && mode.IsBridge()) {
+			addresses = append(addresses, c.Config.IPAddress)
+		}
+	}
+	return addresses
+}
+
 func (container *Container) ReleaseNetwork() {
 	if container.Config.NetworkDisabled || !container.hostConfig.NetworkMode.IsPrivate() {
 		return
@@ -590,16 +901,28 @@ func (container *Container) RestoreNetwork() error {
 	job := eng.Job("allocate_interface", container.ID)
 	job.Setenv("RequestedIP", container.NetworkSettings.IPAddress)
 	job.Setenv("RequestedMac", container.NetworkSettings.MacAddress)
+	if err := container.setNetworkID(job); err != nil {
+		return err
+	}
 	if err := job.Run(); err != nil {
 		return err
 	}
 
-	// Re-allocate any previously allocated ports.
-	for port := range container.NetworkSettings.Ports {
-		if err := container.allocatePort(eng, port, container.NetworkSettings.Ports); err != nil {
+	// Re-allocate any previously allocated ports, the contiguous ones as
+	// ranges like the ranges published as a whole.
+	ports := collapsePortRanges(container.NetworkSettings.Ports)
+	for port := range ports {
+		if port.IsRange() {
+			continue
+		}
+		if err := container.allocatePort(eng, port, ports); err != nil {
 			return err
 		}
 	}
+	if err := container.allocatePortRanges(eng, ports); err != nil {
+		return err
+	}
+	container.NetworkSettings.Ports = ports
 	return nil
 }
 
@@ -607,6 +930,8 @@ func (container *Container) RestoreNetwork() error {
 // around how containers are linked together.  It also unmounts the container's root filesystem.
 func (container *Container) cleanup() {
 	container.ReleaseNetwork()
+	// for the links hiding the container once it is stopped
+	container.daemon.publishAddresses(container)
 
 	// Disable all active links
 	if container.activeLinks != nil {
@@ -662,6 +987,32 @@ func (container *Container) Pause() error {
 	return container.daemon.Pause(container)
 }
 
+// Checkpoint dumps the running container to disk and stops it, it can then
+// be started again from where it was with Restore
+func (container *Container) Checkpoint() error {
+	if !container.IsRunning() {
+		return fmt.Errorf("Container %s is not running", container.ID)
+	}
+	if container.IsPaused() {
+		return fmt.Errorf("Container %s is paused. Unpause the container before checkpointing", container.ID)
+	}
+
+	// the container is going to exit, the restart policy must not kick in.
+	// This has to be set before the checkpoint stops the container, and be
+	// undone if the checkpoint failed and left it running
+	container.monitor.ExitOnNext()
+
+	if err := container.daemon.Checkpoint(container); err != nil {
+		container.monitor.cancelExitOnNext()
+		return err
+	}
+	return nil
+}
+
+func (container *Container) checkpointPath() string {
+	return path.Join(container.root, "checkpoint")
+}
+
 func (container *Container) Unpause() error {
 	if !container.IsPaused() {
 		return fmt.Errorf("Container %s is not paused", container.ID)
@@ -697,14 +1048,24 @@ func (container *Container) Kill() error {
 	return nil
 }
 
+// setStoppedByUser records that the container was stopped by the user, so
+// that it is not restarted with the daemon by the unless-stopped policy
+func (container *Container) setStoppedByUser() error {
+	container.Lock()
+	defer container.Unlock()
+	container.StoppedByUser = true
+	return container.toDisk()
+}
+
 func (container *Container) Stop(seconds int) error {
 	if !container.IsRunning() {
 		return nil
 	}
 
-	// 1. Send a SIGTERM
-	if err := container.KillSig(15); err != nil {
-		log.Infof("Failed to send SIGTERM to the process, force killing")
+	// 1. Send the stop signal, SIGTERM unless the container was created with another one
+	stopSignal := container.stopSignal()
+	if err := container.KillSig(stopSignal); err != nil {
+		log.Infof("Failed to send %s to the process, force killing", syscall.Signal(stopSignal))
 		if err := container.KillSig(9); err != nil {
 			return err
 		}
@@ -712,7 +1073,7 @@ func (container *Container) Stop(seconds int) error {
 
 	// 2. Wait for the process to exit on its own
 	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
-		log.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
+		log.Infof("Container %v failed to exit within %d seconds of %s - using the force", container.ID, seconds, syscall.Signal(stopSignal))
 		// 3. If it doesn't, then send SIGKILL
 		if err := container.Kill(); err != nil {
 			container.WaitStop(-1 * time.Second)
@@ -722,6 +1083,27 @@ func (container *Container) Stop(seconds int) error {
 	return nil
 }
 
+// stopSignal returns the signal the container's process is stopped with
+func (container *Container) stopSignal() int {
+	var stopSignal syscall.Signal
+	if container.Config.StopSignal != "" {
+		stopSignal, _ = signal.ParseSignal(container.Config.StopSignal)
+	}
+	if int(stopSignal) <= 0 {
+		stopSignal, _ = signal.ParseSignal(runconfig.DefaultStopSignal)
+	}
+	return int(stopSignal)
+}
+
+// StopTimeout returns the number of seconds the container is given to exit
+// after the stop signal before it is killed
+func (container *Container) StopTimeout() int {
+	if container.Config.StopTimeout > 0 {
+		return container.Config.StopTimeout
+	}
+	return runconfig.DefaultStopTimeout
+}
+
 func (container *Container) Restart(seconds int) error {
 	// Avoid unnecessarily unmounting and then directly mounting
 	// the container when the container stops and then starts
@@ -768,7 +1150,7 @@ func (container *Container) Export() (archive.Archive, error) {
 		return nil, err
 	}
 
-	archive, err := archive.Tar(container.basefs, archive.Uncompressed)
+	archive, err := chrootarchive.Tar(container.basefs, nil)
 	if err != nil {
 		container.Unmount()
 		return nil, err
@@ -822,6 +1204,10 @@ func (container *Container) hostConfigPath() (string, error) {
 	return container.getRootResourcePath("hostconfig.json")
 }
 
+func (container *Container) execsPath() (string, error) {
+	return container.getRootResourcePath("execs.json")
+}
+
 func (container *Container) jsonPath() (string, error) {
 	return container.getRootResourcePath("config.json")
 }
@@ -946,10 +1332,135 @@ func (container *Container) SetHostConfig(hostConfig *runconfig.HostConfig) {
 	container.Unlock()
 }
 
+// EnableLink sets up the link of the running container to child named name:
+// the iptables rules and, unless the embedded DNS resolves it, the records of
+// its alias in /etc/hosts. The environment variables of the link are only set
+// at start.
+func (container *Container) EnableLink(child *Container, name string) error {
+	link, err := container.newLink(child, path.Join(container.Name, name))
+	if err != nil {
+		return err
+	}
+	if err := link.Enable(); err != nil {
+		return err
+	}
+	if container.activeLinks == nil {
+		container.activeLinks = make(map[string]*links.Link)
+	}
+	container.activeLinks[name] = link
+
+	if !container.usesEmbeddedDns() {
+		if err := container.updateLinkHosts(link.Alias()); err != nil {
+			container.DisableLink(name)
+			return err
+		}
+	}
+	return nil
+}
+
+// newLink returns the link named name to child, with the IPv6 addresses of
+// both containers if they have one
+func (container *Container) newLink(child *Container, name string) (*links.Link, error) {
+	childSettings := child.networkOwner().NetworkSettings
+	link, err := links.NewLink(
+		container.NetworkSettings.IPAddress,
+		childSettings.IPAddress,
+		name,
+		child.Config.Env,
+		child.Config.ExposedPorts,
+		container.daemon.eng)
+	if err != nil {
+		return nil, err
+	}
+	link.ParentIPv6 = container.NetworkSettings.GlobalIPv6Address
+	link.ChildIPv6 = childSettings.GlobalIPv6Address
+	return link, nil
+}
+
+// linkHostsRecords returns the /etc/hosts records of the link alias to child,
+// followed by the name of child, for its IPv4 and global IPv6 addresses, if
+// it has some
+func linkHostsRecords(child *Container, alias string) []etchosts.Record {
+	hosts := alias
+	if name := strings.TrimPrefix(child.Name, "/"); name != alias {
+		hosts += " " + name
+	}
+	settings := child.networkOwner().NetworkSettings
+	if settings == nil || settings.IPAddress == "" {
+		return nil
+	}
+	records := []etchosts.Record{{Hosts: hosts, IP: settings.IPAddress}}
+	if settings.GlobalIPv6Address != "" {
+		records = append(records, etchosts.Record{Hosts: hosts, IP: settings.GlobalIPv6Address})
+	}
+	return records
+}
+
+// linksHostsRecords returns the /etc/hosts records of the links to children,
+// by their full link names, which have the alias or, if it is empty, of all
+// of them, except the hidden links to unreachable containers. The containers
+// sharing an alias are in the order of their links.
+func linksHostsRecords(children map[string]*Container, alias string, hidden map[string]bool) []etchosts.Record {
+	names := make([]string, 0, len(children))
+	for name, child := range children {
+		if hidden[path.Base(name)] && !child.reachable() {
+			continue
+		}
+		if alias == "" || links.Alias(name) == alias {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var records []etchosts.Record
+	for _, name := range names {
+		records = append(records, linkHostsRecords(children[name], links.Alias(name))...)
+	}
+	return records
+}
+
+// updateLinkHosts replaces the records of alias in /etc/hosts of the
+// container with the ones of all the containers it is linked to as alias
+func (container *Container) updateLinkHosts(alias string) error {
+	children, err := container.daemon.Children(container.Name)
+	if err != nil {
+		return err
+	}
+	return etchosts.Replace(container.HostsPath, []string{alias}, linksHostsRecords(children, alias, container.HiddenLinks))
+}
+
+// setLinkHidden sets whether the alias of the link of the container named
+// name is hidden while the linked container is not reachable
+func (container *Container) setLinkHidden(name string, hide bool) {
+	if !hide {
+		delete(container.HiddenLinks, name)
+		return
+	}
+	if container.HiddenLinks == nil {
+		container.HiddenLinks = make(map[string]bool)
+	}
+	container.HiddenLinks[name] = true
+}
+
+// reachable returns whether the container has its network and is not waiting
+// to be restarted. Its state is read without locking it, as the caller may
+// hold the lock.
+func (container *Container) reachable() bool {
+	settings := container.networkOwner().NetworkSettings
+	return settings != nil && settings.IPAddress != "" && !container.Restarting
+}
+
+// DisableLink disables the link of the container named name, whose records
+// in /etc/hosts are removed once the link is deleted from the graph
 func (container *Container) DisableLink(name string) {
 	if container.activeLinks != nil {
 		if link, exists := container.activeLinks[name]; exists {
 			link.Disable()
+			delete(container.activeLinks, name)
+			if container.Running && !container.usesEmbeddedDns() {
+				if err := container.updateLinkHosts(link.Alias()); err != nil {
+					log.Errorf("Failed to remove the link %s from /etc/hosts of %s: %v", name, container.ID, err)
+				}
+			}
 		} else {
 			log.Debugf("Could not find active link for %s", name)
 		}
@@ -1107,23 +1618,6 @@ func (container *Container) updateResolvConf(updatedResolvConf []byte, newResolv
 	return nil
 }
 
-func (container *Container) updateParentsHosts() error {
-	refs := container.daemon.ContainerGraph().RefPaths(container.ID)
-	for _, ref := range refs {
-		if ref.ParentID == "0" {
-			continue
-		}
-		c := container.daemon.Get(ref.ParentID)
-		if c != nil && !container.daemon.config.DisableNetwork && container.hostConfig.NetworkMode.IsPrivate() {
-			log.Debugf("Update /etc/hosts of %s for alias %s with ip %s", c.ID, ref.Name, container.NetworkSettings.IPAddress)
-			if err := etchosts.Update(c.HostsPath, container.NetworkSettings.IPAddress, ref.Name); err != nil {
-				log.Errorf("Failed to update /etc/hosts in parent container %s for alias %s: %v", c.ID, ref.Name, err)
-			}
-		}
-	}
-	return nil
-}
-
 func (container *Container) initializeNetworking() error {
 	var err error
 	if container.hostConfig.NetworkMode.IsHost() {
@@ -1176,6 +1670,11 @@ func (container *Container) initializeNetworking() error {
 	if err := container.AllocateNetwork(); err != nil {
 		return err
 	}
+	if container.usesEmbeddedDns() {
+		if err := container.setupEmbeddedDns(); err != nil {
+			return err
+		}
+	}
 	return container.buildHostnameAndHostsFiles(container.NetworkSettings.IPAddress)
 }
 
@@ -1221,43 +1720,44 @@ func (container *Container) setupLinkedContainers() ([]string, error) {
 				return nil, fmt.Errorf("Cannot link to a non running container: %s AS %s", child.Name, linkAlias)
 			}
 
-			link, err := links.NewLink(
-				container.NetworkSettings.IPAddress,
-				child.NetworkSettings.IPAddress,
-				linkAlias,
-				child.Config.Env,
-				child.Config.ExposedPorts,
-				daemon.eng)
-
+			link, err := container.newLink(child, linkAlias)
 			if err != nil {
 				rollback()
 				return nil, err
 			}
 
-			container.activeLinks[link.Alias()] = link
+			container.activeLinks[path.Base(linkAlias)] = link
 			if err := link.Enable(); err != nil {
 				rollback()
 				return nil, err
 			}
 
-			for _, envVar := range link.ToEnv() {
-				env = append(env, envVar)
+			// the hosts records or the DNS are enough to reach the linked
+			// containers, without leaking their ports and environment
+			if daemon.config.LinkEnv {
+				for _, envVar := range link.ToEnv() {
+					env = append(env, envVar)
+				}
 			}
 		}
 	}
 	return env, nil
 }
 
-func (container *Container) createDaemonEnvironment(linkedEnv []string) []string {
-	// if a domain name was specified, append it to the hostname (see #7851)
-	fullHostname := container.Config.Hostname
+// fullHostname returns the container's hostname, including the domain name
+// if one was specified (see #7851)
+func (container *Container) fullHostname() string {
 	if container.Config.Domainname != "" {
-		fullHostname = fmt.Sprintf("%s.%s", fullHostname, container.Config.Domainname)
+		return fmt.Sprintf("%s.%s", container.Config.Hostname, container.Config.Domainname)
 	}
+	return container.Config.Hostname
+}
+
+func (container *Container) createDaemonEnvironment(linkedEnv []string) []string {
 	// Setup environment
 	env := []string{
 		"PATH=" + DefaultPathEnv,
-		"HOSTNAME=" + fullHostname,
+		"HOSTNAME=" + container.fullHostname(),
 		// Note: we don't set HOME here because it'll get autoset intelligently
 		// based on the value of USER inside dockerinit, but only if it isn't
 		// set already (ie, that can be overridden by setting HOME via -e or ENV
@@ -1319,8 +1819,10 @@ func (container *Container) startLoggingToDisk() error {
 	return nil
 }
 
-func (container *Container) waitForStart() error {
-	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+func (container *Container) waitForStart(imagesDir string, reattach bool) error {
+	container.monitor = newContainerMonitor(container, container.daemon.restartBackoff(container.hostConfig.RestartPolicy))
+	container.monitor.restoreDir = imagesDir
+	container.monitor.reattach = reattach
 
 	// block until we either receive an error from the initial start of the container's
 	// process or until the process is running in the container
@@ -1364,6 +1866,151 @@ func (container *Container) allocatePort(eng *engine.Engine, port nat.Port, bind
 	return nil
 }
 
+// allocatePortRanges maps each range of ports of bindings as a whole, with
+// one allocate_port job per binding, and replaces it in bindings with the
+// bindings of each of its ports
+func (container *Container) allocatePortRanges(eng *engine.Engine, bindings nat.PortMap) error {
+	var ranges []nat.Port
+	for port := range bindings {
+		if port.IsRange() {
+			ranges = append(ranges, port)
+		}
+	}
+
+	for _, port := range ranges {
+		start, end, err := port.Range()
+		if err != nil {
+			return err
+		}
+		binding := bindings[port]
+		if container.hostConfig.PublishAllPorts && len(binding) == 0 {
+			binding = append(binding, nat.PortBinding{})
+		}
+
+		for _, b := range binding {
+			job := eng.Job("allocate_port", container.ID)
+			job.Setenv("HostIP", b.HostIp)
+			if b.HostPort != "" {
+				hostStart, hostEnd, err := parsers.ParsePortRange(b.HostPort)
+				if err != nil {
+					return err
+				}
+				if int(hostEnd-hostStart) != end-start {
+					return fmt.Errorf("Invalid ranges specified for container and host Ports: %s and %s", port.Port(), b.HostPort)
+				}
+				job.SetenvInt("HostPort", int(hostStart))
+			}
+			job.Setenv("Proto", port.Proto())
+			job.SetenvInt("ContainerPort", start)
+			job.SetenvInt("PortCount", end-start+1)
+
+			portEnv, err := job.Stdout.AddEnv()
+			if err != nil {
+				return err
+			}
+			if err := job.Run(); err != nil {
+				return err
+			}
+
+			hostStart := portEnv.GetInt("HostPort")
+			for i := 0; i <= end-start; i++ {
+				p := nat.NewPort(port.Proto(), strconv.Itoa(start+i))
+				bindings[p] = append(bindings[p], nat.PortBinding{
+					HostIp:   portEnv.Get("HostIP"),
+					HostPort: strconv.Itoa(hostStart + i),
+				})
+			}
+		}
+		delete(bindings, port)
+	}
+	return nil
+}
+
+// inPortRange returns whether port is in one of the ranges of bindings
+func inPortRange(port nat.Port, bindings nat.PortMap) bool {
+	for p := range bindings {
+		if !p.IsRange() || p.Proto() != port.Proto() {
+			continue
+		}
+		start, end, err := p.Range()
+		if err != nil {
+			continue
+		}
+		if n := port.Int(); n >= start && n <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// mappedPort is a port mapped to a port of a host IP
+type mappedPort struct {
+	proto, hostIP  string
+	port, hostPort int
+}
+
+// byOffset sorts the mapped ports by protocol, host IP, offset of their host
+// port and port
+type byOffset []mappedPort
+
+func (s byOffset) Len() int      { return len(s) }
+func (s byOffset) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byOffset) Less(i, j int) bool {
+	a, b := s[i], s[j]
+	if a.proto != b.proto {
+		return a.proto < b.proto
+	}
+	if a.hostIP != b.hostIP {
+		return a.hostIP < b.hostIP
+	}
+	if a.hostPort-a.port != b.hostPort-b.port {
+		return a.hostPort-a.port < b.hostPort-b.port
+	}
+	return a.port < b.port
+}
+
+// collapsePortRanges returns the bindings with the contiguous ports bound to
+// contiguous ports of the same host IP merged into ranges
+func collapsePortRanges(bindings nat.PortMap) nat.PortMap {
+	var (
+		collapsed = make(nat.PortMap)
+		ports     []mappedPort
+	)
+	for port, binding := range bindings {
+		if len(binding) == 0 {
+			collapsed[port] = binding
+			continue
+		}
+		for _, b := range binding {
+			hostPort, err := strconv.Atoi(b.HostPort)
+			if err != nil || port.IsRange() {
+				collapsed[port] = append(collapsed[port], b)
+				continue
+			}
+			ports = append(ports, mappedPort{port.Proto(), b.HostIp, port.Int(), hostPort})
+		}
+	}
+
+	// the ports of a range have the same offset to their host ports
+	sort.Sort(byOffset(ports))
+	for i := 0; i < len(ports); {
+		j := i + 1
+		for j < len(ports) && ports[j].proto == ports[i].proto && ports[j].hostIP == ports[i].hostIP &&
+			ports[j].port == ports[j-1].port+1 && ports[j].hostPort == ports[j-1].hostPort+1 {
+			j++
+		}
+		port := nat.NewPort(ports[i].proto, strconv.Itoa(ports[i].port))
+		hostPort := strconv.Itoa(ports[i].hostPort)
+		if j-i > 1 {
+			port = nat.NewPort(ports[i].proto, fmt.Sprintf("%d-%d", ports[i].port, ports[j-1].port))
+			hostPort = fmt.Sprintf("%d-%d", ports[i].hostPort, ports[j-1].hostPort)
+		}
+		collapsed[port] = append(collapsed[port], nat.PortBinding{HostIp: ports[i].hostIP, HostPort: hostPort})
+		i = j
+	}
+	return collapsed
+}
+
 func (container *Container) GetProcessLabel() string {
 	// even if we have a process label return "" if we are running
 	// in privileged mode
@@ -1412,6 +2059,21 @@ func (container *Container) getNetworkedContainer() (*Container, error) {
 	}
 }
 
+// networkOwner returns the container whose network container uses, following
+// the chain of --net=container:, or container itself if it has its own
+func (container *Container) networkOwner() *Container {
+	owner := container
+	for owner.hostConfig.NetworkMode.IsContainer() {
+		parts := strings.SplitN(string(owner.hostConfig.NetworkMode), ":", 2)
+		nc := owner.daemon.Get(parts[1])
+		if nc == nil || nc == container {
+			break
+		}
+		owner = nc
+	}
+	return owner
+}
+
 func (container *Container) Stats() (*execdriver.ResourceStats, error) {
 	return container.daemon.Stats(container)
 }
@@ -1,8 +1,15 @@
 package daemon
 
 import (
-	"github.com/docker/docker/nat"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
+
+	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/ulimit"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/libcontainer/devices"
 )
 
 func TestParseNetworkOptsPrivateOnly(t *testing.T) {
@@ -166,6 +173,32 @@ func TestParseNetworkOptsUdp(t *testing.T) {
 	}
 }
 
+func TestCollapsePortRanges(t *testing.T) {
+	bindings := nat.PortMap{
+		"8000/udp": {{HostIp: "0.0.0.0", HostPort: "9000"}},
+		"8001/udp": {{HostIp: "0.0.0.0", HostPort: "9001"}},
+		"8002/udp": {{HostIp: "0.0.0.0", HostPort: "9002"}, {HostIp: "127.0.0.1", HostPort: "7000"}},
+		"8003/udp": {{HostIp: "0.0.0.0", HostPort: "9010"}},
+		"80/tcp":   {{HostIp: "0.0.0.0", HostPort: "81"}},
+		"443/tcp":  nil,
+	}
+	collapsed := collapsePortRanges(bindings)
+	expected := nat.PortMap{
+		"8000-8002/udp": {{HostIp: "0.0.0.0", HostPort: "9000-9002"}},
+		"8002/udp":      {{HostIp: "127.0.0.1", HostPort: "7000"}},
+		"8003/udp":      {{HostIp: "0.0.0.0", HostPort: "9010"}},
+		"80/tcp":        {{HostIp: "0.0.0.0", HostPort: "81"}},
+		"443/tcp":       nil,
+	}
+	if !reflect.DeepEqual(collapsed, expected) {
+		t.Fatalf("Expected %v, got %v", expected, collapsed)
+	}
+
+	if !inPortRange("8001/udp", collapsed) || inPortRange("8001/tcp", collapsed) || inPortRange("8003/udp", collapsed) {
+		t.Fatal("Expected only 8001/udp to be in a range")
+	}
+}
+
 func TestGetFullName(t *testing.T) {
 	name, err := GetFullContainerName("testing")
 	if err != nil {
@@ -195,3 +228,76 @@ func TestValidContainerNames(t *testing.T) {
 		}
 	}
 }
+
+func TestGetDevicesFromPath(t *testing.T) {
+	devs, err := getDevicesFromPath(runconfig.DeviceMapping{
+		PathOnHost:        "/dev/null",
+		PathInContainer:   "/dev/xnull",
+		CgroupPermissions: "rwm",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 || devs[0].Path != "/dev/xnull" {
+		t.Fatalf("Expected a single device at /dev/xnull, got %v", devs)
+	}
+
+	tmp, err := ioutil.TempDir("", "TestGetDevicesFromPath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := getDevicesFromPath(runconfig.DeviceMapping{
+		PathOnHost:        tmp,
+		PathInContainer:   "/dev/snd",
+		CgroupPermissions: "rwm",
+	}); err == nil {
+		t.Fatal("Expected an error for a directory without device nodes")
+	}
+}
+
+func TestMergeUlimits(t *testing.T) {
+	defaults := map[string]*ulimit.Ulimit{
+		"core":   {Name: "core", Soft: 0, Hard: 0},
+		"nofile": {Name: "nofile", Soft: 1024, Hard: 4096},
+	}
+	ulimits := []*ulimit.Ulimit{
+		{Name: "nofile", Soft: 2048, Hard: 2048},
+	}
+
+	merged := mergeUlimits(ulimits, defaults)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 ulimits, got %d", len(merged))
+	}
+	for _, ul := range merged {
+		switch ul.Name {
+		case "nofile":
+			if ul.Soft != 2048 || ul.Hard != 2048 {
+				t.Fatalf("expected the container's nofile ulimit to override the default, got %s", ul)
+			}
+		case "core":
+			if ul.Soft != 0 || ul.Hard != 0 {
+				t.Fatalf("expected the default core ulimit, got %s", ul)
+			}
+		default:
+			t.Fatalf("unexpected ulimit %s", ul)
+		}
+	}
+}
+
+func TestGetDeviceFromCgroupRule(t *testing.T) {
+	dev, err := getDeviceFromCgroupRule("c 189:* rwm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := dev.GetCgroupAllowString(); s != "c 189:* rwm" {
+		t.Fatalf("expected c 189:* rwm, got %s", s)
+	}
+	if dev.MajorNumber != 189 || dev.MinorNumber != devices.Wildcard {
+		t.Fatalf("expected 189:-1, got %d:%d", dev.MajorNumber, dev.MinorNumber)
+	}
+
+	if _, err := getDeviceFromCgroupRule("c 189 rwm"); err == nil {
+		t.Fatal("expected an error for an invalid rule")
+	}
+}
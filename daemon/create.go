@@ -2,11 +2,14 @@ package daemon
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libcontainer/label"
 )
@@ -91,6 +94,41 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	if warnings, err = daemon.mergeAndVerifyConfig(config, img); err != nil {
 		return nil, nil, err
 	}
+	// the remote api doesn't go through the checks of the client, an unset
+	// StopTimeout is 0 and stands for the default
+	if config.StopSignal != "" {
+		if _, err := signal.ParseSignal(config.StopSignal); err != nil {
+			return nil, nil, err
+		}
+	}
+	if config.StopTimeout < 0 {
+		return nil, nil, runconfig.ErrInvalidStopTimeout
+	}
+	// not "No such network", which the client takes for a missing image
+	if hostConfig != nil && hostConfig.NetworkMode.IsUserDefined() && daemon.networks.Get(string(hostConfig.NetworkMode)) == nil {
+		return nil, nil, fmt.Errorf("Invalid network mode: network %s does not exist, create it with docker network create", hostConfig.NetworkMode)
+	}
+	if hostConfig != nil && hostConfig.NetworkMode.IsUserDefined() && hostConfig.PublishesPorts() {
+		return nil, nil, runconfig.ErrConflictNetworkAndPublish
+	}
+	// the address is checked against the subnet of the network at start
+	if config.IPAddress != "" {
+		if ip := net.ParseIP(config.IPAddress); ip == nil || ip.To4() == nil {
+			return nil, nil, fmt.Errorf("Invalid IPv4 address: %s", config.IPAddress)
+		}
+		if config.NetworkDisabled || (hostConfig != nil && !hostConfig.NetworkMode.IsPrivate()) {
+			return nil, nil, runconfig.ErrConflictNetworkAndIP
+		}
+	}
+	// collisions with the other running containers are checked at start
+	if config.MacAddress != "" {
+		if config.MacAddress, err = opts.ValidateMACAddress(config.MacAddress); err != nil {
+			return nil, nil, err
+		}
+		if config.NetworkDisabled || (hostConfig != nil && !hostConfig.NetworkMode.IsPrivate()) {
+			return nil, nil, runconfig.ErrConflictNetworkAndMac
+		}
+	}
 	if hostConfig != nil && hostConfig.SecurityOpt == nil {
 		hostConfig.SecurityOpt, err = daemon.GenerateSecurityOpt(hostConfig.IpcMode, hostConfig.PidMode)
 		if err != nil {
@@ -105,6 +105,11 @@ type Daemon struct {
 	execDriver     execdriver.Driver
 	trustStore     *trust.TrustStore
 	statsCollector *statsCollector
+	dnsResolver    *dnsResolver
+	networks       *networkStore
+
+	// called with each container whose addresses changed
+	addressWatchers []func(*Container)
 }
 
 // Install installs daemon capabilities to eng.
@@ -112,11 +117,16 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 	// FIXME: remove ImageDelete's dependency on Daemon, then move to graph/
 	for name, method := range map[string]engine.Handler{
 		"attach":            daemon.ContainerAttach,
+		"checkpoint":        daemon.ContainerCheckpoint,
 		"commit":            daemon.ContainerCommit,
 		"container_changes": daemon.ContainerChanges,
 		"container_copy":    daemon.ContainerCopy,
+		"container_hosts":   daemon.ContainerSetHosts,
 		"container_rename":  daemon.ContainerRename,
+		"container_update":  daemon.ContainerUpdate,
 		"container_inspect": daemon.ContainerInspect,
+		"container_link":    daemon.ContainerLink,
+		"container_unlink":  daemon.ContainerUnlink,
 		"container_stats":   daemon.ContainerStats,
 		"containers":        daemon.Containers,
 		"create":            daemon.ContainerCreate,
@@ -128,6 +138,7 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 		"pause":             daemon.ContainerPause,
 		"resize":            daemon.ContainerResize,
 		"restart":           daemon.ContainerRestart,
+		"restore":           daemon.ContainerRestore,
 		"start":             daemon.ContainerStart,
 		"stop":              daemon.ContainerStop,
 		"top":               daemon.ContainerTop,
@@ -138,6 +149,14 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 		"execStart":         daemon.ContainerExecStart,
 		"execResize":        daemon.ContainerExecResize,
 		"execInspect":       daemon.ContainerExecInspect,
+		"execWait":          daemon.ContainerExecWait,
+		"execAttach":        daemon.ContainerExecAttach,
+		"execRm":            daemon.ContainerExecRm,
+		"networkCreate":     daemon.NetworkCreate,
+		"networkList":       daemon.NetworkList,
+		"networkRm":         daemon.NetworkRm,
+		"networkConnect":    daemon.NetworkConnect,
+		"networkDisconnect": daemon.NetworkDisconnect,
 	} {
 		if err := eng.Register(name, method); err != nil {
 			return err
@@ -211,6 +230,27 @@ func (daemon *Daemon) Register(container *Container) error {
 }
 
 // register makes a container object usable by the daemon as <container.ID>
+// killOldProcess kills the process with pid of container left running by the
+// previous daemon
+func (daemon *Daemon) killOldProcess(container *Container, pid int) {
+	// We only have to handle this for lxc because the other drivers will ensure that
+	// no processes are left when docker dies
+	if container.ExecDriver == "" || strings.Contains(container.ExecDriver, "lxc") {
+		lxc.KillLxc(container.ID, 9)
+		return
+	}
+	// use the current driver and ensure that the container is dead x.x
+	cmd := &execdriver.Command{
+		ID: container.ID,
+	}
+	var err error
+	cmd.ProcessConfig.Process, err = os.FindProcess(pid)
+	if err != nil {
+		log.Debugf("cannot find existing process for %d", pid)
+	}
+	daemon.execDriver.Terminate(cmd)
+}
+
 func (daemon *Daemon) register(container *Container, updateSuffixarray bool) error {
 	if container.daemon != nil || daemon.Exists(container.ID) {
 		return fmt.Errorf("Container is already loaded")
@@ -225,8 +265,8 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 	container.daemon = daemon
 
 	// Attach to stdout and stderr
-	container.stderr = broadcastwriter.New()
-	container.stdout = broadcastwriter.New()
+	container.stderr = newOutputWriter(container.ID, "stderr")
+	container.stdout = newOutputWriter(container.ID, "stdout")
 	// Attach to stdin
 	if container.Config.OpenStdin {
 		container.stdin, container.stdinPipe = io.Pipe()
@@ -246,27 +286,16 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 	//        if so, then we need to restart monitor and init a new lock
 	// If the container is supposed to be running, make sure of it
 	if container.IsRunning() {
+		if daemon.liveRestore(container) && container.ExecDriver == daemon.execDriver.Name() && daemon.execDriver.Info(container.ID).IsRunning() {
+			log.Debugf("leaving container %s running to reattach to it", container.ID)
+			return nil
+		}
 		log.Debugf("killing old running container %s", container.ID)
 
 		existingPid := container.Pid
 		container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
 
-		// We only have to handle this for lxc because the other drivers will ensure that
-		// no processes are left when docker dies
-		if container.ExecDriver == "" || strings.Contains(container.ExecDriver, "lxc") {
-			lxc.KillLxc(container.ID, 9)
-		} else {
-			// use the current driver and ensure that the container is dead x.x
-			cmd := &execdriver.Command{
-				ID: container.ID,
-			}
-			var err error
-			cmd.ProcessConfig.Process, err = os.FindProcess(existingPid)
-			if err != nil {
-				log.Debugf("cannot find existing process for %d", existingPid)
-			}
-			daemon.execDriver.Terminate(cmd)
-		}
+		daemon.killOldProcess(container, existingPid)
 
 		if err := container.Unmount(); err != nil {
 			log.Debugf("unmount error %s", err)
@@ -310,7 +339,8 @@ func (daemon *Daemon) LogToDisk(src *broadcastwriter.BroadcastWriter, dst, strea
 	if err != nil {
 		return err
 	}
-	src.AddWriter(log, stream)
+	// nothing may be missing from the log
+	src.AddWriter(log, stream, broadcastwriter.BlockWhenFull)
 	return nil
 }
 
@@ -388,13 +418,27 @@ func (daemon *Daemon) restore() error {
 		registeredContainers = append(registeredContainers, container)
 	}
 
+	// reattach to the containers left running by the previous daemon, the
+	// ones which exited since are restarted below like the stopped ones
+	for _, container := range registeredContainers {
+		if container.IsRunning() {
+			log.Debugf("Reattaching to container %s", container.ID)
+
+			if err := container.reattach(); err != nil {
+				log.Debugf("Failed to reattach to container %s: %s", container.ID, err)
+			}
+		}
+	}
+
 	// check the restart policy on the containers and restart any container with
-	// the restart policy of "always"
+	// the restart policy of "always", or of "unless-stopped" unless it was
+	// stopped by the user, after the containers it depends on
 	if daemon.config.AutoRestart {
 		log.Debugf("Restarting containers...")
 
-		for _, container := range registeredContainers {
+		for _, container := range sortByDependencies(registeredContainers, daemon.dependencies) {
 			if container.hostConfig.RestartPolicy.Name == "always" ||
+				(container.hostConfig.RestartPolicy.Name == "unless-stopped" && !container.StoppedByUser) ||
 				(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0) {
 				log.Debugf("Starting container %s", container.ID)
 
@@ -413,6 +457,69 @@ func (daemon *Daemon) restore() error {
 	return nil
 }
 
+// dependencies returns the containers which must be running for container to
+// start: the containers it links to, uses the volumes of or joins the network
+// or the IPC namespace of
+func (daemon *Daemon) dependencies(container *Container) []*Container {
+	var deps []*Container
+	if children, err := daemon.Children(container.Name); err == nil {
+		for _, child := range children {
+			deps = append(deps, child)
+		}
+	}
+	for _, spec := range container.hostConfig.VolumesFrom {
+		if id, _, err := parseVolumesFromSpec(spec); err == nil {
+			if c := daemon.Get(id); c != nil {
+				deps = append(deps, c)
+			}
+		}
+	}
+	if mode := container.hostConfig.NetworkMode; mode.IsContainer() {
+		if parts := strings.SplitN(string(mode), ":", 2); len(parts) == 2 {
+			if c := daemon.Get(parts[1]); c != nil {
+				deps = append(deps, c)
+			}
+		}
+	}
+	if mode := container.hostConfig.IpcMode; mode.IsContainer() {
+		if c := daemon.Get(mode.Container()); c != nil {
+			deps = append(deps, c)
+		}
+	}
+	return deps
+}
+
+// sortByDependencies returns containers ordered so that each of them comes
+// after the ones it depends on, and in their order otherwise. The containers
+// of a dependency cycle are all returned still
+func sortByDependencies(containers []*Container, dependencies func(*Container) []*Container) []*Container {
+	var (
+		sorted  = make([]*Container, 0, len(containers))
+		visited = make(map[string]bool)
+		wanted  = make(map[string]bool)
+		visit   func(c *Container)
+	)
+	for _, c := range containers {
+		wanted[c.ID] = true
+	}
+	visit = func(c *Container) {
+		if visited[c.ID] {
+			return
+		}
+		visited[c.ID] = true
+		for _, dep := range dependencies(c) {
+			if wanted[dep.ID] {
+				visit(dep)
+			}
+		}
+		sorted = append(sorted, c)
+	}
+	for _, c := range containers {
+		visit(c)
+	}
+	return sorted
+}
+
 // set up the watch on the host's /etc/resolv.conf so that we can update container's
 // live resolv.conf when the network changes on the host
 func (daemon *Daemon) setupResolvconfWatcher() error {
@@ -604,6 +711,10 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	)
 
 	for _, opt := range config.SecurityOpt {
+		if opt == "no-new-privileges" {
+			container.NoNewPrivileges = true
+			continue
+		}
 		con := strings.SplitN(opt, ":", 2)
 		if len(con) == 1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
@@ -739,31 +850,51 @@ func (daemon *Daemon) Parents(name string) ([]string, error) {
 }
 
 func (daemon *Daemon) RegisterLink(parent, child *Container, alias string) error {
-	fullName := path.Join(parent.Name, alias)
-	if !daemon.containerGraph.Exists(fullName) {
+	fullName, exists := daemon.linkName(parent, child, alias)
+	if !exists {
 		_, err := daemon.containerGraph.Set(fullName, child.ID)
 		return err
 	}
 	return nil
 }
 
+// linkName returns the full name of the link of parent to child as alias,
+// and whether it exists. The first container linked as alias gets it as name,
+// the other ones sharing it get the alias followed by "@" and their ID.
+func (daemon *Daemon) linkName(parent, child *Container, alias string) (string, bool) {
+	fullName := path.Join(parent.Name, alias)
+	if e := daemon.containerGraph.Get(fullName); e == nil || e.ID() == child.ID {
+		return fullName, e != nil
+	}
+	fullName += "@" + utils.TruncateID(child.ID)
+	return fullName, daemon.containerGraph.Exists(fullName)
+}
+
 func (daemon *Daemon) RegisterLinks(container *Container, hostConfig *runconfig.HostConfig) error {
 	if hostConfig != nil && hostConfig.Links != nil {
 		for _, l := range hostConfig.Links {
-			parts, err := parsers.PartParser("name:alias", l)
+			name, alias, hide, err := parsers.ParseLink(l)
 			if err != nil {
 				return err
 			}
-			child := daemon.Get(parts["name"])
+			child := daemon.Get(name)
 			if child == nil {
-				return fmt.Errorf("Could not get container for %s", parts["name"])
+				return fmt.Errorf("Could not get container for %s", name)
 			}
 			if child.hostConfig.NetworkMode.IsHost() {
 				return runconfig.ErrConflictHostNetworkAndLinks
 			}
-			if err := daemon.RegisterLink(container, child, parts["alias"]); err != nil {
+			aliases, err := splitLinkAliases(alias)
+			if err != nil {
 				return err
 			}
+			for _, alias := range aliases {
+				if err := daemon.RegisterLink(container, child, alias); err != nil {
+					return err
+				}
+				linkName, _ := daemon.linkName(container, child, alias)
+				container.setLinkHidden(path.Base(linkName), hide)
+			}
 		}
 
 		// After we load all the links into the daemon
@@ -796,9 +927,24 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 	if !config.EnableIptables && !config.InterContainerCommunication {
 		return nil, fmt.Errorf("You specified --iptables=false with --icc=false. ICC uses iptables to function. Please set --icc or --iptables to true.")
 	}
+	if config.LinkReject && config.InterContainerCommunication {
+		return nil, fmt.Errorf("You specified --link-reject with --icc=true. Linked containers can reach all the ports of each other with ICC. Please set --icc to false.")
+	}
 	if !config.EnableIptables && config.EnableIpMasq {
 		config.EnableIpMasq = false
 	}
+	defaultPolicy := runconfig.RestartPolicy{
+		Delay:      int(config.RestartDelay / time.Millisecond),
+		MaxDelay:   int(config.RestartMaxDelay / time.Millisecond),
+		Multiplier: config.RestartMultiplier,
+		Jitter:     config.RestartJitter,
+	}
+	if err := defaultPolicy.ValidateBackoff(); err != nil {
+		return nil, err
+	}
+	if _, err := runconfig.ParseRestartPolicy(config.DefaultRestartPolicy); err != nil {
+		return nil, fmt.Errorf("Invalid --default-restart-policy: %s", err)
+	}
 	config.DisableNetwork = config.BridgeIface == disableNetworkBridge
 
 	// Claim the pidfile first, to avoid any and all unexpected race conditions.
@@ -920,7 +1066,9 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		job := eng.Job("init_networkdriver")
 
 		job.SetenvBool("EnableIptables", config.EnableIptables)
+		job.Setenv("FirewallDriver", config.FirewallDriver)
 		job.SetenvBool("InterContainerCommunication", config.InterContainerCommunication)
+		job.SetenvBool("LinkReject", config.LinkReject)
 		job.SetenvBool("EnableIpForward", config.EnableIpForward)
 		job.SetenvBool("EnableIpMasq", config.EnableIpMasq)
 		job.SetenvBool("EnableIPv6", config.EnableIPv6)
@@ -961,8 +1109,13 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		sysInitPath = localCopy
 	}
 
+	networks, err := newNetworkStore(path.Join(config.Root, "networks.json"))
+	if err != nil {
+		return nil, err
+	}
+
 	sysInfo := sysinfo.New(false)
-	ed, err := execdrivers.NewDriver(config.ExecDriver, config.Root, sysInitPath, sysInfo)
+	ed, err := execdrivers.NewDriver(config.ExecDriver, config.Root, sysInitPath, sysInfo, config.CgroupDriver)
 	if err != nil {
 		return nil, err
 	}
@@ -985,6 +1138,15 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		eng:            eng,
 		trustStore:     t,
 		statsCollector: newStatsCollector(1 * time.Second),
+		networks:       networks,
+	}
+	daemon.subscribeAddresses(daemon.updateParentsHosts)
+	if !config.DisableNetwork {
+		// used with --embedded-dns, and always on the user-defined networks
+		daemon.dnsResolver = newDnsResolver(daemon)
+		if err := daemon.restoreNetworks(); err != nil {
+			return nil, err
+		}
 	}
 	if err := daemon.restore(); err != nil {
 		return nil, err
@@ -1003,11 +1165,17 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		if err := daemon.shutdown(); err != nil {
 			log.Errorf("daemon.shutdown(): %s", err)
 		}
+		if daemon.dnsResolver != nil {
+			daemon.dnsResolver.Close()
+		}
 		if err := portallocator.ReleaseAll(); err != nil {
 			log.Errorf("portallocator.ReleaseAll(): %s", err)
 		}
-		if err := daemon.driver.Cleanup(); err != nil {
-			log.Errorf("daemon.driver.Cleanup(): %s", err.Error())
+		// the containers left running still use their root filesystem
+		if !daemon.config.LiveRestore {
+			if err := daemon.driver.Cleanup(); err != nil {
+				log.Errorf("daemon.driver.Cleanup(): %s", err.Error())
+			}
 		}
 		if err := daemon.containerGraph.Close(); err != nil {
 			log.Errorf("daemon.containerGraph.Close(): %s", err.Error())
@@ -1022,14 +1190,26 @@ func (daemon *Daemon) shutdown() error {
 	log.Debugf("starting clean shutdown of all containers...")
 	for _, container := range daemon.List() {
 		c := container
+		if daemon.liveRestore(c) && c.IsRunning() && !c.IsRestarting() {
+			log.Debugf("leaving %s running", c.ID)
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+				if err := daemon.saveExecs(c); err != nil {
+					log.Errorf("Error saving the execs of %s: %s", c.ID, err)
+				}
+			}()
+			continue
+		}
 		if c.IsRunning() {
 			log.Debugf("stopping %s", c.ID)
 			group.Add(1)
 
 			go func() {
 				defer group.Done()
-				if err := c.KillSig(15); err != nil {
-					log.Debugf("kill 15 error for %s - %s", c.ID, err)
+				if err := c.KillSig(c.stopSignal()); err != nil {
+					log.Debugf("kill %d error for %s - %s", c.stopSignal(), c.ID, err)
 				}
 				c.WaitStop(-1 * time.Second)
 				log.Debugf("container stopped %s", c.ID)
@@ -1075,6 +1255,40 @@ func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback e
 	return daemon.execDriver.Run(c.command, pipes, startCallback)
 }
 
+func (daemon *Daemon) Checkpoint(c *Container) error {
+	cp, ok := daemon.execDriver.(execdriver.Checkpointer)
+	if !ok {
+		return execdriver.ErrCheckpointNotSupported
+	}
+	return cp.Checkpoint(c.command, c.checkpointPath())
+}
+
+func (daemon *Daemon) Restore(c *Container, pipes *execdriver.Pipes, imagesDir string, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	cp, ok := daemon.execDriver.(execdriver.Checkpointer)
+	if !ok {
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrCheckpointNotSupported
+	}
+	return cp.Restore(c.command, pipes, imagesDir, startCallback)
+}
+
+func (daemon *Daemon) Reattach(c *Container, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	r, ok := daemon.execDriver.(execdriver.Reattacher)
+	if !ok {
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrReattachNotSupported
+	}
+	return r.Reattach(c.command, pipes, startCallback)
+}
+
+// liveRestore returns whether container is left running when the daemon
+// stops, for the daemon started next to reattach to it
+func (daemon *Daemon) liveRestore(container *Container) bool {
+	if _, ok := daemon.execDriver.(execdriver.Reattacher); !ok || !daemon.config.LiveRestore {
+		return false
+	}
+	// the stdin and the tty of the container go away with the daemon
+	return !container.Config.Tty && !container.Config.OpenStdin
+}
+
 func (daemon *Daemon) Pause(c *Container) error {
 	if err := daemon.execDriver.Pause(c.command); err != nil {
 		return err
@@ -1,8 +1,10 @@
 package daemon
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/pkg/truncindex"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -37,3 +39,119 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatal("Expected parseSecurityOpt error, got nil")
 	}
 }
+
+func TestNetworkSharers(t *testing.T) {
+	daemon := &Daemon{
+		containers: &contStore{s: make(map[string]*Container)},
+		idIndex:    truncindex.NewTruncIndex([]string{}),
+	}
+	add := func(id string, mode runconfig.NetworkMode) *Container {
+		c := &Container{ID: id, daemon: daemon, State: NewState(), hostConfig: &runconfig.HostConfig{NetworkMode: mode}}
+		daemon.containers.Add(id, c)
+		daemon.idIndex.Add(id)
+		return c
+	}
+	owner := add("owner", "bridge")
+	sharer := add("sharer", "container:owner")
+	transitive := add("transitive", "container:sharer")
+	add("other", "bridge")
+
+	if c := transitive.networkOwner(); c != owner {
+		t.Fatalf("Expected owner to be the network owner of transitive, got %v", c)
+	}
+	sharers := daemon.networkSharers(owner)
+	if len(sharers) != 2 {
+		t.Fatalf("Expected 2 containers sharing the network of owner, got %d", len(sharers))
+	}
+	for _, c := range sharers {
+		if c != sharer && c != transitive {
+			t.Fatalf("Unexpected container sharing the network of owner: %s", c.ID)
+		}
+	}
+
+	var published []string
+	daemon.subscribeAddresses(func(c *Container) {
+		published = append(published, c.ID)
+	})
+	daemon.publishAddresses(owner)
+	if len(published) != 3 || published[0] != "owner" {
+		t.Fatalf("Expected owner and its sharers to be published, got %v", published)
+	}
+}
+
+func TestLinksHostsRecords(t *testing.T) {
+	add := func(name, ip string) *Container {
+		return &Container{Name: name, hostConfig: &runconfig.HostConfig{NetworkMode: "bridge"}, NetworkSettings: &NetworkSettings{IPAddress: ip}}
+	}
+	children := map[string]*Container{
+		"/lb/web@0123456789ab": add("/web2", "172.17.0.3"),
+		"/lb/web":              add("/web1", "172.17.0.2"),
+		"/lb/db":               add("/db", "172.17.0.4"),
+		"/lb/stopped":          add("/stopped", ""),
+	}
+
+	records := linksHostsRecords(children, "web", nil)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records for web, got %v", records)
+	}
+	if records[0].Hosts != "web web1" || records[0].IP != "172.17.0.2" || records[1].Hosts != "web web2" || records[1].IP != "172.17.0.3" {
+		t.Fatalf("Unexpected records for web: %v", records)
+	}
+	if records := linksHostsRecords(children, "", nil); len(records) != 3 || records[0].Hosts != "db" {
+		t.Fatalf("Expected the records of the running containers, got %v", records)
+	}
+
+	restarting := add("/web3", "172.17.0.5")
+	restarting.State = &State{Running: true, Restarting: true}
+	children["/lb/web@3456789abcde"] = restarting
+	if records := linksHostsRecords(children, "web", nil); len(records) != 3 {
+		t.Fatalf("Expected the records of the restarting container, got %v", records)
+	}
+	hidden := map[string]bool{"web@3456789abcde": true}
+	if records := linksHostsRecords(children, "web", hidden); len(records) != 2 || records[1].Hosts != "web web2" {
+		t.Fatalf("Expected the hidden restarting container to have no records, got %v", records)
+	}
+	restarting.Restarting = false
+	if records := linksHostsRecords(children, "web", hidden); len(records) != 3 {
+		t.Fatalf("Expected the hidden container to have records once restarted, got %v", records)
+	}
+}
+
+func TestSplitLinkAliases(t *testing.T) {
+	if aliases, err := splitLinkAliases("db,database"); err != nil || len(aliases) != 2 || aliases[1] != "database" {
+		t.Fatalf("Expected the aliases db and database, got %v (%v)", aliases, err)
+	}
+	for _, invalid := range []string{"", "db,", "db,db", "db@other"} {
+		if _, err := splitLinkAliases(invalid); err == nil {
+			t.Fatalf("Expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	var (
+		db    = &Container{ID: "db"}
+		app   = &Container{ID: "app"}
+		web   = &Container{ID: "web"}
+		other = &Container{ID: "other"}
+		a     = &Container{ID: "a"}
+		b     = &Container{ID: "b"}
+	)
+	deps := map[string][]*Container{
+		"web": {app},
+		"app": {db, &Container{ID: "not-restarted"}},
+		"a":   {b},
+		"b":   {a},
+	}
+	sorted := sortByDependencies([]*Container{web, other, app, db, a, b}, func(c *Container) []*Container {
+		return deps[c.ID]
+	})
+
+	var ids []string
+	for _, c := range sorted {
+		ids = append(ids, c.ID)
+	}
+	if expected := []string{"db", "app", "web", "other", "b", "a"}; strings.Join(ids, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected the containers in the order %v, got %v", expected, ids)
+	}
+}
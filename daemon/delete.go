@@ -38,13 +38,17 @@ func (daemon *Daemon) ContainerRm(job *engine.Job) engine.Status {
 		}
 		parentContainer := daemon.Get(pe.ID())
 
-		if parentContainer != nil {
-			parentContainer.DisableLink(n)
-		}
-
 		if err := daemon.ContainerGraph().Delete(name); err != nil {
 			return job.Error(err)
 		}
+
+		if parentContainer != nil {
+			parentContainer.DisableLink(n)
+			parentContainer.Lock()
+			parentContainer.setLinkHidden(n, false)
+			parentContainer.toDisk()
+			parentContainer.Unlock()
+		}
 		return engine.StatusOK
 	}
 
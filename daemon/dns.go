@@ -0,0 +1,193 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/links"
+	"github.com/docker/docker/pkg/dnsserver"
+	"github.com/docker/docker/pkg/networkfs/resolvconf"
+)
+
+// dnsResolver answers the DNS queries of the containers for their link
+// aliases and the names of the other containers of their network with their
+// current addresses, for the containers of the user-defined networks and,
+// when the daemon runs with --embedded-dns, for all of them. It listens on
+// the gateway of the containers, which is their nameserver.
+type dnsResolver struct {
+	sync.Mutex
+	daemon  *Daemon
+	servers map[string]*dnsserver.Server // by gateway
+
+	// incremented by each query, to rotate the addresses of the
+	// containers sharing a link alias
+	rotation uint32
+}
+
+func newDnsResolver(daemon *Daemon) *dnsResolver {
+	return &dnsResolver{daemon: daemon, servers: make(map[string]*dnsserver.Server)}
+}
+
+// listen starts answering the queries sent to gateway, if it does not yet
+func (r *dnsResolver) listen(gateway string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.servers[gateway]; exists {
+		return nil
+	}
+	server, err := dnsserver.New(net.JoinHostPort(gateway, "53"), r.lookup, r.upstream)
+	if err != nil {
+		return err
+	}
+	r.servers[gateway] = server
+	go func() {
+		if err := server.Serve(); err != nil {
+			log.Errorf("Error serving DNS on %s: %s", gateway, err)
+		}
+	}()
+	return nil
+}
+
+func (r *dnsResolver) Close() {
+	r.Lock()
+	defer r.Unlock()
+	for gateway, server := range r.servers {
+		server.Close()
+		delete(r.servers, gateway)
+	}
+}
+
+// containerByIP returns the running container with the address ip
+func (r *dnsResolver) containerByIP(ip net.IP) *Container {
+	for _, c := range r.daemon.List() {
+		if c.IsRunning() && c.NetworkSettings != nil && net.ParseIP(c.NetworkSettings.IPAddress).Equal(ip) {
+			return c
+		}
+	}
+	return nil
+}
+
+// lookup resolves the link aliases of the client container, and the names of
+// the other containers on its network unless inter-container communication
+// is disabled. The addresses of the containers sharing an alias are answered
+// in round-robin.
+func (r *dnsResolver) lookup(client net.IP, name string) ([]net.IP, bool) {
+	container := r.containerByIP(client)
+	if container == nil {
+		return nil, false
+	}
+
+	var targets []*Container
+	if children, err := r.daemon.Children(container.Name); err == nil {
+		var linkNames []string
+		for linkName := range children {
+			if strings.ToLower(links.Alias(linkName)) == name {
+				linkNames = append(linkNames, linkName)
+			}
+		}
+		sort.Strings(linkNames)
+		for _, linkName := range linkNames {
+			// the stopped containers are skipped below, whether hidden or not
+			if container.HiddenLinks[path.Base(linkName)] && children[linkName].IsRestarting() {
+				continue
+			}
+			targets = append(targets, children[linkName])
+		}
+	}
+	if len(targets) > 1 {
+		start := int(atomic.AddUint32(&r.rotation, 1) % uint32(len(targets)))
+		targets = append(targets[start:], targets[:start]...)
+	}
+	if len(targets) == 0 && r.daemon.config.InterContainerCommunication {
+		target := r.daemon.Get(name)
+		// only by name, not by ID, and on the same network
+		if target != nil && target.Name == "/"+name && sameNetwork(container, target.networkOwner()) {
+			targets = append(targets, target)
+		}
+	}
+
+	var (
+		ips   []net.IP
+		found bool
+	)
+	for _, target := range targets {
+		if !target.IsRunning() {
+			continue
+		}
+		// the containers sharing the network of another one have its addresses
+		owner := target.networkOwner()
+		if !owner.hostConfig.NetworkMode.IsPrivate() {
+			continue
+		}
+		found = true
+		if ip := net.ParseIP(owner.NetworkSettings.IPAddress); ip != nil {
+			ips = append(ips, ip)
+		}
+		if ip := net.ParseIP(owner.NetworkSettings.GlobalIPv6Address); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, found
+}
+
+// sameNetwork returns whether a and b are on the same bridge, docker0 or the
+// one of a user-defined network
+func sameNetwork(a, b *Container) bool {
+	if a.hostConfig.NetworkMode.IsBridge() {
+		return b.hostConfig.NetworkMode.IsBridge()
+	}
+	return a.hostConfig.NetworkMode == b.hostConfig.NetworkMode
+}
+
+// upstream returns the nameservers set with --dns for the client container
+// or the daemon, otherwise the ones of the host
+func (r *dnsResolver) upstream(client net.IP) []string {
+	var dns []string
+	if container := r.containerByIP(client); container != nil && len(container.hostConfig.Dns) > 0 {
+		dns = container.hostConfig.Dns
+	} else if len(r.daemon.config.Dns) > 0 {
+		dns = r.daemon.config.Dns
+	} else if resolvConf, err := resolvconf.Get(); err == nil {
+		// the daemon runs on the host, its localhost nameservers work too
+		dns = resolvconf.GetNameservers(resolvConf)
+	}
+	if len(dns) == 0 {
+		dns = []string{"8.8.8.8", "8.8.4.4"}
+	}
+
+	upstream := make([]string, len(dns))
+	for i, ns := range dns {
+		upstream[i] = net.JoinHostPort(ns, "53")
+	}
+	return upstream
+}
+
+// usesEmbeddedDns returns whether the names of the links and the containers
+// are resolved by the DNS resolver of the daemon instead of /etc/hosts
+func (container *Container) usesEmbeddedDns() bool {
+	if container.daemon.dnsResolver == nil || container.hostConfig == nil {
+		return false
+	}
+	return container.daemon.config.EmbeddedDns || container.hostConfig.NetworkMode.IsUserDefined()
+}
+
+// setupEmbeddedDns points the resolv.conf of container to the DNS resolver on
+// its gateway, keeping the search domains
+func (container *Container) setupEmbeddedDns() error {
+	gateway := container.NetworkSettings.Gateway
+	if err := container.daemon.dnsResolver.listen(gateway); err != nil {
+		return fmt.Errorf("Cannot start the DNS resolver on %s: %s", gateway, err)
+	}
+	resolvConf, err := ioutil.ReadFile(container.ResolvConfPath)
+	if err != nil {
+		return err
+	}
+	return resolvconf.Build(container.ResolvConfPath, []string{gateway}, resolvconf.GetSearchDomains(resolvConf))
+}
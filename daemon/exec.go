@@ -1,34 +1,73 @@
 package daemon
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/execdriver/lxc"
 	"github.com/docker/docker/engine"
-	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
 
+const (
+	// execRetentionCount is the number of finished execs kept per container
+	execRetentionCount = 100
+	// execRetentionTime is how long a finished exec is kept
+	execRetentionTime = 10 * time.Minute
+	// execTimeoutExitCode is the exit code of an exec killed by its
+	// timeout, the same as timeout(1)
+	execTimeoutExitCode = 124
+	// execKillTimeout is how long the daemon waits for the execs it killed
+	// when it stops and leaves their container running
+	execKillTimeout = 10 * time.Second
+)
+
 type execConfig struct {
 	sync.Mutex
 	ID            string
 	Running       bool
 	ExitCode      int
+	TimedOut      bool
+	StartedAt     time.Time
 	ProcessConfig execdriver.ProcessConfig
 	StreamConfig
 	OpenStdin  bool
 	OpenStderr bool
 	OpenStdout bool
 	Container  *Container
+
+	// started is set once the terminal of the command exists, the size
+	// requested before is kept in height and width
+	started       bool
+	height, width int
+	pid           int // of the command, once started
+	finishedAt    time.Time
+	// waitUnpause is how long the start waits for a paused container to
+	// be unpaused, it fails right away when it is 0
+	waitUnpause time.Duration
+	// the command is killed by timer once it ran for timeout
+	timeout time.Duration
+	timer   *time.Timer
+	// waitStop is closed once the command exited, or once the exec was
+	// removed
+	waitStop chan struct{}
+	stopOnce sync.Once
 }
 
 type execStore struct {
@@ -70,9 +109,141 @@ func (e *execStore) List() []string {
 }
 
 func (execConfig *execConfig) Resize(h, w int) error {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	if !execConfig.started {
+		// the client resizes right after the start request, usually before
+		// the command runs: the size is applied once it is started
+		execConfig.height, execConfig.width = h, w
+		return nil
+	}
 	return execConfig.ProcessConfig.Terminal.Resize(h, w)
 }
 
+// setStarted records the pid of the command and applies the size requested
+// before its terminal existed
+func (execConfig *execConfig) setStarted(pid int) {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	execConfig.started = true
+	execConfig.pid = pid
+	if execConfig.timeout > 0 {
+		execConfig.timer = time.AfterFunc(execConfig.timeout, execConfig.timeOut)
+	}
+	if execConfig.height > 0 || execConfig.width > 0 {
+		if err := execConfig.ProcessConfig.Terminal.Resize(execConfig.height, execConfig.width); err != nil {
+			log.Errorf("Error resizing exec %s: %s", execConfig.ID, err)
+		}
+	}
+}
+
+// stopWaiting releases the waits for the command, it is called once the
+// command exited or the exec was removed
+func (execConfig *execConfig) stopWaiting() {
+	execConfig.stopOnce.Do(func() {
+		if execConfig.waitStop != nil {
+			close(execConfig.waitStop)
+		}
+	})
+}
+
+// timeOut kills the command once it exceeded its timeout
+func (execConfig *execConfig) timeOut() {
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	if !execConfig.Running || execConfig.pid == 0 {
+		return
+	}
+	log.Debugf("Exec %s exceeded its timeout of %s, killing it", execConfig.ID, execConfig.timeout)
+	execConfig.TimedOut = true
+	execConfig.kill()
+}
+
+// kill kills the command, it is called with execConfig locked once the
+// command started
+func (execConfig *execConfig) kill() {
+	if err := killProcessTree(execConfig.pid); err != nil {
+		log.Errorf("Error killing exec %s: %s", execConfig.ID, err)
+	}
+}
+
+// killProcessTree kills the process pid and all its descendants. The pid of
+// an exec is the one of nsenter, which forks the command to enter the pid
+// namespace of the container and waits for it: killing nsenter alone would
+// leave the command, and whatever it started, running and holding the
+// streams of the exec. The processes are stopped as they are found, until a
+// listing finds no new one, so that none forks once the tree is known.
+func killProcessTree(pid int) error {
+	if err := syscall.Kill(pid, syscall.SIGSTOP); err != nil {
+		return err
+	}
+	var (
+		tree    = []int{pid}
+		stopped = map[int]bool{pid: true}
+	)
+	for found := true; found; {
+		children, err := processChildren()
+		if err != nil {
+			log.Errorf("Error listing the descendants of %d: %s", pid, err)
+			break
+		}
+		found = false
+		for i := 0; i < len(tree); i++ {
+			for _, child := range children[tree[i]] {
+				if stopped[child] {
+					continue
+				}
+				if err := syscall.Kill(child, syscall.SIGSTOP); err != nil {
+					// the process exited
+					continue
+				}
+				stopped[child] = true
+				tree = append(tree, child)
+				found = true
+			}
+		}
+	}
+	for i := len(tree) - 1; i > 0; i-- {
+		if err := syscall.Kill(tree[i], syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			log.Errorf("Error killing %d, descendant of %d: %s", tree[i], pid, err)
+		}
+	}
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// processChildren returns the pids of the children of every process by the
+// pid of their parent, from the parent pid in /proc/<pid>/stat
+func processChildren() (map[int][]int, error) {
+	dirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int][]int)
+	for _, dir := range dirs {
+		child, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join("/proc", dir.Name(), "stat"))
+		if err != nil {
+			// the process exited
+			continue
+		}
+		// the name of the command, in parentheses, may contain spaces
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		parent, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		children[parent] = append(children[parent], child)
+	}
+	return children, nil
+}
+
 func (d *Daemon) registerExecCommand(execConfig *execConfig) {
 	// Storing execs in container inorder to kill them gracefully whenever the container is stopped or removed.
 	execConfig.Container.execCommands.Add(execConfig.ID, execConfig)
@@ -94,9 +265,188 @@ func (d *Daemon) getExecConfig(name string) (*execConfig, error) {
 func (d *Daemon) unregisterExecCommand(execConfig *execConfig) {
 	execConfig.Container.execCommands.Delete(execConfig.ID)
 	d.execCommands.Delete(execConfig.ID)
+	execConfig.stopWaiting()
+}
+
+// execFinished unregisters execConfig after execRetentionTime, and the oldest
+// finished execs of its container beyond execRetentionCount right away
+func (d *Daemon) execFinished(execConfig *execConfig) {
+	time.AfterFunc(execRetentionTime, func() { d.unregisterExecCommand(execConfig) })
+
+	var finished byFinishedAt
+	execConfig.Container.execCommands.RLock()
+	for _, eConfig := range execConfig.Container.execCommands.s {
+		finished = append(finished, eConfig)
+	}
+	execConfig.Container.execCommands.RUnlock()
+
+	n := 0
+	for _, eConfig := range finished {
+		eConfig.Lock()
+		if !eConfig.Running && !eConfig.finishedAt.IsZero() {
+			finished[n] = eConfig
+			n++
+		}
+		eConfig.Unlock()
+	}
+	finished = finished[:n]
+	if len(finished) <= execRetentionCount {
+		return
+	}
+	// finishedAt is not written again once set
+	sort.Sort(finished)
+	for _, eConfig := range finished[:len(finished)-execRetentionCount] {
+		d.unregisterExecCommand(eConfig)
+	}
+}
+
+// execRecord is a finished exec as written to the directory of its container
+// when the daemon stops and leaves the container running, for the daemon
+// which reattaches to the container to show it again
+type execRecord struct {
+	ID            string
+	ExitCode      int
+	TimedOut      bool
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	ProcessConfig execdriver.ProcessConfig
+	OpenStdin     bool
+	OpenStderr    bool
+	OpenStdout    bool
+}
+
+// saveExecs kills the execs running in container, whose streams go away with
+// the daemon, and writes the finished execs of container to its directory
+func (d *Daemon) saveExecs(container *Container) error {
+	var execs []*execConfig
+	container.execCommands.RLock()
+	for _, eConfig := range container.execCommands.s {
+		execs = append(execs, eConfig)
+	}
+	container.execCommands.RUnlock()
+
+	for _, eConfig := range execs {
+		eConfig.Lock()
+		if eConfig.Running && eConfig.pid != 0 {
+			log.Debugf("killing exec %s of %s left running", eConfig.ID, container.ID)
+			eConfig.kill()
+		}
+		eConfig.Unlock()
+	}
+
+	records := []execRecord{}
+	timeout := time.After(execKillTimeout)
+	for _, eConfig := range execs {
+		eConfig.Lock()
+		running, waitStop := eConfig.Running, eConfig.waitStop
+		eConfig.Unlock()
+		if running && waitStop != nil {
+			select {
+			case <-waitStop:
+			case <-timeout:
+			}
+		}
+
+		eConfig.Lock()
+		if !eConfig.Running && !eConfig.finishedAt.IsZero() {
+			records = append(records, execRecord{
+				ID:            eConfig.ID,
+				ExitCode:      eConfig.ExitCode,
+				TimedOut:      eConfig.TimedOut,
+				StartedAt:     eConfig.StartedAt,
+				FinishedAt:    eConfig.finishedAt,
+				ProcessConfig: eConfig.ProcessConfig,
+				OpenStdin:     eConfig.OpenStdin,
+				OpenStderr:    eConfig.OpenStderr,
+				OpenStdout:    eConfig.OpenStdout,
+			})
+		} else {
+			log.Errorf("Exec %s of %s did not exit, it is not kept", eConfig.ID, container.ID)
+		}
+		eConfig.Unlock()
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	pth, err := container.execsPath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(pth, data, 0600)
 }
 
-func (d *Daemon) getActiveContainer(name string) (*Container, error) {
+// loadExecs registers again the execs written to the directory of container
+// by the daemon which left it running
+func (d *Daemon) loadExecs(container *Container) error {
+	pth, err := container.execsPath()
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	// the execs of the next run of the container are not the same
+	if err := os.Remove(pth); err != nil {
+		return err
+	}
+
+	var records []execRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for _, record := range records {
+		eConfig := &execConfig{
+			ID:            record.ID,
+			ExitCode:      record.ExitCode,
+			TimedOut:      record.TimedOut,
+			StartedAt:     record.StartedAt,
+			ProcessConfig: record.ProcessConfig,
+			OpenStdin:     record.OpenStdin,
+			OpenStderr:    record.OpenStderr,
+			OpenStdout:    record.OpenStdout,
+			Container:     container,
+			finishedAt:    record.FinishedAt,
+			waitStop:      make(chan struct{}),
+		}
+		eConfig.stopWaiting()
+		d.registerExecCommand(eConfig)
+		d.execFinished(eConfig)
+	}
+	return nil
+}
+
+type byFinishedAt []*execConfig
+
+func (s byFinishedAt) Len() int           { return len(s) }
+func (s byFinishedAt) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byFinishedAt) Less(i, j int) bool { return s[i].finishedAt.Before(s[j].finishedAt) }
+
+// reserveExec counts one more exec running in container, unless it already
+// runs the maximum set by --max-concurrent-execs
+func (d *Daemon) reserveExec(container *Container) bool {
+	max := int32(d.config.MaxConcurrentExecs)
+	for {
+		running := atomic.LoadInt32(&container.runningExecs)
+		if max > 0 && running >= max {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&container.runningExecs, running, running+1) {
+			return true
+		}
+	}
+}
+
+func (d *Daemon) execLimitError(container *Container) error {
+	return fmt.Errorf("Container %s already runs the maximum of %d exec instances (--max-concurrent-execs)", container.ID, d.config.MaxConcurrentExecs)
+}
+
+func (d *Daemon) getActiveContainer(name string, allowPaused bool) (*Container, error) {
 	container := d.Get(name)
 
 	if container == nil {
@@ -106,7 +456,7 @@ func (d *Daemon) getActiveContainer(name string) (*Container, error) {
 	if !container.IsRunning() {
 		return nil, fmt.Errorf("Container %s is not running", name)
 	}
-	if container.IsPaused() {
+	if !allowPaused && container.IsPaused() {
 		return nil, fmt.Errorf("Container %s is paused, unpause the container before exec", name)
 	}
 	return container, nil
@@ -123,23 +473,46 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) engine.Status {
 
 	var name = job.Args[0]
 
-	container, err := d.getActiveContainer(name)
+	config, err := runconfig.ExecConfigFromJob(job)
 	if err != nil {
 		return job.Error(err)
 	}
 
-	config, err := runconfig.ExecConfigFromJob(job)
+	// with WaitUnpause, the start waits for a paused container to be unpaused
+	container, err := d.getActiveContainer(name, config.WaitUnpause > 0)
 	if err != nil {
 		return job.Error(err)
 	}
 
+	if max := d.config.MaxConcurrentExecs; max > 0 && atomic.LoadInt32(&container.runningExecs) >= int32(max) {
+		return job.Error(d.execLimitError(container))
+	}
+
+	if config.Memory != 0 && config.Memory < 4194304 {
+		return job.Errorf("Minimum memory limit allowed is 4MB")
+	}
+
+	if config.Timeout < 0 {
+		return job.Errorf("Invalid timeout %d, must be a positive number of seconds", config.Timeout)
+	}
+
 	entrypoint, args := d.getEntrypointAndArgs(nil, config.Cmd)
 
 	processConfig := execdriver.ProcessConfig{
+		User:       config.User,
+		Privileged: config.Privileged,
 		Tty:        config.Tty,
+		Env:        config.Env,
+		WorkingDir: config.WorkingDir,
 		Entrypoint: entrypoint,
 		Arguments:  args,
 	}
+	if config.Memory > 0 || config.CpuShares > 0 {
+		processConfig.Resources = &execdriver.Resources{
+			Memory:    config.Memory,
+			CpuShares: config.CpuShares,
+		}
+	}
 
 	execConfig := &execConfig{
 		ID:            utils.GenerateRandomID(),
@@ -150,6 +523,9 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) engine.Status {
 		ProcessConfig: processConfig,
 		Container:     container,
 		Running:       false,
+		waitUnpause:   time.Duration(config.WaitUnpause) * time.Second,
+		timeout:       time.Duration(config.Timeout) * time.Second,
+		waitStop:      make(chan struct{}),
 	}
 
 	container.LogEvent("exec_create: " + execConfig.ProcessConfig.Entrypoint + " " + strings.Join(execConfig.ProcessConfig.Arguments, " "))
@@ -177,13 +553,30 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) engine.Status {
 		return job.Error(err)
 	}
 
+	if execConfig.Container.IsPaused() {
+		if execConfig.waitUnpause == 0 {
+			return job.Errorf("Container %s is paused, unpause the container before exec", execConfig.Container.ID)
+		}
+		if err := execConfig.Container.WaitUnpaused(execConfig.waitUnpause); err != nil {
+			return job.Errorf("Container %s is still paused, cannot start exec %s: %s", execConfig.Container.ID, execName, err)
+		}
+	}
+
 	func() {
 		execConfig.Lock()
 		defer execConfig.Unlock()
 		if execConfig.Running {
 			err = fmt.Errorf("Error: Exec command %s is already running", execName)
+			return
+		}
+		// execs created while the limit was not reached yet are checked
+		// again when they start
+		if !d.reserveExec(execConfig.Container) {
+			err = d.execLimitError(execConfig.Container)
+			return
 		}
 		execConfig.Running = true
+		execConfig.StartedAt = time.Now().UTC()
 	}()
 	if err != nil {
 		return job.Error(err)
@@ -210,8 +603,8 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) engine.Status {
 		cStderr = job.Stderr
 	}
 
-	execConfig.StreamConfig.stderr = broadcastwriter.New()
-	execConfig.StreamConfig.stdout = broadcastwriter.New()
+	execConfig.StreamConfig.stderr = newOutputWriter(execConfig.ID, "stderr")
+	execConfig.StreamConfig.stdout = newOutputWriter(execConfig.ID, "stdout")
 	// Attach to stdin
 	if execConfig.OpenStdin {
 		execConfig.StreamConfig.stdin, execConfig.StreamConfig.stdinPipe = io.Pipe()
@@ -255,16 +648,148 @@ func (d *Daemon) Exec(c *Container, execConfig *execConfig, pipes *execdriver.Pi
 		exitStatus = 128
 	}
 
+	execConfig.Lock()
+	if execConfig.timer != nil {
+		execConfig.timer.Stop()
+	}
+	if execConfig.TimedOut {
+		exitStatus = execTimeoutExitCode
+	}
 	execConfig.ExitCode = exitStatus
 	execConfig.Running = false
+	execConfig.finishedAt = time.Now().UTC()
+	execConfig.Unlock()
+	atomic.AddInt32(&c.runningExecs, -1)
+	execConfig.stopWaiting()
+	c.LogEvent(fmt.Sprintf("exec_die: %s %s (exit code %d)", execConfig.ProcessConfig.Entrypoint, strings.Join(execConfig.ProcessConfig.Arguments, " "), exitStatus))
+	d.execFinished(execConfig)
 
 	return exitStatus, err
 }
 
+func (d *Daemon) ContainerExecWait(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s EXEC", job.Name)
+	}
+	name := job.Args[0]
+
+	execConfig := d.execCommands.Get(name)
+	if execConfig == nil {
+		return job.Errorf("No such exec instance '%s' found in daemon", name)
+	}
+
+	execConfig.Lock()
+	started := !execConfig.StartedAt.IsZero()
+	execConfig.Unlock()
+	if !started {
+		return job.Errorf("Conflict, exec %s was never started, start it before waiting for it", name)
+	}
+
+	<-execConfig.waitStop
+	execConfig.Lock()
+	exitCode, finished := execConfig.ExitCode, !execConfig.finishedAt.IsZero()
+	execConfig.Unlock()
+	if !finished {
+		return job.Errorf("Conflict, exec %s was removed before it exited", name)
+	}
+	job.Printf("%d\n", exitCode)
+	return engine.StatusOK
+}
+
+// ContainerExecAttach attaches to the streams of a running exec, so that a
+// client which detached from it or lost its connection can reconnect
+func (d *Daemon) ContainerExecAttach(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s EXEC", job.Name)
+	}
+
+	var (
+		name             = job.Args[0]
+		stdin            = job.GetenvBool("stdin")
+		stdout           = job.GetenvBool("stdout")
+		stderr           = job.GetenvBool("stderr")
+		cStdin           io.ReadCloser
+		cStdout, cStderr io.Writer
+	)
+
+	execConfig, err := d.getExecConfig(name)
+	if err != nil {
+		return job.Error(err)
+	}
+
+	execConfig.Lock()
+	// the streams are set up by the start, they exist once it started
+	running := execConfig.Running && execConfig.started
+	execConfig.Unlock()
+	if !running {
+		return job.Errorf("Conflict, cannot attach to exec %s, it is not running", name)
+	}
+
+	if stdin && execConfig.OpenStdin {
+		r, w := io.Pipe()
+		go func() {
+			defer w.Close()
+			defer log.Debugf("Closing buffered stdin pipe")
+			io.Copy(w, job.Stdin)
+		}()
+		cStdin = r
+	}
+	// the output is broadcast even when the start did not attach to it
+	if stdout {
+		cStdout = job.Stdout
+	}
+	if stderr {
+		cStderr = job.Stderr
+	}
+
+	<-d.attach(&execConfig.StreamConfig, execConfig.OpenStdin, true, execConfig.ProcessConfig.Tty, cStdin, cStdout, cStderr)
+	return engine.StatusOK
+}
+
+func (d *Daemon) ContainerExecRm(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s EXEC", job.Name)
+	}
+	name := job.Args[0]
+
+	execConfig := d.execCommands.Get(name)
+	if execConfig == nil {
+		return job.Errorf("No such exec instance '%s' found in daemon", name)
+	}
+
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	if execConfig.Running {
+		return job.Errorf("Conflict, cannot remove the running exec instance %s", name)
+	}
+	d.unregisterExecCommand(execConfig)
+	return engine.StatusOK
+}
+
 func (container *Container) GetExecIDs() []string {
 	return container.execCommands.List()
 }
 
+// getExecPids returns the IDs of the running execs of container by their pid
+func (container *Container) getExecPids() map[int]string {
+	container.execCommands.RLock()
+	execs := make([]*execConfig, 0, len(container.execCommands.s))
+	for _, execConfig := range container.execCommands.s {
+		execs = append(execs, execConfig)
+	}
+	container.execCommands.RUnlock()
+
+	pids := make(map[int]string)
+	for _, execConfig := range execs {
+		execConfig.Lock()
+		if execConfig.Running && execConfig.pid != 0 {
+			pids[execConfig.pid] = execConfig.ID
+		}
+		execConfig.Unlock()
+	}
+	return pids
+}
+
 func (container *Container) Exec(execConfig *execConfig) error {
 	container.Lock()
 	defer container.Unlock()
@@ -280,6 +805,7 @@ func (container *Container) Exec(execConfig *execConfig) error {
 				c.Close()
 			}
 		}
+		execConfig.setStarted(pid)
 		close(waitStart)
 	}
 
@@ -315,6 +841,7 @@ func (container *Container) monitorExec(execConfig *execConfig, callback execdri
 			log.Errorf("Error closing stdin while running in %s: %s", container.ID, err)
 		}
 	}
+	execConfig.StreamConfig.flushOutput(execConfig.ID)
 	if err := execConfig.StreamConfig.stdout.Clean(); err != nil {
 		log.Errorf("Error closing stdout while running in %s: %s", container.ID, err)
 	}
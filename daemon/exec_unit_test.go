@@ -0,0 +1,228 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/engine"
+)
+
+func TestExecFinishedKeepsLastExecs(t *testing.T) {
+	daemon := &Daemon{execCommands: newExecStore()}
+	container := &Container{execCommands: newExecStore()}
+
+	running := &execConfig{ID: "running", Running: true, Container: container}
+	created := &execConfig{ID: "created", Container: container}
+	daemon.registerExecCommand(running)
+	daemon.registerExecCommand(created)
+
+	now := time.Now()
+	var last *execConfig
+	for i := 0; i < execRetentionCount+10; i++ {
+		last = &execConfig{ID: fmt.Sprintf("finished%d", i), Container: container, finishedAt: now.Add(time.Duration(i) * time.Second)}
+		daemon.registerExecCommand(last)
+	}
+	daemon.execFinished(last)
+
+	if n := len(container.execCommands.List()); n != execRetentionCount+2 {
+		t.Fatalf("Expected %d execs to be kept, got %d", execRetentionCount+2, n)
+	}
+	for i := 0; i < 10; i++ {
+		if daemon.execCommands.Get(fmt.Sprintf("finished%d", i)) != nil {
+			t.Fatalf("Expected finished%d to be unregistered", i)
+		}
+	}
+	for _, id := range []string{"running", "created", "finished10", last.ID} {
+		if daemon.execCommands.Get(id) == nil {
+			t.Fatalf("Expected %s to be kept", id)
+		}
+	}
+}
+
+func TestReserveExec(t *testing.T) {
+	daemon := &Daemon{config: &Config{MaxConcurrentExecs: 2}}
+	container := &Container{}
+
+	if !daemon.reserveExec(container) || !daemon.reserveExec(container) {
+		t.Fatal("Expected 2 execs to be reserved")
+	}
+	if daemon.reserveExec(container) {
+		t.Fatal("Expected the third exec to be over the limit")
+	}
+	container.runningExecs--
+	if !daemon.reserveExec(container) {
+		t.Fatal("Expected an exec to be reserved once another one finished")
+	}
+
+	daemon.config.MaxConcurrentExecs = 0
+	for i := 0; i < 10; i++ {
+		if !daemon.reserveExec(container) {
+			t.Fatal("Expected execs not to be limited")
+		}
+	}
+}
+
+func TestExecTimeOutKillsCommand(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	execConfig := &execConfig{ID: "timeout", Running: true, timeout: 10 * time.Millisecond}
+	execConfig.setStarted(cmd.Process.Pid)
+
+	waitErr := make(chan error)
+	go func() { waitErr <- cmd.Wait() }()
+	select {
+	case err := <-waitErr:
+		if err == nil {
+			t.Fatal("Expected the command to be killed")
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("Timeout waiting for the command to be killed")
+	}
+
+	execConfig.Lock()
+	defer execConfig.Unlock()
+	if !execConfig.TimedOut {
+		t.Fatal("Expected the exec to be marked as timed out")
+	}
+}
+
+func TestExecTimeOutKillsDescendantsOfCommand(t *testing.T) {
+	// like nsenter, the shell forks the command and waits for it, the
+	// command itself forks sleep, which ignores its stdin
+	cmd := exec.Command("sh", "-c", "sh -c 'sleep 30; exit $?'; exit $?")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		children, err := processChildren()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, child := range children[cmd.Process.Pid] {
+			if len(children[child]) > 0 {
+				found = true
+			}
+		}
+		if found {
+			break
+		}
+		if i == 100 {
+			cmd.Process.Kill()
+			t.Fatal("Timeout waiting for the command to fork sleep")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	execConfig := &execConfig{ID: "timeout", Running: true, timeout: 10 * time.Millisecond}
+	execConfig.setStarted(cmd.Process.Pid)
+
+	// the wait returns once the stdout of the shell and of sleep is closed
+	waitErr := make(chan error)
+	go func() { waitErr <- cmd.Wait() }()
+	select {
+	case err := <-waitErr:
+		if err == nil {
+			t.Fatal("Expected the command to be killed")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the descendants of the command to be killed")
+	}
+}
+
+func TestContainerExecWaitNotStarted(t *testing.T) {
+	eng := engine.New()
+	daemon := &Daemon{eng: eng, execCommands: newExecStore()}
+	eng.Register("execWait", daemon.ContainerExecWait)
+	container := &Container{execCommands: newExecStore()}
+
+	created := &execConfig{ID: "created", Container: container, waitStop: make(chan struct{})}
+	daemon.registerExecCommand(created)
+	if err := eng.Job("execWait", "created").Run(); err == nil || !strings.Contains(err.Error(), "never started") {
+		t.Fatalf("Expected an error for an exec never started, got %v", err)
+	}
+
+	// an exec removed while it is waited for releases the wait
+	started := &execConfig{ID: "started", Container: container, StartedAt: time.Now(), waitStop: make(chan struct{})}
+	daemon.registerExecCommand(started)
+	waitErr := make(chan error)
+	go func() { waitErr <- eng.Job("execWait", "started").Run() }()
+	time.Sleep(50 * time.Millisecond)
+	daemon.unregisterExecCommand(started)
+	select {
+	case err := <-waitErr:
+		if err == nil || !strings.Contains(err.Error(), "removed") {
+			t.Fatalf("Expected an error for a removed exec, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the wait to return once the exec was removed")
+	}
+}
+
+func TestSaveAndLoadExecs(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-execs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	daemon := &Daemon{execCommands: newExecStore()}
+	container := &Container{root: root, execCommands: newExecStore()}
+
+	finished := &execConfig{ID: "finished", ExitCode: 3, StartedAt: time.Now(), Container: container, finishedAt: time.Now()}
+	finished.ProcessConfig.Entrypoint = "true"
+	daemon.registerExecCommand(finished)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	running := &execConfig{ID: "running", Running: true, StartedAt: time.Now(), Container: container, pid: cmd.Process.Pid, waitStop: make(chan struct{})}
+	daemon.registerExecCommand(running)
+	go func() {
+		cmd.Wait()
+		running.Lock()
+		running.Running, running.ExitCode, running.finishedAt = false, 137, time.Now()
+		running.Unlock()
+		running.stopWaiting()
+	}()
+
+	if err := daemon.saveExecs(container); err != nil {
+		cmd.Process.Kill()
+		t.Fatal(err)
+	}
+
+	// the daemon started next reattaches to the container
+	daemon = &Daemon{execCommands: newExecStore()}
+	container = &Container{root: root, execCommands: newExecStore()}
+	if err := daemon.loadExecs(container); err != nil {
+		t.Fatal(err)
+	}
+	for id, exitCode := range map[string]int{"finished": 3, "running": 137} {
+		eConfig := daemon.execCommands.Get(id)
+		if eConfig == nil || container.execCommands.Get(id) == nil {
+			t.Fatalf("Expected exec %s to be loaded", id)
+		}
+		if eConfig.Running || eConfig.ExitCode != exitCode || eConfig.finishedAt.IsZero() {
+			t.Fatalf("Expected exec %s to be finished with exit code %d, got %#v", id, exitCode, eConfig)
+		}
+		// waiting for a loaded exec returns right away
+		<-eConfig.waitStop
+	}
+	pth, err := container.execsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(pth); !os.IsNotExist(err) {
+		t.Fatal("Expected the saved execs to be removed once loaded")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/devices"
 )
@@ -20,6 +21,8 @@ var (
 	ErrWaitTimeoutReached      = errors.New("Wait timeout reached")
 	ErrDriverAlreadyRegistered = errors.New("A driver already registered this docker init function")
 	ErrDriverNotFound          = errors.New("The requested docker init has not been found")
+	ErrCheckpointNotSupported  = errors.New("Checkpoint and restore are not supported by this execution driver")
+	ErrReattachNotSupported    = errors.New("Reattaching to running containers is not supported by this execution driver")
 )
 
 type StartCallback func(*ProcessConfig, int)
@@ -66,6 +69,22 @@ type Driver interface {
 	Stats(id string) (*ResourceStats, error)      // Get resource stats for a running container
 }
 
+// Checkpointer is implemented by drivers that can dump a running container
+// to disk and restore it from the dumped images later on
+type Checkpointer interface {
+	Checkpoint(c *Command, imagesDir string) error
+	// Restore restores the container from imagesDir, blocks until the process exits and returns the exit code
+	Restore(c *Command, pipes *Pipes, imagesDir string, startCallback StartCallback) (ExitStatus, error)
+}
+
+// Reattacher is implemented by drivers whose containers keep running when the
+// daemon stops, when they are run with LiveRestore, and can be reattached to
+// once it starts again
+type Reattacher interface {
+	// Reattach attaches to the process of the container run by a previous daemon, blocks until the process exits and returns the exit code
+	Reattach(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error)
+}
+
 // Network settings of the container
 type Network struct {
 	Interface      *NetworkInterface `json:"interface"` // if interface is nil then networking is disabled
@@ -85,6 +104,11 @@ type Pid struct {
 	HostPid bool `json:"host_pid"`
 }
 
+// UTS settings of the container
+type UTS struct {
+	HostUTS bool `json:"host_uts"`
+}
+
 type NetworkInterface struct {
 	Gateway              string `json:"gateway"`
 	IPAddress            string `json:"ip"`
@@ -98,10 +122,12 @@ type NetworkInterface struct {
 }
 
 type Resources struct {
-	Memory     int64  `json:"memory"`
-	MemorySwap int64  `json:"memory_swap"`
-	CpuShares  int64  `json:"cpu_shares"`
-	Cpuset     string `json:"cpuset"`
+	Memory     int64            `json:"memory"`
+	MemorySwap int64            `json:"memory_swap"`
+	CpuShares  int64            `json:"cpu_shares"`
+	Cpuset     string           `json:"cpuset"`
+	CpusetMems string           `json:"cpuset_mems"`
+	Rlimits    []*ulimit.Rlimit `json:"rlimits"`
 }
 
 type ResourceStats struct {
@@ -117,25 +143,31 @@ type Mount struct {
 	Writable    bool   `json:"writable"`
 	Private     bool   `json:"private"`
 	Slave       bool   `json:"slave"`
+	Device      string `json:"device"` // "tmpfs" for tmpfs mounts, empty for bind mounts
+	Data        string `json:"data"`   // mount options for tmpfs mounts
 }
 
 // Describes a process that will be run inside a container.
 type ProcessConfig struct {
 	exec.Cmd `json:"-"`
 
-	Privileged bool     `json:"privileged"`
-	User       string   `json:"user"`
-	Tty        bool     `json:"tty"`
-	Entrypoint string   `json:"entrypoint"`
-	Arguments  []string `json:"arguments"`
-	Terminal   Terminal `json:"-"` // standard or tty terminal
-	Console    string   `json:"-"` // dev/console path
+	Privileged bool       `json:"privileged"`
+	User       string     `json:"user"`
+	Tty        bool       `json:"tty"`
+	Entrypoint string     `json:"entrypoint"`
+	Arguments  []string   `json:"arguments"`
+	Env        []string   `json:"env"`         // set over the environment of the container, for exec
+	WorkingDir string     `json:"working_dir"` // overrides the working directory of the container, for exec
+	Resources  *Resources `json:"resources"`   // limits within the ones of the container, for exec
+	Terminal   Terminal   `json:"-"`           // standard or tty terminal
+	Console    string     `json:"-"`           // dev/console path
 }
 
 // Process wrapps an os/exec.Cmd to add more metadata
 type Command struct {
 	ID                 string            `json:"id"`
-	Rootfs             string            `json:"rootfs"` // root fs of the container
+	Rootfs             string            `json:"rootfs"`   // root fs of the container
+	Hostname           string            `json:"hostname"` // hostname of the container, unused when sharing the host's uts namespace
 	ReadonlyRootfs     bool              `json:"readonly_rootfs"`
 	InitPath           string            `json:"initpath"` // dockerinit
 	WorkingDir         string            `json:"working_dir"`
@@ -143,6 +175,7 @@ type Command struct {
 	Network            *Network          `json:"network"`
 	Ipc                *Ipc              `json:"ipc"`
 	Pid                *Pid              `json:"pid"`
+	UTS                *UTS              `json:"uts"`
 	Resources          *Resources        `json:"resources"`
 	Mounts             []Mount           `json:"mounts"`
 	AllowedDevices     []*devices.Device `json:"allowed_devices"`
@@ -155,4 +188,8 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
+	NoNewPrivileges    bool              `json:"no_new_privileges"` // processes of the container can't gain privileges through execve
+	StopSignal         int               `json:"stop_signal"`       // signal sent to the init process to stop the container
+	Gpus               bool              `json:"gpus"`              // give the container access to the host's GPU devices
+	LiveRestore        bool              `json:"live_restore"`      // the process outlives the daemon, its output goes through fifos
 }
@@ -0,0 +1,12 @@
+package lxc
+
+import (
+	"github.com/docker/libcontainer/apparmor"
+)
+
+// installAppArmorProfile (re)generates and loads the docker-default profile,
+// the lxc template confines unprivileged containers with it unless another
+// profile was requested with --security-opt apparmor:<profile>
+func installAppArmorProfile() error {
+	return apparmor.InstallDefaultProfile()
+}
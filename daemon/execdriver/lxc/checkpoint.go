@@ -0,0 +1,45 @@
+package lxc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+// Checkpoint dumps the state of the running container into imagesDir with
+// lxc-checkpoint (which uses CRIU) and stops the container afterwards
+func (d *driver) Checkpoint(c *execdriver.Command, imagesDir string) error {
+	if err := os.RemoveAll(imagesDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(imagesDir, 0700); err != nil {
+		return err
+	}
+
+	output, err := exec.Command("lxc-checkpoint", "-s", "-n", c.ID, "-D", imagesDir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Err: %s Output: %s", err, output)
+	}
+	return nil
+}
+
+// Restore starts the container from the images written by Checkpoint and,
+// like Run, blocks until the container exits
+func (d *driver) Restore(c *execdriver.Command, pipes *execdriver.Pipes, imagesDir string, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	configPath, err := d.prepareCommand(c, pipes)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	params := []string{
+		"lxc-checkpoint",
+		"-r", "-F",
+		"-n", c.ID,
+		"-D", imagesDir,
+		"--rcfile", configPath,
+	}
+
+	return d.startAndWait(c, params, startCallback)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/utils"
 	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/devices"
 	"github.com/docker/libcontainer/mount/nodes"
 )
 
@@ -29,56 +30,58 @@ const DriverName = "lxc"
 
 var ErrExec = errors.New("Unsupported: Exec is not supported by the lxc driver")
 
+// FIXME: the driver generates config.lxc and execs lxc-start. Switching to
+// the liblxc Go bindings (gopkg.in/lxc/go-lxc.v2) is blocked on vendoring
+// them, they need cgo and the liblxc headers to build.
 type driver struct {
 	root       string // root path for the driver to use
 	initPath   string
 	apparmor   bool
 	sharedRoot bool
+	version    string // version of the installed lxc tools, probed at init
+	systemd    bool   // place containers in systemd scopes rather than raw cgroupfs paths
 }
 
-func NewDriver(root, initPath string, apparmor bool) (*driver, error) {
+func NewDriver(root, initPath string, apparmor bool, cgroupDriver string) (*driver, error) {
 	// setup unconfined symlink
 	if err := linkLxcStart(root); err != nil {
 		return nil, err
 	}
 
+	useSystemd, err := useSystemdCgroups(cgroupDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	if apparmor {
+		if err := installAppArmorProfile(); err != nil {
+			return nil, err
+		}
+	}
+
+	version := probeVersion()
+	if version == "" {
+		log.Infof("Unable to detect the lxc version, lxc configuration keys will not be checked")
+	} else {
+		log.Debugf("Using lxc %s", version)
+	}
+
 	return &driver{
 		apparmor:   apparmor,
 		root:       root,
 		initPath:   initPath,
 		sharedRoot: rootIsShared(),
+		version:    version,
+		systemd:    useSystemd,
 	}, nil
 }
 
 func (d *driver) Name() string {
-	version := d.version()
-	return fmt.Sprintf("%s-%s", DriverName, version)
+	return fmt.Sprintf("%s-%s", DriverName, d.version)
 }
 //lxchCoRun
 func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
-	var (
-		term execdriver.Terminal
-		err  error
-	)
-
-	if c.ProcessConfig.Tty {
-		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
-	} else {
-		term, err = execdriver.NewStdConsole(&c.ProcessConfig, pipes)
-	}
-	c.ProcessConfig.Terminal = term
-
-	c.Mounts = append(c.Mounts, execdriver.Mount{
-		Source:      d.initPath,
-		Destination: c.InitPath,
-		Writable:    false,
-		Private:     true,
-	})
-
-	if err := d.generateEnvConfig(c); err != nil {
-		return execdriver.ExitStatus{ExitCode: -1}, err
-	}
-	configPath, err := d.generateLXCConfig(c)
+	configPath, err := d.prepareCommand(c, pipes)
 	if err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
@@ -88,8 +91,40 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		"-f", configPath,
 	}
 	if c.Network.ContainerID != "" {
+		// join the network namespace of the other container through the pid
+		// of its init, lxc can't always resolve containers started with -f
+		pid, err := d.getContainerPid(c.Network.ContainerID)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
 		params = append(params,
-			"--share-net", c.Network.ContainerID,
+			"--share-net", strconv.Itoa(pid),
+		)
+	}
+	if c.Ipc != nil {
+		if c.Ipc.ContainerID != "" {
+			pid, err := d.getContainerPid(c.Ipc.ContainerID)
+			if err != nil {
+				return execdriver.ExitStatus{ExitCode: -1}, err
+			}
+			params = append(params,
+				"--share-ipc", strconv.Itoa(pid),
+			)
+		} else if c.Ipc.HostIpc {
+			// sharing the namespace of pid 1 keeps the container in the host's ipc namespace
+			params = append(params,
+				"--share-ipc", "1",
+			)
+		}
+	}
+	if c.Pid != nil && c.Pid.HostPid {
+		params = append(params,
+			"--share-pid", "1",
+		)
+	}
+	if c.UTS != nil && c.UTS.HostUTS {
+		params = append(params,
+			"--share-uts", "1",
 		)
 	}
 
@@ -117,15 +152,79 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 
 		}
 		params = append(params, "-privileged")
+	} else {
+		// dockerinit drops the capabilities from the bounding set as well, lxc
+		// only does so for lxc.cap.drop and lxc.cap.keep
+		if len(c.CapAdd) > 0 {
+			params = append(params, "-cap-add", strings.Join(c.CapAdd, ":"))
+		}
+		if len(c.CapDrop) > 0 {
+			params = append(params, "-cap-drop", strings.Join(c.CapDrop, ":"))
+		}
+	}
+
+	if c.NoNewPrivileges && !d.supports(featureNoNewPrivs) {
+		// lxc can't set no_new_privs itself, dockerinit does it before exec
+		params = append(params, "-no-new-privileges")
+	}
+
+	if c.Resources != nil && len(c.Resources.Rlimits) > 0 && !d.supports(featureLimit) {
+		// lxc can't set the resource limits itself, dockerinit does it
+		var rlimits []string
+		for _, rlimit := range c.Resources.Rlimits {
+			rlimits = append(rlimits, fmt.Sprintf("%s=%d:%d", rlimit.Name(), rlimit.Soft, rlimit.Hard))
+		}
+		params = append(params, "-rlimits", strings.Join(rlimits, ","))
 	}
 
 	if c.WorkingDir != "" {
 		params = append(params, "-w", c.WorkingDir)
 	}
 
+	if c.ProcessConfig.Tty {
+		params = append(params, "-tty")
+	}
+
 	params = append(params, "--", c.ProcessConfig.Entrypoint)
 	params = append(params, c.ProcessConfig.Arguments...)
 
+	return d.startAndWait(c, params, startCallback)
+}
+
+// prepareCommand sets up the terminal, the dockerinit and env mounts and
+// writes the lxc config for the container, returning the config's path
+func (d *driver) prepareCommand(c *execdriver.Command, pipes *execdriver.Pipes) (string, error) {
+	var (
+		term execdriver.Terminal
+		err  error
+	)
+
+	if c.ProcessConfig.Tty {
+		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
+	} else {
+		term, err = execdriver.NewStdConsole(&c.ProcessConfig, pipes)
+	}
+	if err != nil {
+		return "", err
+	}
+	c.ProcessConfig.Terminal = term
+
+	c.Mounts = append(c.Mounts, execdriver.Mount{
+		Source:      d.initPath,
+		Destination: c.InitPath,
+		Writable:    false,
+		Private:     true,
+	})
+
+	if err := d.generateEnvConfig(c); err != nil {
+		return "", err
+	}
+	return d.generateLXCConfig(c)
+}
+
+// startAndWait execs params (lxc-start or an equivalent), waits for the
+// container to be running and blocks until it exits
+func (d *driver) startAndWait(c *execdriver.Command, params []string, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
 	if d.sharedRoot {
 		// lxc-start really needs / to be non-shared, or all kinds of stuff break
 		// when lxc-start unmount things and those unmounts propagate to the main
@@ -141,6 +240,11 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 			"unshare", "-m", "--", "/bin/sh", "-c", shellString,
 		}
 	}
+	if d.systemd {
+		// run lxc-start in a transient scope, lxc creates the container's
+		// cgroups below it
+		params = append(systemdScopeParams(c.ID), params...)
+	}
 
 	var (
 		name = params[0]
@@ -237,26 +341,6 @@ func (d *driver) Terminate(c *execdriver.Command) error {
 	return KillLxc(c.ID, 9)
 }
 
-func (d *driver) version() string {
-	var (
-		version string
-		output  []byte
-		err     error
-	)
-	if _, errPath := exec.LookPath("lxc-version"); errPath == nil {
-		output, err = exec.Command("lxc-version").CombinedOutput()
-	} else {
-		output, err = exec.Command("lxc-start", "--version").CombinedOutput()
-	}
-	if err == nil {
-		version = strings.TrimSpace(string(output))
-		if parts := strings.SplitN(version, ":", 2); len(parts) == 2 {
-			version = strings.TrimSpace(parts[1])
-		}
-	}
-	return version
-}
-
 func KillLxc(id string, sig int) error {
 	var (
 		err    error
@@ -265,8 +349,12 @@ func KillLxc(id string, sig int) error {
 	_, err = exec.LookPath("lxc-kill")
 	if err == nil {
 		output, err = exec.Command("lxc-kill", "-n", id, strconv.Itoa(sig)).CombinedOutput()
+	} else if sig == int(syscall.SIGKILL) {
+		output, err = exec.Command("lxc-stop", "-k", "-n", id).CombinedOutput()
 	} else {
-		output, err = exec.Command("lxc-stop", "-k", "-n", id, strconv.Itoa(sig)).CombinedOutput()
+		// lxc-stop can't deliver an arbitrary signal, e.g. the container's
+		// stop signal, so send it to the container's init process directly
+		return signalLxcInit(id, sig)
 	}
 	if err != nil {
 		return fmt.Errorf("Err: %s Output: %s", err, output)
@@ -274,6 +362,22 @@ func KillLxc(id string, sig int) error {
 	return nil
 }
 
+// signalLxcInit sends sig to the init process of the running container id
+func signalLxcInit(id string, sig int) error {
+	output, err := exec.Command("lxc-info", "-n", id).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Err: %s Output: %s", err, output)
+	}
+	info, err := parseLxcInfo(string(output))
+	if err != nil {
+		return err
+	}
+	if !info.Running || info.Pid <= 0 {
+		return fmt.Errorf("%s is not running", id)
+	}
+	return syscall.Kill(info.Pid, syscall.Signal(sig))
+}
+
 // wait for the process to start and return the pid for the process
 func (d *driver) waitForStart(c *execdriver.Command, waitLock chan struct{}) (int, error) {
 	var (
@@ -311,6 +415,22 @@ func (d *driver) getInfo(id string) ([]byte, error) {
 	return exec.Command("lxc-info", "-n", id).CombinedOutput()
 }
 
+// getContainerPid returns the pid of the init process of a running container
+func (d *driver) getContainerPid(id string) (int, error) {
+	output, err := d.getInfo(id)
+	if err != nil {
+		return -1, fmt.Errorf("Err: %s Output: %s", err, output)
+	}
+	info, err := parseLxcInfo(string(output))
+	if err != nil {
+		return -1, err
+	}
+	if !info.Running || info.Pid <= 0 {
+		return -1, fmt.Errorf("%s is not a valid running container to join", id)
+	}
+	return info.Pid, nil
+}
+
 type info struct {
 	ID     string
 	driver *driver
@@ -351,6 +471,9 @@ func (d *driver) GetPidsForContainer(id string) ([]int, error) {
 	if err != nil {
 		return pids, err
 	}
+	if d.systemd {
+		cgroupDir = systemdScopePath(id)
+	}
 
 	filename := filepath.Join(cgroupRoot, cgroupDir, id, "tasks")
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
@@ -410,6 +533,33 @@ func rootIsShared() bool {
 func (d *driver) generateLXCConfig(c *execdriver.Command) (string, error) {
 	root := path.Join(d.root, "containers", c.ID, "config.lxc")
 
+	if err := d.checkFeatures(c); err != nil {
+		return "", err
+	}
+
+	var gpus []*devices.Device
+	if c.Gpus {
+		var err error
+		if gpus, err = gpuDevices(); err != nil {
+			return "", err
+		}
+	}
+
+	// a container sharing the ipc namespace of the host or of another
+	// container sees the /dev/shm of that namespace, not a private one
+	var shm string
+	if c.Ipc != nil {
+		if c.Ipc.HostIpc {
+			shm = "/dev/shm"
+		} else if c.Ipc.ContainerID != "" {
+			pid, err := d.getContainerPid(c.Ipc.ContainerID)
+			if err != nil {
+				return "", err
+			}
+			shm = fmt.Sprintf("/proc/%d/root/dev/shm", pid)
+		}
+	}
+
 	fo, err := os.Create(root)
 	if err != nil {
 		return "", err
@@ -418,10 +568,18 @@ func (d *driver) generateLXCConfig(c *execdriver.Command) (string, error) {
 
 	if err := LxcTemplateCompiled.Execute(fo, struct {
 		*execdriver.Command
-		AppArmor bool
+		AppArmor   bool
+		NoNewPrivs bool
+		Limits     bool
+		Shm        string
+		GpuDevices []*devices.Device
 	}{
-		Command:  c,
-		AppArmor: d.apparmor,
+		Command:    c,
+		AppArmor:   d.apparmor,
+		NoNewPrivs: c.NoNewPrivileges && d.supports(featureNoNewPrivs),
+		Limits:     d.supports(featureLimit),
+		Shm:        shm,
+		GpuDevices: gpus,
 	}); err != nil {
 		return "", err
 	}
@@ -504,8 +662,10 @@ func (t *TtyConsole) AttachPipes(command *exec.Cmd, pipes *execdriver.Pipes) err
 	}()
 
 	if pipes.Stdin != nil {
+		// the pty is not made the controlling terminal of lxc-start, dockerinit
+		// claims it inside the container so that window size changes and job
+		// control signals reach the container's processes
 		command.Stdin = t.SlavePty
-		command.SysProcAttr.Setctty = true
 
 		go func() {
 			io.Copy(t.MasterPty, pipes.Stdin)
@@ -0,0 +1,42 @@
+package lxc
+
+import (
+	"path/filepath"
+
+	"github.com/docker/libcontainer/devices"
+)
+
+// gpuDevicePatterns are the host device nodes used by the NVIDIA driver and
+// by DRI (Mesa, Intel and AMD GPUs)
+var gpuDevicePatterns = []string{
+	"/dev/nvidiactl",
+	"/dev/nvidia[0-9]*",
+	"/dev/nvidia-uvm",
+	"/dev/nvidia-uvm-tools",
+	"/dev/nvidia-modeset",
+	"/dev/dri/card*",
+	"/dev/dri/renderD*",
+}
+
+// gpuDevices discovers the GPU device nodes present on the host, they are
+// allowed in the container's devices cgroup and bind mounted into it
+func gpuDevices() ([]*devices.Device, error) {
+	var devs []*devices.Device
+	for _, pattern := range gpuDevicePatterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			dev, err := devices.GetDevice(m, "rwm")
+			if err != nil {
+				if err == devices.ErrNotADeviceNode {
+					continue
+				}
+				return nil, err
+			}
+			devs = append(devs, dev)
+		}
+	}
+	return devs, nil
+}
@@ -30,6 +30,9 @@ type InitArgs struct {
 	Root       string
 	CapAdd     string
 	CapDrop    string
+	Tty        bool
+	NoNewPrivs bool
+	Rlimits    string
 }
 
 func init() {
@@ -86,6 +89,9 @@ func getArgs() *InitArgs {
 		mtu        = flag.Int("mtu", 1500, "interface mtu")
 		capAdd     = flag.String("cap-add", "", "capabilities to add")
 		capDrop    = flag.String("cap-drop", "", "capabilities to drop")
+		tty        = flag.Bool("tty", false, "make the console the controlling terminal")
+		noNewPrivs = flag.Bool("no-new-privileges", false, "set no_new_privs before executing the command")
+		rlimits    = flag.String("rlimits", "", "resource limits, as name=soft:hard separated by commas, to set before executing the command")
 	)
 
 	flag.Parse()
@@ -100,6 +106,9 @@ func getArgs() *InitArgs {
 		Mtu:        *mtu,
 		CapAdd:     *capAdd,
 		CapDrop:    *capDrop,
+		Tty:        *tty,
+		NoNewPrivs: *noNewPrivs,
+		Rlimits:    *rlimits,
 	}
 }
 
@@ -2,23 +2,128 @@ package lxc
 
 import (
 	"fmt"
+	"strings"
+	"syscall"
 
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/native/template"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/namespaces"
+	"github.com/docker/libcontainer/security/capabilities"
+	"github.com/docker/libcontainer/system"
 	"github.com/docker/libcontainer/utils"
 )
 
+// PR_SET_NO_NEW_PRIVS is missing from the syscall package
+const prSetNoNewPrivs = 38
+
 func finalizeNamespace(args *InitArgs) error {
 	if err := utils.CloseExecFrom(3); err != nil {
 		return err
 	}
+	if args.Tty {
+		if err := setupTty(); err != nil {
+			return fmt.Errorf("setup tty %s", err)
+		}
+	}
+
+	// before the capabilities are dropped, raising a hard limit needs
+	// CAP_SYS_RESOURCE
+	if err := setupRlimits(args.Rlimits); err != nil {
+		return fmt.Errorf("setup rlimits %s", err)
+	}
+
+	var caps []string
+	if !args.Privileged {
+		var err error
+		if caps, err = execdriver.TweakCapabilities(template.New().Capabilities, splitCapabilities(args.CapAdd), splitCapabilities(args.CapDrop)); err != nil {
+			return err
+		}
+		// drop capabilities in bounding set before changing user
+		if err := capabilities.DropBoundingSet(caps); err != nil {
+			return fmt.Errorf("drop bounding set %s", err)
+		}
+		// preserve existing capabilities while we change users
+		if err := system.SetKeepCaps(); err != nil {
+			return fmt.Errorf("set keep caps %s", err)
+		}
+	}
+
 	if err := namespaces.SetupUser(&libcontainer.Config{
 		User: args.User,
 	}); err != nil {
 		return fmt.Errorf("setup user %s", err)
 	}
+
+	if !args.Privileged {
+		if err := system.ClearKeepCaps(); err != nil {
+			return fmt.Errorf("clear keep caps %s", err)
+		}
+		if err := capabilities.DropCapabilities(caps); err != nil {
+			return fmt.Errorf("drop capabilities %s", err)
+		}
+	}
+
 	if err := setupWorkingDirectory(args); err != nil {
 		return err
 	}
+
+	if args.NoNewPrivs {
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+			return fmt.Errorf("set no_new_privs %s", errno)
+		}
+	}
 	return nil
 }
+
+// setupRlimits sets the resource limits passed to dockerinit by the driver
+// when lxc is too old to set them itself
+func setupRlimits(list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, value := range strings.Split(list, ",") {
+		u, err := ulimit.Parse(value)
+		if err != nil {
+			return err
+		}
+		rlimit, err := u.GetRlimit()
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setrlimit(rlimit.Type, &syscall.Rlimit{Cur: rlimit.Soft, Max: rlimit.Hard}); err != nil {
+			return fmt.Errorf("%s: %s", u.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitCapabilities splits the colon separated capability list passed to
+// dockerinit by the driver
+func splitCapabilities(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ":")
+}
+
+// setupTty starts a new session and makes the container's console, the pty
+// lxc-start was given as stdio, its controlling terminal so that resizes
+// deliver SIGWINCH and ^C/^Z reach the foreground process group
+func setupTty() error {
+	if _, err := syscall.Setsid(); err != nil && err != syscall.EPERM {
+		return err
+	}
+	for _, fd := range []uintptr{0, 1, 2} {
+		if !term.IsTerminal(fd) {
+			continue
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCSCTTY), 0); errno != 0 {
+			return errno
+		}
+		return nil
+	}
+	return fmt.Errorf("no terminal attached to the container's stdio")
+}
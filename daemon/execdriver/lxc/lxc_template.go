@@ -19,11 +19,14 @@ const LxcTemplate = `
 # network configuration
 lxc.network.type = veth
 lxc.network.link = {{.Network.Interface.Bridge}}
+lxc.network.veth.pair = {{vethPairName .ID}}
 lxc.network.name = eth0
 lxc.network.mtu = {{.Network.Mtu}}
 lxc.network.flags = up
 {{else if .Network.HostNetworking}}
 lxc.network.type = none
+{{else if .Network.ContainerID}}
+# network namespace is shared with container {{.Network.ContainerID}} (lxc-start --share-net)
 {{else}}
 # network is disabled (-n=false)
 lxc.network.type = empty
@@ -31,9 +34,27 @@ lxc.network.flags = up
 lxc.network.mtu = {{.Network.Mtu}}
 {{end}}
 
+# hostname
+{{if and .Hostname (not (isHostUTS .UTS))}}
+lxc.utsname = {{.Hostname}}
+{{end}}
+
+{{if .StopSignal}}
+# signal sent by lxc-stop to shut the container down cleanly
+lxc.haltsignal = {{.StopSignal}}
+{{end}}
+
 # root filesystem
 {{$ROOTFS := .Rootfs}}
 lxc.rootfs = {{$ROOTFS}}
+{{if .ReadonlyRootfs}}
+lxc.rootfs.options = ro
+{{end}}
+
+{{if .ProcessLabel}}
+# selinux label of the container's processes
+lxc.se_context = {{.ProcessLabel}}
+{{end}}
 
 # use a dedicated pts for the container (and limit the number of pseudo terminal
 # available)
@@ -54,6 +75,9 @@ lxc.cgroup.devices.deny = a
 {{range $allowedDevice := .AllowedDevices}}
 lxc.cgroup.devices.allow = {{$allowedDevice.GetCgroupAllowString}}
 {{end}}
+{{range $gpu := .GpuDevices}}
+lxc.cgroup.devices.allow = {{$gpu.GetCgroupAllowString}}
+{{end}}
 {{end}}
 
 # standard mount point
@@ -75,8 +99,20 @@ lxc.aa_profile = unconfined
 # In non-privileged mode, lxc will automatically mount /proc and /sys in readonly mode
 # for security. See: http://man7.org/linux/man-pages/man5/lxc.container.conf.5.html
 lxc.mount.auto = proc sys
+# hide the parts of /proc and /sys that leak information about the host
+{{range $path := maskedPaths}}
+lxc.mount.entry = /dev/null {{escapeFstabSpaces $ROOTFS}}{{$path}} none bind,optional 0 0
+{{end}}
+{{range $path := maskedDirs}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}{{$path}} tmpfs {{formatMountLabel "ro,nosuid,nodev,noexec,optional" $.MountLabel}} 0 0
+{{end}}
+{{range $path := readonlyPaths}}
+lxc.mount.entry = {{$path}} {{escapeFstabSpaces $ROOTFS}}{{$path}} none bind,ro,optional 0 0
+{{end}}
 	{{if .AppArmorProfile}}
 lxc.aa_profile = {{.AppArmorProfile}}
+	{{else if .AppArmor}}
+lxc.aa_profile = docker-default
 	{{end}}
 {{end}}
 
@@ -84,10 +120,23 @@ lxc.aa_profile = {{.AppArmorProfile}}
 lxc.mount.entry = {{.ProcessConfig.Console}} {{escapeFstabSpaces $ROOTFS}}/dev/console none bind,rw 0 0
 {{end}}
 
-lxc.mount.entry = devpts {{escapeFstabSpaces $ROOTFS}}/dev/pts devpts {{formatMountLabel "newinstance,ptmxmode=0666,nosuid,noexec" ""}} 0 0
-lxc.mount.entry = shm {{escapeFstabSpaces $ROOTFS}}/dev/shm tmpfs {{formatMountLabel "size=65536k,nosuid,nodev,noexec" ""}} 0 0
+lxc.mount.entry = devpts {{escapeFstabSpaces $ROOTFS}}/dev/pts devpts {{formatMountLabel "newinstance,ptmxmode=0666,nosuid,noexec" $.MountLabel}} 0 0
+{{if .Shm}}
+lxc.mount.entry = {{escapeFstabSpaces .Shm}} {{escapeFstabSpaces $ROOTFS}}/dev/shm none rbind,rw 0 0
+{{else}}
+lxc.mount.entry = shm {{escapeFstabSpaces $ROOTFS}}/dev/shm tmpfs {{formatMountLabel "size=65536k,nosuid,nodev,noexec" $.MountLabel}} 0 0
+{{end}}
+
+{{if .ReadonlyRootfs}}
+# the root filesystem is read-only, give the container writable scratch space
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/run tmpfs {{formatMountLabel "nosuid,nodev,mode=755,optional" $.MountLabel}} 0 0
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/tmp tmpfs {{formatMountLabel "nosuid,nodev,mode=1777,optional" $.MountLabel}} 0 0
+{{end}}
 
 {{range $value := .Mounts}}
+{{if eq $value.Device "tmpfs"}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} tmpfs {{formatMountLabel (tmpfsOptions $value.Data) $.MountLabel}} 0 0
+{{else}}
 {{$createVal := isDirectory $value.Source}}
 {{if $value.Writable}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,rw,create={{$createVal}} 0 0
@@ -95,6 +144,11 @@ lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabS
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,ro,create={{$createVal}} 0 0
 {{end}}
 {{end}}
+{{end}}
+
+{{range $gpu := .GpuDevices}}
+lxc.mount.entry = {{$gpu.Path}} {{escapeFstabSpaces $ROOTFS}}{{$gpu.Path}} none bind,optional,create=file 0 0
+{{end}}
 
 # limits
 {{if .Resources}}
@@ -111,6 +165,14 @@ lxc.cgroup.cpu.shares = {{.Resources.CpuShares}}
 {{if .Resources.Cpuset}}
 lxc.cgroup.cpuset.cpus = {{.Resources.Cpuset}}
 {{end}}
+{{if .Resources.CpusetMems}}
+lxc.cgroup.cpuset.mems = {{.Resources.CpusetMems}}
+{{end}}
+{{if .Limits}}
+{{range $rlimit := .Resources.Rlimits}}
+lxc.limit.{{$rlimit.Name}} = {{$rlimit.Soft}}:{{$rlimit.Hard}}
+{{end}}
+{{end}}
 {{end}}
 
 {{if .LxcConfig}}
@@ -126,9 +188,11 @@ lxc.network.ipv4 = {{.Network.Interface.IPAddress}}/{{.Network.Interface.IPPrefi
 {{if .Network.Interface.Gateway}}
 lxc.network.ipv4.gateway = {{.Network.Interface.Gateway}}
 {{end}}
+{{end}}
 
-{{if .ProcessConfig.Env}}
-lxc.utsname = {{getHostname .ProcessConfig.Env}}
+{{if .NoNewPrivs}}
+# processes of the container can't gain privileges through execve
+lxc.no_new_privs = 1
 {{end}}
 
 {{if .ProcessConfig.Privileged}}
@@ -146,17 +210,54 @@ lxc.cap.drop = {{.}}
 		{{end}}
 	{{end}}
 {{end}}
-{{end}}
 `
 
 var LxcTemplateCompiled *template.Template
 
+var (
+	// maskedPaths are files covered with /dev/null in unprivileged containers
+	maskedPaths = []string{
+		"/proc/kcore",
+		"/proc/keys",
+		"/proc/latency_stats",
+		"/proc/timer_list",
+		"/proc/timer_stats",
+		"/proc/sched_debug",
+	}
+	// maskedDirs are directories covered with an empty read-only tmpfs
+	maskedDirs = []string{
+		"/proc/acpi",
+		"/proc/scsi",
+		"/sys/firmware",
+	}
+	// readonlyPaths are bind mounted read-only from the host. /proc/sys and
+	// /proc/sysrq-trigger are left out as lxc.mount.auto already mounts
+	// them read-only, and /proc/sys is namespaced.
+	readonlyPaths = []string{
+		"/proc/asound",
+		"/proc/bus",
+		"/proc/fs",
+		"/proc/irq",
+	}
+)
+
 // Escape spaces in strings according to the fstab documentation, which is the
 // format for "lxc.mount.entry" lines in lxc.conf. See also "man 5 fstab".
 func escapeFstabSpaces(field string) string {
 	return strings.Replace(field, " ", "\\040", -1)
 }
 
+// tmpfsOptions returns the mount options for a user requested tmpfs mount.
+// The defaults come first so that options given on the command line, e.g.
+// "exec", override them.
+func tmpfsOptions(data string) string {
+	opts := "nosuid,nodev,noexec"
+	if data != "" {
+		opts += "," + data
+	}
+	return opts + ",create=dir"
+}
+
 func keepCapabilities(adds []string, drops []string) ([]string, error) {
 	container := nativeTemplate.New()
 	log.Debugf("adds %s drops %s\n", adds, drops)
@@ -226,14 +327,21 @@ func getLabel(c map[string][]string, name string) string {
 	return ""
 }
 
-func getHostname(env []string) string {
-	for _, kv := range env {
-		parts := strings.SplitN(kv, "=", 2)
-		if parts[0] == "HOSTNAME" && len(parts) == 2 {
-			return parts[1]
-		}
+// vethPairName returns the name of the host side of the veth pair of the
+// container's interface. It is derived from the container ID so that host
+// side rules can target a given container; names are kept within the
+// kernel's 15 character limit.
+func vethPairName(id string) string {
+	if len(id) > 11 {
+		id = id[:11]
 	}
-	return ""
+	return "veth" + id
+}
+
+// isHostUTS reports whether the container shares the host's uts namespace,
+// in which case lxc must leave the hostname alone
+func isHostUTS(uts *execdriver.UTS) bool {
+	return uts != nil && uts.HostUTS
 }
 
 func init() {
@@ -241,11 +349,16 @@ func init() {
 	funcMap := template.FuncMap{
 		"getMemorySwap":     getMemorySwap,
 		"escapeFstabSpaces": escapeFstabSpaces,
+		"tmpfsOptions":      tmpfsOptions,
+		"maskedPaths":       func() []string { return maskedPaths },
+		"maskedDirs":        func() []string { return maskedDirs },
+		"readonlyPaths":     func() []string { return readonlyPaths },
 		"formatMountLabel":  label.FormatMountLabel,
 		"isDirectory":       isDirectory,
 		"keepCapabilities":  keepCapabilities,
 		"dropList":          dropList,
-		"getHostname":       getHostname,
+		"isHostUTS":         isHostUTS,
+		"vethPairName":      vethPairName,
 	}
 	LxcTemplateCompiled, err = template.New("lxc").Funcs(funcMap).Parse(LxcTemplate)
 	if err != nil {
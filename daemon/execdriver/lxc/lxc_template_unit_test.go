@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"github.com/docker/docker/daemon/execdriver"
 	nativeTemplate "github.com/docker/docker/daemon/execdriver/native/template"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer/devices"
+	"github.com/docker/libcontainer/label"
 	"github.com/docker/libcontainer/security/capabilities"
 	"github.com/syndtr/gocapability/capability"
 	"io/ioutil"
@@ -15,6 +17,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -39,7 +42,7 @@ func TestLXCConfig(t *testing.T) {
 		cpu    = cpuMin + rand.Intn(cpuMax-cpuMin)
 	)
 
-	driver, err := NewDriver(root, "", false)
+	driver, err := NewDriver(root, "", false, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -67,6 +70,515 @@ func TestLXCConfig(t *testing.T) {
 		fmt.Sprintf("lxc.cgroup.memory.memsw.limit_in_bytes = %d", mem*2))
 }
 
+func TestLXCConfigRlimits(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigRlimits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Resources: &execdriver.Resources{
+			Rlimits: []*ulimit.Rlimit{
+				{Type: ulimit.RLIMIT_NOFILE, Soft: 1024, Hard: 2048},
+				{Type: ulimit.RLIMIT_NPROC, Soft: 512, Hard: 512},
+				{Type: ulimit.RLIMIT_CORE, Soft: 0, Hard: 0},
+				{Type: ulimit.RLIMIT_MEMLOCK, Soft: 65536, Hard: 65536},
+			},
+		},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	driver.version = "2.0.0"
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.limit.nofile = 1024:2048")
+	grepFile(t, p, "lxc.limit.nproc = 512:512")
+	grepFile(t, p, "lxc.limit.core = 0:0")
+	grepFile(t, p, "lxc.limit.memlock = 65536:65536")
+
+	// dockerinit sets the limits when lxc can't
+	driver.version = "1.0.6"
+	if p, err = driver.generateLXCConfig(command); err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.limit", true)
+}
+
+func TestLXCConfigCpuset(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigCpuset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Resources: &execdriver.Resources{
+			Cpuset:     "0-3",
+			CpusetMems: "0,1",
+		},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.cgroup.cpuset.cpus = 0-3")
+	grepFile(t, p, "lxc.cgroup.cpuset.mems = 0,1")
+}
+
+func TestLXCConfigTmpfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigTmpfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		Mounts: []execdriver.Mount{
+			{Destination: "/run", Writable: true, Device: "tmpfs", Data: "rw,size=64m"},
+			{Destination: "/var/cache", Writable: true, Device: "tmpfs"},
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.mount.entry = tmpfs //run tmpfs nosuid,nodev,noexec,rw,size=64m,create=dir 0 0")
+	grepFile(t, p, "lxc.mount.entry = tmpfs //var/cache tmpfs nosuid,nodev,noexec,create=dir 0 0")
+}
+
+func TestLXCConfigMaskedPaths(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigMaskedPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.mount.entry = /dev/null /proc/kcore none bind,optional 0 0")
+	grepFile(t, p, "lxc.mount.entry = tmpfs /sys/firmware tmpfs ro,nosuid,nodev,noexec,optional 0 0")
+	grepFile(t, p, "lxc.mount.entry = /proc/irq /proc/irq none bind,ro,optional 0 0")
+
+	command.ProcessConfig.Privileged = true
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.mount.entry = /dev/null /proc/kcore none bind,optional 0 0", true)
+}
+
+func TestLXCConfigGpus(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigGpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	defer func(patterns []string) { gpuDevicePatterns = patterns }(gpuDevicePatterns)
+	gpuDevicePatterns = []string{"/dev/null", path.Join(root, "*")}
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+		Gpus:          true,
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.cgroup.devices.allow = c 1:3 rwm")
+	grepFile(t, p, "lxc.mount.entry = /dev/null /dev/null none bind,optional,create=file 0 0")
+	grepFileWithReverse(t, p, "lxc.mount.entry = "+root, true)
+}
+
+func TestLXCConfigStopSignal(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigStopSignal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:         "1",
+		StopSignal: int(syscall.SIGINT),
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.haltsignal = 2")
+}
+
+func TestLXCConfigReadonlyRootfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigReadonlyRootfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:             "1",
+		Rootfs:         "/var/lib/docker/rootfs",
+		ReadonlyRootfs: true,
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.rootfs.options = ro")
+	grepFile(t, p, "lxc.mount.entry = tmpfs /var/lib/docker/rootfs/run tmpfs nosuid,nodev,mode=755,optional 0 0")
+	grepFile(t, p, "lxc.mount.entry = tmpfs /var/lib/docker/rootfs/tmp tmpfs nosuid,nodev,mode=1777,optional 0 0")
+
+	command.ReadonlyRootfs = false
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.rootfs.options", true)
+	grepFileWithReverse(t, p, "lxc.mount.entry = tmpfs /var/lib/docker/rootfs/run", true)
+	grepFileWithReverse(t, p, "lxc.mount.entry = tmpfs /var/lib/docker/rootfs/tmp", true)
+}
+
+func TestLXCConfigHostUTS(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigHostUTS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:       "1",
+		Hostname: "testhost",
+		UTS:      &execdriver.UTS{HostUTS: true},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.utsname", true)
+
+	command.UTS.HostUTS = false
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.utsname = testhost")
+}
+
+func TestLXCConfigHostIpc(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigHostIpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:  "1",
+		Ipc: &execdriver.Ipc{HostIpc: true},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, fmt.Sprintf("lxc.mount.entry = /dev/shm %s/dev/shm none rbind,rw 0 0", command.Rootfs))
+	grepFileWithReverse(t, p, "lxc.mount.entry = shm", true)
+
+	command.Ipc.HostIpc = false
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, fmt.Sprintf("lxc.mount.entry = shm %s/dev/shm tmpfs size=65536k,nosuid,nodev,noexec 0 0", command.Rootfs))
+}
+
+func TestLXCConfigContainerNetwork(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigContainerNetwork")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:         1500,
+			ContainerID: "2",
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.network.type", true)
+	grepFileWithReverse(t, p, "lxc.network.mtu", true)
+}
+
+func TestLXCConfigDefaultAppArmorProfile(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigDefaultAppArmorProfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pretend apparmor is enabled without loading the profile on the host
+	driver.apparmor = true
+
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.aa_profile = docker-default")
+
+	command.AppArmorProfile = "custom-profile"
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.aa_profile = custom-profile")
+	grepFileWithReverse(t, p, "lxc.aa_profile = docker-default", true)
+}
+
+func TestLXCConfigNoNewPrivileges(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigNoNewPrivileges")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:              "1",
+		NoNewPrivileges: true,
+		CapDrop:         []string{"MKNOD"},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+
+	driver.version = "1.0.6"
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dockerinit sets no_new_privs when lxc can't
+	grepFileWithReverse(t, p, "lxc.no_new_privs", true)
+	// capabilities are dropped even without a network interface
+	grepFile(t, p, fmt.Sprintf("lxc.cap.keep = %d", capability.CAP_CHOWN))
+	grepFileWithReverse(t, p, fmt.Sprintf("lxc.cap.keep = %d", capability.CAP_MKNOD), true)
+
+	driver.version = "2.1.1"
+	if p, err = driver.generateLXCConfig(command); err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.no_new_privs = 1")
+}
+
+func TestLXCConfigSELinuxLabels(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigSELinuxLabels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID:           "1",
+		ProcessLabel: "system_u:system_r:svirt_lxc_net_t:s0:c1,c2",
+		MountLabel:   "system_u:object_r:svirt_sandbox_file_t:s0:c1,c2",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.se_context = system_u:system_r:svirt_lxc_net_t:s0:c1,c2")
+	grepFile(t, p, "lxc.mount.entry = devpts /dev/pts devpts "+label.FormatMountLabel("newinstance,ptmxmode=0666,nosuid,noexec", command.MountLabel))
+
+	command.ProcessLabel = ""
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.se_context", true)
+}
+
+func TestLXCConfigVethPair(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigVethPair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	id := "0123456789abcdef0123456789abcdef"
+	os.MkdirAll(path.Join(root, "containers", id), 0777)
+
+	driver, err := NewDriver(root, "", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: id,
+		Network: &execdriver.Network{
+			Mtu: 1500,
+			Interface: &execdriver.NetworkInterface{
+				IPAddress:   "10.10.10.10",
+				IPPrefixLen: 24,
+				Bridge:      "docker0",
+			},
+		},
+		ProcessConfig: execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.network.veth.pair = veth0123456789a")
+}
+
 func TestCustomLxcConfig(t *testing.T) {
 	root, err := ioutil.TempDir("", "TestCustomLxcConfig")
 	if err != nil {
@@ -76,7 +588,7 @@ func TestCustomLxcConfig(t *testing.T) {
 
 	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
 
-	driver, err := NewDriver(root, "", false)
+	driver, err := NewDriver(root, "", false, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -194,7 +706,7 @@ func TestCustomLxcConfigMounts(t *testing.T) {
 	}
 	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
 
-	driver, err := NewDriver(root, "", false)
+	driver, err := NewDriver(root, "", false, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -248,7 +760,7 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 	}
 	defer os.RemoveAll(root)
 	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
-	driver, err := NewDriver(root, "", true)
+	driver, err := NewDriver(root, "", true, "")
 
 	if err != nil {
 		t.Fatal(err)
@@ -257,9 +769,9 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 		Privileged: false,
 	}
 
-	processConfig.Env = []string{"HOSTNAME=testhost"}
 	command := &execdriver.Command{
-		ID: "1",
+		ID:       "1",
+		Hostname: "testhost",
 		LxcConfig: []string{
 			"lxc.cgroup.cpuset.cpus = 0,1",
 		},
@@ -313,7 +825,7 @@ func TestCustomLxcConfigMiscOverride(t *testing.T) {
 	}
 	defer os.RemoveAll(root)
 	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
-	driver, err := NewDriver(root, "", false)
+	driver, err := NewDriver(root, "", false, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -321,9 +833,9 @@ func TestCustomLxcConfigMiscOverride(t *testing.T) {
 		Privileged: false,
 	}
 
-	processConfig.Env = []string{"HOSTNAME=testhost"}
 	command := &execdriver.Command{
-		ID: "1",
+		ID:       "1",
+		Hostname: "testhost",
 		LxcConfig: []string{
 			"lxc.cgroup.cpuset.cpus = 0,1",
 			"lxc.network.ipv4 = 172.0.0.1",
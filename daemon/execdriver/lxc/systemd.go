@@ -0,0 +1,59 @@
+package lxc
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+
+	"github.com/docker/docker/pkg/systemd"
+)
+
+const (
+	CgroupDriverCgroupfs = "cgroupfs"
+	CgroupDriverSystemd  = "systemd"
+
+	// systemdSlice is the slice the containers' scopes are created in
+	systemdSlice = "system.slice"
+)
+
+// useSystemdCgroups validates the requested cgroup driver and reports whether
+// containers should be placed in systemd scopes
+func useSystemdCgroups(cgroupDriver string) (bool, error) {
+	switch cgroupDriver {
+	case "", CgroupDriverCgroupfs:
+		return false, nil
+	case CgroupDriverSystemd:
+		if !systemd.SdBooted() {
+			return false, fmt.Errorf("the systemd cgroup driver requires a host booted with systemd")
+		}
+		if _, err := exec.LookPath("systemd-run"); err != nil {
+			return false, fmt.Errorf("the systemd cgroup driver requires systemd-run: %s", err)
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown cgroup driver %s", cgroupDriver)
+}
+
+// systemdScopeName returns the name of the transient scope of container id
+func systemdScopeName(id string) string {
+	return fmt.Sprintf("docker-%s.scope", id)
+}
+
+// systemdScopePath returns the path of the scope of container id, relative to
+// the root of a cgroup hierarchy
+func systemdScopePath(id string) string {
+	return path.Join("/", systemdSlice, systemdScopeName(id))
+}
+
+// systemdScopeParams returns the systemd-run invocation running the rest of
+// the command line in the transient scope of container id
+func systemdScopeParams(id string) []string {
+	return []string{
+		"systemd-run",
+		"--scope",
+		"--slice", systemdSlice,
+		"--unit", systemdScopeName(id),
+		"--description", "docker container " + id,
+		"--",
+	}
+}
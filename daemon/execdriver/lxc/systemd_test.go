@@ -0,0 +1,26 @@
+package lxc
+
+import (
+	"testing"
+)
+
+func TestUseSystemdCgroups(t *testing.T) {
+	for _, driver := range []string{"", CgroupDriverCgroupfs} {
+		useSystemd, err := useSystemdCgroups(driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if useSystemd {
+			t.Fatalf("expected cgroup driver %q not to use systemd", driver)
+		}
+	}
+	if _, err := useSystemdCgroups("notarealdriver"); err == nil {
+		t.Fatal("expected an error for an unknown cgroup driver")
+	}
+}
+
+func TestSystemdScopePath(t *testing.T) {
+	if p := systemdScopePath("1"); p != "/system.slice/docker-1.scope" {
+		t.Fatalf("expected /system.slice/docker-1.scope, got %s", p)
+	}
+}
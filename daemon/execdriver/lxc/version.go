@@ -0,0 +1,105 @@
+package lxc
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/version"
+)
+
+// feature is an lxc configuration key the driver may render, along with the
+// first lxc release understanding it
+type feature struct {
+	key        string
+	minVersion version.Version
+}
+
+var (
+	featureMountAuto  = feature{"lxc.mount.auto", "1.0.0"}
+	featureAppArmor   = feature{"lxc.aa_profile", "0.8.0"}
+	featureIdMap      = feature{"lxc.id_map", "1.0.0"}
+	featureSeccomp    = feature{"lxc.seccomp", "1.0.0"}
+	featureNoNewPrivs = feature{"lxc.no_new_privs", "2.1.0"}
+	featureLimit      = feature{"lxc.limit", "2.0.0"}
+)
+
+// probeVersion returns the version of the installed lxc tools, or an empty
+// string when it can't be determined
+func probeVersion() string {
+	var (
+		version string
+		output  []byte
+		err     error
+	)
+	if _, errPath := exec.LookPath("lxc-version"); errPath == nil {
+		output, err = exec.Command("lxc-version").CombinedOutput()
+	} else {
+		output, err = exec.Command("lxc-start", "--version").CombinedOutput()
+	}
+	if err == nil {
+		version = strings.TrimSpace(string(output))
+		if parts := strings.SplitN(version, ":", 2); len(parts) == 2 {
+			version = strings.TrimSpace(parts[1])
+		}
+	}
+	return version
+}
+
+// releaseVersion strips pre-release suffixes such as "~rc1" or "-alpha1"
+// from an lxc version, leaving the dotted release numbers
+func releaseVersion(v string) version.Version {
+	if i := strings.IndexFunc(v, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	}); i != -1 {
+		v = v[:i]
+	}
+	return version.Version(strings.TrimSuffix(v, "."))
+}
+
+// requestedFeatures returns the version dependent configuration keys the
+// template renders for c, or the user passes through --lxc-conf
+func (d *driver) requestedFeatures(c *execdriver.Command) []feature {
+	var features []feature
+	if c.ProcessConfig.Privileged {
+		if d.apparmor {
+			features = append(features, featureAppArmor)
+		}
+	} else {
+		features = append(features, featureMountAuto)
+		if c.AppArmorProfile != "" || d.apparmor {
+			features = append(features, featureAppArmor)
+		}
+	}
+	for _, f := range []feature{featureIdMap, featureSeccomp} {
+		for _, value := range c.LxcConfig {
+			if strings.HasPrefix(strings.TrimPrefix(strings.TrimSpace(value), "lxc."), strings.TrimPrefix(f.key, "lxc.")) {
+				features = append(features, f)
+				break
+			}
+		}
+	}
+	return features
+}
+
+// supports reports whether the installed lxc understands the configuration
+// key of f, assuming it does when the version is unknown
+func (d *driver) supports(f feature) bool {
+	return d.version == "" || !releaseVersion(d.version).LessThan(f.minVersion)
+}
+
+// checkFeatures makes sure the installed lxc understands every configuration
+// key needed by c, rather than letting lxc-start fail on an unknown key
+func (d *driver) checkFeatures(c *execdriver.Command) error {
+	var unsupported []string
+	for _, f := range d.requestedFeatures(c) {
+		if !d.supports(f) {
+			unsupported = append(unsupported, fmt.Sprintf("%s (requires lxc %s)", f.key, f.minVersion))
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("lxc %s does not support the requested features: %s", d.version, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
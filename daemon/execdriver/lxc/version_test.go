@@ -0,0 +1,52 @@
+package lxc
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+func TestReleaseVersion(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"1.0.6":         "1.0.6",
+		"1.1.0~rc1":     "1.1.0",
+		"1.0.0.alpha1":  "1.0.0",
+		"0.7.5-ubuntu1": "0.7.5",
+	} {
+		if v := releaseVersion(raw); string(v) != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, raw, v)
+		}
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	d := &driver{version: "0.7.5", apparmor: true}
+
+	privileged := &execdriver.Command{
+		ProcessConfig: execdriver.ProcessConfig{Privileged: true},
+	}
+	if err := d.checkFeatures(privileged); err == nil {
+		t.Fatal("expected lxc.aa_profile to be unsupported by lxc 0.7.5")
+	}
+
+	d.apparmor = false
+	if err := d.checkFeatures(privileged); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.checkFeatures(&execdriver.Command{}); err == nil {
+		t.Fatal("expected lxc.mount.auto to be unsupported by lxc 0.7.5")
+	}
+
+	d.version = "1.0.6"
+	c := &execdriver.Command{
+		LxcConfig: []string{"seccomp = /usr/share/lxc/config/common.seccomp"},
+	}
+	if err := d.checkFeatures(c); err != nil {
+		t.Fatal(err)
+	}
+
+	d.version = ""
+	if err := d.checkFeatures(c); err != nil {
+		t.Fatalf("expected no check when the lxc version is unknown, got %s", err)
+	}
+}
@@ -22,7 +22,7 @@ import (
 func (d *driver) createContainer(c *execdriver.Command) (*libcontainer.Config, error) {
 	container := template.New()
 
-	container.Hostname = getEnv("HOSTNAME", c.ProcessConfig.Env)
+	container.Hostname = c.Hostname
 	container.Tty = c.ProcessConfig.Tty
 	container.User = c.ProcessConfig.User
 	container.WorkingDir = c.WorkingDir
@@ -45,6 +45,10 @@ func (d *driver) createContainer(c *execdriver.Command) (*libcontainer.Config, e
 		return nil, err
 	}
 
+	if err := d.createUTS(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := d.createNetwork(container, c); err != nil {
 		return nil, err
 	}
@@ -63,10 +67,20 @@ func (d *driver) createContainer(c *execdriver.Command) (*libcontainer.Config, e
 		container.AppArmorProfile = c.AppArmorProfile
 	}
 
+	if c.NoNewPrivileges {
+		return nil, fmt.Errorf("no-new-privileges is not supported by the %s driver", DriverName)
+	}
+
+	if c.Gpus {
+		return nil, fmt.Errorf("GPU access is not supported by the %s driver, use --device instead", DriverName)
+	}
+
 	if err := d.setupCgroups(container, c); err != nil {
 		return nil, err
 	}
 
+	d.setupRlimits(container, c)
+
 	if err := d.setupMounts(container, c); err != nil {
 		return nil, err
 	}
@@ -165,6 +179,17 @@ func (d *driver) createPid(container *libcontainer.Config, c *execdriver.Command
 	return nil
 }
 
+func (d *driver) createUTS(container *libcontainer.Config, c *execdriver.Command) error {
+	if c.UTS != nil && c.UTS.HostUTS {
+		container.Namespaces.Remove(libcontainer.NEWUTS)
+		// never change the hostname of the host
+		container.Hostname = ""
+		return nil
+	}
+
+	return nil
+}
+
 func (d *driver) setPrivileged(container *libcontainer.Config) (err error) {
 	container.Capabilities = capabilities.GetAllCapabilities()
 	container.Cgroups.AllowAllDevices = true
@@ -196,13 +221,34 @@ func (d *driver) setupCgroups(container *libcontainer.Config, c *execdriver.Comm
 		container.Cgroups.MemoryReservation = c.Resources.Memory
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
 		container.Cgroups.CpusetCpus = c.Resources.Cpuset
+		container.Cgroups.CpusetMems = c.Resources.CpusetMems
 	}
 
 	return nil
 }
 
+func (d *driver) setupRlimits(container *libcontainer.Config, c *execdriver.Command) {
+	if c.Resources == nil {
+		return
+	}
+
+	for _, rlimit := range c.Resources.Rlimits {
+		container.Rlimits = append(container.Rlimits, libcontainer.Rlimit((*rlimit)))
+	}
+}
+
 func (d *driver) setupMounts(container *libcontainer.Config, c *execdriver.Command) error {
 	for _, m := range c.Mounts {
+		if m.Device == "tmpfs" {
+			if m.Data != "" {
+				return fmt.Errorf("tmpfs mount options for %s are not supported by the %s driver", m.Destination, DriverName)
+			}
+			container.MountConfig.Mounts = append(container.MountConfig.Mounts, &mount.Mount{
+				Type:        "tmpfs",
+				Destination: m.Destination,
+			})
+			continue
+		}
 		container.MountConfig.Mounts = append(container.MountConfig.Mounts, &mount.Mount{
 			Type:        "bind",
 			Source:      m.Source,
@@ -10,7 +10,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -113,6 +112,23 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
+	// the output goes through fifos which outlive the daemon, for the daemon
+	// started next to reattach to the process
+	var fifos []*os.File
+	if c.LiveRestore {
+		if fifos, err = d.createFifos(c.ID); err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		defer d.removeFifos(c.ID)
+		copied, err := d.copyFifos(c.ID, pipes)
+		if err != nil {
+			closeFiles(fifos)
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		defer copied.Wait()
+		c.ProcessConfig.Stdout, c.ProcessConfig.Stderr = fifos[0], fifos[1]
+	}
+
 	execOutputChan := make(chan execOutput, 1)
 	waitForStart := make(chan struct{})
 
@@ -149,6 +165,7 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 
 	select {
 	case execOutput := <-execOutputChan:
+		closeFiles(fifos)
 		return execdriver.ExitStatus{ExitCode: execOutput.exitCode}, execOutput.err
 	case <-waitForStart:
 		break
@@ -168,6 +185,7 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	}
 	// wait for the container to exit.
 	execOutput := <-execOutputChan
+	closeFiles(fifos)
 
 	return execdriver.ExitStatus{ExitCode: execOutput.exitCode, OOMKilled: oomKill}, execOutput.err
 }
@@ -313,16 +331,6 @@ func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	}, nil
 }
 
-func getEnv(key string, env []string) string {
-	for _, pair := range env {
-		parts := strings.Split(pair, "=")
-		if parts[0] == key {
-			return parts[1]
-		}
-	}
-	return ""
-}
-
 type TtyConsole struct {
 	MasterPty *os.File
 }
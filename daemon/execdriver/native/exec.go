@@ -4,16 +4,22 @@ package native
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/utils"
 	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/apparmor"
+	"github.com/docker/libcontainer/cgroups"
 	"github.com/docker/libcontainer/namespaces"
+	"github.com/docker/libcontainer/security/capabilities"
 )
 
 const execCommandName = "nsenter-exec"
@@ -38,7 +44,6 @@ func nsenterExec() {
 	}
 }
 
-// TODO(vishh): Add support for running in priviledged mode and running as a different user.
 func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
 	active := d.activeContainers[c.ID]
 	if active == nil {
@@ -61,10 +66,106 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 
 	args := append([]string{processConfig.Entrypoint}, processConfig.Arguments...)
 
-	return namespaces.ExecIn(active.container, state, args, os.Args[0], "exec", processConfig.Stdin, processConfig.Stdout, processConfig.Stderr, processConfig.Console,
+	config := *active.container
+	if processConfig.User != "" {
+		// the user and its groups are looked up in the container once
+		// its namespaces are joined
+		config.User = processConfig.User
+	}
+	if len(processConfig.Env) > 0 {
+		config.Env = utils.ReplaceOrAppendEnvValues(append([]string(nil), config.Env...), processConfig.Env)
+	}
+	if processConfig.WorkingDir != "" {
+		config.WorkingDir = processConfig.WorkingDir
+	}
+	if processConfig.Privileged {
+		config.Capabilities = capabilities.GetAllCapabilities()
+		if apparmor.IsEnabled() {
+			config.AppArmorProfile = "unconfined"
+		}
+		state = privilegedState(state)
+	}
+	if processConfig.Resources != nil {
+		paths, created, err := execCgroups(state.CgroupPaths, processConfig.Resources)
+		if err != nil {
+			term.Close()
+			return -1, err
+		}
+		defer cgroups.RemovePaths(created)
+		limited := *state
+		limited.CgroupPaths = paths
+		state = &limited
+	}
+
+	return namespaces.ExecIn(&config, state, args, os.Args[0], "exec", processConfig.Stdin, processConfig.Stdout, processConfig.Stderr, processConfig.Console,
 		func(cmd *exec.Cmd) {
 			if startCallback != nil {
 				startCallback(&c.ProcessConfig, cmd.Process.Pid)
 			}
 		})
 }
+
+// privilegedState returns a copy of state without the devices cgroup of the
+// container, so that a privileged exec stays in the devices cgroup of the
+// daemon and is not restricted to the devices of the container
+func privilegedState(state *libcontainer.State) *libcontainer.State {
+	privileged := *state
+	privileged.CgroupPaths = make(map[string]string, len(state.CgroupPaths))
+	for subsystem, path := range state.CgroupPaths {
+		if subsystem != "devices" {
+			privileged.CgroupPaths[subsystem] = path
+		}
+	}
+	return &privileged
+}
+
+// execCgroups creates child cgroups of the cgroups of the container limiting
+// an exec to resources, within the ones of the container. It returns the
+// cgroup paths the exec joins, and the paths of the cgroups created, to be
+// removed once the exec exited.
+func execCgroups(paths map[string]string, resources *execdriver.Resources) (map[string]string, map[string]string, error) {
+	limits := make(map[string]map[string]string)
+	if resources.Memory > 0 {
+		limits["memory"] = map[string]string{"memory.limit_in_bytes": strconv.FormatInt(resources.Memory, 10)}
+	}
+	if resources.CpuShares > 0 {
+		limits["cpu"] = map[string]string{"cpu.shares": strconv.FormatInt(resources.CpuShares, 10)}
+	}
+
+	joined := make(map[string]string, len(paths))
+	for subsystem, path := range paths {
+		joined[subsystem] = path
+	}
+	created := make(map[string]string)
+	// subsystems mounted together share a cgroup
+	children := make(map[string]string)
+	for subsystem, files := range limits {
+		parent, ok := paths[subsystem]
+		if !ok {
+			cgroups.RemovePaths(created)
+			return nil, nil, fmt.Errorf("cgroup subsystem %s of the container not found", subsystem)
+		}
+		child, ok := children[parent]
+		if !ok {
+			var err error
+			if child, err = ioutil.TempDir(parent, "exec-"); err != nil {
+				cgroups.RemovePaths(created)
+				return nil, nil, err
+			}
+			children[parent] = child
+			created[subsystem] = child
+		}
+		for file, value := range files {
+			if err := ioutil.WriteFile(filepath.Join(child, file), []byte(value), 0700); err != nil {
+				cgroups.RemovePaths(created)
+				return nil, nil, err
+			}
+		}
+	}
+	for subsystem, path := range paths {
+		if child, ok := children[path]; ok {
+			joined[subsystem] = child
+		}
+	}
+	return joined, created, nil
+}
@@ -0,0 +1,62 @@
+// +build linux
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+func TestExecCgroups(t *testing.T) {
+	root, err := ioutil.TempDir("", "exec-cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	paths := map[string]string{
+		"memory":  filepath.Join(root, "memory"),
+		"cpu":     filepath.Join(root, "cpu,cpuacct"),
+		"cpuacct": filepath.Join(root, "cpu,cpuacct"),
+		"devices": filepath.Join(root, "devices"),
+	}
+	for _, path := range paths {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	joined, created, err := execCgroups(paths, &execdriver.Resources{Memory: 4194304, CpuShares: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("Expected 2 cgroups to be created, got %v", created)
+	}
+	if joined["devices"] != paths["devices"] {
+		t.Fatalf("Expected the devices cgroup of the container to be joined, got %s", joined["devices"])
+	}
+	if joined["cpu"] != joined["cpuacct"] || filepath.Dir(joined["cpu"]) != paths["cpu"] {
+		t.Fatalf("Expected cpu and cpuacct to join the same child cgroup, got %s and %s", joined["cpu"], joined["cpuacct"])
+	}
+	for _, limit := range []struct{ subsystem, file, value string }{
+		{"memory", "memory.limit_in_bytes", "4194304"},
+		{"cpu", "cpu.shares", "512"},
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(joined[limit.subsystem], limit.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != limit.value {
+			t.Fatalf("Expected %s to be %s, got %s", limit.file, limit.value, data)
+		}
+	}
+
+	if _, _, err := execCgroups(map[string]string{"cpu": paths["cpu"]}, &execdriver.Resources{Memory: 4194304}); err == nil {
+		t.Fatal("Expected an error without the memory cgroup of the container")
+	}
+}
@@ -0,0 +1,179 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/system"
+)
+
+// reattachPollInterval is how often the process of a reattached container,
+// which is not a child of the daemon, is checked for exit
+const reattachPollInterval = 100 * time.Millisecond
+
+var fifoStreams = []string{"stdout", "stderr"}
+
+func (d *driver) fifoPath(id, stream string) string {
+	return filepath.Join(d.root, id, stream)
+}
+
+// createFifos creates the fifos the process of the container writes its
+// output to instead of the pipes of the daemon, and returns them opened for
+// the process. They are opened read-write so that the process never gets
+// SIGPIPE while no daemon reads them, it only blocks once they are full
+func (d *driver) createFifos(id string) ([]*os.File, error) {
+	var files []*os.File
+	for _, stream := range fifoStreams {
+		path := d.fifoPath(id, stream)
+		os.Remove(path)
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			closeFiles(files)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// copyFifos copies the output of the container from its fifos to pipes until
+// no process writes to them anymore
+func (d *driver) copyFifos(id string, pipes *execdriver.Pipes) (*sync.WaitGroup, error) {
+	var (
+		copied  = &sync.WaitGroup{}
+		readers []*os.File
+	)
+	for _, stream := range fifoStreams {
+		// a fifo without a writer left would block the open for good
+		f, err := os.OpenFile(d.fifoPath(id, stream), os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			closeFiles(readers)
+			return nil, err
+		}
+		if err := syscall.SetNonblock(int(f.Fd()), false); err != nil {
+			f.Close()
+			closeFiles(readers)
+			return nil, err
+		}
+		readers = append(readers, f)
+	}
+
+	for i, dst := range []io.Writer{pipes.Stdout, pipes.Stderr} {
+		copied.Add(1)
+		go func(dst io.Writer, src *os.File) {
+			defer copied.Done()
+			defer src.Close()
+			if dst == nil {
+				dst = ioutil.Discard
+			}
+			if _, err := io.Copy(dst, src); err != nil {
+				log.Debugf("copying the output of %s: %s", id, err)
+			}
+		}(dst, readers[i])
+	}
+	return copied, nil
+}
+
+func (d *driver) removeFifos(id string) {
+	for _, stream := range fifoStreams {
+		os.Remove(d.fifoPath(id, stream))
+	}
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// Reattach attaches to the process of a container run with LiveRestore by a
+// previous daemon. The process is not a child of this daemon, so it is polled
+// until it exits, and its exit code can't be known: it is reported as -1,
+// which the on-failure restart policy doesn't take for a failure
+func (d *driver) Reattach(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	dataPath := filepath.Join(d.root, c.ID)
+	state, err := libcontainer.GetState(dataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRunning
+		}
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	// the previous daemon isn't there to clean up after the process anymore
+	defer func() {
+		d.cleanContainer(c.ID)
+		d.removeFifos(c.ID)
+		cgroups.RemovePaths(state.CgroupPaths)
+		libcontainer.DeleteState(dataPath)
+	}()
+
+	if !isRunning(state) {
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRunning
+	}
+
+	container, err := d.createContainer(c)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	copied, err := d.copyFifos(c.ID, pipes)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+	if c.ProcessConfig.Process, err = os.FindProcess(state.InitPid); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	d.Lock()
+	d.activeContainers[c.ID] = &activeContainer{
+		container: container,
+		cmd:       &c.ProcessConfig.Cmd,
+	}
+	d.Unlock()
+
+	if startCallback != nil {
+		c.ContainerPid = state.InitPid
+		startCallback(&c.ProcessConfig, c.ContainerPid)
+	}
+
+	oomKillNotification, err := libcontainer.NotifyOnOOM(state)
+	if err != nil {
+		log.Warnf("WARNING: Your kernel does not support OOM notifications: %s", err)
+	}
+
+	for isRunning(state) {
+		time.Sleep(reattachPollInterval)
+	}
+	copied.Wait()
+
+	// the notifications end once the cgroups are removed
+	cgroups.RemovePaths(state.CgroupPaths)
+	oomKill := false
+	if oomKillNotification != nil {
+		_, oomKill = <-oomKillNotification
+	}
+	return execdriver.ExitStatus{ExitCode: -1, OOMKilled: oomKill}, nil
+}
+
+// isRunning returns whether the init process of state is still running, and
+// not another process which got its pid since
+func isRunning(state *libcontainer.State) bool {
+	started, err := system.GetProcessStartTime(state.InitPid)
+	return err == nil && started == state.InitStartTime
+}
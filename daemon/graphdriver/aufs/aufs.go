@@ -304,14 +304,14 @@ func (a *Driver) Put(id string) error {
 // layer and its parent layer which may be "".
 func (a *Driver) Diff(id, parent string) (archive.Archive, error) {
 	// AUFS doesn't need the parent layer to produce a diff.
-	return archive.TarWithOptions(path.Join(a.rootPath(), "diff", id), &archive.TarOptions{
+	return chrootarchive.Tar(path.Join(a.rootPath(), "diff", id), &archive.TarOptions{
 		Compression:     archive.Uncompressed,
 		ExcludePatterns: []string{".wh..wh.*"},
 	})
 }
 
-func (a *Driver) applyDiff(id string, diff archive.ArchiveReader) error {
-	return chrootarchive.Untar(diff, path.Join(a.rootPath(), "diff", id), nil)
+func (a *Driver) applyDiff(id string, diff archive.ArchiveReader, options *archive.TarOptions) error {
+	return chrootarchive.Untar(diff, path.Join(a.rootPath(), "diff", id), options)
 }
 
 // DiffSize calculates the changes between the specified id
@@ -325,9 +325,9 @@ func (a *Driver) DiffSize(id, parent string) (size int64, err error) {
 // ApplyDiff extracts the changeset from the given diff into the
 // layer with the specified id and parent, returning the size of the
 // new layer in bytes.
-func (a *Driver) ApplyDiff(id, parent string, diff archive.ArchiveReader) (size int64, err error) {
+func (a *Driver) ApplyDiff(id, parent string, diff archive.ArchiveReader, options *archive.TarOptions) (size int64, err error) {
 	// AUFS doesn't need the parent id to apply the diff.
-	if err = a.applyDiff(id, diff); err != nil {
+	if err = a.applyDiff(id, diff, options); err != nil {
 		return
 	}
 
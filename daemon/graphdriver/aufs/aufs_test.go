@@ -621,7 +621,7 @@ func TestApplyDiff(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := d.applyDiff("3", diff); err != nil {
+	if err := d.applyDiff("3", diff, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -108,8 +108,9 @@ type Driver interface {
 	Changes(id, parent string) ([]archive.Change, error)
 	// ApplyDiff extracts the changeset from the given diff into the
 	// layer with the specified id and parent, returning the size of the
-	// new layer in bytes.
-	ApplyDiff(id, parent string, diff archive.ArchiveReader) (size int64, err error)
+	// new layer in bytes. options, which may be nil, bound the extraction
+	// as for chrootarchive.ApplyLayer.
+	ApplyDiff(id, parent string, diff archive.ArchiveReader, options *archive.TarOptions) (size int64, err error)
 	// DiffSize calculates the changes between the specified id
 	// and its parent and returns the size in bytes of the changes
 	// relative to its base filesystem directory.
@@ -28,7 +28,7 @@ var (
 
 type ApplyDiffProtoDriver interface {
 	graphdriver.ProtoDriver
-	ApplyDiff(id, parent string, diff archive.ArchiveReader) (size int64, err error)
+	ApplyDiff(id, parent string, diff archive.ArchiveReader, options *archive.TarOptions) (size int64, err error)
 }
 
 type naiveDiffDriverWithApply struct {
@@ -43,10 +43,10 @@ func NaiveDiffDriverWithApply(driver ApplyDiffProtoDriver) graphdriver.Driver {
 	}
 }
 
-func (d *naiveDiffDriverWithApply) ApplyDiff(id, parent string, diff archive.ArchiveReader) (int64, error) {
-	b, err := d.applyDiff.ApplyDiff(id, parent, diff)
+func (d *naiveDiffDriverWithApply) ApplyDiff(id, parent string, diff archive.ArchiveReader, options *archive.TarOptions) (int64, error) {
+	b, err := d.applyDiff.ApplyDiff(id, parent, diff, options)
 	if err == ErrApplyDiffFallback {
-		return d.Driver.ApplyDiff(id, parent, diff)
+		return d.Driver.ApplyDiff(id, parent, diff, options)
 	}
 	return b, err
 }
@@ -334,7 +334,7 @@ func (d *Driver) Put(id string) error {
 	return nil
 }
 
-func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader) (size int64, err error) {
+func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader, options *archive.TarOptions) (size int64, err error) {
 	dir := d.dir(id)
 
 	if parent == "" {
@@ -372,7 +372,7 @@ func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader)
 		return 0, err
 	}
 
-	if size, err = chrootarchive.ApplyLayer(tmpRootDir, diff); err != nil {
+	if size, err = chrootarchive.ApplyLayer(tmpRootDir, diff, options); err != nil {
 		return 0, err
 	}
 
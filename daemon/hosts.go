@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/networkfs/etchosts"
+)
+
+// ContainerSetHosts replaces the extra hosts of the container NAME with the
+// ExtraHosts list. The hosts file of a running container is rewritten in a
+// single write, the others get the new records at their next start.
+func (daemon *Daemon) ContainerSetHosts(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container := daemon.Get(name)
+	if container == nil {
+		return job.Errorf("No such container: %s", name)
+	}
+
+	extraHosts := job.GetenvList("ExtraHosts")
+	for _, extraHost := range extraHosts {
+		if _, err := opts.ValidateExtraHost(extraHost); err != nil {
+			return job.Error(err)
+		}
+	}
+	// these containers use the hosts file of the host or of another container
+	if mode := container.hostConfig.NetworkMode; mode.IsHost() || mode.IsContainer() {
+		return job.Errorf("Cannot change the hosts of container %s in the network mode %s", container.Name[1:], mode)
+	}
+
+	container.Lock()
+	defer container.Unlock()
+	if container.Running {
+		var oldHosts []string
+		for _, r := range extraHostsRecords(container.hostConfig.ExtraHosts) {
+			oldHosts = append(oldHosts, r.Hosts)
+		}
+		if err := etchosts.Replace(container.HostsPath, oldHosts, extraHostsRecords(extraHosts)); err != nil {
+			return job.Error(err)
+		}
+	}
+	container.hostConfig.ExtraHosts = extraHosts
+	if err := container.WriteHostConfig(); err != nil {
+		return job.Error(err)
+	}
+	container.LogEvent("hosts")
+	return engine.StatusOK
+}
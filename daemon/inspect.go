@@ -3,8 +3,10 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/links"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -42,6 +44,12 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 		out.Set("HostsPath", container.HostsPath)
 		out.SetJson("Name", container.Name)
 		out.SetInt("RestartCount", container.RestartCount)
+		out.SetInt("FailureCount", container.FailureCount)
+		var restartState RestartState
+		if container.monitor != nil {
+			restartState = container.monitor.restartState()
+		}
+		out.SetJson("RestartState", restartState)
 		out.Set("Driver", container.Driver)
 		out.Set("ExecDriver", container.ExecDriver)
 		out.Set("MountLabel", container.MountLabel)
@@ -54,7 +62,14 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) engine.Status {
 
 		if children, err := daemon.Children(container.Name); err == nil {
 			for linkAlias, child := range children {
-				container.hostConfig.Links = append(container.hostConfig.Links, fmt.Sprintf("%s:%s", child.Name, linkAlias))
+				hidden := container.HiddenLinks[path.Base(linkAlias)]
+				// without the ID telling apart the containers sharing an alias
+				linkAlias = path.Join(path.Dir(linkAlias), links.Alias(linkAlias))
+				link := fmt.Sprintf("%s:%s", child.Name, linkAlias)
+				if hidden {
+					link += ":hide"
+				}
+				container.hostConfig.Links = append(container.hostConfig.Links, link)
 			}
 		}
 
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/runconfig"
+)
+
+// ContainerLink links the container PARENT to CHILD as ALIAS, or as each of
+// the aliases separated by commas in ALIAS, hidden while CHILD is not running
+// if Hide is set. The links are enabled right away if PARENT is running, as if
+// they were set at its start. Several containers can be linked to PARENT as
+// the same alias.
+func (daemon *Daemon) ContainerLink(job *engine.Job) engine.Status {
+	if len(job.Args) != 3 {
+		return job.Errorf("Usage: %s PARENT CHILD ALIAS", job.Name)
+	}
+	parent, child, err := daemon.getLinkContainers(job.Args[0], job.Args[1])
+	if err != nil {
+		return job.Error(err)
+	}
+	aliases, err := splitLinkAliases(job.Args[2])
+	if err != nil {
+		return job.Error(err)
+	}
+	for _, alias := range aliases {
+		if !validContainerNamePattern.MatchString(alias) || alias[0] == '/' {
+			return job.Errorf("Invalid link alias (%s), only %s are allowed", alias, validContainerNameChars)
+		}
+	}
+	if parent.ID == child.ID {
+		return job.Errorf("Cannot link container %s to itself", parent.Name[1:])
+	}
+	switch {
+	case parent.hostConfig.NetworkMode.IsHost(), child.hostConfig.NetworkMode.IsHost():
+		return job.Error(runconfig.ErrConflictHostNetworkAndLinks)
+	case parent.hostConfig.NetworkMode.IsContainer():
+		return job.Error(runconfig.ErrConflictContainerNetworkAndLinks)
+	}
+
+	// read before parent is locked: linking them the other way round at the
+	// same time would lock them in the opposite order and deadlock
+	childRunning := child.IsRunning()
+	parent.Lock()
+	defer parent.Unlock()
+	for _, alias := range aliases {
+		if _, exists := daemon.linkName(parent, child, alias); exists {
+			return job.Errorf("Conflict, container %s already has a link named %s to %s", parent.Name[1:], alias, child.Name[1:])
+		}
+		if parent.Running && !childRunning {
+			return job.Errorf("Cannot link to a non running container: %s AS %s", child.Name, path.Join(parent.Name, alias))
+		}
+	}
+	hide := job.GetenvBool("Hide")
+	for _, alias := range aliases {
+		// registered first, for the records of the other containers sharing
+		// the alias in /etc/hosts
+		name, _ := daemon.linkName(parent, child, alias)
+		if err := daemon.RegisterLink(parent, child, alias); err != nil {
+			return job.Error(err)
+		}
+		parent.setLinkHidden(path.Base(name), hide)
+		if parent.Running {
+			if err := parent.EnableLink(child, path.Base(name)); err != nil {
+				daemon.containerGraph.Delete(name)
+				parent.setLinkHidden(path.Base(name), false)
+				return job.Error(err)
+			}
+		}
+	}
+	if err := parent.toDisk(); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// ContainerUnlink removes the link ALIAS, or the links of each of the aliases
+// separated by commas in ALIAS, of the container PARENT to CHILD, and
+// disables them if PARENT is running.
+func (daemon *Daemon) ContainerUnlink(job *engine.Job) engine.Status {
+	if len(job.Args) != 3 {
+		return job.Errorf("Usage: %s PARENT CHILD ALIAS", job.Name)
+	}
+	parent, child, err := daemon.getLinkContainers(job.Args[0], job.Args[1])
+	if err != nil {
+		return job.Error(err)
+	}
+	aliases, err := splitLinkAliases(job.Args[2])
+	if err != nil {
+		return job.Error(err)
+	}
+
+	parent.Lock()
+	defer parent.Unlock()
+	names := make([]string, len(aliases))
+	for i, alias := range aliases {
+		name, exists := daemon.linkName(parent, child, alias)
+		if !exists {
+			if name = path.Join(parent.Name, alias); daemon.containerGraph.Exists(name) {
+				return job.Errorf("The link %s is not to container %s", name, child.Name[1:])
+			}
+			return job.Errorf("No such link: %s", name)
+		}
+		names[i] = name
+	}
+
+	for _, name := range names {
+		// deleted first, for the records of the other containers sharing
+		// the alias in /etc/hosts
+		if err := daemon.containerGraph.Delete(name); err != nil {
+			return job.Error(err)
+		}
+		parent.DisableLink(path.Base(name))
+		parent.setLinkHidden(path.Base(name), false)
+	}
+	if err := parent.toDisk(); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// splitLinkAliases returns the aliases separated by commas in aliases
+func splitLinkAliases(aliases string) ([]string, error) {
+	list := strings.Split(aliases, ",")
+	for i, alias := range list {
+		if alias == "" || strings.ContainsAny(alias, "/@") {
+			return nil, fmt.Errorf("Invalid link alias (%s)", alias)
+		}
+		for _, other := range list[:i] {
+			if alias == other {
+				return nil, fmt.Errorf("Duplicate link alias (%s)", alias)
+			}
+		}
+	}
+	return list, nil
+}
+
+func (daemon *Daemon) getLinkContainers(parentName, childName string) (*Container, *Container, error) {
+	parent := daemon.Get(parentName)
+	if parent == nil {
+		return nil, nil, fmt.Errorf("No such container: %s", parentName)
+	}
+	child := daemon.Get(childName)
+	if child == nil {
+		return nil, nil, fmt.Errorf("No such container: %s", childName)
+	}
+	return parent, child, nil
+}
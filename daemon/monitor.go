@@ -1,8 +1,13 @@
 package daemon
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"math/rand"
+	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,7 +17,18 @@ import (
 	"github.com/docker/docker/utils"
 )
 
-const defaultTimeIncrement = 100
+const (
+	defaultRestartDelay      = 100 * time.Millisecond
+	defaultRestartMultiplier = 2
+)
+
+// restartHookTimeout is how long the hook of the restart policy of a
+// container may run before it is killed and the container restarted
+const restartHookTimeout = time.Minute
+
+// logFlushTimeout is how long the writers of the output of an exited
+// container are given to write what they were sent
+const logFlushTimeout = 5 * time.Second
 
 // containerMonitor monitors the execution of a container's main process.
 // If a restart policy is specified for the container the monitor will ensure that the
@@ -29,7 +45,7 @@ type containerMonitor struct {
 	restartPolicy runconfig.RestartPolicy
 
 	// failureCount is the number of times the container has failed to
-	// start in a row
+	// start in a row, or since it last ran for the reset window of the policy
 	failureCount int
 
 	// shouldStop signals the monitor that the next time the container exits it is
@@ -44,26 +60,85 @@ type containerMonitor struct {
 	// left waiting for nothing to happen during this time
 	stopChan chan struct{}
 
-	// timeIncrement is the amount of time to wait between restarts
+	// timeIncrement is the amount of time to wait between restarts, before
+	// the jitter of the policy is applied
 	// this is in milliseconds
 	timeIncrement int
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// lastRestartTime is the time the container was last restarted by the
+	// monitor, and nextRestartTime the time it is waiting for to be restarted
+	// again, zero when it isn't
+	lastRestartTime time.Time
+	nextRestartTime time.Time
+
+	// restoreDir holds the checkpoint images the container's process is restored
+	// from the first time it is run, it is empty for a regular start
+	restoreDir string
+
+	// reattach tells the monitor that the container's process was left running
+	// by the previous daemon, it is reattached to instead of run the first time
+	// around
+	reattach bool
+
+	// exitCodeUnknown is set while the process monitored is the one
+	// reattached to, its exit code can't be known as it is not a child of the
+	// daemon
+	exitCodeUnknown bool
+}
+
+// RestartState is the live state of the restart policy of a container, as
+// shown by inspect
+type RestartState struct {
+	// Attempt is the number of restarts since the container was started
+	Attempt       int
+	LastRestartAt time.Time
+	NextRestartAt time.Time
+	// Delay is the delay before the next restart, in milliseconds, without
+	// the jitter and the stabilize delay, and Backoff whether it grew above
+	// the delay of the policy because the container keeps exiting quickly
+	Delay   int
+	Backoff bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
-// honoring the provided restart policy
+// honoring the provided restart policy, whose delays are already set
 func newContainerMonitor(container *Container, policy runconfig.RestartPolicy) *containerMonitor {
 	return &containerMonitor{
 		container:     container,
 		restartPolicy: policy,
-		timeIncrement: defaultTimeIncrement,
+		timeIncrement: policy.Delay,
 		stopChan:      make(chan struct{}),
 		startSignal:   make(chan struct{}),
 	}
 }
 
+// restartBackoff returns policy with the delay settings it leaves to 0 set to
+// the defaults of the daemon
+func (daemon *Daemon) restartBackoff(policy runconfig.RestartPolicy) runconfig.RestartPolicy {
+	if policy.Delay == 0 {
+		policy.Delay = int(daemon.config.RestartDelay / time.Millisecond)
+		if policy.Delay == 0 {
+			policy.Delay = int(defaultRestartDelay / time.Millisecond)
+		}
+	}
+	if policy.MaxDelay == 0 {
+		policy.MaxDelay = int(daemon.config.RestartMaxDelay / time.Millisecond)
+	}
+	if policy.Multiplier == 0 {
+		policy.Multiplier = daemon.config.RestartMultiplier
+		if policy.Multiplier == 0 {
+			policy.Multiplier = defaultRestartMultiplier
+		}
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = daemon.config.RestartJitter
+	}
+	return policy
+}
+
 // Stop signals to the container monitor that it should stop monitoring the container
 // for exits the next time the process dies
 func (m *containerMonitor) ExitOnNext() {
@@ -79,6 +154,40 @@ func (m *containerMonitor) ExitOnNext() {
 	m.mux.Unlock()
 }
 
+// cancelExitOnNext undoes ExitOnNext when the container was not stopped after
+// all, so that the restart policy applies again to its next exit
+func (m *containerMonitor) cancelExitOnNext() {
+	m.mux.Lock()
+	if m.shouldStop {
+		m.shouldStop = false
+		m.stopChan = make(chan struct{})
+	}
+	m.mux.Unlock()
+}
+
+// setRestartPolicy applies policy from the next exit of the container on, the
+// restart delay starting over from the delay of policy
+func (m *containerMonitor) setRestartPolicy(policy runconfig.RestartPolicy) {
+	m.mux.Lock()
+	m.restartPolicy = policy
+	m.timeIncrement = policy.Delay
+	m.mux.Unlock()
+}
+
+// restartState returns the live state of the restart policy of the container
+func (m *containerMonitor) restartState() RestartState {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return RestartState{
+		Attempt:       m.container.RestartCount,
+		LastRestartAt: m.lastRestartTime,
+		NextRestartAt: m.nextRestartTime,
+		Delay:         m.timeIncrement,
+		Backoff:       m.timeIncrement > m.restartPolicy.Delay,
+	}
+}
+
 // Close closes the container's resources such as networking allocations and
 // unmounts the contatiner's root filesystem
 func (m *containerMonitor) Close() error {
@@ -119,6 +228,7 @@ func (m *containerMonitor) Start() error {
 
 	// reset the restart count
 	m.container.RestartCount = -1
+	m.container.FailureCount = 0
 
 	for {
 		m.container.RestartCount++
@@ -135,7 +245,7 @@ func (m *containerMonitor) Start() error {
 
 		m.lastStartTime = time.Now()
 
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
+		if exitStatus, err = m.run(pipes); err != nil {
 			// if we receive an internal error from the initial start of a container then lets
 			// return it instead of entering the restart loop
 			if m.container.RestartCount == 0 {
@@ -155,6 +265,8 @@ func (m *containerMonitor) Start() error {
 
 		if m.shouldRestart(exitStatus.ExitCode) {
 			m.container.SetRestarting(&exitStatus)
+			// for the links hiding the container until it is restarted
+			m.container.daemon.publishAddresses(m.container)
 			if exitStatus.OOMKilled {
 				m.container.LogEvent("oom")
 			}
@@ -163,7 +275,8 @@ func (m *containerMonitor) Start() error {
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
 			// restarting the container because of some types of errors ( networking cut out, etc... )
-			m.waitForNextRestart()
+			delay := m.nextRestartDelay() + time.Duration(m.restartPolicy.StabilizeDelay)*time.Millisecond
+			m.waitForNextRestart(delay)
 
 			// we need to check this before reentering the loop because the waitForNextRestart could have
 			// been terminated by a request from a user
@@ -171,6 +284,16 @@ func (m *containerMonitor) Start() error {
 				m.container.ExitCode = exitStatus.ExitCode
 				return err
 			}
+			m.runRestartHook(exitStatus.ExitCode)
+			// the container may have been stopped while the hook ran
+			if m.shouldStop {
+				m.container.ExitCode = exitStatus.ExitCode
+				return err
+			}
+			m.mux.Lock()
+			m.lastRestartTime = time.Now().UTC()
+			m.mux.Unlock()
+			m.container.LogEvent(fmt.Sprintf("policy_restart: attempt %d, delay %s, exit code %d", m.container.RestartCount+1, delay, exitStatus.ExitCode))
 			continue
 		}
 		m.container.ExitCode = exitStatus.ExitCode
@@ -183,34 +306,120 @@ func (m *containerMonitor) Start() error {
 	}
 }
 
+// run executes the container's process, it is restored from the checkpoint
+// images or reattached to instead the first time around if the monitor was
+// asked to
+func (m *containerMonitor) run(pipes *execdriver.Pipes) (execdriver.ExitStatus, error) {
+	m.exitCodeUnknown = m.reattach
+	if m.reattach {
+		m.reattach = false
+		return m.container.daemon.Reattach(m.container, pipes, m.callback)
+	}
+	if imagesDir := m.restoreDir; imagesDir != "" {
+		m.restoreDir = ""
+		return m.container.daemon.Restore(m.container, pipes, imagesDir, m.callback)
+	}
+	return m.container.daemon.Run(m.container, pipes, m.callback)
+}
+
 // resetMonitor resets the stateful fields on the containerMonitor based on the
 // previous runs success or failure.  Reguardless of success, if the container had
-// an execution time of more than 10s then reset the timer back to the default
+// an execution time of more than 10s then reset the timer back to the delay of the policy
 func (m *containerMonitor) resetMonitor(successful bool) {
 	executionTime := time.Now().Sub(m.lastStartTime).Seconds()
 
 	if executionTime > 10 {
-		m.timeIncrement = defaultTimeIncrement
+		m.timeIncrement = m.restartPolicy.Delay
 	} else {
 		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
-		m.timeIncrement *= 2
+		// the process.  We will build up by multiplying the increment by the
+		// multiplier of the policy, up to its maximum delay
+		m.timeIncrement = int(float64(m.timeIncrement) * m.restartPolicy.Multiplier)
+		if max := m.restartPolicy.MaxDelay; max > 0 && m.timeIncrement > max {
+			m.timeIncrement = max
+		}
 	}
 
 	// the container exited successfully so we need to reset the failure counter
 	if successful {
 		m.failureCount = 0
 	} else {
+		// after a run longer than the reset window, the failure is the first one
+		if window := m.restartPolicy.ResetWindow; window > 0 && executionTime*1000 >= float64(window) {
+			m.failureCount = 0
+		}
 		m.failureCount++
 	}
+	m.container.FailureCount = m.failureCount
 }
 
-// waitForNextRestart waits with the default time increment to restart the container unless
+// waitForNextRestart waits for delay to restart the container unless
 // a user or docker asks for the container to be stopped
-func (m *containerMonitor) waitForNextRestart() {
+func (m *containerMonitor) waitForNextRestart(delay time.Duration) {
+	m.mux.Lock()
+	m.nextRestartTime = time.Now().UTC().Add(delay)
+	stopChan := m.stopChan
+	m.mux.Unlock()
+
 	select {
-	case <-time.After(time.Duration(m.timeIncrement) * time.Millisecond):
-	case <-m.stopChan:
+	case <-time.After(delay):
+	case <-stopChan:
+	}
+
+	m.mux.Lock()
+	m.nextRestartTime = time.Time{}
+	m.mux.Unlock()
+}
+
+// nextRestartDelay returns the time increment with a random part of it, up to
+// the jitter of the policy, added or removed
+func (m *containerMonitor) nextRestartDelay() time.Duration {
+	delay := m.timeIncrement
+	if jitter := m.restartPolicy.Jitter; jitter > 0 {
+		delay += int(float64(delay) * jitter * (2*rand.Float64() - 1))
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// runRestartHook runs the restart hook of the daemon, if any, on the host
+// before the container is restarted, with the details of the container in
+// its environment. The container is restarted even if the hook fails
+func (m *containerMonitor) runRestartHook(exitCode int) {
+	hook := m.container.daemon.config.RestartHook
+	if hook == "" {
+		return
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(hook)
+	cmd.Env = append(os.Environ(),
+		"DOCKER_CONTAINER_ID="+m.container.ID,
+		"DOCKER_CONTAINER_NAME="+strings.TrimPrefix(m.container.Name, "/"),
+		"DOCKER_CONTAINER_ROOTFS="+m.container.basefs,
+		fmt.Sprintf("DOCKER_EXIT_CODE=%d", exitCode),
+		fmt.Sprintf("DOCKER_RESTART_ATTEMPT=%d", m.container.RestartCount+1),
+	)
+	cmd.Stdout, cmd.Stderr = &output, &output
+
+	if err := cmd.Start(); err != nil {
+		log.Errorf("%s: Error running the restart hook: %s", m.container.ID, err)
+		return
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(restartHookTimeout):
+		cmd.Process.Kill()
+		err = fmt.Errorf("killed after %s", restartHookTimeout)
+		<-done
+	}
+	if err != nil {
+		log.Errorf("%s: Error running the restart hook: %s: %s", m.container.ID, err, output.String())
 	}
 }
 
@@ -226,7 +435,7 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 	}
 
 	switch m.restartPolicy.Name {
-	case "always":
+	case "always", "unless-stopped":
 		return true
 	case "on-failure":
 		// the default value of 0 for MaximumRetryCount means that we will not enforce a maximum count
@@ -236,6 +445,13 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 			return false
 		}
 
+		// the -1 of a reattached process doesn't tell that it failed
+		if m.exitCodeUnknown {
+			log.Debugf("not restarting container %s with an unknown exit code",
+				utils.TruncateID(m.container.ID))
+			return false
+		}
+
 		return exitCode != 0
 	}
 
@@ -255,6 +471,9 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 
 	m.container.setRunning(pid)
+	if m.container.RestartCount > 0 {
+		m.container.daemon.publishAddresses(m.container)
+	}
 
 	// signal that the process has started
 	// close channel only if not closed
@@ -274,6 +493,11 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 // if lock is true, then container locked during reset
 func (m *containerMonitor) resetContainer(lock bool) {
 	container := m.container
+
+	// the last line of the output may not be terminated, it is flushed
+	// before the container is locked
+	container.flushOutput(container.ID)
+
 	if lock {
 		container.Lock()
 		defer container.Unlock()
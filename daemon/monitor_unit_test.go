@@ -0,0 +1,232 @@
+package daemon
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/broadcastwriter"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	daemon := &Daemon{config: &Config{RestartDelay: 500 * time.Millisecond, RestartMaxDelay: time.Minute}}
+
+	policy := daemon.restartBackoff(runconfig.RestartPolicy{Name: "always", Jitter: 0.5})
+	if policy.Delay != 500 || policy.MaxDelay != 60000 || policy.Multiplier != defaultRestartMultiplier || policy.Jitter != 0.5 {
+		t.Fatalf("Expected the defaults of the daemon except the jitter, got %+v", policy)
+	}
+
+	daemon.config = &Config{}
+	if policy := daemon.restartBackoff(runconfig.RestartPolicy{}); policy.Delay != 100 || policy.MaxDelay != 0 || policy.Multiplier != 2 {
+		t.Fatalf("Expected the built-in defaults, got %+v", policy)
+	}
+}
+
+func TestResetMonitorBackoff(t *testing.T) {
+	policy := runconfig.RestartPolicy{Name: "always", Delay: 100, MaxDelay: 1000, Multiplier: 3}
+	m := newContainerMonitor(&Container{}, policy)
+
+	expected := []int{300, 900, 1000, 1000}
+	for _, delay := range expected {
+		m.lastStartTime = time.Now()
+		m.resetMonitor(false)
+		if m.timeIncrement != delay {
+			t.Fatalf("Expected a delay of %dms, got %dms", delay, m.timeIncrement)
+		}
+	}
+
+	// a long run resets the delay
+	m.lastStartTime = time.Now().Add(-time.Minute)
+	m.resetMonitor(true)
+	if m.timeIncrement != 100 {
+		t.Fatalf("Expected the delay to be reset to 100ms, got %dms", m.timeIncrement)
+	}
+}
+
+func TestNextRestartDelayJitter(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{Delay: 1000, Jitter: 0.2})
+	for i := 0; i < 100; i++ {
+		if delay := m.nextRestartDelay(); delay < 800*time.Millisecond || delay > 1200*time.Millisecond {
+			t.Fatalf("Expected a delay between 800ms and 1.2s, got %s", delay)
+		}
+	}
+
+	m.restartPolicy.Jitter = 0
+	if delay := m.nextRestartDelay(); delay != time.Second {
+		t.Fatalf("Expected a delay of 1s without jitter, got %s", delay)
+	}
+}
+
+func TestResetMonitorFailureWindow(t *testing.T) {
+	container := &Container{}
+	m := newContainerMonitor(container, runconfig.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3, Delay: 100, Multiplier: 2, ResetWindow: 60000})
+
+	for i := 1; i <= 3; i++ {
+		m.lastStartTime = time.Now()
+		m.resetMonitor(false)
+		if m.failureCount != i || container.FailureCount != i {
+			t.Fatalf("Expected %d failures, got %d (%d in the container)", i, m.failureCount, container.FailureCount)
+		}
+	}
+
+	// a failure after a run longer than the window is counted from 0
+	m.lastStartTime = time.Now().Add(-2 * time.Minute)
+	m.resetMonitor(false)
+	if m.failureCount != 1 || container.FailureCount != 1 {
+		t.Fatalf("Expected the failures to be counted again from 0, got %d", m.failureCount)
+	}
+
+	m.restartPolicy.ResetWindow = 0
+	m.lastStartTime = time.Now().Add(-2 * time.Minute)
+	m.resetMonitor(false)
+	if m.failureCount != 2 {
+		t.Fatalf("Expected the failures to keep being counted without a window, got %d", m.failureCount)
+	}
+}
+
+func TestRunRestartHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restart-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	hook := filepath.Join(dir, "hook")
+	script := "#!/bin/sh\necho $DOCKER_CONTAINER_ID $DOCKER_CONTAINER_NAME $DOCKER_CONTAINER_ROOTFS $DOCKER_EXIT_CODE $DOCKER_RESTART_ATTEMPT > " + out + "\n"
+	if err := ioutil.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	container := &Container{ID: "abc", Name: "/web", basefs: "/rootfs", daemon: &Daemon{config: &Config{RestartHook: hook}}}
+	container.RestartCount = 2
+	m := newContainerMonitor(container, runconfig.RestartPolicy{Name: "always"})
+	m.runRestartHook(3)
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "abc web /rootfs 3 3\n"; string(data) != expected {
+		t.Fatalf("Expected the hook to get %q, got %q", expected, data)
+	}
+}
+
+func TestRestartState(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{Name: "always", Delay: 100, Multiplier: 2})
+	if state := m.restartState(); state.Backoff || state.Delay != 100 || !state.NextRestartAt.IsZero() {
+		t.Fatalf("Expected no backoff nor next restart, got %+v", state)
+	}
+
+	m.lastStartTime = time.Now()
+	m.resetMonitor(false)
+	m.container.RestartCount = 1
+	if state := m.restartState(); !state.Backoff || state.Delay != 200 || state.Attempt != 1 {
+		t.Fatalf("Expected a backoff to 200ms on the first attempt, got %+v", state)
+	}
+
+	go m.waitForNextRestart(time.Minute)
+	for i := 0; m.restartState().NextRestartAt.IsZero(); i++ {
+		if i == 100 {
+			t.Fatal("Expected the next restart time to be set while waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if next := m.restartState().NextRestartAt; next.Before(time.Now().Add(50 * time.Second)) {
+		t.Fatalf("Expected the next restart in about a minute, got %s", next)
+	}
+
+	m.ExitOnNext()
+	for i := 0; !m.restartState().NextRestartAt.IsZero(); i++ {
+		if i == 100 {
+			t.Fatal("Expected the next restart time to be cleared once stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCheckpointFailureKeepsRestartPolicy(t *testing.T) {
+	container := &Container{State: NewState(), daemon: &Daemon{}}
+	container.monitor = newContainerMonitor(container, runconfig.RestartPolicy{Name: "always"})
+	container.SetRunning(1)
+
+	// the exec driver doesn't support checkpoints
+	if err := container.Checkpoint(); err == nil {
+		t.Fatal("Expected the checkpoint to fail")
+	}
+	if container.monitor.shouldStop {
+		t.Fatal("Expected the restart policy to still apply after a failed checkpoint")
+	}
+	if !container.monitor.shouldRestart(1) {
+		t.Fatal("Expected the container to be restarted on its next exit")
+	}
+
+	// the stop of the container still interrupts the wait for the next restart
+	container.monitor.ExitOnNext()
+	done := make(chan struct{})
+	go func() {
+		container.monitor.waitForNextRestart(time.Minute)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the wait for the next restart to be interrupted")
+	}
+}
+
+func TestReattachedUnknownExitCodeNotFailure(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{Name: "on-failure"})
+	m.exitCodeUnknown = true
+	if m.shouldRestart(-1) {
+		t.Fatal("Expected a reattached container not to be restarted on failure")
+	}
+
+	m.exitCodeUnknown = false
+	if !m.shouldRestart(-1) {
+		t.Fatal("Expected the container to be restarted on failure")
+	}
+
+	m = newContainerMonitor(&Container{}, runconfig.RestartPolicy{Name: "always"})
+	m.exitCodeUnknown = true
+	if !m.shouldRestart(-1) {
+		t.Fatal("Expected a reattached container to be restarted always")
+	}
+}
+
+func TestResetContainerFlushesUnlocked(t *testing.T) {
+	container := &Container{State: NewState(), Config: &runconfig.Config{}, command: &execdriver.Command{}}
+	container.stdout = newOutputWriter("test", "stdout")
+	container.stderr = newOutputWriter("test", "stderr")
+	// nobody reads the output of the consumer, the flush waits for it
+	reader, writer := io.Pipe()
+	container.stdout.AddWriter(writer, "", broadcastwriter.BlockWhenFull)
+	container.stdout.Write([]byte("foo"))
+
+	m := newContainerMonitor(container, runconfig.RestartPolicy{})
+	done := make(chan struct{})
+	go func() {
+		m.resetContainer(true)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	locked := make(chan struct{})
+	go func() {
+		container.Lock()
+		container.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the container not to be locked while its output is flushed")
+	}
+	reader.Close()
+	<-done
+}
@@ -0,0 +1,257 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+)
+
+// Network is a user-defined network, created with `docker network create`.
+// The containers run with --net=NAME are attached to its bridge.
+type Network struct {
+	ID     string
+	Name   string
+	Subnet string // address of the bridge and prefix length, e.g. 10.1.0.1/24
+	Bridge string
+}
+
+// networkStore keeps the user-defined networks by name, saved in path
+type networkStore struct {
+	sync.Mutex
+	path     string
+	networks map[string]*Network
+}
+
+func newNetworkStore(path string) (*networkStore, error) {
+	store := &networkStore{path: path, networks: make(map[string]*Network)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.networks); err != nil {
+		return nil, fmt.Errorf("Error loading the networks from %s: %s", path, err)
+	}
+	return store, nil
+}
+
+func (s *networkStore) Get(name string) *Network {
+	s.Lock()
+	defer s.Unlock()
+	return s.networks[name]
+}
+
+func (s *networkStore) List() []*Network {
+	s.Lock()
+	defer s.Unlock()
+	var networks []*Network
+	for _, n := range s.networks {
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// save writes the networks to disk, it has to be called locked
+func (s *networkStore) save() error {
+	data, err := json.Marshal(s.networks)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+// restoreNetworks sets up the bridges of the networks again
+func (daemon *Daemon) restoreNetworks() error {
+	for _, n := range daemon.networks.List() {
+		job := daemon.eng.Job("create_network", n.ID)
+		job.Setenv("Subnet", n.Subnet)
+		if err := job.Run(); err != nil {
+			return fmt.Errorf("Error restoring network %s: %s", n.Name, err)
+		}
+	}
+	return nil
+}
+
+func (daemon *Daemon) NetworkCreate(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s NAME", job.Name)
+	}
+	if daemon.config.DisableNetwork {
+		return job.Errorf("Networking is disabled, cannot create networks")
+	}
+	name := job.Args[0]
+	if !runconfig.ValidNetworkName(name) {
+		return job.Errorf("Invalid network name (%s), only [a-zA-Z0-9][a-zA-Z0-9_.-] are allowed, except bridge, host, none and container", name)
+	}
+
+	daemon.networks.Lock()
+	defer daemon.networks.Unlock()
+	if _, exists := daemon.networks.networks[name]; exists {
+		return job.Errorf("Conflict, network %s already exists", name)
+	}
+
+	var (
+		id       = utils.GenerateRandomID()
+		out      *engine.Env
+		err      error
+		driveJob = daemon.eng.Job("create_network", id)
+	)
+	driveJob.Setenv("Subnet", job.Getenv("Subnet"))
+	if out, err = driveJob.Stdout.AddEnv(); err != nil {
+		return job.Error(err)
+	}
+	if err := driveJob.Run(); err != nil {
+		return job.Error(err)
+	}
+
+	daemon.networks.networks[name] = &Network{
+		ID:     id,
+		Name:   name,
+		Subnet: out.Get("Subnet"),
+		Bridge: out.Get("Bridge"),
+	}
+	if err := daemon.networks.save(); err != nil {
+		delete(daemon.networks.networks, name)
+		daemon.eng.Job("delete_network", id).Run()
+		return job.Error(err)
+	}
+
+	job.Printf("%s\n", id)
+	return engine.StatusOK
+}
+
+func (daemon *Daemon) NetworkList(job *engine.Job) engine.Status {
+	networks := daemon.networks.List()
+	sort.Sort(networksByName(networks))
+
+	outs := engine.NewTable("", 0)
+	for _, n := range networks {
+		out := &engine.Env{}
+		out.Set("Id", n.ID)
+		out.Set("Name", n.Name)
+		out.Set("Subnet", n.Subnet)
+		out.Set("Bridge", n.Bridge)
+		var containers []string
+		for _, c := range daemon.networkContainers(n.Name) {
+			containers = append(containers, c.Name[1:])
+		}
+		out.SetList("Containers", containers)
+		outs.Add(out)
+	}
+	if _, err := outs.WriteListTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+func (daemon *Daemon) NetworkRm(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s NAME", job.Name)
+	}
+	name := job.Args[0]
+
+	daemon.networks.Lock()
+	defer daemon.networks.Unlock()
+	n, exists := daemon.networks.networks[name]
+	if !exists {
+		return job.Errorf("No such network: %s", name)
+	}
+	if containers := daemon.networkContainers(name); len(containers) > 0 {
+		return job.Errorf("Conflict, network %s is used by container %s, disconnect it first", name, containers[0].Name[1:])
+	}
+
+	if err := daemon.eng.Job("delete_network", n.ID).Run(); err != nil {
+		return job.Error(err)
+	}
+	delete(daemon.networks.networks, name)
+	if err := daemon.networks.save(); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// NetworkConnect moves the container in the second argument to the network
+// in the first one. The container has to be stopped, a container is on a
+// single network, and is connected to it when it starts.
+func (daemon *Daemon) NetworkConnect(job *engine.Job) engine.Status {
+	if len(job.Args) != 2 {
+		return job.Errorf("Usage: %s NETWORK CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	if daemon.networks.Get(name) == nil {
+		return job.Errorf("No such network: %s", name)
+	}
+	if err := daemon.setNetworkMode(job.Args[1], "", runconfig.NetworkMode(name)); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// NetworkDisconnect moves the container in the second argument from the
+// network in the first one back to the default bridge.
+func (daemon *Daemon) NetworkDisconnect(job *engine.Job) engine.Status {
+	if len(job.Args) != 2 {
+		return job.Errorf("Usage: %s NETWORK CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	if daemon.networks.Get(name) == nil {
+		return job.Errorf("No such network: %s", name)
+	}
+	if err := daemon.setNetworkMode(job.Args[1], runconfig.NetworkMode(name), "bridge"); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// setNetworkMode sets the network mode of the stopped container name to mode,
+// if it is currently from, or any private network mode if from is empty
+func (daemon *Daemon) setNetworkMode(name string, from, mode runconfig.NetworkMode) error {
+	container := daemon.Get(name)
+	if container == nil {
+		return fmt.Errorf("No such container: %s", name)
+	}
+
+	container.Lock()
+	defer container.Unlock()
+	if container.Running {
+		return fmt.Errorf("Conflict, container %s is running, stop it before changing its network", container.Name[1:])
+	}
+	current := container.hostConfig.NetworkMode
+	if !current.IsPrivate() {
+		return fmt.Errorf("Conflict, container %s uses the network mode %s", container.Name[1:], current)
+	}
+	if from != "" && current != from {
+		return fmt.Errorf("Container %s is not connected to network %s", container.Name[1:], from)
+	}
+	if mode.IsUserDefined() && container.hostConfig.PublishesPorts() {
+		return fmt.Errorf("Conflict, container %s publishes ports, which are only published on the default bridge", container.Name[1:])
+	}
+
+	container.hostConfig.NetworkMode = mode
+	return container.WriteHostConfig()
+}
+
+// networkContainers returns the containers on the network name
+func (daemon *Daemon) networkContainers(name string) []*Container {
+	var containers []*Container
+	for _, c := range daemon.List() {
+		if c.hostConfig != nil && string(c.hostConfig.NetworkMode) == name {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+type networksByName []*Network
+
+func (n networksByName) Len() int           { return len(n) }
+func (n networksByName) Less(i, j int) bool { return n[i].Name < n[j].Name }
+func (n networksByName) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
@@ -1,6 +1,7 @@
 package bridge
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,11 +13,12 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+	"github.com/docker/docker/daemon/networkdriver/firewall/firewalls"
 	"github.com/docker/docker/daemon/networkdriver/ipallocator"
 	"github.com/docker/docker/daemon/networkdriver/portmapper"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
-	"github.com/docker/docker/pkg/iptables"
 	"github.com/docker/docker/pkg/networkfs/resolvconf"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/libcontainer/netlink"
@@ -31,7 +33,9 @@ const (
 type networkInterface struct {
 	IP           net.IP
 	IPv6         net.IP
-	PortMappings []net.Addr // there are mappings to the host interfaces
+	MacAddress   net.HardwareAddr
+	PortMappings []net.Addr   // there are mappings to the host interfaces
+	Network      *userNetwork // nil on the default bridge
 }
 
 type ifaces struct {
@@ -52,6 +56,25 @@ func (i *ifaces) Get(key string) *networkInterface {
 	return res
 }
 
+func (i *ifaces) Delete(key string) {
+	i.Lock()
+	delete(i.c, key)
+	i.Unlock()
+}
+
+// MacOwner returns the key of the interface, other than the given key,
+// which has the MAC address, or an empty string
+func (i *ifaces) MacOwner(key string, mac net.HardwareAddr) string {
+	i.Lock()
+	defer i.Unlock()
+	for k, n := range i.c {
+		if k != key && bytes.Equal(n.MacAddress, mac) {
+			return k
+		}
+	}
+	return ""
+}
+
 var (
 	addrs = []string{
 		// Here we don't follow the convention of using the 1st IP of the range for the gateway.
@@ -78,6 +101,16 @@ var (
 	bridgeIPv6Addr    net.IP
 	globalIPv6Network *net.IPNet
 
+	// the firewall driver, with --iptables, and its settings for the
+	// user-defined networks
+	firewallDriver firewall.Driver
+	iccEnabled     bool
+	ipMasqEnabled  bool
+	ipv6Enabled    bool
+
+	// whether the non exposed ports of the links are rejected
+	linkRejectEnabled bool
+
 	defaultBindingIP  = net.ParseIP("0.0.0.0")
 	currentInterfaces = ifaces{c: make(map[string]*networkInterface)}
 )
@@ -195,13 +228,8 @@ func InitDriver(job *engine.Job) engine.Status {
 		bridgeIPv6Addr = networkv6.IP
 	}
 
-	// Configure iptables for link support
-	if enableIPTables {
-		if err := setupIPTables(addrv4, icc, ipMasq); err != nil {
-			return job.Error(err)
-		}
-
-	}
+	iccEnabled, ipMasqEnabled, ipv6Enabled = icc, ipMasq, enableIPv6
+	linkRejectEnabled = job.GetenvBool("LinkReject")
 
 	if ipForward {
 		// Enable IPv4 forwarding
@@ -220,21 +248,22 @@ func InitDriver(job *engine.Job) engine.Status {
 		}
 	}
 
-	// We can always try removing the iptables
-	if err := iptables.RemoveExistingChain("DOCKER", iptables.Nat); err != nil {
+	driver, err := firewalls.NewDriver(job.Getenv("FirewallDriver"), bridgeIface)
+	if err != nil {
+		return job.Error(err)
+	}
+	// We can always try removing the rules of a previous run
+	if err := driver.Cleanup(); err != nil {
 		return job.Error(err)
 	}
 
+	// Configure the firewall for link support
 	if enableIPTables {
-		_, err := iptables.NewChain("DOCKER", bridgeIface, iptables.Nat)
-		if err != nil {
+		if err := driver.Setup(networkv4, icc, ipMasq, enableIPv6); err != nil {
 			return job.Error(err)
 		}
-		chain, err := iptables.NewChain("DOCKER", bridgeIface, iptables.Filter)
-		if err != nil {
-			return job.Error(err)
-		}
-		portmapper.SetIptablesChain(chain)
+		firewallDriver = driver
+		portmapper.SetFirewall(driver)
 	}
 
 	bridgeIPv4Network = networkv4
@@ -272,6 +301,8 @@ func InitDriver(job *engine.Job) engine.Status {
 		"release_interface":  Release,
 		"allocate_port":      AllocatePort,
 		"link":               LinkContainers,
+		"create_network":     CreateNetwork,
+		"delete_network":     DeleteNetwork,
 	} {
 		if err := job.Eng.Register(name, f); err != nil {
 			return job.Error(err)
@@ -280,74 +311,6 @@ func InitDriver(job *engine.Job) engine.Status {
 	return engine.StatusOK
 }
 
-func setupIPTables(addr net.Addr, icc, ipmasq bool) error {
-	// Enable NAT
-
-	if ipmasq {
-		natArgs := []string{"POSTROUTING", "-t", "nat", "-s", addr.String(), "!", "-o", bridgeIface, "-j", "MASQUERADE"}
-
-		if !iptables.Exists(natArgs...) {
-			if output, err := iptables.Raw(append([]string{"-I"}, natArgs...)...); err != nil {
-				return fmt.Errorf("Unable to enable network bridge NAT: %s", err)
-			} else if len(output) != 0 {
-				return &iptables.ChainError{Chain: "POSTROUTING", Output: output}
-			}
-		}
-	}
-
-	var (
-		args       = []string{"FORWARD", "-i", bridgeIface, "-o", bridgeIface, "-j"}
-		acceptArgs = append(args, "ACCEPT")
-		dropArgs   = append(args, "DROP")
-	)
-
-	if !icc {
-		iptables.Raw(append([]string{"-D"}, acceptArgs...)...)
-
-		if !iptables.Exists(dropArgs...) {
-			log.Debugf("Disable inter-container communication")
-			if output, err := iptables.Raw(append([]string{"-I"}, dropArgs...)...); err != nil {
-				return fmt.Errorf("Unable to prevent intercontainer communication: %s", err)
-			} else if len(output) != 0 {
-				return fmt.Errorf("Error disabling intercontainer communication: %s", output)
-			}
-		}
-	} else {
-		iptables.Raw(append([]string{"-D"}, dropArgs...)...)
-
-		if !iptables.Exists(acceptArgs...) {
-			log.Debugf("Enable inter-container communication")
-			if output, err := iptables.Raw(append([]string{"-I"}, acceptArgs...)...); err != nil {
-				return fmt.Errorf("Unable to allow intercontainer communication: %s", err)
-			} else if len(output) != 0 {
-				return fmt.Errorf("Error enabling intercontainer communication: %s", output)
-			}
-		}
-	}
-
-	// Accept all non-intercontainer outgoing packets
-	outgoingArgs := []string{"FORWARD", "-i", bridgeIface, "!", "-o", bridgeIface, "-j", "ACCEPT"}
-	if !iptables.Exists(outgoingArgs...) {
-		if output, err := iptables.Raw(append([]string{"-I"}, outgoingArgs...)...); err != nil {
-			return fmt.Errorf("Unable to allow outgoing packets: %s", err)
-		} else if len(output) != 0 {
-			return &iptables.ChainError{Chain: "FORWARD outgoing", Output: output}
-		}
-	}
-
-	// Accept incoming packets for existing connections
-	existingArgs := []string{"FORWARD", "-o", bridgeIface, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}
-
-	if !iptables.Exists(existingArgs...) {
-		if output, err := iptables.Raw(append([]string{"-I"}, existingArgs...)...); err != nil {
-			return fmt.Errorf("Unable to allow incoming packets: %s", err)
-		} else if len(output) != 0 {
-			return &iptables.ChainError{Chain: "FORWARD incoming", Output: output}
-		}
-	}
-	return nil
-}
-
 // configureBridge attempts to create and configure a network bridge interface named `bridgeIface` on the host
 // If bridgeIP is empty, it will try to find a non-conflicting IP from the Docker-specified private ranges
 // If the bridge `bridgeIface` already exists, it will only perform the IP address association with the existing
@@ -505,24 +468,53 @@ func Allocate(job *engine.Job) engine.Status {
 		id            = job.Args[0]
 		requestedIP   = net.ParseIP(job.Getenv("RequestedIP"))
 		requestedIPv6 = net.ParseIP(job.Getenv("RequestedIPv6"))
+		reservedIPs   []net.IP
 		globalIPv6    net.IP
+		network       *userNetwork
+		ipv4Network   = bridgeIPv4Network
+		bridge        = bridgeIface
 	)
 
+	// on a user-defined network instead of the default bridge
+	if networkID := job.Getenv("NetworkID"); networkID != "" {
+		if network = networks.Get(networkID); network == nil {
+			return job.Errorf("No such network: %s", networkID)
+		}
+		ipv4Network, bridge = network.network, network.bridge
+	}
+
 	if requestedIP != nil {
-		ip, err = ipallocator.RequestIP(bridgeIPv4Network, requestedIP)
+		if ip, err = ipallocator.RequestIP(ipv4Network, requestedIP); err != nil {
+			return job.Errorf("Cannot assign the address %s on %s: %s", requestedIP, bridge, err)
+		}
 	} else {
-		ip, err = ipallocator.RequestIP(bridgeIPv4Network, nil)
-	}
-	if err != nil {
-		return job.Error(err)
+		// the static addresses of the other containers of the network,
+		// which get them when they start
+		for _, reserved := range job.GetenvList("ReservedIPs") {
+			if reservedIP := net.ParseIP(reserved); reservedIP != nil {
+				reservedIPs = append(reservedIPs, reservedIP)
+			}
+		}
+		if ip, err = ipallocator.RequestIPExcluding(ipv4Network, reservedIPs); err != nil {
+			return job.Error(err)
+		}
 	}
 
 	// If no explicit mac address was given, generate a random one.
-	if mac, err = net.ParseMAC(job.Getenv("RequestedMac")); err != nil {
+	if requestedMac := job.Getenv("RequestedMac"); requestedMac != "" {
+		if mac, err = net.ParseMAC(requestedMac); err != nil {
+			ipallocator.ReleaseIP(ipv4Network, ip)
+			return job.Errorf("Invalid MAC address: %s", requestedMac)
+		}
+	} else {
 		mac = generateMacAddr(ip)
 	}
+	if owner := currentInterfaces.MacOwner(id, mac); owner != "" {
+		ipallocator.ReleaseIP(ipv4Network, ip)
+		return job.Errorf("The MAC address %s is already used by container %s", mac, owner)
+	}
 
-	if globalIPv6Network != nil {
+	if globalIPv6Network != nil && network == nil {
 		// if globalIPv6Network Size is at least a /80 subnet generate IPv6 address from MAC address
 		netmask_ones, _ := globalIPv6Network.Mask.Size()
 		if requestedIPv6 == nil && netmask_ones <= 80 {
@@ -542,12 +534,12 @@ func Allocate(job *engine.Job) engine.Status {
 
 	out := engine.Env{}
 	out.Set("IP", ip.String())
-	out.Set("Mask", bridgeIPv4Network.Mask.String())
-	out.Set("Gateway", bridgeIPv4Network.IP.String())
+	out.Set("Mask", ipv4Network.Mask.String())
+	out.Set("Gateway", ipv4Network.IP.String())
 	out.Set("MacAddress", mac.String())
-	out.Set("Bridge", bridgeIface)
+	out.Set("Bridge", bridge)
 
-	size, _ := bridgeIPv4Network.Mask.Size()
+	size, _ := ipv4Network.Mask.Size()
 	out.SetInt("IPPrefixLen", size)
 
 	// if linklocal IPv6
@@ -559,7 +551,7 @@ func Allocate(job *engine.Job) engine.Status {
 	out.Set("LinkLocalIPv6", localIPv6.String())
 	out.Set("MacAddress", mac.String())
 
-	if globalIPv6Network != nil {
+	if globalIPv6 != nil {
 		out.Set("GlobalIPv6", globalIPv6.String())
 		sizev6, _ := globalIPv6Network.Mask.Size()
 		out.SetInt("GlobalIPv6PrefixLen", sizev6)
@@ -567,8 +559,10 @@ func Allocate(job *engine.Job) engine.Status {
 	}
 
 	currentInterfaces.Set(id, &networkInterface{
-		IP:   ip,
-		IPv6: globalIPv6,
+		IP:         ip,
+		IPv6:       globalIPv6,
+		MacAddress: mac,
+		Network:    network,
 	})
 
 	out.WriteTo(job.Stdout)
@@ -593,14 +587,19 @@ func Release(job *engine.Job) engine.Status {
 		}
 	}
 
-	if err := ipallocator.ReleaseIP(bridgeIPv4Network, containerInterface.IP); err != nil {
+	ipv4Network := bridgeIPv4Network
+	if containerInterface.Network != nil {
+		ipv4Network = containerInterface.Network.network
+	}
+	if err := ipallocator.ReleaseIP(ipv4Network, containerInterface.IP); err != nil {
 		log.Infof("Unable to release IPv4 %s", err)
 	}
-	if globalIPv6Network != nil {
+	if containerInterface.IPv6 != nil {
 		if err := ipallocator.ReleaseIP(globalIPv6Network, containerInterface.IPv6); err != nil {
 			log.Infof("Unable to release IPv6 %s", err)
 		}
 	}
+	currentInterfaces.Delete(id)
 	return engine.StatusOK
 }
 
@@ -614,10 +613,20 @@ func AllocatePort(job *engine.Job) engine.Status {
 		hostIP        = job.Getenv("HostIP")
 		hostPort      = job.GetenvInt("HostPort")
 		containerPort = job.GetenvInt("ContainerPort")
+		portCount     = job.GetenvInt("PortCount") // contiguous ports from the host and container ports
 		proto         = job.Getenv("Proto")
 		network       = currentInterfaces.Get(id)
 	)
 
+	if portCount < 1 {
+		portCount = 1
+	}
+
+	// the DOCKER chain forwards the mapped ports to docker0 only
+	if network.Network != nil {
+		return job.Errorf("Cannot map port %d: ports are only mapped on the default bridge", containerPort)
+	}
+
 	if hostIP != "" {
 		ip = net.ParseIP(hostIP)
 		if ip == nil {
@@ -645,7 +654,7 @@ func AllocatePort(job *engine.Job) engine.Status {
 
 	var host net.Addr
 	for i := 0; i < MaxAllocatedPortAttempts; i++ {
-		if host, err = portmapper.Map(container, ip, hostPort); err == nil {
+		if host, err = portmapper.MapRange(container, ip, hostPort, portCount); err == nil {
 			break
 		}
 		// There is no point in immediately retrying to map an explicitly
@@ -682,7 +691,7 @@ func AllocatePort(job *engine.Job) engine.Status {
 func LinkContainers(job *engine.Job) engine.Status {
 	var (
 		action       = job.Args[0]
-		nfAction     iptables.Action
+		nfAction     firewall.Action
 		childIP      = job.Getenv("ChildIP")
 		parentIP     = job.Getenv("ParentIP")
 		ignoreErrors = job.GetenvBool("IgnoreErrors")
@@ -691,14 +700,18 @@ func LinkContainers(job *engine.Job) engine.Status {
 
 	switch action {
 	case "-A":
-		nfAction = iptables.Append
+		nfAction = firewall.Append
 	case "-I":
-		nfAction = iptables.Insert
+		nfAction = firewall.Insert
 	case "-D":
-		nfAction = iptables.Delete
+		nfAction = firewall.Delete
 	default:
 		return job.Errorf("Invalid action '%s' specified", action)
 	}
+	// nothing restricts the links without --iptables
+	if firewallDriver == nil {
+		return engine.StatusOK
+	}
 
 	ip1 := net.ParseIP(parentIP)
 	if ip1 == nil {
@@ -709,10 +722,46 @@ func LinkContainers(job *engine.Job) engine.Status {
 		return job.Errorf("child IP '%s' is invalid", childIP)
 	}
 
-	chain := iptables.Chain{Name: "DOCKER", Bridge: bridgeIface}
+	// the IPv6 addresses, if both containers have one
+	var ip6s []net.IP
+	if parentIPv6, childIPv6 := job.Getenv("ParentIPv6"), job.Getenv("ChildIPv6"); parentIPv6 != "" && childIPv6 != "" && ipv6Enabled {
+		ip6s = []net.IP{net.ParseIP(parentIPv6), net.ParseIP(childIPv6)}
+		if ip6s[0] == nil || ip6s[1] == nil {
+			return job.Errorf("IPv6 addresses '%s' and '%s' are invalid", parentIPv6, childIPv6)
+		}
+	}
+
+	// the rejection of the other ports comes after the exposed ones
+	reject := func() error {
+		if !linkRejectEnabled {
+			return nil
+		}
+		if err := firewallDriver.Reject(nfAction, ip1, ip2); err != nil {
+			return err
+		}
+		if ip6s != nil {
+			return firewallDriver.Reject(nfAction, ip6s[0], ip6s[1])
+		}
+		return nil
+	}
+	if nfAction == firewall.Insert {
+		if err := reject(); !ignoreErrors && err != nil {
+			return job.Error(err)
+		}
+	}
 	for _, p := range ports {
 		port := nat.Port(p)
-		if err := chain.Link(nfAction, ip1, ip2, port.Int(), port.Proto()); !ignoreErrors && err != nil {
+		if err := firewallDriver.Link(nfAction, ip1, ip2, port.Int(), port.Proto()); !ignoreErrors && err != nil {
+			return job.Error(err)
+		}
+		if ip6s != nil {
+			if err := firewallDriver.Link(nfAction, ip6s[0], ip6s[1], port.Int(), port.Proto()); !ignoreErrors && err != nil {
+				return job.Error(err)
+			}
+		}
+	}
+	if nfAction != firewall.Insert {
+		if err := reject(); !ignoreErrors && err != nil {
 			return job.Error(err)
 		}
 	}
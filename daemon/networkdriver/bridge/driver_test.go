@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/docker/docker/daemon/networkdriver/firewall/firewalls"
 	"github.com/docker/docker/daemon/networkdriver/portmapper"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/iptables"
@@ -79,6 +80,125 @@ func TestAllocatePortDetection(t *testing.T) {
 	}
 }
 
+func TestAllocatePortRange(t *testing.T) {
+	eng := engine.New()
+	eng.Logging = false
+
+	job := eng.Job("initdriver")
+	if res := InitDriver(job); res != engine.StatusOK {
+		t.Fatal("Failed to initialize network driver")
+	}
+
+	job = eng.Job("allocate_interface", "port_range")
+	if res := Allocate(job); res != engine.StatusOK {
+		t.Fatal("Failed to allocate network interface")
+	}
+	defer eng.Job("release_interface", "port_range").Run()
+
+	job = eng.Job("allocate_port", "port_range")
+	job.Setenv("HostIP", "127.0.0.1")
+	job.Setenv("Proto", "udp")
+	job.SetenvInt("ContainerPort", 8000)
+	job.SetenvInt("PortCount", 101)
+	env, err := job.Stdout.AddEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Run(); err != nil {
+		t.Fatalf("Failed to allocate the range of ports: %s", err)
+	}
+	hostPort := env.GetInt("HostPort")
+	if hostPort == 0 {
+		t.Fatal("Expected the first host port of the range")
+	}
+
+	// the last port of the range is allocated too
+	job = eng.Job("allocate_port", "port_range")
+	job.Setenv("HostIP", "127.0.0.1")
+	job.SetenvInt("HostPort", hostPort+100)
+	job.Setenv("Proto", "udp")
+	job.SetenvInt("ContainerPort", 9000)
+	if res := AllocatePort(job); res == engine.StatusOK {
+		t.Fatal("Duplicate port allocation granted by AllocatePort")
+	}
+}
+
+func TestAllocateRequestedIP(t *testing.T) {
+	eng := engine.New()
+	eng.Logging = false
+
+	job := eng.Job("initdriver")
+	if res := InitDriver(job); res != engine.StatusOK {
+		t.Fatal("Failed to initialize network driver")
+	}
+
+	ip := bridgeIPv4Network.IP.Mask(bridgeIPv4Network.Mask)
+	ip[len(ip)-1] = 42
+
+	job = eng.Job("allocate_interface", "requested_ip")
+	job.Setenv("RequestedIP", ip.String())
+	env, err := job.Stdout.AddEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Run(); err != nil {
+		t.Fatalf("Failed to allocate the requested address %s: %s", ip, err)
+	}
+	defer eng.Job("release_interface", "requested_ip").Run()
+	if allocated := env.Get("IP"); allocated != ip.String() {
+		t.Fatalf("Expected the address %s, got %s", ip, allocated)
+	}
+
+	for _, requested := range []string{ip.String(), "203.0.113.1"} {
+		job = eng.Job("allocate_interface", "other")
+		job.Setenv("RequestedIP", requested)
+		if res := Allocate(job); res == engine.StatusOK {
+			t.Fatalf("Expected the allocation of %s to fail", requested)
+		}
+	}
+}
+
+func TestAllocateRequestedMac(t *testing.T) {
+	eng := engine.New()
+	eng.Logging = false
+
+	job := eng.Job("initdriver")
+	if res := InitDriver(job); res != engine.StatusOK {
+		t.Fatal("Failed to initialize network driver")
+	}
+
+	mac := "92:d0:c6:0a:29:33"
+	job = eng.Job("allocate_interface", "requested_mac")
+	job.Setenv("RequestedMac", mac)
+	env, err := job.Stdout.AddEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Run(); err != nil {
+		t.Fatalf("Failed to allocate the requested MAC address %s: %s", mac, err)
+	}
+	if allocated := env.Get("MacAddress"); allocated != mac {
+		t.Fatalf("Expected the MAC address %s, got %s", mac, allocated)
+	}
+
+	job = eng.Job("allocate_interface", "other")
+	job.Setenv("RequestedMac", mac)
+	if res := Allocate(job); res == engine.StatusOK {
+		t.Fatalf("Expected the allocation of the MAC address %s in use to fail", mac)
+	}
+
+	// the MAC address is available again once released
+	if res := Release(eng.Job("release_interface", "requested_mac")); res != engine.StatusOK {
+		t.Fatal("Failed to release the interface")
+	}
+	job = eng.Job("allocate_interface", "other")
+	job.Setenv("RequestedMac", mac)
+	if res := Allocate(job); res != engine.StatusOK {
+		t.Fatalf("Failed to allocate the released MAC address %s", mac)
+	}
+	Release(eng.Job("release_interface", "other"))
+}
+
 func TestHostnameFormatChecking(t *testing.T) {
 	eng := engine.New()
 	eng.Logging = false
@@ -148,6 +268,10 @@ func TestLinkContainers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if firewallDriver, err = firewalls.NewDriver("iptables", bridgeIface); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { firewallDriver = nil }()
 
 	if res := LinkContainers(job); res != engine.StatusOK {
 		t.Fatalf("LinkContainers failed")
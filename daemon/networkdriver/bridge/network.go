@@ -0,0 +1,207 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/networkfs/resolvconf"
+	"github.com/docker/libcontainer/netlink"
+)
+
+// userNetwork is a user-defined network, with its own bridge and subnet.
+// Its containers can talk to each other, and to the outside world, but not
+// to the containers of docker0 or of the other networks.
+type userNetwork struct {
+	bridge  string
+	network *net.IPNet // the address of the bridge, in the subnet
+}
+
+type userNetworks struct {
+	m map[string]*userNetwork
+	sync.Mutex
+}
+
+func (n *userNetworks) Get(id string) *userNetwork {
+	n.Lock()
+	defer n.Unlock()
+	return n.m[id]
+}
+
+var networks = userNetworks{m: make(map[string]*userNetwork)}
+
+// bridgeName returns the name of the bridge of the network id, which fits
+// the 15 characters of an interface name
+func bridgeName(id string) string {
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return "br-" + id
+}
+
+// gatewayCIDR returns the address of the bridge of subnet in CIDR notation,
+// the first address of subnet unless it sets one (e.g. 10.1.0.0/24 gives
+// 10.1.0.1/24, 10.1.0.254/24 is kept)
+func gatewayCIDR(subnet string) (string, error) {
+	ip, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return "", err
+	}
+	ip = ip.To4()
+	if ip == nil {
+		return "", fmt.Errorf("Invalid subnet %s: only IPv4 subnets are supported", subnet)
+	}
+	if ones, _ := network.Mask.Size(); ones > 30 {
+		return "", fmt.Errorf("Invalid subnet %s: too small", subnet)
+	}
+	if ip.Equal(network.IP) {
+		ip = append(net.IP(nil), ip...)
+		ip[3]++
+	}
+	return (&net.IPNet{IP: ip, Mask: network.Mask}).String(), nil
+}
+
+// freeSubnet returns the first of the docker ranges which overlaps with no
+// route, nor with a nameserver
+func freeSubnet() (string, error) {
+	nameservers := []string{}
+	if resolvConf, _ := resolvconf.Get(); resolvConf != nil {
+		nameservers = resolvconf.GetNameserversAsCIDR(resolvConf)
+	}
+	for _, addr := range addrs {
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return "", err
+		}
+		if networkdriver.CheckNameserverOverlaps(nameservers, network) == nil && networkdriver.CheckRouteOverlaps(network) == nil {
+			return addr, nil
+		}
+	}
+	return "", fmt.Errorf("Could not find a free IP address range, set a subnet")
+}
+
+// CreateNetwork sets up the bridge of the network named by the ID in the
+// first argument, with the Subnet given, or a free one. The bridge is reused
+// if it exists. It outputs the Bridge, the Subnet and the Gateway.
+func CreateNetwork(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s ID", job.Name)
+	}
+	var (
+		id     = job.Args[0]
+		bridge = bridgeName(id)
+		subnet = job.Getenv("Subnet")
+		err    error
+	)
+
+	networks.Lock()
+	defer networks.Unlock()
+	if _, exists := networks.m[id]; exists {
+		return job.Errorf("Network %s already exists", id)
+	}
+
+	if addr, _, err := networkdriver.GetIfaceAddr(bridge); err == nil {
+		// left by a previous run of the daemon
+		subnet = addr.String()
+	} else {
+		if subnet == "" {
+			if subnet, err = freeSubnet(); err != nil {
+				return job.Error(err)
+			}
+		}
+		if subnet, err = gatewayCIDR(subnet); err != nil {
+			return job.Error(err)
+		}
+		if err := createNetworkBridge(bridge, subnet); err != nil {
+			return job.Error(err)
+		}
+	}
+
+	ip, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return job.Error(err)
+	}
+	network.IP = ip
+
+	if firewallDriver != nil {
+		if err := firewallDriver.SetupNetwork(firewall.Insert, bridge, network, iccEnabled, ipMasqEnabled); err != nil {
+			netlink.DeleteBridge(bridge)
+			return job.Error(err)
+		}
+	}
+
+	// Block the bridge address in the IP allocator
+	ipallocator.RequestIP(network, network.IP)
+	networks.m[id] = &userNetwork{bridge: bridge, network: network}
+	log.Debugf("Created network %s on bridge %s with subnet %s", id, bridge, network)
+
+	out := engine.Env{}
+	out.Set("Bridge", bridge)
+	out.Set("Subnet", network.String())
+	out.Set("Gateway", network.IP.String())
+	if _, err := out.WriteTo(job.Stdout); err != nil {
+		return job.Error(err)
+	}
+	return engine.StatusOK
+}
+
+// DeleteNetwork removes the bridge of the network named by the ID in the
+// first argument, and its firewall rules.
+func DeleteNetwork(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s ID", job.Name)
+	}
+	id := job.Args[0]
+
+	networks.Lock()
+	defer networks.Unlock()
+	n, exists := networks.m[id]
+	if !exists {
+		return job.Errorf("No such network: %s", id)
+	}
+
+	if firewallDriver != nil {
+		if err := firewallDriver.SetupNetwork(firewall.Delete, n.bridge, n.network, iccEnabled, ipMasqEnabled); err != nil {
+			log.Errorf("Error removing the firewall rules of network %s: %s", id, err)
+		}
+	}
+	if err := netlink.DeleteBridge(n.bridge); err != nil && !os.IsNotExist(err) {
+		return job.Error(err)
+	}
+	ipallocator.ReleaseIP(n.network, n.network.IP)
+	delete(networks.m, id)
+	return engine.StatusOK
+}
+
+func createNetworkBridge(bridge, subnet string) error {
+	if err := createBridgeIface(bridge); err != nil && !os.IsExist(err) {
+		return err
+	}
+	iface, err := net.InterfaceByName(bridge)
+	if err != nil {
+		return err
+	}
+	ip, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return err
+	}
+	if err := networkdriver.CheckRouteOverlaps(network); err != nil {
+		netlink.DeleteBridge(bridge)
+		return err
+	}
+	if err := netlink.NetworkLinkAddIp(iface, ip, network); err != nil {
+		netlink.DeleteBridge(bridge)
+		return fmt.Errorf("Unable to add private network: %s", err)
+	}
+	if err := netlink.NetworkLinkUp(iface); err != nil {
+		netlink.DeleteBridge(bridge)
+		return fmt.Errorf("Unable to start network bridge: %s", err)
+	}
+	return nil
+}
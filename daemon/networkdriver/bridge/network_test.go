@@ -0,0 +1,33 @@
+package bridge
+
+import (
+	"testing"
+)
+
+func TestGatewayCIDR(t *testing.T) {
+	for subnet, expected := range map[string]string{
+		"10.1.0.0/24":   "10.1.0.1/24",
+		"10.1.0.254/24": "10.1.0.254/24",
+		"172.20.0.0/16": "172.20.0.1/16",
+	} {
+		gateway, err := gatewayCIDR(subnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gateway != expected {
+			t.Fatalf("Expected gateway %s for %s, got %s", expected, subnet, gateway)
+		}
+	}
+
+	for _, subnet := range []string{"10.1.0.0", "10.1.0.0/31", "2001:db8::/64"} {
+		if _, err := gatewayCIDR(subnet); err == nil {
+			t.Fatalf("Expected subnet %s to be invalid", subnet)
+		}
+	}
+}
+
+func TestBridgeName(t *testing.T) {
+	if name := bridgeName("3b5f9c0a8e7d6c5b4a39281706f5e4d3"); name != "br-3b5f9c0a8e7d" {
+		t.Fatalf("Expected br-3b5f9c0a8e7d, got %s", name)
+	}
+}
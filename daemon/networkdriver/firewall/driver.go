@@ -0,0 +1,40 @@
+package firewall
+
+import (
+	"net"
+)
+
+// Action is the way a rule is set: at the end or the top of its chain, or
+// removed
+type Action int
+
+const (
+	Append Action = iota
+	Insert
+	Delete
+)
+
+// Driver sets up the rules of the bridge of the containers in the firewall
+// of the host, selected with --firewall-driver
+type Driver interface {
+	Name() string // Name returns the name of the driver, as set with --firewall-driver
+	// Cleanup removes the rules of the published ports left by a previous
+	// run of the daemon
+	Cleanup() error
+	// Setup sets up the rules of the bridge, with the address addr: the
+	// masquerading of its traffic with ipMasq, inter-container communication
+	// as set with icc, the outgoing traffic and its replies, and the chains
+	// of the published ports and the links, for IPv6 too with ipv6
+	Setup(addr *net.IPNet, icc, ipMasq, ipv6 bool) error
+	// Forward sets the DNAT of count contiguous ports from port of ip to the
+	// ports from destPort of destAddr
+	Forward(action Action, ip net.IP, port, count int, proto, destAddr string, destPort int) error
+	// Link accepts the connections from ip1 to port of ip2, and their replies
+	Link(action Action, ip1, ip2 net.IP, port int, proto string) error
+	// Reject rejects the other packets from ip1 to ip2, after the ones of Link
+	Reject(action Action, ip1, ip2 net.IP) error
+	// SetupNetwork inserts or deletes the rules of the user-defined network
+	// on bridge, isolated from the bridge of the driver and the other
+	// networks, with the same settings as Setup
+	SetupNetwork(action Action, bridge string, network *net.IPNet, icc, ipMasq bool) error
+}
@@ -0,0 +1,21 @@
+package firewalls
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+	"github.com/docker/docker/daemon/networkdriver/firewall/iptables"
+	"github.com/docker/docker/daemon/networkdriver/firewall/nftables"
+)
+
+// NewDriver returns the firewall driver named name, setting up the rules of
+// bridge
+func NewDriver(name, bridge string) (firewall.Driver, error) {
+	switch name {
+	case "", iptables.DriverName:
+		return iptables.NewDriver(bridge), nil
+	case nftables.DriverName:
+		return nftables.NewDriver(bridge), nil
+	}
+	return nil, fmt.Errorf("unknown firewall driver %s", name)
+}
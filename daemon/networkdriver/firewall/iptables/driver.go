@@ -0,0 +1,193 @@
+package iptables
+
+import (
+	"fmt"
+	"net"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+	"github.com/docker/docker/pkg/iptables"
+)
+
+const DriverName = "iptables"
+
+var actions = map[firewall.Action]iptables.Action{
+	firewall.Append: iptables.Append,
+	firewall.Insert: iptables.Insert,
+	firewall.Delete: iptables.Delete,
+}
+
+// driver sets up the rules with the iptables and ip6tables binaries, in the
+// DOCKER chains of the filter and nat tables
+type driver struct {
+	bridge string
+	chain  *iptables.Chain // the filter chain of the links and published ports
+	chain6 *iptables.Chain // the ip6tables chain of the links, with IPv6
+}
+
+func NewDriver(bridge string) firewall.Driver {
+	return &driver{bridge: bridge}
+}
+
+func (d *driver) Name() string {
+	return DriverName
+}
+
+func (d *driver) Cleanup() error {
+	return iptables.RemoveExistingChain("DOCKER", iptables.Nat)
+}
+
+func (d *driver) Setup(addr *net.IPNet, icc, ipMasq, ipv6 bool) error {
+	// Enable NAT
+	if ipMasq {
+		natArgs := []string{"POSTROUTING", "-t", "nat", "-s", addr.String(), "!", "-o", d.bridge, "-j", "MASQUERADE"}
+
+		if !iptables.Exists(natArgs...) {
+			if output, err := iptables.Raw(append([]string{"-I"}, natArgs...)...); err != nil {
+				return fmt.Errorf("Unable to enable network bridge NAT: %s", err)
+			} else if len(output) != 0 {
+				return &iptables.ChainError{Chain: "POSTROUTING", Output: output}
+			}
+		}
+	}
+	if err := d.setupForwardRules(iptables.Raw, iptables.Exists, icc); err != nil {
+		return err
+	}
+	// the IPv6 traffic of the containers is not masqueraded
+	if ipv6 {
+		if err := d.setupForwardRules(iptables.Raw6, iptables.Exists6, icc); err != nil {
+			return err
+		}
+	}
+
+	if _, err := iptables.NewChain("DOCKER", d.bridge, iptables.Nat); err != nil {
+		return err
+	}
+	chain, err := iptables.NewChain("DOCKER", d.bridge, iptables.Filter)
+	if err != nil {
+		return err
+	}
+	d.chain = chain
+	if ipv6 {
+		if d.chain6, err = iptables.NewChain6("DOCKER", d.bridge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupForwardRules sets up the FORWARD rules of the bridge with iptables or
+// ip6tables: inter-container communication, outgoing and incoming traffic
+func (d *driver) setupForwardRules(raw func(...string) ([]byte, error), exists func(...string) bool, icc bool) error {
+	var (
+		args       = []string{"FORWARD", "-i", d.bridge, "-o", d.bridge, "-j"}
+		acceptArgs = append(args, "ACCEPT")
+		dropArgs   = append(args, "DROP")
+	)
+
+	if !icc {
+		raw(append([]string{"-D"}, acceptArgs...)...)
+
+		if !exists(dropArgs...) {
+			log.Debugf("Disable inter-container communication")
+			if output, err := raw(append([]string{"-I"}, dropArgs...)...); err != nil {
+				return fmt.Errorf("Unable to prevent intercontainer communication: %s", err)
+			} else if len(output) != 0 {
+				return fmt.Errorf("Error disabling intercontainer communication: %s", output)
+			}
+		}
+	} else {
+		raw(append([]string{"-D"}, dropArgs...)...)
+
+		if !exists(acceptArgs...) {
+			log.Debugf("Enable inter-container communication")
+			if output, err := raw(append([]string{"-I"}, acceptArgs...)...); err != nil {
+				return fmt.Errorf("Unable to allow intercontainer communication: %s", err)
+			} else if len(output) != 0 {
+				return fmt.Errorf("Error enabling intercontainer communication: %s", output)
+			}
+		}
+	}
+
+	// Accept all non-intercontainer outgoing packets
+	outgoingArgs := []string{"FORWARD", "-i", d.bridge, "!", "-o", d.bridge, "-j", "ACCEPT"}
+	if !exists(outgoingArgs...) {
+		if output, err := raw(append([]string{"-I"}, outgoingArgs...)...); err != nil {
+			return fmt.Errorf("Unable to allow outgoing packets: %s", err)
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "FORWARD outgoing", Output: output}
+		}
+	}
+
+	// Accept incoming packets for existing connections
+	existingArgs := []string{"FORWARD", "-o", d.bridge, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}
+
+	if !exists(existingArgs...) {
+		if output, err := raw(append([]string{"-I"}, existingArgs...)...); err != nil {
+			return fmt.Errorf("Unable to allow incoming packets: %s", err)
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "FORWARD incoming", Output: output}
+		}
+	}
+	return nil
+}
+
+func (d *driver) Forward(action firewall.Action, ip net.IP, port, count int, proto, destAddr string, destPort int) error {
+	return d.linkChain(false).ForwardRange(actions[action], ip, port, count, proto, destAddr, destPort)
+}
+
+func (d *driver) Link(action firewall.Action, ip1, ip2 net.IP, port int, proto string) error {
+	return d.linkChain(ip1.To4() == nil).Link(actions[action], ip1, ip2, port, proto)
+}
+
+func (d *driver) Reject(action firewall.Action, ip1, ip2 net.IP) error {
+	return d.linkChain(ip1.To4() == nil).Reject(actions[action], ip1, ip2)
+}
+
+// linkChain returns the DOCKER filter chain of iptables, or of ip6tables
+// with ipv6 if IPv6 is set up
+func (d *driver) linkChain(ipv6 bool) *iptables.Chain {
+	if ipv6 && d.chain6 != nil {
+		return d.chain6
+	}
+	if d.chain == nil {
+		return &iptables.Chain{Name: "DOCKER", Bridge: d.bridge, Table: iptables.Filter}
+	}
+	return d.chain
+}
+
+// SetupNetwork inserts or deletes the rules of the network on bridge:
+// masquerading, inter-container communication as set with --icc, outgoing
+// traffic, and isolation from the bridge of the driver and the other networks
+func (d *driver) SetupNetwork(action firewall.Action, bridge string, network *net.IPNet, icc, ipMasq bool) error {
+	subnet := &net.IPNet{IP: network.IP.Mask(network.Mask), Mask: network.Mask}
+	rules := [][]string{
+		{"FORWARD", "-o", bridge, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"},
+		{"FORWARD", "-i", bridge, "!", "-o", bridge, "-j", "ACCEPT"},
+		// inserted above the accepted outgoing traffic
+		{"FORWARD", "-i", bridge, "-o", d.bridge, "-j", "DROP"},
+		{"FORWARD", "-i", d.bridge, "-o", bridge, "-j", "DROP"},
+		{"FORWARD", "-i", bridge, "-o", "br-+", "-j", "DROP"},
+	}
+	if icc {
+		rules = append(rules, []string{"FORWARD", "-i", bridge, "-o", bridge, "-j", "ACCEPT"})
+	} else {
+		rules = append(rules, []string{"FORWARD", "-i", bridge, "-o", bridge, "-j", "DROP"})
+	}
+	if ipMasq {
+		rules = append(rules, []string{"POSTROUTING", "-t", "nat", "-s", subnet.String(), "!", "-o", bridge, "-j", "MASQUERADE"})
+	}
+
+	for _, rule := range rules {
+		exists := iptables.Exists(rule...)
+		if (action != firewall.Delete) == exists {
+			continue
+		}
+		if output, err := iptables.Raw(append([]string{string(actions[action])}, rule...)...); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: rule[0], Output: output}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package nftables
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+	"github.com/docker/docker/pkg/nftables"
+)
+
+const (
+	DriverName = "nftables"
+	table      = "docker"
+)
+
+// nft calls the nft binary, replaced in the tests
+var nft = nftables.Raw
+
+// driver sets up the rules with the nft binary, in the docker tables of the
+// ip and ip6 families which it owns, without the iptables compatibility
+// layer. Its rules are commented with a hash of their expression, to find
+// their handle when they are deleted.
+type driver struct {
+	bridge string
+	ipv6   bool
+}
+
+func NewDriver(bridge string) firewall.Driver {
+	return &driver{bridge: bridge}
+}
+
+func (d *driver) Name() string {
+	return DriverName
+}
+
+// Cleanup removes the tables, which are set up from scratch by Setup
+func (d *driver) Cleanup() error {
+	for _, family := range []string{"ip", "ip6"} {
+		// they don't exist without a previous run of the daemon
+		nft("delete", "table", family, table)
+	}
+	return nil
+}
+
+func (d *driver) Setup(addr *net.IPNet, icc, ipMasq, ipv6 bool) error {
+	commands := [][]string{
+		{"add", "table", "ip", table},
+		{"add", "chain", "ip", table, "prerouting", "{ type nat hook prerouting priority -100 ; }"},
+		{"add", "chain", "ip", table, "output", "{ type nat hook output priority -100 ; }"},
+		{"add", "chain", "ip", table, "postrouting", "{ type nat hook postrouting priority 100 ; }"},
+		{"add", "chain", "ip", table, "forward", "{ type filter hook forward priority 0 ; }"},
+		// the DNAT of the published ports, and the links and published ports
+		{"add", "chain", "ip", table, "dnat"},
+		{"add", "chain", "ip", table, "containers"},
+	}
+	if ipv6 {
+		commands = append(commands,
+			[]string{"add", "table", "ip6", table},
+			[]string{"add", "chain", "ip6", table, "forward", "{ type filter hook forward priority 0 ; }"},
+			[]string{"add", "chain", "ip6", table, "containers"})
+	}
+	for _, command := range commands {
+		if _, err := nft(command...); err != nil {
+			return err
+		}
+	}
+	d.ipv6 = ipv6
+
+	rules := []struct {
+		family, chain, expr string
+	}{
+		{"ip", "prerouting", "fib daddr type local jump dnat"},
+		{"ip", "output", "ip daddr != 127.0.0.0/8 fib daddr type local jump dnat"},
+	}
+	if ipMasq {
+		subnet := &net.IPNet{IP: addr.IP.Mask(addr.Mask), Mask: addr.Mask}
+		rules = append(rules, struct{ family, chain, expr string }{"ip", "postrouting", fmt.Sprintf("ip saddr %s oifname != %q masquerade", subnet, d.bridge)})
+	}
+	families := []string{"ip"}
+	if ipv6 {
+		families = append(families, "ip6")
+	}
+	verdict := "accept"
+	if !icc {
+		verdict = "drop"
+	}
+	for _, family := range families {
+		for _, expr := range []string{
+			fmt.Sprintf("oifname %q jump containers", d.bridge),
+			// Accept incoming packets for existing connections
+			fmt.Sprintf("oifname %q ct state related,established accept", d.bridge),
+			// Accept all non-intercontainer outgoing packets
+			fmt.Sprintf("iifname %q oifname != %q accept", d.bridge, d.bridge),
+			fmt.Sprintf("iifname %q oifname %q %s", d.bridge, d.bridge, verdict),
+		} {
+			rules = append(rules, struct{ family, chain, expr string }{family, "forward", expr})
+		}
+	}
+	for _, r := range rules {
+		if err := rule(firewall.Append, r.family, r.chain, r.expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *driver) Forward(action firewall.Action, ip net.IP, port, count int, proto, destAddr string, destPort int) error {
+	var daddr string
+	if !ip.IsUnspecified() {
+		daddr = fmt.Sprintf("ip daddr %s ", ip)
+	}
+	var rules []struct{ chain, expr string }
+	switch {
+	case count <= 1:
+		dest := net.JoinHostPort(destAddr, strconv.Itoa(destPort))
+		rules = append(rules, struct{ chain, expr string }{"dnat", fmt.Sprintf("%siifname != %q %s dport %d dnat to %s", daddr, d.bridge, proto, port, dest)})
+	case port == destPort:
+		// the destination ports are the ones of the packets
+		rules = append(rules, struct{ chain, expr string }{"dnat", fmt.Sprintf("%siifname != %q %s dport %s dnat to %s", daddr, d.bridge, proto, portRange(port, count), destAddr)})
+	default:
+		for i := 0; i < count; i++ {
+			dest := net.JoinHostPort(destAddr, strconv.Itoa(destPort+i))
+			rules = append(rules, struct{ chain, expr string }{"dnat", fmt.Sprintf("%siifname != %q %s dport %d dnat to %s", daddr, d.bridge, proto, port+i, dest)})
+		}
+	}
+	rules = append(rules,
+		struct{ chain, expr string }{"containers", fmt.Sprintf("iifname != %q oifname %q ip daddr %s %s dport %s accept", d.bridge, d.bridge, destAddr, proto, portRange(destPort, count))},
+		struct{ chain, expr string }{"postrouting", fmt.Sprintf("ip saddr %s ip daddr %s %s dport %s masquerade", destAddr, destAddr, proto, portRange(destPort, count))})
+	for _, r := range rules {
+		if err := rule(action, "ip", r.chain, r.expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *driver) Link(action firewall.Action, ip1, ip2 net.IP, port int, proto string) error {
+	family, err := d.family(ip1)
+	if err != nil {
+		return err
+	}
+	between := fmt.Sprintf("iifname %q oifname %q", d.bridge, d.bridge)
+	for _, expr := range []string{
+		fmt.Sprintf("%s %s saddr %s %s daddr %s %s dport %d accept", between, family, ip1, family, ip2, proto, port),
+		// only the replies, ip2 can't open connections from port to any port of ip1
+		fmt.Sprintf("%s %s saddr %s %s daddr %s %s sport %d ct state related,established accept", between, family, ip2, family, ip1, proto, port),
+	} {
+		if err := rule(action, family, "containers", expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *driver) Reject(action firewall.Action, ip1, ip2 net.IP) error {
+	family, err := d.family(ip1)
+	if err != nil {
+		return err
+	}
+	between := fmt.Sprintf("iifname %q oifname %q %s saddr %s %s daddr %s", d.bridge, d.bridge, family, ip1, family, ip2)
+	for _, expr := range []string{
+		between + " meta l4proto tcp reject with tcp reset",
+		between + " reject",
+	} {
+		if err := rule(action, family, "containers", expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// portRange returns the expression of count ports from port
+func portRange(port, count int) string {
+	if count <= 1 {
+		return strconv.Itoa(port)
+	}
+	return fmt.Sprintf("%d-%d", port, port+count-1)
+}
+
+// family returns the family of the tables of ip, if they are set up
+func (d *driver) family(ip net.IP) (string, error) {
+	if ip.To4() != nil {
+		return "ip", nil
+	}
+	if !d.ipv6 {
+		return "", fmt.Errorf("Cannot set rules for %s without IPv6", ip)
+	}
+	return "ip6", nil
+}
+
+// SetupNetwork inserts or deletes the rules of the network on bridge:
+// masquerading, inter-container communication as set with --icc, outgoing
+// traffic, and isolation from the bridge of the driver and the other networks
+func (d *driver) SetupNetwork(action firewall.Action, bridge string, network *net.IPNet, icc, ipMasq bool) error {
+	subnet := &net.IPNet{IP: network.IP.Mask(network.Mask), Mask: network.Mask}
+	verdict := "accept"
+	if !icc {
+		verdict = "drop"
+	}
+	rules := []struct{ chain, expr string }{
+		{"forward", fmt.Sprintf("oifname %q ct state related,established accept", bridge)},
+		{"forward", fmt.Sprintf("iifname %q oifname != %q accept", bridge, bridge)},
+		// inserted above the accepted outgoing traffic
+		{"forward", fmt.Sprintf("iifname %q oifname %q drop", bridge, d.bridge)},
+		{"forward", fmt.Sprintf("iifname %q oifname %q drop", d.bridge, bridge)},
+		{"forward", fmt.Sprintf("iifname %q oifname \"br-*\" drop", bridge)},
+		{"forward", fmt.Sprintf("iifname %q oifname %q %s", bridge, bridge, verdict)},
+	}
+	if ipMasq {
+		rules = append(rules, struct{ chain, expr string }{"postrouting", fmt.Sprintf("ip saddr %s oifname != %q masquerade", subnet, bridge)})
+	}
+
+	for _, r := range rules {
+		handle, err := handle("ip", r.chain, r.expr)
+		if err != nil {
+			return err
+		}
+		if (action != firewall.Delete) == (handle != 0) {
+			continue
+		}
+		if err := rule(action, "ip", r.chain, r.expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rule appends, inserts or deletes the rule with the expression expr in the
+// chain of the docker table of family
+func rule(action firewall.Action, family, chain, expr string) error {
+	var command []string
+	switch action {
+	case firewall.Append, firewall.Insert:
+		verb := "add"
+		if action == firewall.Insert {
+			verb = "insert"
+		}
+		command = append([]string{verb, "rule", family, table, chain}, strings.Fields(expr)...)
+		command = append(command, "comment", strconv.Quote(comment(expr)))
+	case firewall.Delete:
+		handle, err := handle(family, chain, expr)
+		if err != nil {
+			return err
+		}
+		if handle == 0 {
+			return fmt.Errorf("No rule %q in the %s chain", expr, chain)
+		}
+		command = []string{"delete", "rule", family, table, chain, "handle", strconv.Itoa(handle)}
+	}
+	_, err := nft(command...)
+	return err
+}
+
+// handle returns the handle of the first rule with the expression expr in
+// the chain of the docker table of family, or 0 if there is none
+func handle(family, chain, expr string) (int, error) {
+	listing, err := nft("-a", "list", "chain", family, table, chain)
+	if err != nil {
+		return 0, err
+	}
+	return nftables.Handle(listing, comment(expr)), nil
+}
+
+// comment returns the comment identifying the rules with the expression expr
+func comment(expr string) string {
+	sum := sha1.Sum([]byte(expr))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,120 @@
+package nftables
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon/networkdriver/firewall"
+)
+
+// fakeNft records the nft commands and lists the rules added by them
+type fakeNft struct {
+	commands []string
+	rules    []string
+}
+
+func (f *fakeNft) run(args ...string) ([]byte, error) {
+	command := strings.Join(args, " ")
+	f.commands = append(f.commands, command)
+	switch args[0] {
+	case "add", "insert":
+		if args[1] == "rule" {
+			f.rules = append(f.rules, command)
+		}
+	case "-a":
+		var listing string
+		for i, r := range f.rules {
+			listing += fmt.Sprintf("%s # handle %d\n", r[strings.Index(r, " comment ")+1:], i+1)
+		}
+		return []byte(listing), nil
+	}
+	return nil, nil
+}
+
+func TestLinkAndDelete(t *testing.T) {
+	fake := &fakeNft{}
+	defer func(raw func(...string) ([]byte, error)) { nft = raw }(nft)
+	nft = fake.run
+
+	d := NewDriver("docker0")
+	ip1, ip2 := net.ParseIP("172.17.0.3"), net.ParseIP("172.17.0.2")
+	if err := d.Link(firewall.Append, ip1, ip2, 80, "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %v", fake.rules)
+	}
+	expected := `add rule ip docker containers iifname "docker0" oifname "docker0" ip saddr 172.17.0.3 ip daddr 172.17.0.2 tcp dport 80 accept comment`
+	if !strings.HasPrefix(fake.rules[0], expected) {
+		t.Fatalf("Expected the rule %q, got %q", expected, fake.rules[0])
+	}
+	if !strings.Contains(fake.rules[1], "tcp sport 80 ct state related,established accept") {
+		t.Fatalf("Expected the replies to be accepted, got %q", fake.rules[1])
+	}
+
+	if err := d.Link(firewall.Delete, ip1, ip2, 80, "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	last := fake.commands[len(fake.commands)-1]
+	if last != "delete rule ip docker containers handle 2" {
+		t.Fatalf("Expected the second rule to be deleted, got %q", last)
+	}
+
+	if err := d.Link(firewall.Append, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 80, "tcp"); err == nil {
+		t.Fatal("Expected linking IPv6 addresses without IPv6 to fail")
+	}
+}
+
+func TestForwardRange(t *testing.T) {
+	fake := &fakeNft{}
+	defer func(raw func(...string) ([]byte, error)) { nft = raw }(nft)
+	nft = fake.run
+
+	d := NewDriver("docker0")
+	if err := d.Forward(firewall.Append, net.ParseIP("0.0.0.0"), 8000, 101, "udp", "172.17.0.2", 8000); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.rules) != 3 {
+		t.Fatalf("Expected a single rule per chain for the range, got %v", fake.rules)
+	}
+	expected := `add rule ip docker dnat iifname != "docker0" udp dport 8000-8100 dnat to 172.17.0.2 comment`
+	if !strings.HasPrefix(fake.rules[0], expected) {
+		t.Fatalf("Expected the rule %q, got %q", expected, fake.rules[0])
+	}
+
+	// the DNAT can't shift the ports, so they are forwarded one by one
+	fake.rules = nil
+	if err := d.Forward(firewall.Append, net.ParseIP("0.0.0.0"), 9000, 3, "tcp", "172.17.0.2", 8000); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.rules) != 5 {
+		t.Fatalf("Expected 3 DNAT rules and 2 rules for the range, got %v", fake.rules)
+	}
+	if !strings.Contains(fake.rules[2], "tcp dport 9002 dnat to 172.17.0.2:8002") {
+		t.Fatalf("Expected the last port to be forwarded to 8002, got %q", fake.rules[2])
+	}
+}
+
+func TestSetupNetworkSkipsExisting(t *testing.T) {
+	fake := &fakeNft{}
+	defer func(raw func(...string) ([]byte, error)) { nft = raw }(nft)
+	nft = fake.run
+
+	d := NewDriver("docker0")
+	_, network, _ := net.ParseCIDR("10.99.0.1/24")
+	if err := d.SetupNetwork(firewall.Insert, "br-test", network, false, true); err != nil {
+		t.Fatal(err)
+	}
+	count := len(fake.rules)
+	if count != 7 {
+		t.Fatalf("Expected 7 rules, got %v", fake.rules)
+	}
+	if err := d.SetupNetwork(firewall.Insert, "br-test", network, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.rules) != count {
+		t.Fatalf("Expected the existing rules not to be inserted again, got %v", fake.rules)
+	}
+}
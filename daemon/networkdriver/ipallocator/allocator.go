@@ -92,11 +92,31 @@ func RequestIP(network *net.IPNet, ip net.IP) (net.IP, error) {
 	}
 
 	if ip == nil {
-		return allocated.getNextIP()
+		return allocated.getNextIP(nil)
 	}
 	return allocated.checkIP(ip)
 }
 
+// RequestIPExcluding requests the next available ip from the given network
+// which is none of the excluded ones, e.g. the static addresses of stopped
+// containers, which can still be requested explicitly
+func RequestIPExcluding(network *net.IPNet, excluded []net.IP) (net.IP, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	key := network.String()
+	allocated, ok := allocatedIPs[key]
+	if !ok {
+		allocated = newAllocatedMap(network)
+		allocatedIPs[key] = allocated
+	}
+
+	skip := make(map[string]struct{}, len(excluded))
+	for _, ip := range excluded {
+		skip[ip.String()] = struct{}{}
+	}
+	return allocated.getNextIP(skip)
+}
+
 // ReleaseIP adds the provided ip back into the pool of
 // available ips to be returned for use.
 func ReleaseIP(network *net.IPNet, ip net.IP) error {
@@ -126,8 +146,8 @@ func (allocated *allocatedMap) checkIP(ip net.IP) (net.IP, error) {
 }
 
 // return an available ip if one is currently available.  If not,
-// return the next available ip for the nextwork
-func (allocated *allocatedMap) getNextIP() (net.IP, error) {
+// return the next available ip for the nextwork, other than the ones in skip
+func (allocated *allocatedMap) getNextIP(skip map[string]struct{}) (net.IP, error) {
 	pos := big.NewInt(0).Set(allocated.last)
 	allRange := big.NewInt(0).Sub(allocated.end, allocated.begin)
 	for i := big.NewInt(0); i.Cmp(allRange) <= 0; i.Add(i, big.NewInt(1)) {
@@ -138,6 +158,9 @@ func (allocated *allocatedMap) getNextIP() (net.IP, error) {
 		if _, ok := allocated.p[bigIntToIP(pos).String()]; ok {
 			continue
 		}
+		if _, ok := skip[bigIntToIP(pos).String()]; ok {
+			continue
+		}
 		allocated.p[bigIntToIP(pos).String()] = struct{}{}
 		allocated.last.Set(pos)
 		return bigIntToIP(pos), nil
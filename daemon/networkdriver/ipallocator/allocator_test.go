@@ -251,6 +251,35 @@ func TestRequestSpecificIp(t *testing.T) {
 	}
 }
 
+func TestRequestIPExcluding(t *testing.T) {
+	defer reset()
+	network := &net.IPNet{
+		IP:   []byte{192, 168, 0, 1},
+		Mask: []byte{255, 255, 255, 248},
+	}
+	excluded := []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.4")}
+
+	for _, expected := range []string{"192.168.0.3", "192.168.0.5", "192.168.0.6"} {
+		ip, err := RequestIPExcluding(network, excluded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ip.String() != expected {
+			t.Fatalf("Expected %s, got %s", expected, ip)
+		}
+	}
+	if ip, err := RequestIPExcluding(network, excluded); err != ErrNoAvailableIPs {
+		t.Fatalf("Expected no address left but the excluded ones, got %s: %v", ip, err)
+	}
+
+	// the excluded addresses can still be requested explicitly
+	for _, ip := range excluded {
+		if _, err := RequestIP(network, ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestRequestSpecificIpV6(t *testing.T) {
 	defer reset()
 	network := &net.IPNet{
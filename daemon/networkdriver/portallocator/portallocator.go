@@ -36,8 +36,9 @@ const (
 )
 
 var (
-	ErrAllPortsAllocated = errors.New("all ports are allocated")
-	ErrUnknownProtocol   = errors.New("unknown protocol")
+	ErrAllPortsAllocated    = errors.New("all ports are allocated")
+	ErrUnknownProtocol      = errors.New("unknown protocol")
+	ErrPortRangeOutOfBounds = errors.New("port range is out of bounds")
 )
 
 var (
@@ -96,21 +97,80 @@ func RequestPort(ip net.IP, proto string, port int) (int, error) {
 		globalMap[ipstr] = protomap
 	}
 	mapping := protomap[proto]
+	used := func(port int) bool {
+		return globalMap.allocated(ip, proto, port)
+	}
 	if port > 0 {
-		if _, ok := mapping.p[port]; !ok {
+		if !used(port) {
 			mapping.p[port] = struct{}{}
 			return port, nil
 		}
 		return 0, NewErrPortAlreadyAllocated(ipstr, port)
 	}
 
-	port, err := mapping.findPort()
+	port, err := mapping.findPort(used)
 	if err != nil {
 		return 0, err
 	}
 	return port, nil
 }
 
+// RequestPortRange requests count contiguous ports from the global ports pool
+// for specified ip and proto, and returns the first one. If port is 0 it
+// finds the first free range in the dynamic ports, otherwise all the ports
+// from port must be free.
+func RequestPortRange(ip net.IP, proto string, port, count int) (int, error) {
+	if count <= 1 {
+		return RequestPort(ip, proto, port)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if proto != "tcp" && proto != "udp" {
+		return 0, ErrUnknownProtocol
+	}
+
+	if ip == nil {
+		ip = defaultIP
+	}
+	ipstr := ip.String()
+	protomap, ok := globalMap[ipstr]
+	if !ok {
+		protomap = newProtoMap()
+		globalMap[ipstr] = protomap
+	}
+	mapping := protomap[proto]
+	used := func(port int) bool {
+		return globalMap.allocated(ip, proto, port)
+	}
+	if port > 0 {
+		if port+count-1 > EndPortRange {
+			return 0, ErrPortRangeOutOfBounds
+		}
+		for i := port; i < port+count; i++ {
+			if used(i) {
+				return 0, NewErrPortAlreadyAllocated(ipstr, i)
+			}
+		}
+		mapping.allocateRange(port, count)
+		return port, nil
+	}
+
+	return mapping.findRange(count, used)
+}
+
+// ReleasePortRange releases count contiguous ports from port from global
+// ports pool for specified ip and proto.
+func ReleasePortRange(ip net.IP, proto string, port, count int) error {
+	for i := port; i < port+count; i++ {
+		if err := ReleasePort(ip, proto, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ReleasePort releases port from global ports pool for specified ip and proto.
 func ReleasePort(ip net.IP, proto string, port int) error {
 	mutex.Lock()
@@ -135,7 +195,22 @@ func ReleaseAll() error {
 	return nil
 }
 
-func (pm *portMap) findPort() (int, error) {
+// allocated returns whether port is allocated for proto on ip, on the
+// unspecified address which binds all the IPs, or on any IP if ip is the
+// unspecified address
+func (m ipMapping) allocated(ip net.IP, proto string, port int) bool {
+	for ipstr, protomap := range m {
+		if _, ok := protomap[proto].p[port]; !ok {
+			continue
+		}
+		if ipstr == ip.String() || ip.IsUnspecified() || net.ParseIP(ipstr).IsUnspecified() {
+			return true
+		}
+	}
+	return false
+}
+
+func (pm *portMap) findPort(used func(int) bool) (int, error) {
 	port := pm.last
 	for i := 0; i <= EndPortRange-BeginPortRange; i++ {
 		port++
@@ -143,7 +218,7 @@ func (pm *portMap) findPort() (int, error) {
 			port = BeginPortRange
 		}
 
-		if _, ok := pm.p[port]; !ok {
+		if !used(port) {
 			pm.p[port] = struct{}{}
 			pm.last = port
 			return port, nil
@@ -151,3 +226,38 @@ func (pm *portMap) findPort() (int, error) {
 	}
 	return 0, ErrAllPortsAllocated
 }
+
+// findRange allocates the first count contiguous ports which are not used
+// after the last allocated one, in the dynamic ports
+func (pm *portMap) findRange(count int, used func(int) bool) (int, error) {
+	if count > EndPortRange-BeginPortRange+1 {
+		return 0, ErrAllPortsAllocated
+	}
+	port := pm.last
+	for i := 0; i <= EndPortRange-BeginPortRange; i++ {
+		port++
+		if port+count-1 > EndPortRange {
+			port = BeginPortRange
+		}
+
+		free := true
+		for j := port; j < port+count; j++ {
+			if used(j) {
+				free = false
+				break
+			}
+		}
+		if free {
+			pm.allocateRange(port, count)
+			pm.last = port + count - 1
+			return port, nil
+		}
+	}
+	return 0, ErrAllPortsAllocated
+}
+
+func (pm *portMap) allocateRange(port, count int) {
+	for i := port; i < port+count; i++ {
+		pm.p[i] = struct{}{}
+	}
+}
@@ -34,6 +34,42 @@ func TestRequestSpecificPort(t *testing.T) {
 	}
 }
 
+func TestRequestPortRange(t *testing.T) {
+	defer reset()
+
+	port, err := RequestPortRange(defaultIP, "udp", 8000, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 8000 {
+		t.Fatalf("Expected port 8000 got %d", port)
+	}
+	if _, err := RequestPortRange(defaultIP, "udp", 8100, 2); err == nil {
+		t.Fatal("Expected an error for a range overlapping an allocated one")
+	}
+
+	// the dynamic ranges are contiguous, after the allocated ports
+	if _, err := RequestPort(defaultIP, "tcp", BeginPortRange+1); err != nil {
+		t.Fatal(err)
+	}
+	if port, err = RequestPortRange(defaultIP, "tcp", 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if expected := BeginPortRange + 2; port != expected {
+		t.Fatalf("Expected port %d got %d", expected, port)
+	}
+
+	if err := ReleasePortRange(defaultIP, "udp", 8000, 101); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestPortRange(defaultIP, "udp", 8050, 51); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestPortRange(defaultIP, "udp", EndPortRange, 2); err != ErrPortRangeOutOfBounds {
+		t.Fatalf("Expected error ErrPortRangeOutOfBounds, got %v", err)
+	}
+}
+
 func TestReleasePort(t *testing.T) {
 	defer reset()
 
@@ -243,3 +279,43 @@ func TestNoDuplicateBPR(t *testing.T) {
 		t.Fatalf("Acquire(0) allocated the same port twice: %d", port)
 	}
 }
+
+func TestPerIPAllocation(t *testing.T) {
+	defer reset()
+
+	ip1 := net.ParseIP("10.0.0.5")
+	ip2 := net.ParseIP("192.168.1.5")
+
+	if _, err := RequestPort(ip1, "tcp", 443); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestPort(ip2, "tcp", 443); err != nil {
+		t.Fatalf("The same port on another IP should be allocated: %s", err)
+	}
+	if _, err := RequestPort(defaultIP, "tcp", 443); err == nil {
+		t.Fatal("The unspecified address should conflict with a port allocated on an IP")
+	}
+	if _, err := RequestPort(defaultIP, "udp", 443); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RequestPort(defaultIP, "tcp", 8080); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestPort(ip1, "tcp", 8080); err == nil {
+		t.Fatal("An IP should conflict with a port allocated on the unspecified address")
+	}
+	if _, err := RequestPortRange(ip1, "tcp", 8079, 3); err == nil {
+		t.Fatal("A range should conflict with a port allocated on the unspecified address")
+	}
+
+	port, err := RequestPort(ip1, "tcp", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := RequestPort(defaultIP, "tcp", 0); err != nil {
+		t.Fatal(err)
+	} else if p == port {
+		t.Fatalf("Acquire(0) on the unspecified address allocated port %d used on %s", port, ip1)
+	}
+}
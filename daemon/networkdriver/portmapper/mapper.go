@@ -7,20 +7,21 @@ import (
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver/firewall"
 	"github.com/docker/docker/daemon/networkdriver/portallocator"
-	"github.com/docker/docker/pkg/iptables"
 )
 
 type mapping struct {
 	proto         string
+	count         int // number of contiguous ports from host and container
 	userlandProxy UserlandProxy
 	host          net.Addr
 	container     net.Addr
 }
 
 var (
-	chain *iptables.Chain
-	lock  sync.Mutex
+	fw   firewall.Driver
+	lock sync.Mutex
 
 	// udp:ip:port
 	currentMappings = make(map[string]*mapping)
@@ -34,11 +35,19 @@ var (
 	ErrPortNotMapped             = errors.New("port is not mapped")
 )
 
-func SetIptablesChain(c *iptables.Chain) {
-	chain = c
+func SetFirewall(d firewall.Driver) {
+	fw = d
 }
 
 func Map(container net.Addr, hostIP net.IP, hostPort int) (host net.Addr, err error) {
+	return MapRange(container, hostIP, hostPort, 1)
+}
+
+// MapRange maps count contiguous ports from hostPort of hostIP to the ports
+// from the one of container, with the same firewall rules and userland proxy
+// process for all of them. If hostPort is 0, the host ports are allocated.
+// The mapping is unmapped with the address of its first host port.
+func MapRange(container net.Addr, hostIP net.IP, hostPort, count int) (host net.Addr, err error) {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -49,33 +58,39 @@ func Map(container net.Addr, hostIP net.IP, hostPort int) (host net.Addr, err er
 		proxy             UserlandProxy
 	)
 
+	if count < 1 {
+		count = 1
+	}
+
 	switch container.(type) {
 	case *net.TCPAddr:
 		proto = "tcp"
-		if allocatedHostPort, err = portallocator.RequestPort(hostIP, proto, hostPort); err != nil {
+		if allocatedHostPort, err = portallocator.RequestPortRange(hostIP, proto, hostPort, count); err != nil {
 			return nil, err
 		}
 
 		m = &mapping{
 			proto:     proto,
+			count:     count,
 			host:      &net.TCPAddr{IP: hostIP, Port: allocatedHostPort},
 			container: container,
 		}
 
-		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.TCPAddr).IP, container.(*net.TCPAddr).Port)
+		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.TCPAddr).IP, container.(*net.TCPAddr).Port, count)
 	case *net.UDPAddr:
 		proto = "udp"
-		if allocatedHostPort, err = portallocator.RequestPort(hostIP, proto, hostPort); err != nil {
+		if allocatedHostPort, err = portallocator.RequestPortRange(hostIP, proto, hostPort, count); err != nil {
 			return nil, err
 		}
 
 		m = &mapping{
 			proto:     proto,
+			count:     count,
 			host:      &net.UDPAddr{IP: hostIP, Port: allocatedHostPort},
 			container: container,
 		}
 
-		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.UDPAddr).IP, container.(*net.UDPAddr).Port)
+		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.UDPAddr).IP, container.(*net.UDPAddr).Port, count)
 	default:
 		return nil, ErrUnknownBackendAddressType
 	}
@@ -83,7 +98,7 @@ func Map(container net.Addr, hostIP net.IP, hostPort int) (host net.Addr, err er
 	// release the allocated port on any further error during return.
 	defer func() {
 		if err != nil {
-			portallocator.ReleasePort(hostIP, proto, allocatedHostPort)
+			portallocator.ReleasePortRange(hostIP, proto, allocatedHostPort, count)
 		}
 	}()
 
@@ -93,15 +108,15 @@ func Map(container net.Addr, hostIP net.IP, hostPort int) (host net.Addr, err er
 	}
 
 	containerIP, containerPort := getIPAndPort(m.container)
-	if err := forward(iptables.Append, m.proto, hostIP, allocatedHostPort, containerIP.String(), containerPort); err != nil {
+	if err := forward(firewall.Append, m.proto, hostIP, allocatedHostPort, count, containerIP.String(), containerPort); err != nil {
 		return nil, err
 	}
 
 	cleanup := func() error {
 		// need to undo the iptables rules before we return
 		proxy.Stop()
-		forward(iptables.Delete, m.proto, hostIP, allocatedHostPort, containerIP.String(), containerPort)
-		if err := portallocator.ReleasePort(hostIP, m.proto, allocatedHostPort); err != nil {
+		forward(firewall.Delete, m.proto, hostIP, allocatedHostPort, count, containerIP.String(), containerPort)
+		if err := portallocator.ReleasePortRange(hostIP, m.proto, allocatedHostPort, count); err != nil {
 			return err
 		}
 
@@ -135,15 +150,15 @@ func Unmap(host net.Addr) error {
 
 	containerIP, containerPort := getIPAndPort(data.container)
 	hostIP, hostPort := getIPAndPort(data.host)
-	if err := forward(iptables.Delete, data.proto, hostIP, hostPort, containerIP.String(), containerPort); err != nil {
-		log.Errorf("Error on iptables delete: %s", err)
+	if err := forward(firewall.Delete, data.proto, hostIP, hostPort, data.count, containerIP.String(), containerPort); err != nil {
+		log.Errorf("Error on firewall delete: %s", err)
 	}
 
 	switch a := host.(type) {
 	case *net.TCPAddr:
-		return portallocator.ReleasePort(a.IP, "tcp", a.Port)
+		return portallocator.ReleasePortRange(a.IP, "tcp", a.Port, data.count)
 	case *net.UDPAddr:
-		return portallocator.ReleasePort(a.IP, "udp", a.Port)
+		return portallocator.ReleasePortRange(a.IP, "udp", a.Port, data.count)
 	}
 	return nil
 }
@@ -168,9 +183,9 @@ func getIPAndPort(a net.Addr) (net.IP, int) {
 	return nil, 0
 }
 
-func forward(action iptables.Action, proto string, sourceIP net.IP, sourcePort int, containerIP string, containerPort int) error {
-	if chain == nil {
+func forward(action firewall.Action, proto string, sourceIP net.IP, sourcePort, count int, containerIP string, containerPort int) error {
+	if fw == nil {
 		return nil
 	}
-	return chain.Forward(action, sourceIP, sourcePort, proto, containerIP, containerPort)
+	return fw.Forward(action, sourceIP, sourcePort, count, proto, containerIP, containerPort)
 }
@@ -4,8 +4,8 @@ import (
 	"net"
 	"testing"
 
+	"github.com/docker/docker/daemon/networkdriver/firewall/iptables"
 	"github.com/docker/docker/daemon/networkdriver/portallocator"
-	"github.com/docker/docker/pkg/iptables"
 )
 
 func init() {
@@ -14,25 +14,22 @@ func init() {
 }
 
 func reset() {
-	chain = nil
+	fw = nil
 	currentMappings = make(map[string]*mapping)
 }
 
-func TestSetIptablesChain(t *testing.T) {
+func TestSetFirewall(t *testing.T) {
 	defer reset()
 
-	c := &iptables.Chain{
-		Name:   "TEST",
-		Bridge: "192.168.1.1",
-	}
+	d := iptables.NewDriver("192.168.1.1")
 
-	if chain != nil {
-		t.Fatal("chain should be nil at init")
+	if fw != nil {
+		t.Fatal("firewall should be nil at init")
 	}
 
-	SetIptablesChain(c)
-	if chain == nil {
-		t.Fatal("chain should not be nil after set")
+	SetFirewall(d)
+	if fw == nil {
+		t.Fatal("firewall should not be nil after set")
 	}
 }
 
@@ -81,6 +78,32 @@ func TestMapPorts(t *testing.T) {
 	}
 }
 
+func TestMapPortRange(t *testing.T) {
+	defer portallocator.ReleaseAll()
+
+	hostIP := net.ParseIP("192.168.0.1")
+	container := &net.UDPAddr{IP: net.ParseIP("172.16.0.1"), Port: 8000}
+
+	host, err := MapRange(container, hostIP, 9000, 101)
+	if err != nil {
+		t.Fatalf("Failed to map the range: %s", err)
+	}
+	if expected := "192.168.0.1:9000"; host.String() != expected {
+		t.Fatalf("Expected the range to be mapped from %s, got %s", expected, host)
+	}
+
+	if _, err := Map(&net.UDPAddr{IP: net.ParseIP("172.16.0.2"), Port: 53}, hostIP, 9100); err == nil {
+		t.Fatal("The last port of the range is in use - mapping should have failed")
+	}
+
+	if err := Unmap(host); err != nil {
+		t.Fatalf("Failed to unmap the range: %s", err)
+	}
+	if _, err := Map(&net.UDPAddr{IP: net.ParseIP("172.16.0.2"), Port: 53}, hostIP, 9100); err != nil {
+		t.Fatalf("Failed to map a port of the released range: %s", err)
+	}
+}
+
 func TestGetUDPKey(t *testing.T) {
 	addr := &net.UDPAddr{IP: net.ParseIP("192.168.1.5"), Port: 53}
 
@@ -20,7 +20,8 @@ func (daemon *Daemon) ContainerRename(job *engine.Job) engine.Status {
 
 	container.Lock()
 	defer container.Unlock()
-	if _, err := daemon.reserveName(container.ID, newName); err != nil {
+	newName, err := daemon.reserveName(container.ID, newName)
+	if err != nil {
 		return job.Errorf("Error when allocating new name: %s", err)
 	}
 
@@ -29,6 +30,15 @@ func (daemon *Daemon) ContainerRename(job *engine.Job) engine.Status {
 	if err := daemon.containerGraph.Delete(oldName); err != nil {
 		return job.Errorf("Failed to delete container %q: %v", oldName, err)
 	}
+	if err := container.toDisk(); err != nil {
+		return job.Error(err)
+	}
+
+	// the links to the container in /etc/hosts of its parents carry its name
+	if container.Running {
+		daemon.updateParentsHosts(container)
+	}
+	container.LogEvent("rename")
 
 	return engine.StatusOK
 }
@@ -35,6 +35,9 @@ func (daemon *Daemon) ContainerStart(job *engine.Job) engine.Status {
 	// creating a container, not during start.
 	if len(job.Environ()) > 0 {
 		hostConfig := runconfig.ContainerHostConfigFromJob(job)
+		if hostConfig.NetworkMode.IsUserDefined() && hostConfig.PublishesPorts() {
+			return job.Error(runconfig.ErrConflictNetworkAndPublish)
+		}
 		if err := daemon.setHostConfig(container, hostConfig); err != nil {
 			return job.Error(err)
 		}
@@ -53,6 +56,16 @@ func (daemon *Daemon) setHostConfig(container *Container, hostConfig *runconfig.
 	if err := parseSecurityOpt(container, hostConfig); err != nil {
 		return err
 	}
+	// the defaults of the daemon are shown by inspect
+	if err := hostConfig.RestartPolicy.ValidateBackoff(); err != nil {
+		return err
+	}
+	if hostConfig.RestartPolicy.Name == "" {
+		// it was validated when the daemon started
+		policy, _ := runconfig.ParseRestartPolicy(daemon.config.DefaultRestartPolicy)
+		hostConfig.RestartPolicy.Name, hostConfig.RestartPolicy.MaximumRetryCount = policy.Name, policy.MaximumRetryCount
+	}
+	hostConfig.RestartPolicy = daemon.restartBackoff(hostConfig.RestartPolicy)
 
 	// FIXME: this should be handled by the volume subsystem
 	// Validate the HostConfig binds. Make sure that:
@@ -21,6 +21,12 @@ type State struct {
 	StartedAt  time.Time
 	FinishedAt time.Time
 	waitChan   chan struct{}
+	// unpauseChan is made by the first waiter while paused
+	unpauseChan chan struct{}
+
+	// set when the container is stopped with docker stop or kill, until it
+	// is started again
+	StoppedByUser bool
 }
 
 func NewState() *State {
@@ -112,6 +118,22 @@ func (s *State) WaitStop(timeout time.Duration) (int, error) {
 	return s.GetExitCode(), nil
 }
 
+// WaitUnpaused waits until state is not paused. If state is not paused it
+// returns immediately. If you want wait forever you must supply negative timeout.
+func (s *State) WaitUnpaused(timeout time.Duration) error {
+	s.Lock()
+	if !s.Paused {
+		s.Unlock()
+		return nil
+	}
+	if s.unpauseChan == nil {
+		s.unpauseChan = make(chan struct{})
+	}
+	unpauseChan := s.unpauseChan
+	s.Unlock()
+	return wait(unpauseChan, timeout)
+}
+
 func (s *State) IsRunning() bool {
 	s.Lock()
 	res := s.Running
@@ -142,7 +164,7 @@ func (s *State) SetRunning(pid int) {
 func (s *State) setRunning(pid int) {
 	s.Error = ""
 	s.Running = true
-	s.Paused = false
+	s.setUnpaused()
 	s.Restarting = false
 	s.ExitCode = 0
 	s.Pid = pid
@@ -207,10 +229,18 @@ func (s *State) SetPaused() {
 
 func (s *State) SetUnpaused() {
 	s.Lock()
-	s.Paused = false
+	s.setUnpaused()
 	s.Unlock()
 }
 
+func (s *State) setUnpaused() {
+	s.Paused = false
+	if s.unpauseChan != nil {
+		close(s.unpauseChan) // fire waiters for unpause
+		s.unpauseChan = nil
+	}
+}
+
 func (s *State) IsPaused() bool {
 	s.Lock()
 	res := s.Paused
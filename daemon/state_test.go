@@ -102,3 +102,41 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateWaitUnpaused(t *testing.T) {
+	s := NewState()
+	if err := s.WaitUnpaused(0); err != nil {
+		t.Fatalf("WaitUnpaused on a state not paused returned %v", err)
+	}
+
+	s.SetPaused()
+	if err := s.WaitUnpaused(10 * time.Millisecond); err == nil {
+		t.Fatal("WaitUnpaused on a paused state should time out")
+	}
+
+	unpaused := make(chan error)
+	go func() {
+		unpaused <- s.WaitUnpaused(-1 * time.Second)
+	}()
+	select {
+	case <-unpaused:
+		t.Fatal("WaitUnpaused returned before the state is unpaused")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.SetUnpaused()
+	select {
+	case err := <-unpaused:
+		if err != nil {
+			t.Fatalf("WaitUnpaused returned %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Unpause callback doesn't fire in 100 milliseconds")
+	}
+
+	// pausing again waits for the next unpause
+	s.SetPaused()
+	if err := s.WaitUnpaused(10 * time.Millisecond); err == nil {
+		t.Fatal("WaitUnpaused on a paused again state should time out")
+	}
+	s.SetUnpaused()
+}
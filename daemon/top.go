@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -49,7 +51,10 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 			return job.Errorf("Couldn't find PID field in ps output")
 		}
 
+		execIDs := execIDsByPid(pids, container.getExecPids())
+
 		processes := [][]string{}
+		processExecIDs := []string{}
 		for _, line := range lines[1:] {
 			if len(line) == 0 {
 				continue
@@ -67,13 +72,58 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) engine.Status {
 					process := fields[:len(header)-1]
 					process = append(process, strings.Join(fields[len(header)-1:], " "))
 					processes = append(processes, process)
+					processExecIDs = append(processExecIDs, execIDs[p])
 				}
 			}
 		}
 		out.SetJson("Processes", processes)
+		out.SetList("ExecIDs", processExecIDs)
 		out.WriteTo(job.Stdout)
 		return engine.StatusOK
 
 	}
 	return job.Errorf("No such container: %s", name)
 }
+
+// execIDsByPid returns the ID of the exec each of pids belongs to, being the
+// process of the exec or one of its descendants. execPids are the pids of the
+// processes of the execs.
+func execIDsByPid(pids []int, execPids map[int]string) map[int]string {
+	execIDs := make(map[int]string, len(pids))
+	if len(execPids) == 0 {
+		return execIDs
+	}
+	parents := make(map[int]int, len(pids))
+	for _, pid := range pids {
+		if ppid, err := getParentPid(pid); err == nil {
+			parents[pid] = ppid
+		}
+	}
+	for _, pid := range pids {
+		// walk up to the first process which is the one of an exec, within
+		// the processes of the container
+		for p, ok := pid, true; ok; p, ok = parents[p] {
+			if id, isExec := execPids[p]; isExec {
+				execIDs[pid] = id
+				break
+			}
+		}
+	}
+	return execIDs
+}
+
+// getParentPid reads the parent pid of pid in /proc/<pid>/stat
+func getParentPid(pid int) (int, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// the command name is between parentheses and may hold any character,
+	// the state and the parent pid follow it
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("Unexpected format of /proc/%d/stat", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExecIDsByPid(t *testing.T) {
+	pid, ppid := os.Getpid(), os.Getppid()
+
+	execIDs := execIDsByPid([]int{pid, ppid}, map[int]string{ppid: "exec"})
+	if execIDs[ppid] != "exec" {
+		t.Fatalf("Expected the process of the exec to belong to it, got %q", execIDs[ppid])
+	}
+	if execIDs[pid] != "exec" {
+		t.Fatalf("Expected the child of the exec to belong to it, got %q", execIDs[pid])
+	}
+
+	execIDs = execIDsByPid([]int{pid, ppid}, map[int]string{pid: "exec"})
+	if execIDs[ppid] != "" {
+		t.Fatalf("Expected the parent of the exec not to belong to it, got %q", execIDs[ppid])
+	}
+}
+
+func TestGetParentPid(t *testing.T) {
+	ppid, err := getParentPid(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ppid != os.Getppid() {
+		t.Fatalf("Expected parent pid %d, got %d", os.Getppid(), ppid)
+	}
+}
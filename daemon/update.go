@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/runconfig"
+)
+
+// ContainerUpdate replaces the restart policy of the container NAME with the
+// RestartPolicy of the job, its delays left to 0 set to the defaults of the
+// daemon. A running container gets it from its next exit on.
+func (daemon *Daemon) ContainerUpdate(job *engine.Job) engine.Status {
+	if len(job.Args) != 1 {
+		return job.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container := daemon.Get(name)
+	if container == nil {
+		return job.Errorf("No such container: %s", name)
+	}
+	if !job.EnvExists("RestartPolicy") {
+		return job.Errorf("Nothing to update in container %s", name)
+	}
+
+	var policy runconfig.RestartPolicy
+	if err := job.GetenvJson("RestartPolicy", &policy); err != nil {
+		return job.Error(err)
+	}
+	if err := policy.Validate(); err != nil {
+		return job.Error(err)
+	}
+	policy = daemon.restartBackoff(policy)
+
+	container.Lock()
+	defer container.Unlock()
+	container.hostConfig.RestartPolicy = policy
+	if container.monitor != nil {
+		container.monitor.setRestartPolicy(policy)
+	}
+	if err := container.WriteHostConfig(); err != nil {
+		return job.Error(err)
+	}
+	container.LogEvent("update")
+	return engine.StatusOK
+}
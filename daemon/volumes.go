@@ -337,6 +337,21 @@ func (container *Container) setupMounts() error {
 		})
 	}
 
+	// Mount user specified tmpfs directories on top of everything else
+	var tmpfsPaths []string
+	for path := range container.hostConfig.Tmpfs {
+		tmpfsPaths = append(tmpfsPaths, path)
+	}
+	sort.Strings(tmpfsPaths)
+	for _, path := range tmpfsPaths {
+		mounts = append(mounts, execdriver.Mount{
+			Destination: path,
+			Writable:    true,
+			Device:      "tmpfs",
+			Data:        container.hostConfig.Tmpfs[path],
+		})
+	}
+
 	container.command.Mounts = mounts
 	return nil
 }
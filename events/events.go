@@ -2,6 +2,7 @@ package events
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 
@@ -116,7 +117,14 @@ func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.
 		return true
 	}
 
-	if isFiltered(event.Status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) || isFiltered(event.ID, eventFilters["container"]) {
+	// exec and policy_restart events are followed by details, as in
+	// "exec_start: ls -l"
+	status := event.Status
+	if i := strings.Index(status, ": "); i >= 0 {
+		status = status[:i]
+	}
+
+	if isFiltered(status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) || isFiltered(event.ID, eventFilters["container"]) {
 		return nil
 	}
 
@@ -152,3 +152,39 @@ func TestEventsCountJob(t *testing.T) {
 		t.Fatalf("There must be 2 subscribers, got %d", count)
 	}
 }
+
+func TestEventsFilterExecEvents(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range []string{"exec_create: ls -l", "exec_start: ls -l", "exec_die: ls -l (exit code 0)", "die"} {
+		if err := eng.Job("log", action, "cont", "image").Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix())
+	job.Setenv("filters", `{"event":["exec_die"]}`)
+	buf := bytes.NewBuffer(nil)
+	job.Stdout.Add(buf)
+	if err := job.Run(); err != nil {
+		t.Fatal(err)
+	}
+	var jm utils.JSONMessage
+	dec := json.NewDecoder(buf)
+	if err := dec.Decode(&jm); err != nil {
+		t.Fatal(err)
+	}
+	if jm.Status != "exec_die: ls -l (exit code 0)" {
+		t.Fatalf("Expected the exec_die event, got %s", jm.Status)
+	}
+	if err := dec.Decode(&jm); err != io.EOF {
+		t.Fatalf("Expected only the exec_die event, got %s", jm.Status)
+	}
+}
@@ -22,6 +22,24 @@ import (
 	"github.com/docker/docker/utils"
 )
 
+// MaxLayerSize and MaxLayerEntries bound the content bytes and the number of
+// entries of the untrusted layers, pulled from a registry or loaded from an
+// archive, so that a layer expanding far beyond its download can't exhaust
+// the disk or the inodes of the host
+var (
+	MaxLayerSize    int64 = 64 << 30
+	MaxLayerEntries int64 = 4 << 20
+)
+
+// untrustedLayerOptions returns the options untrusted layers are registered
+// with
+func untrustedLayerOptions() *archive.TarOptions {
+	return &archive.TarOptions{
+		MaxSize:    MaxLayerSize,
+		MaxEntries: MaxLayerEntries,
+	}
+}
+
 // A Graph is a store for versioned filesystem images and the relationship between them.
 type Graph struct {
 	Root    string
@@ -132,14 +150,15 @@ func (graph *Graph) Create(layerData archive.ArchiveReader, containerID, contain
 		img.ContainerConfig = *containerConfig
 	}
 
-	if err := graph.Register(img, layerData); err != nil {
+	if err := graph.Register(img, layerData, nil); err != nil {
 		return nil, err
 	}
 	return img, nil
 }
 
-// Register imports a pre-existing image into the graph.
-func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader) (err error) {
+// Register imports a pre-existing image into the graph. options, which may
+// be nil, bound the extraction of the layer, see MaxLayerSize.
+func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader, options *archive.TarOptions) (err error) {
 	defer func() {
 		// If any error occurs, remove the new dir from the driver.
 		// Don't check for errors since the dir might not have been created.
@@ -181,7 +200,7 @@ func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader)
 	}
 	// Apply the diff/layer
 	img.SetGraph(graph)
-	if err := image.StoreImage(img, layerData, tmp); err != nil {
+	if err := image.StoreImage(img, layerData, tmp, options); err != nil {
 		return err
 	}
 	// Commit
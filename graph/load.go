@@ -111,7 +111,7 @@ func (s *TagStore) recursiveLoad(eng *engine.Engine, address, tmpImageDir string
 				}
 			}
 		}
-		if err := s.graph.Register(img, layer); err != nil {
+		if err := s.graph.Register(img, layer, untrustedLayerOptions()); err != nil {
 			return err
 		}
 	}
@@ -31,7 +31,7 @@ func TestManifestTarsumCache(t *testing.T) {
 		t.Fatal(err)
 	}
 	img := &image.Image{ID: testManifestImageID}
-	if err := store.graph.Register(img, archive); err != nil {
+	if err := store.graph.Register(img, archive, nil); err != nil {
 		t.Fatal(err)
 	}
 	if err := store.Set(testManifestImageName, testManifestTag, testManifestImageID, false); err != nil {
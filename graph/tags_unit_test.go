@@ -66,7 +66,7 @@ func mkTestTagStore(root string, t *testing.T) *TagStore {
 		t.Fatal(err)
 	}
 	img := &image.Image{ID: testOfficialImageID}
-	if err := graph.Register(img, officialArchive); err != nil {
+	if err := graph.Register(img, officialArchive, nil); err != nil {
 		t.Fatal(err)
 	}
 	if err := store.Set(testOfficialImageName, "", testOfficialImageID, false); err != nil {
@@ -77,7 +77,7 @@ func mkTestTagStore(root string, t *testing.T) *TagStore {
 		t.Fatal(err)
 	}
 	img = &image.Image{ID: testPrivateImageID}
-	if err := graph.Register(img, privateArchive); err != nil {
+	if err := graph.Register(img, privateArchive, nil); err != nil {
 		t.Fatal(err)
 	}
 	if err := store.Set(testPrivateImageName, "", testPrivateImageID, false); err != nil {
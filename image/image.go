@@ -78,11 +78,12 @@ func LoadImage(root string) (*Image, error) {
 
 // StoreImage stores file system layer data for the given image to the
 // image's registered storage driver. Image metadata is stored in a file
-// at the specified root directory.
-func StoreImage(img *Image, layerData archive.ArchiveReader, root string) (err error) {
+// at the specified root directory. options, which may be nil, are passed
+// to the driver to bound the extraction of the layer.
+func StoreImage(img *Image, layerData archive.ArchiveReader, root string, options *archive.TarOptions) (err error) {
 	// Store the layer. If layerData is not nil, unpack it into the new layer
 	if layerData != nil {
-		if img.Size, err = img.graph.Driver().ApplyDiff(img.ID, img.Parent, layerData); err != nil {
+		if img.Size, err = img.graph.Driver().ApplyDiff(img.ID, img.Parent, layerData, options); err != nil {
 			return err
 		}
 	}
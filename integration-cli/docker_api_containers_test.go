@@ -49,8 +49,6 @@ func TestContainerApiGetAll(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("container REST API - check GET json/all=1")
 }
 
 func TestContainerApiGetExport(t *testing.T) {
@@ -85,8 +83,6 @@ func TestContainerApiGetExport(t *testing.T) {
 		t.Fatalf("The created test file has not been found in the exported image")
 	}
 	deleteAllContainers()
-
-	logDone("container REST API - check GET containers/export")
 }
 
 func TestContainerApiGetChanges(t *testing.T) {
@@ -122,11 +118,10 @@ func TestContainerApiGetChanges(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("container REST API - check GET containers/changes")
 }
 
 func TestContainerApiStartVolumeBinds(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	defer deleteAllContainers()
 	name := "testing"
 	config := map[string]interface{}{
@@ -158,8 +153,6 @@ func TestContainerApiStartVolumeBinds(t *testing.T) {
 	if pth != bindPath {
 		t.Fatalf("expected volume host path to be %s, got %s", bindPath, pth)
 	}
-
-	logDone("container REST API - check volume binds on start")
 }
 
 func TestContainerApiStartVolumesFrom(t *testing.T) {
@@ -200,13 +193,12 @@ func TestContainerApiStartVolumesFrom(t *testing.T) {
 	if pth != pth2 {
 		t.Fatalf("expected volume host path to be %s, got %s", pth, pth2)
 	}
-
-	logDone("container REST API - check VolumesFrom on start")
 }
 
 // Ensure that volumes-from has priority over binds/anything else
 // This is pretty much the same as TestRunApplyVolumesFromBeforeVolumes, except with passing the VolumesFrom and the bind on start
 func TestVolumesFromHasPriority(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	defer deleteAllContainers()
 	volName := "voltst"
 	volPath := "/tmp"
@@ -250,8 +242,20 @@ func TestVolumesFromHasPriority(t *testing.T) {
 	if pth != pth2 {
 		t.Fatalf("expected volume host path to be %s, got %s", pth, pth2)
 	}
+}
 
-	logDone("container REST API - check VolumesFrom has priority")
+func TestContainerApiCreateInvalidStopSettings(t *testing.T) {
+	defer deleteAllContainers()
+
+	for _, config := range []map[string]interface{}{
+		{"Image": "busybox", "StopSignal": "SIGFOO"},
+		{"Image": "busybox", "StopTimeout": -1},
+	} {
+		out, err := sockRequest("POST", "/containers/create", config)
+		if err == nil || !strings.Contains(string(out), "Invalid") {
+			t.Fatalf("Expected the creation of a container with %v to fail, got %s (%v)", config, out, err)
+		}
+	}
 }
 
 func TestGetContainerStats(t *testing.T) {
@@ -297,7 +301,6 @@ func TestGetContainerStats(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	logDone("container REST API - check GET containers/stats")
 }
 
 func TestBuildApiDockerfilePath(t *testing.T) {
@@ -329,8 +332,6 @@ func TestBuildApiDockerfilePath(t *testing.T) {
 	if !strings.Contains(string(out), "must be within the build context") {
 		t.Fatalf("Didn't complain about leaving build context: %s", out)
 	}
-
-	logDone("container REST API - check build w/bad Dockerfile path")
 }
 
 func TestBuildApiDockerfileSymlink(t *testing.T) {
@@ -363,6 +364,4 @@ func TestBuildApiDockerfileSymlink(t *testing.T) {
 	if !strings.Contains(string(out), "Cannot locate specified Dockerfile: Dockerfile") {
 		t.Fatalf("Didn't complain about leaving build context: %s", out)
 	}
-
-	logDone("container REST API - check build w/bad Dockerfile symlink path")
 }
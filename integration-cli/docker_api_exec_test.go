@@ -2,11 +2,27 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
 	"testing"
 )
 
+// createExec creates an exec in container and returns its ID
+func createExec(container string, config map[string]interface{}) (string, error) {
+	body, err := sockRequest("POST", fmt.Sprintf("/containers/%s/exec", container), config)
+	// the exec is created with 201 Created
+	if err != nil && !strings.Contains(err.Error(), "201 Created") {
+		return "", fmt.Errorf("exec create failed: %s %v", body, err)
+	}
+	var created struct{ Id string }
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", err
+	}
+	return created.Id, nil
+}
+
 // Regression test for #9414
 func TestExecApiCreateNoCmd(t *testing.T) {
 	defer deleteAllContainers()
@@ -20,6 +36,72 @@ func TestExecApiCreateNoCmd(t *testing.T) {
 	if err == nil || !bytes.Contains(body, []byte("No exec command specified")) {
 		t.Fatalf("Expected error when creating exec command with no Cmd specified: %q", err)
 	}
+}
+
+func TestExecApiResizeBeforeStart(t *testing.T) {
+	defer deleteAllContainers()
+	name := "exec_resize_test"
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	id, err := createExec(name, map[string]interface{}{"Cmd": []string{"true"}, "Tty": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body, err := sockRequest("POST", "/exec/"+id+"/resize?h=40&w=100", nil); err != nil {
+		t.Fatalf("resize of an exec not started yet failed: %s %v", body, err)
+	}
+}
+
+func TestExecApiDelete(t *testing.T) {
+	defer deleteAllContainers()
+	name := "exec_delete_test"
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	id, err := createExec(name, map[string]interface{}{"Cmd": []string{"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the exec is deleted with 204 No Content
+	if body, err := sockRequest("DELETE", "/exec/"+id, nil); err != nil && !strings.Contains(err.Error(), "204 No Content") {
+		t.Fatalf("exec delete failed: %s %v", body, err)
+	}
 
-	logDone("exec create API - returns error when missing Cmd")
+	if body, err := sockRequest("GET", "/exec/"+id+"/json", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Expected deleted exec to be not found: %s %v", body, err)
+	}
+}
+
+func TestExecApiWait(t *testing.T) {
+	defer deleteAllContainers()
+	name := "exec_wait_test"
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "-d", name, "sh", "-c", "sleep 1; exit 3"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	body, err := sockRequest("POST", "/exec/"+id+"/wait", nil)
+	if err != nil {
+		t.Fatalf("exec wait failed: %s %v", body, err)
+	}
+	var result struct{ StatusCode int }
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != 3 {
+		t.Fatalf("Expected exit code 3, got %d", result.StatusCode)
+	}
 }
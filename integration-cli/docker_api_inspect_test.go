@@ -54,6 +54,4 @@ func TestInspectApiContainerResponse(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("container json - check keys in container json response")
 }
@@ -20,8 +20,6 @@ func TestResizeApiResponse(t *testing.T) {
 	if err != nil {
 		t.Fatalf("resize Request failed %v", err)
 	}
-
-	logDone("container resize - when started")
 }
 
 func TestResizeApiResponseWhenContainerNotStarted(t *testing.T) {
@@ -48,6 +46,4 @@ func TestResizeApiResponseWhenContainerNotStarted(t *testing.T) {
 	if !strings.Contains(string(body), "Cannot resize container") && !strings.Contains(string(body), cleanedContainerID) {
 		t.Fatalf("resize should fail with message 'Cannot resize container' but instead received %s", string(body))
 	}
-
-	logDone("container resize - when not started should not resize")
 }
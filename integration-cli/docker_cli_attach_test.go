@@ -84,8 +84,6 @@ func TestAttachMultipleAndRestart(t *testing.T) {
 	case <-time.After(attachWait):
 		t.Fatalf("Attaches did not finish properly")
 	}
-
-	logDone("attach - multiple attach")
 }
 
 func TestAttachTtyWithoutStdin(t *testing.T) {
@@ -131,6 +129,4 @@ func TestAttachTtyWithoutStdin(t *testing.T) {
 	case <-time.After(attachWait):
 		t.Fatal("attach is running but should have failed")
 	}
-
-	logDone("attach - forbid piped stdin to tty enabled container")
 }
@@ -54,8 +54,6 @@ func TestAttachClosedOnContainerStop(t *testing.T) {
 	case <-time.After(attachWait):
 		t.Fatal("timed out without attach returning")
 	}
-
-	logDone("attach - return after container finished")
 }
 
 func TestAttachAfterDetach(t *testing.T) {
@@ -134,6 +132,4 @@ func TestAttachAfterDetach(t *testing.T) {
 	if !strings.Contains(string(bytes[:nBytes]), "/ #") {
 		t.Fatalf("failed to get a new prompt. got %s", string(bytes[:nBytes]))
 	}
-
-	logDone("attach - reconnect after detaching")
 }
@@ -37,8 +37,6 @@ func TestBuildJSONEmptyRun(t *testing.T) {
 	if err != nil {
 		t.Fatal("error when dealing with a RUN statement with empty JSON array")
 	}
-
-	logDone("build - RUN with an empty array should not panic")
 }
 
 func TestBuildEmptyWhitespace(t *testing.T) {
@@ -58,8 +56,6 @@ func TestBuildEmptyWhitespace(t *testing.T) {
 	if err == nil {
 		t.Fatal("no error when dealing with a RUN statement with no content on the same line")
 	}
-
-	logDone("build - statements with whitespace and no content should generate a parse error")
 }
 
 func TestBuildShCmdJSONEntrypoint(t *testing.T) {
@@ -93,8 +89,6 @@ func TestBuildShCmdJSONEntrypoint(t *testing.T) {
 	if strings.TrimSpace(out) != "/bin/sh -c echo test" {
 		t.Fatal("CMD did not contain /bin/sh -c")
 	}
-
-	logDone("build - CMD should always contain /bin/sh -c when specified without JSON")
 }
 
 func TestBuildEnvironmentReplacementUser(t *testing.T) {
@@ -118,8 +112,6 @@ func TestBuildEnvironmentReplacementUser(t *testing.T) {
 	if res != `"foo"` {
 		t.Fatal("User foo from environment not in Config.User on image")
 	}
-
-	logDone("build - user environment replacement")
 }
 
 func TestBuildEnvironmentReplacementVolume(t *testing.T) {
@@ -149,8 +141,6 @@ func TestBuildEnvironmentReplacementVolume(t *testing.T) {
 	if _, ok := volumes["/quux"]; !ok {
 		t.Fatal("Volume /quux from environment not in Config.Volumes on image")
 	}
-
-	logDone("build - volume environment replacement")
 }
 
 func TestBuildEnvironmentReplacementExpose(t *testing.T) {
@@ -180,8 +170,6 @@ func TestBuildEnvironmentReplacementExpose(t *testing.T) {
 	if _, ok := exposedPorts["80/tcp"]; !ok {
 		t.Fatal("Exposed port 80 from environment not in Config.ExposedPorts on image")
 	}
-
-	logDone("build - expose environment replacement")
 }
 
 func TestBuildEnvironmentReplacementWorkdir(t *testing.T) {
@@ -198,8 +186,6 @@ func TestBuildEnvironmentReplacementWorkdir(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - workdir environment replacement")
 }
 
 func TestBuildEnvironmentReplacementAddCopy(t *testing.T) {
@@ -228,8 +214,6 @@ func TestBuildEnvironmentReplacementAddCopy(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - add/copy environment replacement")
 }
 
 func TestBuildEnvironmentReplacementEnv(t *testing.T) {
@@ -274,8 +258,6 @@ func TestBuildEnvironmentReplacementEnv(t *testing.T) {
 	if !found {
 		t.Fatal("Never found the `bar` env variable")
 	}
-
-	logDone("build - env environment replacement")
 }
 
 func TestBuildHandleEscapes(t *testing.T) {
@@ -364,8 +346,6 @@ func TestBuildHandleEscapes(t *testing.T) {
 	if _, ok := result[`\\\\\\${FOO}`]; !ok {
 		t.Fatal(`Could not find volume \\\\\\${FOO} set from env foo in volumes table`)
 	}
-
-	logDone("build - handle escapes")
 }
 
 func TestBuildOnBuildLowercase(t *testing.T) {
@@ -399,8 +379,6 @@ func TestBuildOnBuildLowercase(t *testing.T) {
 	if strings.Contains(out, "ONBUILD ONBUILD") {
 		t.Fatalf("Got an ONBUILD ONBUILD error with no error: got %s", out)
 	}
-
-	logDone("build - handle case-insensitive onbuild statement")
 }
 
 func TestBuildEnvEscapes(t *testing.T) {
@@ -424,8 +402,6 @@ func TestBuildEnvEscapes(t *testing.T) {
 	if strings.TrimSpace(out) != "$" {
 		t.Fatalf("Env TEST was not overwritten with bar when foo was supplied to dockerfile: was %q", strings.TrimSpace(out))
 	}
-
-	logDone("build - env should handle \\$ properly")
 }
 
 func TestBuildEnvOverwrite(t *testing.T) {
@@ -454,8 +430,6 @@ func TestBuildEnvOverwrite(t *testing.T) {
 	if strings.TrimSpace(out) != "bar" {
 		t.Fatalf("Env TEST was not overwritten with bar when foo was supplied to dockerfile: was %q", strings.TrimSpace(out))
 	}
-
-	logDone("build - env should overwrite builder ENV during run")
 }
 
 func TestBuildOnBuildForbiddenMaintainerInSourceImage(t *testing.T) {
@@ -488,7 +462,6 @@ func TestBuildOnBuildForbiddenMaintainerInSourceImage(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden maintainer in source image")
 
 }
 
@@ -522,7 +495,6 @@ func TestBuildOnBuildForbiddenFromInSourceImage(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden from in source image")
 
 }
 
@@ -556,7 +528,6 @@ func TestBuildOnBuildForbiddenChainedInSourceImage(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden chained in source image")
 
 }
 
@@ -593,8 +564,6 @@ ONBUILD RUN ["true"]`,
 	if !regexp.MustCompile(`(?m)^hello world`).MatchString(out) {
 		t.Fatal("did not get echo output from onbuild", out)
 	}
-
-	logDone("build - onbuild with json entrypoint/cmd")
 }
 
 func TestBuildOnBuildEntrypointJSON(t *testing.T) {
@@ -628,8 +597,6 @@ ONBUILD ENTRYPOINT ["echo"]`,
 	if !regexp.MustCompile(`(?m)^hello world`).MatchString(out) {
 		t.Fatal("got malformed output from onbuild", out)
 	}
-
-	logDone("build - onbuild with json entrypoint")
 }
 
 func TestBuildCacheADD(t *testing.T) {
@@ -663,8 +630,6 @@ func TestBuildCacheADD(t *testing.T) {
 	if strings.Contains(out, "Using cache") {
 		t.Fatal("2nd build used cache on ADD, it shouldn't")
 	}
-
-	logDone("build - build two images with remote ADD")
 }
 
 func TestBuildSixtySteps(t *testing.T) {
@@ -682,7 +647,6 @@ func TestBuildSixtySteps(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - build an image with sixty build steps")
 }
 
 func TestBuildAddSingleFileToRoot(t *testing.T) {
@@ -708,7 +672,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add single file to root")
 }
 
 // Issue #3960: "ADD src ." hangs
@@ -737,7 +700,6 @@ ADD test_file .`,
 		t.Fatal("Build with adding to workdir timed out")
 	case <-done:
 	}
-	logDone("build - add single file to workdir")
 }
 
 func TestBuildAddSingleFileToExistDir(t *testing.T) {
@@ -764,7 +726,6 @@ RUN [ $(ls -l /exists/exists_file | awk '{print $3":"$4}') = 'dockerio:dockerio'
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add single file to existing dir")
 }
 
 func TestBuildCopyAddMultipleFiles(t *testing.T) {
@@ -802,7 +763,6 @@ RUN [ $(ls -l /exists/exists_file | awk '{print $3":"$4}') = 'dockerio:dockerio'
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - multiple file copy/add tests")
 }
 
 func TestBuildAddMultipleFilesToFile(t *testing.T) {
@@ -824,8 +784,6 @@ func TestBuildAddMultipleFilesToFile(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple add files to file")
 }
 
 func TestBuildJSONAddMultipleFilesToFile(t *testing.T) {
@@ -847,8 +805,6 @@ func TestBuildJSONAddMultipleFilesToFile(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple add files to file json syntax")
 }
 
 func TestBuildAddMultipleFilesToFileWild(t *testing.T) {
@@ -870,8 +826,6 @@ func TestBuildAddMultipleFilesToFileWild(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple add files to file wild")
 }
 
 func TestBuildJSONAddMultipleFilesToFileWild(t *testing.T) {
@@ -893,8 +847,6 @@ func TestBuildJSONAddMultipleFilesToFileWild(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple add files to file wild json syntax")
 }
 
 func TestBuildCopyMultipleFilesToFile(t *testing.T) {
@@ -916,8 +868,6 @@ func TestBuildCopyMultipleFilesToFile(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple copy files to file")
 }
 
 func TestBuildJSONCopyMultipleFilesToFile(t *testing.T) {
@@ -939,8 +889,6 @@ func TestBuildJSONCopyMultipleFilesToFile(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple copy files to file json syntax")
 }
 
 func TestBuildAddFileWithWhitespace(t *testing.T) {
@@ -977,7 +925,6 @@ RUN [ $(cat "/test dir/test_file6") = 'test6' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add file with whitespace")
 }
 
 func TestBuildCopyFileWithWhitespace(t *testing.T) {
@@ -1014,7 +961,6 @@ RUN [ $(cat "/test dir/test_file6") = 'test6' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy file with whitespace")
 }
 
 func TestBuildAddMultipleFilesToFileWithWhitespace(t *testing.T) {
@@ -1036,8 +982,6 @@ func TestBuildAddMultipleFilesToFileWithWhitespace(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple add files to file with whitespace")
 }
 
 func TestBuildCopyMultipleFilesToFileWithWhitespace(t *testing.T) {
@@ -1059,8 +1003,6 @@ func TestBuildCopyMultipleFilesToFileWithWhitespace(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain %q) got:\n%v", expected, err)
 	}
-
-	logDone("build - multiple copy files to file with whitespace")
 }
 
 func TestBuildCopyWildcard(t *testing.T) {
@@ -1110,8 +1052,6 @@ func TestBuildCopyWildcard(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("didn't use the cache")
 	}
-
-	logDone("build - copy wild card")
 }
 
 func TestBuildCopyWildcardNoFind(t *testing.T) {
@@ -1132,8 +1072,6 @@ func TestBuildCopyWildcardNoFind(t *testing.T) {
 	if !strings.Contains(err.Error(), "No source files were specified") {
 		t.Fatalf("Wrong error %v, must be about no source files", err)
 	}
-
-	logDone("build - copy wild card no find")
 }
 
 func TestBuildCopyWildcardCache(t *testing.T) {
@@ -1167,8 +1105,6 @@ func TestBuildCopyWildcardCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("didn't use the cache")
 	}
-
-	logDone("build - copy wild card cache")
 }
 
 func TestBuildAddSingleFileToNonExistingDir(t *testing.T) {
@@ -1194,8 +1130,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - add single file to non-existing dir")
 }
 
 func TestBuildAddDirContentToRoot(t *testing.T) {
@@ -1220,7 +1154,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add directory contents to root")
 }
 
 func TestBuildAddDirContentToExistingDir(t *testing.T) {
@@ -1247,7 +1180,6 @@ RUN [ $(ls -l /exists/test_file | awk '{print $3":"$4}') = 'root:root' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add directory contents to existing dir")
 }
 
 func TestBuildAddWholeDirToRoot(t *testing.T) {
@@ -1275,7 +1207,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add whole directory to root")
 }
 
 // Testing #5941
@@ -1295,7 +1226,6 @@ ADD . /`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add etc directory to root")
 }
 
 // Testing #9401
@@ -1320,7 +1250,6 @@ RUN [ $(ls -l /usr/bin/suidbin | awk '{print $1}') = '-rwsr-xr-x' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add preserves files special bits")
 }
 
 func TestBuildCopySingleFileToRoot(t *testing.T) {
@@ -1346,7 +1275,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy single file to root")
 }
 
 // Issue #3960: "ADD src ." hangs - adapted for COPY
@@ -1375,7 +1303,6 @@ COPY test_file .`,
 		t.Fatal("Build with adding to workdir timed out")
 	case <-done:
 	}
-	logDone("build - copy single file to workdir")
 }
 
 func TestBuildCopySingleFileToExistDir(t *testing.T) {
@@ -1402,7 +1329,6 @@ RUN [ $(ls -l /exists/exists_file | awk '{print $3":"$4}') = 'dockerio:dockerio'
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy single file to existing dir")
 }
 
 func TestBuildCopySingleFileToNonExistDir(t *testing.T) {
@@ -1428,7 +1354,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy single file to non-existing dir")
 }
 
 func TestBuildCopyDirContentToRoot(t *testing.T) {
@@ -1453,7 +1378,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy directory contents to root")
 }
 
 func TestBuildCopyDirContentToExistDir(t *testing.T) {
@@ -1480,7 +1404,6 @@ RUN [ $(ls -l /exists/test_file | awk '{print $3":"$4}') = 'root:root' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy directory contents to existing dir")
 }
 
 func TestBuildCopyWholeDirToRoot(t *testing.T) {
@@ -1508,7 +1431,6 @@ RUN [ $(ls -l /exists | awk '{print $3":"$4}') = 'dockerio:dockerio' ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy whole directory to root")
 }
 
 func TestBuildCopyEtcToRoot(t *testing.T) {
@@ -1527,7 +1449,6 @@ COPY . /`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - copy etc directory to root")
 }
 
 func TestBuildCopyDisallowRemote(t *testing.T) {
@@ -1539,7 +1460,6 @@ COPY https://index.docker.io/robots.txt /`,
 	if err == nil || !strings.Contains(out, "Source can't be a URL for COPY") {
 		t.Fatalf("Error should be about disallowed remote source, got err: %s, out: %q", err, out)
 	}
-	logDone("build - copy - disallow copy from remote")
 }
 
 func TestBuildAddBadLinks(t *testing.T) {
@@ -1613,8 +1533,6 @@ func TestBuildAddBadLinks(t *testing.T) {
 	if _, err := os.Stat(nonExistingFile); err == nil || err != nil && !os.IsNotExist(err) {
 		t.Fatalf("%s shouldn't have been written and it shouldn't exist", nonExistingFile)
 	}
-
-	logDone("build - ADD must add files in container")
 }
 
 func TestBuildAddBadLinksVolume(t *testing.T) {
@@ -1665,8 +1583,6 @@ func TestBuildAddBadLinksVolume(t *testing.T) {
 	if _, err := os.Stat(nonExistingFile); err == nil || err != nil && !os.IsNotExist(err) {
 		t.Fatalf("%s shouldn't have been written and it shouldn't exist", nonExistingFile)
 	}
-
-	logDone("build - ADD should add files in volume")
 }
 
 // Issue #5270 - ensure we throw a better error than "unexpected EOF"
@@ -1796,9 +1712,6 @@ func TestBuildWithInaccessibleFilesInContext(t *testing.T) {
 		}
 
 	}
-	logDone("build - ADD from context with inaccessible files must fail")
-	logDone("build - ADD from context with accessible links must work")
-	logDone("build - ADD from context with ignored inaccessible files must work")
 }
 
 func TestBuildForceRm(t *testing.T) {
@@ -1828,8 +1741,6 @@ func TestBuildForceRm(t *testing.T) {
 	if containerCountBefore != containerCountAfter {
 		t.Fatalf("--force-rm shouldn't have left containers behind")
 	}
-
-	logDone("build - ensure --force-rm doesn't leave containers behind")
 }
 
 func TestBuildRm(t *testing.T) {
@@ -1910,9 +1821,6 @@ func TestBuildRm(t *testing.T) {
 		deleteImages(name)
 
 	}
-
-	logDone("build - ensure --rm doesn't leave containers behind and that --rm=true is the default")
-	logDone("build - ensure --rm=false overrides the default")
 }
 
 func TestBuildWithVolumes(t *testing.T) {
@@ -1959,8 +1867,6 @@ func TestBuildWithVolumes(t *testing.T) {
 	if !equal {
 		t.Fatalf("Volumes %s, expected %s", result, expected)
 	}
-
-	logDone("build - with volumes")
 }
 
 func TestBuildMaintainer(t *testing.T) {
@@ -1981,7 +1887,6 @@ func TestBuildMaintainer(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Maintainer %s, expected %s", res, expected)
 	}
-	logDone("build - maintainer")
 }
 
 func TestBuildUser(t *testing.T) {
@@ -2004,7 +1909,6 @@ func TestBuildUser(t *testing.T) {
 	if res != expected {
 		t.Fatalf("User %s, expected %s", res, expected)
 	}
-	logDone("build - user")
 }
 
 func TestBuildRelativeWorkdir(t *testing.T) {
@@ -2031,7 +1935,6 @@ func TestBuildRelativeWorkdir(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Workdir %s, expected %s", res, expected)
 	}
-	logDone("build - relative workdir")
 }
 
 func TestBuildWorkdirWithEnvVariables(t *testing.T) {
@@ -2055,7 +1958,6 @@ func TestBuildWorkdirWithEnvVariables(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Workdir %s, expected %s", res, expected)
 	}
-	logDone("build - workdir with env variables")
 }
 
 func TestBuildRelativeCopy(t *testing.T) {
@@ -2095,7 +1997,6 @@ func TestBuildRelativeCopy(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - relative copy/add")
 }
 
 func TestBuildEnv(t *testing.T) {
@@ -2118,7 +2019,6 @@ func TestBuildEnv(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Env %s, expected %s", res, expected)
 	}
-	logDone("build - env")
 }
 
 func TestBuildContextCleanup(t *testing.T) {
@@ -2142,8 +2042,6 @@ func TestBuildContextCleanup(t *testing.T) {
 	if err = compareDirectoryEntries(entries, entriesFinal); err != nil {
 		t.Fatalf("context should have been deleted, but wasn't")
 	}
-
-	logDone("build - verify context cleanup works properly")
 }
 
 func TestBuildContextCleanupFailedBuild(t *testing.T) {
@@ -2168,8 +2066,6 @@ func TestBuildContextCleanupFailedBuild(t *testing.T) {
 	if err = compareDirectoryEntries(entries, entriesFinal); err != nil {
 		t.Fatalf("context should have been deleted, but wasn't")
 	}
-
-	logDone("build - verify context cleanup works properly after a failed build")
 }
 
 func TestBuildCmd(t *testing.T) {
@@ -2190,7 +2086,6 @@ func TestBuildCmd(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Cmd %s, expected %s", res, expected)
 	}
-	logDone("build - cmd")
 }
 
 func TestBuildExpose(t *testing.T) {
@@ -2211,7 +2106,6 @@ func TestBuildExpose(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Exposed ports %s, expected %s", res, expected)
 	}
-	logDone("build - expose")
 }
 
 func TestBuildExposeMorePorts(t *testing.T) {
@@ -2267,7 +2161,6 @@ func TestBuildExposeMorePorts(t *testing.T) {
 	if len(exposedPorts) != 0 {
 		t.Errorf("Unexpected extra exposed ports %v", exposedPorts)
 	}
-	logDone("build - expose large number of ports")
 }
 
 func TestBuildExposeOrder(t *testing.T) {
@@ -2290,7 +2183,6 @@ func TestBuildExposeOrder(t *testing.T) {
 	if id1 != id2 {
 		t.Errorf("EXPOSE should invalidate the cache only when ports actually changed")
 	}
-	logDone("build - expose order")
 }
 
 func TestBuildEmptyEntrypointInheritance(t *testing.T) {
@@ -2332,8 +2224,6 @@ func TestBuildEmptyEntrypointInheritance(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Entrypoint %s, expected %s", res, expected)
 	}
-
-	logDone("build - empty entrypoint inheritance")
 }
 
 func TestBuildEmptyEntrypoint(t *testing.T) {
@@ -2355,8 +2245,6 @@ func TestBuildEmptyEntrypoint(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Entrypoint %s, expected %s", res, expected)
 	}
-
-	logDone("build - empty entrypoint")
 }
 
 func TestBuildEntrypoint(t *testing.T) {
@@ -2377,8 +2265,6 @@ func TestBuildEntrypoint(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Entrypoint %s, expected %s", res, expected)
 	}
-
-	logDone("build - entrypoint")
 }
 
 // #6445 ensure ONBUILD triggers aren't committed to grandchildren
@@ -2450,8 +2336,6 @@ func TestBuildOnBuildLimitedInheritence(t *testing.T) {
 	if strings.Contains(out3, "ONBUILD PARENT") {
 		t.Fatalf("ONBUILD instruction ran in grandchild of ONBUILD parent")
 	}
-
-	logDone("build - onbuild")
 }
 
 func TestBuildWithCache(t *testing.T) {
@@ -2478,7 +2362,6 @@ func TestBuildWithCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - with cache")
 }
 
 func TestBuildWithoutCache(t *testing.T) {
@@ -2507,7 +2390,6 @@ func TestBuildWithoutCache(t *testing.T) {
 	if id1 == id2 {
 		t.Fatal("The cache should have been invalided but hasn't.")
 	}
-	logDone("build - without cache")
 }
 
 func TestBuildConditionalCache(t *testing.T) {
@@ -2546,8 +2428,6 @@ func TestBuildConditionalCache(t *testing.T) {
 	if id3 != id2 {
 		t.Fatal("Should have used the cache")
 	}
-
-	logDone("build - conditional cache")
 }
 
 func TestBuildADDLocalFileWithCache(t *testing.T) {
@@ -2577,7 +2457,6 @@ func TestBuildADDLocalFileWithCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - add local file with cache")
 }
 
 func TestBuildADDMultipleLocalFileWithCache(t *testing.T) {
@@ -2607,7 +2486,6 @@ func TestBuildADDMultipleLocalFileWithCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - add multiple local files with cache")
 }
 
 func TestBuildADDLocalFileWithoutCache(t *testing.T) {
@@ -2637,7 +2515,6 @@ func TestBuildADDLocalFileWithoutCache(t *testing.T) {
 	if id1 == id2 {
 		t.Fatal("The cache should have been invalided but hasn't.")
 	}
-	logDone("build - add local file without cache")
 }
 
 func TestBuildCopyDirButNotFile(t *testing.T) {
@@ -2669,7 +2546,6 @@ func TestBuildCopyDirButNotFile(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but wasn't")
 	}
-	logDone("build - add current directory but not file")
 }
 
 func TestBuildADDCurrentDirWithCache(t *testing.T) {
@@ -2735,7 +2611,6 @@ func TestBuildADDCurrentDirWithCache(t *testing.T) {
 	if id4 != id5 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - add current directory with cache")
 }
 
 func TestBuildADDCurrentDirWithoutCache(t *testing.T) {
@@ -2764,7 +2639,6 @@ func TestBuildADDCurrentDirWithoutCache(t *testing.T) {
 	if id1 == id2 {
 		t.Fatal("The cache should have been invalided but hasn't.")
 	}
-	logDone("build - add current directory without cache")
 }
 
 func TestBuildADDRemoteFileWithCache(t *testing.T) {
@@ -2796,7 +2670,6 @@ func TestBuildADDRemoteFileWithCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - add remote file with cache")
 }
 
 func TestBuildADDRemoteFileWithoutCache(t *testing.T) {
@@ -2829,7 +2702,6 @@ func TestBuildADDRemoteFileWithoutCache(t *testing.T) {
 	if id1 == id2 {
 		t.Fatal("The cache should have been invalided but hasn't.")
 	}
-	logDone("build - add remote file without cache")
 }
 
 func TestBuildADDRemoteFileMTime(t *testing.T) {
@@ -2891,7 +2763,6 @@ func TestBuildADDRemoteFileMTime(t *testing.T) {
 	if id3 != id4 {
 		t.Fatal("The cache should have been used but wasn't - #2")
 	}
-	logDone("build - add remote file testing mtime")
 }
 
 func TestBuildADDLocalAndRemoteFilesWithCache(t *testing.T) {
@@ -2926,7 +2797,6 @@ func TestBuildADDLocalAndRemoteFilesWithCache(t *testing.T) {
 	if id1 != id2 {
 		t.Fatal("The cache should have been used but hasn't.")
 	}
-	logDone("build - add local and remote file with cache")
 }
 
 func testContextTar(t *testing.T, compression archive.Compression) {
@@ -2954,7 +2824,6 @@ CMD ["cat", "/foo"]`,
 	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
 		t.Fatalf("build failed to complete: %v %v", out, err)
 	}
-	logDone(fmt.Sprintf("build - build an image with a context tar, compression: %v", compression))
 }
 
 func TestBuildContextTarGzip(t *testing.T) {
@@ -2978,7 +2847,6 @@ func TestBuildNoContext(t *testing.T) {
 	}
 
 	deleteImages("nocontext")
-	logDone("build - build an image with no context")
 }
 
 // TODO: TestCaching
@@ -3015,7 +2883,6 @@ func TestBuildADDLocalAndRemoteFilesWithoutCache(t *testing.T) {
 	if id1 == id2 {
 		t.Fatal("The cache should have been invalided but hasn't.")
 	}
-	logDone("build - add local and remote file without cache")
 }
 
 func TestBuildWithVolumeOwnership(t *testing.T) {
@@ -3045,8 +2912,6 @@ func TestBuildWithVolumeOwnership(t *testing.T) {
 	if expected := "daemon   daemon"; !strings.Contains(out, expected) {
 		t.Fatalf("expected %s received %s", expected, out)
 	}
-
-	logDone("build - volume ownership")
 }
 
 // testing #1405 - config.Cmd does not get cleaned up if
@@ -3083,7 +2948,6 @@ func TestBuildEntrypointRunCleanup(t *testing.T) {
 	if expected := "<no value>"; res != expected {
 		t.Fatalf("Cmd %s, expected %s", res, expected)
 	}
-	logDone("build - cleanup cmd after RUN")
 }
 
 func TestBuildForbiddenContextPath(t *testing.T) {
@@ -3105,8 +2969,6 @@ func TestBuildForbiddenContextPath(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err == nil || !strings.Contains(err.Error(), expected) {
 		t.Fatalf("Wrong error: (should contain \"%s\") got:\n%v", expected, err)
 	}
-
-	logDone("build - forbidden context path")
 }
 
 func TestBuildADDFileNotFound(t *testing.T) {
@@ -3126,7 +2988,6 @@ func TestBuildADDFileNotFound(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - add file not found")
 }
 
 func TestBuildInheritance(t *testing.T) {
@@ -3167,7 +3028,6 @@ func TestBuildInheritance(t *testing.T) {
 	if ports1 != ports2 {
 		t.Fatalf("Ports must be same: %s != %s", ports1, ports2)
 	}
-	logDone("build - inheritance")
 }
 
 func TestBuildFails(t *testing.T) {
@@ -3185,7 +3045,6 @@ func TestBuildFails(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - fails")
 }
 
 func TestBuildFailsDockerfileEmpty(t *testing.T) {
@@ -3199,7 +3058,6 @@ func TestBuildFailsDockerfileEmpty(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - fails with empty dockerfile")
 }
 
 func TestBuildOnBuild(t *testing.T) {
@@ -3219,7 +3077,6 @@ func TestBuildOnBuild(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - onbuild")
 }
 
 func TestBuildOnBuildForbiddenChained(t *testing.T) {
@@ -3236,7 +3093,6 @@ func TestBuildOnBuildForbiddenChained(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden chained")
 }
 
 func TestBuildOnBuildForbiddenFrom(t *testing.T) {
@@ -3253,7 +3109,6 @@ func TestBuildOnBuildForbiddenFrom(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden from")
 }
 
 func TestBuildOnBuildForbiddenMaintainer(t *testing.T) {
@@ -3270,7 +3125,6 @@ func TestBuildOnBuildForbiddenMaintainer(t *testing.T) {
 	} else {
 		t.Fatal("Error must not be nil")
 	}
-	logDone("build - onbuild forbidden maintainer")
 }
 
 // gh #2446
@@ -3293,7 +3147,6 @@ func TestBuildAddToSymlinkDest(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add to symlink destination")
 }
 
 func TestBuildEscapeWhitespace(t *testing.T) {
@@ -3316,8 +3169,6 @@ docker.com>"
 	if res != "\"Docker IO <io@docker.com>\"" {
 		t.Fatalf("Parsed string did not match the escaped string. Got: %q", res)
 	}
-
-	logDone("build - validate escaping whitespace")
 }
 
 func TestBuildVerifyIntString(t *testing.T) {
@@ -3338,8 +3189,6 @@ func TestBuildVerifyIntString(t *testing.T) {
 	if !strings.Contains(out, "\"123\"") {
 		t.Fatalf("Output does not contain the int as a string:\n%s", out)
 	}
-
-	logDone("build - verify int/strings as strings")
 }
 
 func TestBuildDockerignore(t *testing.T) {
@@ -3370,7 +3219,6 @@ func TestBuildDockerignore(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - test .dockerignore")
 }
 
 func TestBuildDockerignoreCleanPaths(t *testing.T) {
@@ -3393,7 +3241,6 @@ func TestBuildDockerignoreCleanPaths(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - test .dockerignore with clean paths")
 }
 
 func TestBuildDockerignoringDockerfile(t *testing.T) {
@@ -3420,8 +3267,6 @@ func TestBuildDockerignoringDockerfile(t *testing.T) {
 	if _, err = buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("Didn't ignore ./Dockerfile correctly:%s", err)
 	}
-
-	logDone("build - test .dockerignore of Dockerfile")
 }
 
 func TestBuildDockerignoringRenamedDockerfile(t *testing.T) {
@@ -3450,8 +3295,6 @@ func TestBuildDockerignoringRenamedDockerfile(t *testing.T) {
 	if _, err = buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("Didn't ignore ./MyDockerfile correctly:%s", err)
 	}
-
-	logDone("build - test .dockerignore of renamed Dockerfile")
 }
 
 func TestBuildDockerignoringDockerignore(t *testing.T) {
@@ -3473,7 +3316,6 @@ func TestBuildDockerignoringDockerignore(t *testing.T) {
 	if _, err = buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("Didn't ignore .dockerignore correctly:%s", err)
 	}
-	logDone("build - test .dockerignore of .dockerignore")
 }
 
 func TestBuildDockerignoreTouchDockerfile(t *testing.T) {
@@ -3526,8 +3368,6 @@ func TestBuildDockerignoreTouchDockerfile(t *testing.T) {
 	if id1 != id2 {
 		t.Fatalf("Didn't use the cache - 3")
 	}
-
-	logDone("build - test .dockerignore touch dockerfile")
 }
 
 func TestBuildDockerignoringWholeDir(t *testing.T) {
@@ -3550,7 +3390,6 @@ func TestBuildDockerignoringWholeDir(t *testing.T) {
 	if _, err = buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - test .dockerignore whole dir with .*")
 }
 
 func TestBuildLineBreak(t *testing.T) {
@@ -3567,7 +3406,6 @@ RUN    [ "$(ls -d /var/run/sshd)" = "/var/run/sshd" ]`,
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - line break with \\")
 }
 
 func TestBuildEOLInLine(t *testing.T) {
@@ -3584,7 +3422,6 @@ RUN    [ "$(ls -d /var/run/sshd)" = "/var/run/sshd" ]`,
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - end of line in dockerfile instruction")
 }
 
 func TestBuildCommentsShebangs(t *testing.T) {
@@ -3604,7 +3441,6 @@ RUN [ "$(/hello.sh)" = "hello world" ]`,
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - comments and shebangs")
 }
 
 func TestBuildUsersAndGroups(t *testing.T) {
@@ -3668,7 +3504,6 @@ RUN [ "$(id -u):$(id -g)/$(id -un):$(id -gn)/$(id -G):$(id -Gn)" = '1042:1043/10
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - users and groups")
 }
 
 func TestBuildEnvUsage(t *testing.T) {
@@ -3702,7 +3537,6 @@ RUN    [ "$(cat $TO)" = "hello" ]
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - environment variables usage")
 }
 
 func TestBuildEnvUsage2(t *testing.T) {
@@ -3742,7 +3576,6 @@ RUN    [ "$abc" = "" ]
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - environment variables usage2")
 }
 
 func TestBuildAddScript(t *testing.T) {
@@ -3766,7 +3599,6 @@ RUN [ "$(cat /testfile)" = 'test!' ]`
 	if err != nil {
 		t.Fatal(err)
 	}
-	logDone("build - add and run script")
 }
 
 func TestBuildAddTar(t *testing.T) {
@@ -3821,8 +3653,6 @@ RUN cat /existing-directory-trailing-slash/test/foo | grep Hi`
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("build failed to complete for TestBuildAddTar: %v", err)
 	}
-
-	logDone("build - ADD tar")
 }
 
 func TestBuildAddTarXz(t *testing.T) {
@@ -3873,8 +3703,6 @@ func TestBuildAddTarXz(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("build failed to complete for TestBuildAddTarXz: %v", err)
 	}
-
-	logDone("build - ADD tar.xz")
 }
 
 func TestBuildAddTarXzGz(t *testing.T) {
@@ -3933,8 +3761,6 @@ func TestBuildAddTarXzGz(t *testing.T) {
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatalf("build failed to complete for TestBuildAddTarXz: %v", err)
 	}
-
-	logDone("build - ADD tar.xz.gz")
 }
 
 func TestBuildFromGIT(t *testing.T) {
@@ -3963,7 +3789,6 @@ func TestBuildFromGIT(t *testing.T) {
 	if res != "docker" {
 		t.Fatalf("Maintainer should be docker, got %s", res)
 	}
-	logDone("build - build from GIT")
 }
 
 func TestBuildCleanupCmdOnEntrypoint(t *testing.T) {
@@ -3996,7 +3821,6 @@ func TestBuildCleanupCmdOnEntrypoint(t *testing.T) {
 	if expected := "[cat]"; res != expected {
 		t.Fatalf("Entrypoint %s, expected %s", res, expected)
 	}
-	logDone("build - cleanup cmd on ENTRYPOINT")
 }
 
 func TestBuildClearCmd(t *testing.T) {
@@ -4017,7 +3841,6 @@ func TestBuildClearCmd(t *testing.T) {
 	if res != "[]" {
 		t.Fatalf("Cmd %s, expected %s", res, "[]")
 	}
-	logDone("build - clearcmd")
 }
 
 func TestBuildEmptyCmd(t *testing.T) {
@@ -4033,7 +3856,6 @@ func TestBuildEmptyCmd(t *testing.T) {
 	if res != "null" {
 		t.Fatalf("Cmd %s, expected %s", res, "null")
 	}
-	logDone("build - empty cmd")
 }
 
 func TestBuildOnBuildOutput(t *testing.T) {
@@ -4054,8 +3876,6 @@ func TestBuildOnBuildOutput(t *testing.T) {
 	if !strings.Contains(out, "Trigger 0, RUN echo foo") {
 		t.Fatal("failed to find the ONBUILD output", out)
 	}
-
-	logDone("build - onbuild output")
 }
 
 func TestBuildInvalidTag(t *testing.T) {
@@ -4067,7 +3887,6 @@ func TestBuildInvalidTag(t *testing.T) {
 	if !strings.Contains(out, "Illegal tag name") || strings.Contains(out, "Sending build context to Docker daemon") {
 		t.Fatalf("failed to stop before building. Error: %s, Output: %s", err, out)
 	}
-	logDone("build - invalid tag")
 }
 
 func TestBuildCmdShDashC(t *testing.T) {
@@ -4087,8 +3906,6 @@ func TestBuildCmdShDashC(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Expected value %s not in Config.Cmd: %s", expected, res)
 	}
-
-	logDone("build - cmd should have sh -c for non-json")
 }
 
 func TestBuildCmdJSONNoShDashC(t *testing.T) {
@@ -4108,8 +3925,6 @@ func TestBuildCmdJSONNoShDashC(t *testing.T) {
 	if res != expected {
 		t.Fatalf("Expected value %s not in Config.Cmd: %s", expected, res)
 	}
-
-	logDone("build - cmd should not have /bin/sh -c for json")
 }
 
 func TestBuildIgnoreInvalidInstruction(t *testing.T) {
@@ -4120,8 +3935,6 @@ func TestBuildIgnoreInvalidInstruction(t *testing.T) {
 	if err != nil {
 		t.Fatal(err, out)
 	}
-
-	logDone("build - ignore invalid Dockerfile instruction")
 }
 
 func TestBuildEntrypointInheritance(t *testing.T) {
@@ -4153,8 +3966,6 @@ func TestBuildEntrypointInheritance(t *testing.T) {
 	if status != 5 {
 		t.Fatalf("expected exit code 5 but received %d", status)
 	}
-
-	logDone("build - clear entrypoint")
 }
 
 func TestBuildEntrypointInheritanceInspect(t *testing.T) {
@@ -4194,8 +4005,6 @@ func TestBuildEntrypointInheritanceInspect(t *testing.T) {
 	if strings.TrimSpace(out) != expected {
 		t.Fatalf("Expected output is %s, got %s", expected, out)
 	}
-
-	logDone("build - entrypoint override inheritance properly")
 }
 
 func TestBuildRunShEntrypoint(t *testing.T) {
@@ -4214,8 +4023,6 @@ func TestBuildRunShEntrypoint(t *testing.T) {
 	if err != nil {
 		t.Fatal(err, out)
 	}
-
-	logDone("build - entrypoint with /bin/echo running successfully")
 }
 
 func TestBuildExoticShellInterpolation(t *testing.T) {
@@ -4244,8 +4051,6 @@ func TestBuildExoticShellInterpolation(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - exotic shell interpolation")
 }
 
 func TestBuildVerifySingleQuoteFails(t *testing.T) {
@@ -4267,8 +4072,6 @@ func TestBuildVerifySingleQuoteFails(t *testing.T) {
 	if err == nil {
 		t.Fatal("The image was not supposed to be able to run")
 	}
-
-	logDone("build - verify single quotes fail")
 }
 
 func TestBuildVerboseOut(t *testing.T) {
@@ -4286,8 +4089,6 @@ RUN echo 123`,
 	if !strings.Contains(out, "\n123\n") {
 		t.Fatalf("Output should contain %q: %q", "123", out)
 	}
-
-	logDone("build - verbose output from commands")
 }
 
 func TestBuildWithTabs(t *testing.T) {
@@ -4307,7 +4108,6 @@ func TestBuildWithTabs(t *testing.T) {
 	if res != expected1 && res != expected2 {
 		t.Fatalf("Missing tabs.\nGot: %s\nExp: %s or %s", res, expected1, expected2)
 	}
-	logDone("build - with tabs")
 }
 
 func TestBuildStderr(t *testing.T) {
@@ -4323,7 +4123,6 @@ func TestBuildStderr(t *testing.T) {
 	if stderr != "" {
 		t.Fatalf("Stderr should have been empty, instead its: %q", stderr)
 	}
-	logDone("build - testing stderr")
 }
 
 func TestBuildChownSingleFile(t *testing.T) {
@@ -4350,8 +4149,6 @@ RUN [ $(ls -l /test | awk '{print $3":"$4}') = 'root:root' ]
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - change permission on single file")
 }
 
 func TestBuildSymlinkBreakout(t *testing.T) {
@@ -4405,7 +4202,6 @@ func TestBuildSymlinkBreakout(t *testing.T) {
 	} else if !os.IsNotExist(err) {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	logDone("build - symlink breakout")
 }
 
 func TestBuildXZHost(t *testing.T) {
@@ -4433,8 +4229,6 @@ RUN [ ! -e /injected ]`,
 	if _, err := buildImageFromContext(name, ctx, true); err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("build - xz host is being used")
 }
 
 func TestBuildVolumesRetainContents(t *testing.T) {
@@ -4467,8 +4261,6 @@ CMD cat /foo/file`,
 	if out != expected {
 		t.Fatalf("expected file contents for /foo/file to be %q but received %q", expected, out)
 	}
-
-	logDone("build - volumes retain contents in build")
 }
 
 func TestBuildRenamedDockerfile(t *testing.T) {
@@ -4568,8 +4360,6 @@ func TestBuildRenamedDockerfile(t *testing.T) {
 	if !strings.Contains(out, "from Dockerfile") {
 		t.Fatalf("Should have used root Dockerfile, output:%s", out)
 	}
-
-	logDone("build - rename dockerfile")
 }
 
 func TestBuildFromOfficialNames(t *testing.T) {
@@ -4590,7 +4380,6 @@ func TestBuildFromOfficialNames(t *testing.T) {
 		}
 		deleteImages(imgName)
 	}
-	logDone("build - from official names")
 }
 
 func TestBuildDockerfileOutsideContext(t *testing.T) {
@@ -4653,6 +4442,4 @@ func TestBuildDockerfileOutsideContext(t *testing.T) {
 		t.Fatalf("Expected error. Out: %s", out)
 	}
 	deleteImages(name)
-
-	logDone("build - Dockerfile outside context")
 }
@@ -35,8 +35,6 @@ func TestCommitAfterContainerIsDone(t *testing.T) {
 
 	deleteContainer(cleanedContainerID)
 	deleteImages(cleanedImageID)
-
-	logDone("commit - echo foo and commit the image")
 }
 
 func TestCommitWithoutPause(t *testing.T) {
@@ -68,8 +66,6 @@ func TestCommitWithoutPause(t *testing.T) {
 
 	deleteContainer(cleanedContainerID)
 	deleteImages(cleanedImageID)
-
-	logDone("commit - echo foo and commit the image with --pause=false")
 }
 
 func TestCommitNewFile(t *testing.T) {
@@ -97,8 +93,6 @@ func TestCommitNewFile(t *testing.T) {
 
 	deleteAllContainers()
 	deleteImages(imageID)
-
-	logDone("commit - commit file and read")
 }
 
 func TestCommitHardlink(t *testing.T) {
@@ -149,8 +143,6 @@ func TestCommitHardlink(t *testing.T) {
 
 	deleteAllContainers()
 	deleteImages(imageID)
-
-	logDone("commit - commit hardlinks")
 }
 
 func TestCommitTTY(t *testing.T) {
@@ -173,8 +165,6 @@ func TestCommitTTY(t *testing.T) {
 	if _, err := runCommand(cmd); err != nil {
 		t.Fatal(err)
 	}
-
-	logDone("commit - commit tty")
 }
 
 func TestCommitWithHostBindMount(t *testing.T) {
@@ -199,6 +189,4 @@ func TestCommitWithHostBindMount(t *testing.T) {
 
 	deleteAllContainers()
 	deleteImages(imageID)
-
-	logDone("commit - commit bind mounted file")
 }
@@ -79,8 +79,6 @@ func TestCpGarbagePath(t *testing.T) {
 	if string(test) != cpContainerContents {
 		t.Errorf("output doesn't match the input for garbage path")
 	}
-
-	logDone("cp - garbage paths relative to container's rootfs")
 }
 
 // Check that relative paths are relative to the container's rootfs
@@ -142,8 +140,6 @@ func TestCpRelativePath(t *testing.T) {
 	if string(test) != cpContainerContents {
 		t.Errorf("output doesn't match the input for relative path")
 	}
-
-	logDone("cp - relative paths relative to container's rootfs")
 }
 
 // Check that absolute paths are relative to the container's rootfs
@@ -205,8 +201,6 @@ func TestCpAbsolutePath(t *testing.T) {
 	if string(test) != cpContainerContents {
 		t.Errorf("output doesn't match the input for absolute path")
 	}
-
-	logDone("cp - absolute paths relative to container's rootfs")
 }
 
 // Test for #5619
@@ -269,8 +263,6 @@ func TestCpAbsoluteSymlink(t *testing.T) {
 	if string(test) != cpContainerContents {
 		t.Errorf("output doesn't match the input for absolute symlink")
 	}
-
-	logDone("cp - absolute symlink relative to container's rootfs")
 }
 
 // Test for #5619
@@ -333,8 +325,6 @@ func TestCpSymlinkComponent(t *testing.T) {
 	if string(test) != cpContainerContents {
 		t.Errorf("output doesn't match the input for symlink path component")
 	}
-
-	logDone("cp - symlink path components relative to container's rootfs")
 }
 
 // Check that cp with unprivileged user doesn't return any error
@@ -369,11 +359,10 @@ func TestCpUnprivilegedUser(t *testing.T) {
 	if err != nil {
 		t.Fatalf("couldn't copy with unprivileged user: %s:%s %s", cleanedContainerID, path, err)
 	}
-
-	logDone("cp - unprivileged user")
 }
 
 func TestCpVolumePath(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	tmpDir, err := ioutil.TempDir("", "cp-test-volumepath")
 	if err != nil {
 		t.Fatal(err)
@@ -475,8 +464,6 @@ func TestCpVolumePath(t *testing.T) {
 	if !bytes.Equal(fb, fb2) {
 		t.Fatalf("Expected copied file to be duplicate of bind-mounted file")
 	}
-
-	logDone("cp - volume path")
 }
 
 func TestCpToDot(t *testing.T) {
@@ -514,5 +501,4 @@ func TestCpToDot(t *testing.T) {
 	if string(content) != "lololol\n" {
 		t.Fatalf("Wrong content in copied file %q, should be %q", content, "lololol\n")
 	}
-	logDone("cp - to dot path")
 }
@@ -57,8 +57,6 @@ func TestCreateArgs(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("create - args")
 }
 
 // Make sure we can set hostconfig options too
@@ -99,8 +97,6 @@ func TestCreateHostConfig(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("create - hostconfig")
 }
 
 func TestCreateWithPortRange(t *testing.T) {
@@ -148,8 +144,6 @@ func TestCreateWithPortRange(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("create - port range")
 }
 
 func TestCreateWithiLargePortRange(t *testing.T) {
@@ -197,8 +191,6 @@ func TestCreateWithiLargePortRange(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("create - large port range")
 }
 
 // "test123" should be printed by docker create + start
@@ -222,8 +214,6 @@ func TestCreateEchoStdout(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("create - echo test123")
 }
 
 func TestCreateVolumesCreated(t *testing.T) {
@@ -242,6 +232,4 @@ func TestCreateVolumesCreated(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error statting volume host path: %q", err)
 	}
-
-	logDone("create - volumes are created")
 }
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/libtrust"
 )
@@ -55,8 +56,182 @@ func TestDaemonRestartWithRunningContainersPorts(t *testing.T) {
 	}
 
 	testRun(map[string]bool{"top1": true, "top2": false}, "After daemon restart: ")
+}
+
+func TestDaemonRestartUnlessStopped(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	for _, name := range []string{"running", "stopped"} {
+		if out, err := d.Cmd("run", "-d", "--name", name, "--restart", "unless-stopped", "busybox:latest", "top"); err != nil {
+			t.Fatalf("Could not run %s: err=%v\n%s", name, err, out)
+		}
+	}
+	if out, err := d.Cmd("stop", "stopped"); err != nil {
+		t.Fatalf("Could not stop the container: err=%v\n%s", err, out)
+	}
+
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	out, err := d.Cmd("ps")
+	if err != nil {
+		t.Fatalf("Could not run ps: err=%v\n%q", err, out)
+	}
+	if !strings.Contains(out, "running") {
+		t.Fatalf("Expected the container which was running to be restarted:\n%s", out)
+	}
+	if strings.Contains(out, "stopped") {
+		t.Fatalf("Expected the container stopped by the user not to be restarted:\n%s", out)
+	}
+}
+
+func TestDaemonDefaultRestartPolicy(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--default-restart-policy=on-failure:3"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
 
-	logDone("daemon - running containers on daemon restart")
+	for name, expected := range map[string]string{
+		"default":  "on-failure 3",
+		"explicit": "no 0",
+	} {
+		args := []string{"create", "--name", name}
+		if name == "explicit" {
+			args = append(args, "--restart=no")
+		}
+		if out, err := d.Cmd(args[0], append(args[1:], "busybox:latest", "true")...); err != nil {
+			t.Fatalf("Could not create %s: err=%v\n%s", name, err, out)
+		}
+		out, err := d.Cmd("inspect", "--format", "{{.HostConfig.RestartPolicy.Name}} {{.HostConfig.RestartPolicy.MaximumRetryCount}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		if strings.TrimSpace(out) != expected {
+			t.Fatalf("Expected the restart policy %q for %s, got %q", expected, name, out)
+		}
+	}
+}
+
+func TestDaemonRestartHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restart-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hook, hookFile := filepath.Join(dir, "hook"), filepath.Join(dir, "out")
+	if err := ioutil.WriteFile(hook, []byte("#!/bin/sh\necho $DOCKER_RESTART_ATTEMPT $DOCKER_EXIT_CODE > "+hookFile+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--restart-hook=" + hook); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--restart=on-failure:1", "busybox:latest", "sh", "-c", "exit 3"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+
+	var content []byte
+	for i := 0; i < 50; i++ {
+		if content, err = ioutil.ReadFile(hookFile); err == nil {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("The restart hook did not run: %s", err)
+	}
+	if got := strings.TrimSpace(string(content)); got != "1 3" {
+		t.Fatalf("The restart hook got %q, expected the attempt 1 and the exit code 3", got)
+	}
+}
+
+func TestDaemonRestartDependenciesFirst(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	// the dependents sort before their dependencies by name
+	for _, args := range [][]string{
+		{"--name", "z_db", "-v", "/data"},
+		{"--name", "y_net"},
+		{"--name", "a_app", "--link", "z_db:db", "--volumes-from", "z_db"},
+		{"--name", "b_sidecar", "--net", "container:y_net"},
+	} {
+		args = append([]string{"run", "-d", "--restart", "always"}, args...)
+		if out, err := d.Cmd(args[0], append(args[1:], "busybox:latest", "top")...); err != nil {
+			t.Fatalf("Could not run %v: err=%v\n%s", args, err, out)
+		}
+	}
+
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	for _, name := range []string{"z_db", "y_net", "a_app", "b_sidecar"} {
+		out, err := d.Cmd("inspect", "--format", "{{.State.Running}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		if strings.TrimSpace(out) != "true" {
+			t.Fatalf("Expected %s to be restarted after the containers it depends on", name)
+		}
+	}
+}
+
+func TestDaemonLiveRestore(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "live", "busybox:latest", "sh", "-c", "echo before; sleep 2; echo after; exec top"); err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	pid, err := d.Cmd("inspect", "--format", "{{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, pid)
+	}
+
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	out, err := d.Cmd("inspect", "--format", "{{.State.Running}} {{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, out)
+	}
+	if expected := "true " + strings.TrimSpace(pid); strings.TrimSpace(out) != expected {
+		t.Fatalf("Expected the process of the container to keep running as %q, got %q", expected, out)
+	}
+	if out, err := d.Cmd("exec", "live", "true"); err != nil {
+		t.Fatalf("Could not exec in the reattached container: err=%v\n%s", err, out)
+	}
+
+	time.Sleep(2 * time.Second)
+	out, err = d.Cmd("logs", "live")
+	if err != nil {
+		t.Fatalf("Could not get the logs: err=%v\n%s", err, out)
+	}
+	if !strings.Contains(out, "before") || !strings.Contains(out, "after") {
+		t.Fatalf("Expected the output from before and after the daemon restart in the logs:\n%s", out)
+	}
+
+	if out, err := d.Cmd("stop", "live"); err != nil {
+		t.Fatalf("Could not stop the reattached container: err=%v\n%s", err, out)
+	}
 }
 
 func TestDaemonRestartWithVolumesRefs(t *testing.T) {
@@ -87,8 +262,6 @@ func TestDaemonRestartWithVolumesRefs(t *testing.T) {
 	if _, err := os.Stat(volumes["/foo"]); err != nil {
 		t.Fatalf("Expected volume to exist: %s - %s", volumes["/foo"], err)
 	}
-
-	logDone("daemon - volume refs are restored")
 }
 
 func TestDaemonStartIptablesFalse(t *testing.T) {
@@ -97,8 +270,14 @@ func TestDaemonStartIptablesFalse(t *testing.T) {
 		t.Fatalf("we should have been able to start the daemon with passing iptables=false: %v", err)
 	}
 	d.Stop()
+}
 
-	logDone("daemon - started daemon with iptables=false")
+func TestDaemonStartLinkRejectWithIcc(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.Start("--link-reject"); err == nil {
+		d.Stop()
+		t.Fatal("Expected the daemon not to start with --link-reject and --icc=true")
+	}
 }
 
 // Issue #8444: If docker0 bridge is modified (intentionally or unintentionally) and
@@ -132,8 +311,6 @@ func TestDaemonStartBridgeWithoutIPAssociation(t *testing.T) {
 	if err := d.Stop(); err != nil {
 		t.Fatalf("Could not stop daemon: %v", err)
 	}
-
-	logDone("daemon - successful daemon start when bridge has no IP association")
 }
 
 func TestDaemonIptablesClean(t *testing.T) {
@@ -175,8 +352,6 @@ func TestDaemonIptablesClean(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("daemon - run,iptables - iptables rules cleaned after daemon restart")
 }
 
 func TestDaemonIptablesCreate(t *testing.T) {
@@ -227,8 +402,6 @@ func TestDaemonIptablesCreate(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("daemon - run,iptables - iptables rules for always restarted container created after daemon restart")
 }
 
 func TestDaemonLoggingLevel(t *testing.T) {
@@ -287,8 +460,6 @@ func TestDaemonLoggingLevel(t *testing.T) {
 	if !strings.Contains(string(content), `level="debug"`) {
 		t.Fatalf(`Missing level="debug" in log file when using both --debug and --log-level=fatal:\n%s`, string(content))
 	}
-
-	logDone("daemon - Logging Level")
 }
 
 func TestDaemonAllocatesListeningPort(t *testing.T) {
@@ -317,8 +488,6 @@ func TestDaemonAllocatesListeningPort(t *testing.T) {
 			t.Fatalf("Expected port is already allocated error: %q", output)
 		}
 	}
-
-	logDone("daemon - daemon listening port is allocated")
 }
 
 // #9629
@@ -350,8 +519,6 @@ func TestDaemonVolumesBindsRefs(t *testing.T) {
 	if out, err := d.Cmd("run", "--volumes-from=voltest", "--name=consumer", "busybox", "/bin/sh", "-c", "[ -f /foo/test ]"); err != nil {
 		t.Fatal(err, out)
 	}
-
-	logDone("daemon - bind refs in data-containers survive daemon restart")
 }
 
 func TestDaemonKeyGeneration(t *testing.T) {
@@ -372,8 +539,6 @@ func TestDaemonKeyGeneration(t *testing.T) {
 	if len(kid) != 59 {
 		t.Fatalf("Bad key ID: %s", kid)
 	}
-
-	logDone("daemon - key generation")
 }
 
 func TestDaemonKeyMigration(t *testing.T) {
@@ -403,8 +568,6 @@ func TestDaemonKeyMigration(t *testing.T) {
 	if k1.KeyID() != k2.KeyID() {
 		t.Fatalf("Key not migrated")
 	}
-
-	logDone("daemon - key migration")
 }
 
 // Simulate an older daemon (pre 1.3) coming up with volumes specified in containers
@@ -477,6 +640,150 @@ func TestDaemonUpgradeWithVolumes(t *testing.T) {
 	if len(dir) == 0 {
 		t.Fatalf("expected volumes config dir to contain data for new volume")
 	}
+}
+
+func TestDaemonMaxConcurrentExecs(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--max-concurrent-execs=1"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "top", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run top: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("exec", "-d", "top", "sleep", "2"); err != nil {
+		t.Fatalf("Could not exec sleep: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("exec", "top", "true")
+	if err == nil || !strings.Contains(out, "maximum of 1 exec instances") {
+		t.Fatalf("Expected the second exec to be over the limit: err=%v\n%s", err, out)
+	}
+
+	time.Sleep(3 * time.Second)
+	if out, err := d.Cmd("exec", "top", "true"); err != nil {
+		t.Fatalf("Could not exec once the first exec finished: err=%v\n%s", err, out)
+	}
+}
+
+func TestDaemonEmbeddedDns(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--embedded-dns"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "db", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run db: err=%v\n%s", err, out)
+	}
+	out, err := d.Cmd("inspect", "-f", "{{.NetworkSettings.IPAddress}}", "db")
+	if err != nil {
+		t.Fatalf("Could not inspect db: err=%v\n%s", err, out)
+	}
+	ip := strings.TrimSpace(out)
+
+	out, err = d.Cmd("run", "--link", "db:database", "busybox:latest", "sh", "-c", "nslookup database; nslookup db")
+	if err != nil {
+		t.Fatalf("Could not resolve the link: err=%v\n%s", err, out)
+	}
+	if strings.Count(out, ip) < 2 {
+		t.Fatalf("Expected the link alias and the container name to resolve to %s:\n%s", ip, out)
+	}
+
+	out, err = d.Cmd("run", "--link", "db:database", "busybox:latest", "cat", "/etc/hosts")
+	if err != nil {
+		t.Fatalf("Could not read /etc/hosts: err=%v\n%s", err, out)
+	}
+	if strings.Contains(out, "database") {
+		t.Fatalf("Expected the link not to be written to /etc/hosts:\n%s", out)
+	}
+}
+
+func TestDaemonNoLinkEnv(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--link-env=false"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "db", "-e", "PASSWORD=secret", "--expose", "5432", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run db: err=%v\n%s", err, out)
+	}
+
+	out, err := d.Cmd("run", "--link", "db:database", "busybox:latest", "sh", "-c", "env; grep database /etc/hosts")
+	if err != nil {
+		t.Fatalf("Could not run the linked container: err=%v\n%s", err, out)
+	}
+	if strings.Contains(out, "DATABASE_") {
+		t.Fatalf("Expected no environment variables for the link:\n%s", out)
+	}
+	if !strings.Contains(out, "\tdatabase") {
+		t.Fatalf("Expected the link in /etc/hosts:\n%s", out)
+	}
+}
+
+func TestDaemonFirewallDriverNftables(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--firewall-driver=nftables"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("run", "-d", "-p", "8080:80", "busybox:latest", "top")
+	if err != nil {
+		t.Fatalf("Could not run the container: err=%v\n%s", err, out)
+	}
+	id := strings.TrimSpace(out)
+	ip, err := d.Cmd("inspect", "--format", "{{.NetworkSettings.IPAddress}}", id)
+	if err != nil {
+		t.Fatalf("Could not inspect the container: err=%v\n%s", err, ip)
+	}
 
-	logDone("daemon - volumes from old(pre 1.3) daemon work")
+	listing, _, err := runCommandWithOutput(exec.Command("nft", "list", "chain", "ip", "docker", "dnat"))
+	if err != nil {
+		t.Fatalf("Could not list the dnat chain: err=%v\n%s", err, listing)
+	}
+	if !strings.Contains(listing, "dport 8080 dnat to "+strings.TrimSpace(ip)+":80") {
+		t.Fatalf("Expected the DNAT rule of the published port:\n%s", listing)
+	}
+
+	if out, err := d.Cmd("rm", "-f", id); err != nil {
+		t.Fatalf("Could not remove the container: err=%v\n%s", err, out)
+	}
+	if listing, _, err = runCommandWithOutput(exec.Command("nft", "list", "chain", "ip", "docker", "dnat")); err != nil {
+		t.Fatalf("Could not list the dnat chain: err=%v\n%s", err, listing)
+	}
+	if strings.Contains(listing, "dport 8080") {
+		t.Fatalf("Expected no DNAT rule after the removal of the container:\n%s", listing)
+	}
+}
+
+func TestDaemonEmbeddedDnsSharedAlias(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--embedded-dns"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	var ips []string
+	for _, name := range []string{"web1", "web2"} {
+		if out, err := d.Cmd("run", "-d", "--name", name, "busybox:latest", "top"); err != nil {
+			t.Fatalf("Could not run %s: err=%v\n%s", name, err, out)
+		}
+		ip, err := d.Cmd("inspect", "--format", "{{.NetworkSettings.IPAddress}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, ip)
+		}
+		ips = append(ips, strings.TrimSpace(ip))
+	}
+
+	out, err := d.Cmd("run", "--link", "web1:web", "--link", "web2:web", "busybox:latest", "nslookup", "web")
+	if err != nil {
+		t.Fatalf("Could not resolve the shared alias: err=%v\n%s", err, out)
+	}
+	for _, ip := range ips {
+		if !strings.Contains(out, ip) {
+			t.Fatalf("Expected %s in the addresses of web:\n%s", ip, out)
+		}
+	}
 }
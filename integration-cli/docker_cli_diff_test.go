@@ -34,8 +34,6 @@ func TestDiffFilenameShownInOutput(t *testing.T) {
 		t.Errorf("couldn't find the new file in docker diff's output: %v", out)
 	}
 	deleteContainer(cleanCID)
-
-	logDone("diff - check if created file shows up")
 }
 
 // test to ensure GH #3840 doesn't occur any more
@@ -68,8 +66,6 @@ func TestDiffEnsureDockerinitFilesAreIgnored(t *testing.T) {
 			}
 		}
 	}
-
-	logDone("diff - check if ignored files show up in diff")
 }
 
 func TestDiffEnsureOnlyKmsgAndPtmx(t *testing.T) {
@@ -100,6 +96,4 @@ func TestDiffEnsureOnlyKmsgAndPtmx(t *testing.T) {
 			t.Errorf("%q is shown in the diff but shouldn't", line)
 		}
 	}
-
-	logDone("diff - ensure that only kmsg and ptmx in diff")
 }
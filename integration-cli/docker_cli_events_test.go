@@ -27,7 +27,6 @@ func TestEventsUntag(t *testing.T) {
 			t.Fatalf("event should be untag, not %#v", v)
 		}
 	}
-	logDone("events - untags are logged")
 }
 
 func TestEventsPause(t *testing.T) {
@@ -61,8 +60,6 @@ func TestEventsPause(t *testing.T) {
 	if waitOut, _, err := runCommandWithOutput(waitCmd); err != nil {
 		t.Fatalf("error thrown while waiting for container: %s, %v", waitOut, err)
 	}
-
-	logDone("events - pause/unpause is logged")
 }
 
 func TestEventsContainerFailStartDie(t *testing.T) {
@@ -92,8 +89,6 @@ func TestEventsContainerFailStartDie(t *testing.T) {
 	if dieEvent[len(dieEvent)-1] != "die" {
 		t.Fatalf("event should be die, not %#v", dieEvent)
 	}
-
-	logDone("events - container failed to start logs die")
 }
 
 func TestEventsLimit(t *testing.T) {
@@ -108,7 +103,6 @@ func TestEventsLimit(t *testing.T) {
 	if nEvents != 64 {
 		t.Fatalf("events should be limited to 64, but received %d", nEvents)
 	}
-	logDone("events - limited to 64 entries")
 }
 
 func TestEventsContainerEvents(t *testing.T) {
@@ -139,8 +133,6 @@ func TestEventsContainerEvents(t *testing.T) {
 	if destroyEvent[len(destroyEvent)-1] != "destroy" {
 		t.Fatalf("event should be destroy, not %#v", destroyEvent)
 	}
-
-	logDone("events - container create, start, die, destroy is logged")
 }
 
 func TestEventsImageUntagDelete(t *testing.T) {
@@ -175,7 +167,6 @@ func TestEventsImageUntagDelete(t *testing.T) {
 	if deleteEvent[len(deleteEvent)-1] != "delete" {
 		t.Fatalf("delete should be delete, not %#v", deleteEvent)
 	}
-	logDone("events - image untag, delete is logged")
 }
 
 func TestEventsImagePull(t *testing.T) {
@@ -196,8 +187,6 @@ func TestEventsImagePull(t *testing.T) {
 	if !strings.HasSuffix(event, "hello-world:latest: pull") {
 		t.Fatalf("Missing pull event - got:%q", event)
 	}
-
-	logDone("events - image pull is logged")
 }
 
 func TestEventsImageImport(t *testing.T) {
@@ -230,8 +219,6 @@ func TestEventsImageImport(t *testing.T) {
 	if !strings.HasSuffix(event, ": import") {
 		t.Fatalf("Missing pull event - got:%q", event)
 	}
-
-	logDone("events - image import is logged")
 }
 
 func TestEventsFilters(t *testing.T) {
@@ -290,6 +277,62 @@ func TestEventsFilters(t *testing.T) {
 	if dieEvent[len(dieEvent)-1] != "die" {
 		t.Fatalf("event should be die, not %#v", dieEvent)
 	}
+}
+
+func TestEventsExec(t *testing.T) {
+	defer deleteAllContainers()
+	since := time.Now().Unix()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", "exec_events", "busybox", "top"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "exec_events", "sh", "-c", "exit 3")); err == nil {
+		t.Fatalf("exec exiting with 3 should have failed: %s", out)
+	}
 
-	logDone("events - filters")
+	eventsCmd := exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", time.Now().Unix()+1),
+		"--filter", "event=exec_create", "--filter", "event=exec_start", "--filter", "event=exec_die")
+	out, exitCode, err := runCommandWithOutput(eventsCmd)
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Failed to get events with exit code %d: %s", exitCode, err)
+	}
+	events := strings.Split(strings.TrimSpace(out), "\n")
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %v", len(events), events)
+	}
+	for i, status := range []string{"exec_create: sh -c exit 3", "exec_start: sh -c exit 3", "exec_die: sh -c exit 3 (exit code 3)"} {
+		if !strings.HasSuffix(events[i], status) {
+			t.Fatalf("event should end with %q, not %q", status, events[i])
+		}
+	}
+}
+
+func TestEventsRestartPolicy(t *testing.T) {
+	defer deleteAllContainers()
+	since := time.Now().Unix()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", "restart_events", "--restart=on-failure:2", "--restart-delay=100ms", "busybox", "sh", "-c", "exit 3"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "wait", "restart_events")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	eventsCmd := exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", time.Now().Unix()+1),
+		"--filter", "container=restart_events", "--filter", "event=policy_restart")
+	out, exitCode, err := runCommandWithOutput(eventsCmd)
+	if exitCode != 0 || err != nil {
+		t.Fatalf("Failed to get events with exit code %d: %s", exitCode, err)
+	}
+	events := strings.Split(strings.TrimSpace(out), "\n")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %v", len(events), events)
+	}
+	for i, status := range []string{"policy_restart: attempt 1, delay 200ms, exit code 3", "policy_restart: attempt 2, delay 400ms, exit code 3"} {
+		if !strings.HasSuffix(events[i], status) {
+			t.Fatalf("event should end with %q, not %q", status, events[i])
+		}
+	}
 }
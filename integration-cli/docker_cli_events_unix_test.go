@@ -54,6 +54,4 @@ func TestEventsRedirectStdout(t *testing.T) {
 	if err := scanner.Err(); err != nil {
 		t.Fatalf("Scan err for command %q: %v", command, err)
 	}
-
-	logDone("events - redirect stdout")
 }
@@ -32,8 +32,6 @@ func TestExec(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("exec - basic test")
 }
 
 func TestExecInteractiveStdinClose(t *testing.T) {
@@ -72,8 +70,6 @@ func TestExecInteractiveStdinClose(t *testing.T) {
 	case <-time.After(10 * time.Second):
 		t.Fatal("timed out running docker exec")
 	}
-
-	logDone("exec - interactive mode closes stdin after execution")
 }
 
 func TestExecInteractive(t *testing.T) {
@@ -125,8 +121,6 @@ func TestExecInteractive(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("exec - Interactive test")
 }
 
 func TestExecAfterContainerRestart(t *testing.T) {
@@ -155,8 +149,6 @@ func TestExecAfterContainerRestart(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("exec - exec running container after container restart")
 }
 
 func TestExecAfterDaemonRestart(t *testing.T) {
@@ -187,8 +179,6 @@ func TestExecAfterDaemonRestart(t *testing.T) {
 	if outStr != "hello" {
 		t.Errorf("container should've printed hello, instead printed %q", outStr)
 	}
-
-	logDone("exec - exec running container after daemon restart")
 }
 
 // Regresssion test for #9155, #9044
@@ -214,8 +204,6 @@ func TestExecEnv(t *testing.T) {
 		!strings.Contains(out, "HOME=/root") {
 		t.Errorf("exec env(%q), expect %q, %q", out, "LALA=value2", "HOME=/root")
 	}
-
-	logDone("exec - exec inherits correct env")
 }
 
 func TestExecExitStatus(t *testing.T) {
@@ -231,8 +219,6 @@ func TestExecExitStatus(t *testing.T) {
 	if ec != 23 {
 		t.Fatalf("Should have had an ExitCode of 23, not: %d", ec)
 	}
-
-	logDone("exec - exec non-zero ExitStatus")
 }
 
 func TestExecPausedContainer(t *testing.T) {
@@ -264,8 +250,6 @@ func TestExecPausedContainer(t *testing.T) {
 	if !strings.Contains(out, expected) {
 		t.Fatal("container should not exec new command if it is paused")
 	}
-
-	logDone("exec - exec should not exec a pause container")
 }
 
 // regression test for #9476
@@ -305,8 +289,6 @@ func TestExecTtyCloseStdin(t *testing.T) {
 
 		t.Fatalf("exec process left running\n\t %s", out)
 	}
-
-	logDone("exec - stdin is closed properly with tty enabled")
 }
 
 func TestExecTtyWithoutStdin(t *testing.T) {
@@ -352,8 +334,6 @@ func TestExecTtyWithoutStdin(t *testing.T) {
 	case <-time.After(3 * time.Second):
 		t.Fatal("exec is running but should have failed")
 	}
-
-	logDone("exec - forbid piped stdin to tty enabled container")
 }
 
 func TestExecParseError(t *testing.T) {
@@ -369,7 +349,6 @@ func TestExecParseError(t *testing.T) {
 	if _, stderr, code, err := runCommandWithStdoutStderr(cmd); err == nil || !strings.Contains(stderr, "See '"+dockerBinary+" exec --help'") || code == 0 {
 		t.Fatalf("Should have thrown error & point to help: %s", stderr)
 	}
-	logDone("exec - error on parseExec should point to help")
 }
 
 func TestExecStopNotHanging(t *testing.T) {
@@ -394,7 +373,6 @@ func TestExecStopNotHanging(t *testing.T) {
 		t.Fatal("Container stop timed out")
 	case <-wait:
 	}
-	logDone("exec - container with exec not hanging on stop")
 }
 
 func TestExecCgroup(t *testing.T) {
@@ -450,6 +428,232 @@ func TestExecCgroup(t *testing.T) {
 			t.Fatal("cgroups mismatched")
 		}
 	}
+}
+
+func TestExecWithUser(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd := exec.Command(dockerBinary, "exec", "-u", "daemon", "parent", "id")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(out, "uid=1(daemon) gid=1(daemon)") {
+		t.Fatalf("exec with user by name expected daemon user got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "-u", "root", "parent", "id")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(out, "uid=0(root) gid=0(root)") {
+		t.Fatalf("exec with user by root expected root user got %s", out)
+	}
+}
+
+func TestExecWithEnv(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "-e", "LALA=value1", "-e", "LALA2=value2", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
 
-	logDone("exec - exec has the container cgroups")
+	cmd := exec.Command(dockerBinary, "exec", "-e", "LALA=value3", "-e", "LALA3=value4", "parent", "env")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.Contains(out, "LALA=value1") ||
+		!strings.Contains(out, "LALA=value3") ||
+		!strings.Contains(out, "LALA2=value2") ||
+		!strings.Contains(out, "LALA3=value4") {
+		t.Fatalf("exec with env expected LALA=value3, LALA2=value2 and LALA3=value4 got %s", out)
+	}
+}
+
+func TestExecWithWorkdir(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "-w", "/tmp", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd := exec.Command(dockerBinary, "exec", "parent", "pwd")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "/tmp" {
+		t.Fatalf("exec without workdir expected /tmp got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "-w", "/bin", "parent", "pwd")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "/bin" {
+		t.Fatalf("exec with workdir expected /bin got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "-w", "bin", "parent", "pwd")
+	if out, _, err := runCommandWithOutput(cmd); err == nil {
+		t.Fatalf("exec with a relative workdir should have failed, got %s", out)
+	}
+}
+
+func TestExecWithPrivileged(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "--cap-drop=ALL", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd := exec.Command(dockerBinary, "exec", "parent", "sh", "-c", "mknod /tmp/sda b 8 0")
+	if out, _, err := runCommandWithOutput(cmd); err == nil || !strings.Contains(out, "Operation not permitted") {
+		t.Fatalf("exec mknod in --cap-drop=ALL container without --privileged should have failed, got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "--privileged", "parent", "sh", "-c", "mknod /tmp/sda b 8 0 && echo ok")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "ok" {
+		t.Fatalf("exec mknod in --cap-drop=ALL container with --privileged failed: %s", out)
+	}
+}
+
+func TestExecWaitUnpause(t *testing.T) {
+	defer deleteAllContainers()
+	defer unpauseAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "testing", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "pause", "testing")); err != nil {
+		t.Fatal(out, err)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "--wait-unpause=1", "testing", "echo", "hello")); err == nil || !strings.Contains(out, "is still paused") {
+		t.Fatalf("exec should time out waiting for the container to be unpaused: %s", out)
+	}
+
+	go func() {
+		time.Sleep(time.Second)
+		runCommand(exec.Command(dockerBinary, "unpause", "testing"))
+	}()
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "--wait-unpause=10", "testing", "echo", "hello"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if strings.TrimSpace(out) != "hello" {
+		t.Fatalf("exec should run once the container is unpaused, got %s", out)
+	}
+}
+
+func TestExecWithResources(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd := exec.Command(dockerBinary, "exec", "-m", "32m", "-c", "512", "parent", "cat", "/proc/self/cgroup")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		inChild := strings.Contains(fields[2], "/exec-")
+		if strings.Contains(fields[1], "memory") || strings.Contains(fields[1], "cpu") {
+			if !inChild {
+				t.Fatalf("exec with resources expected to run in a child cgroup, got %s", line)
+			}
+		} else if inChild {
+			t.Fatalf("exec with resources expected to stay in the cgroup of the container, got %s", line)
+		}
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "-m", "1m", "parent", "true")); err == nil || !strings.Contains(out, "Minimum memory limit allowed is 4MB") {
+		t.Fatalf("exec with less than 4MB of memory should have failed, got %s", out)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	start := time.Now()
+	cmd := exec.Command(dockerBinary, "exec", "--timeout", "1s", "parent", "sleep", "30")
+	out, exitCode, err := runCommandWithOutput(cmd)
+	if err == nil || exitCode != 124 {
+		t.Fatalf("exec exceeding its timeout expected to exit with 124, got %d: %s", exitCode, out)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Second {
+		t.Fatalf("exec exceeding its timeout expected to be killed, took %s", elapsed)
+	}
+	// the command itself is killed, not only nsenter running it
+	psCmd := exec.Command(dockerBinary, "exec", "parent", "ps")
+	out, _, err = runCommandWithOutput(psCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if strings.Contains(out, "sleep 30") {
+		t.Fatalf("exec exceeding its timeout expected to be killed, still running: %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "--timeout", "30s", "parent", "true")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatalf("exec within its timeout expected to succeed: %s, %v", out, err)
+	}
+}
+
+func TestExecAttach(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "parent", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd := exec.Command(dockerBinary, "exec", "-d", "parent", "sh", "-c", "sleep 2; echo hello; exit 3")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	execID := strings.TrimSpace(out)
+
+	cmd = exec.Command(dockerBinary, "exec", "attach", execID)
+	out, exitCode, err := runCommandWithOutput(cmd)
+	if err == nil || exitCode != 3 {
+		t.Fatalf("exec attach expected to exit with the exit code of the exec, got %d: %s", exitCode, out)
+	}
+	if strings.TrimSpace(out) != "hello" {
+		t.Fatalf("exec attach expected to get the output of the exec, got %q", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "exec", "attach", execID)
+	if out, _, err := runCommandWithOutput(cmd); err == nil || !strings.Contains(out, "not running") {
+		t.Fatalf("exec attach to a finished exec should have failed, got %s", out)
+	}
 }
@@ -48,7 +48,4 @@ func TestExportContainerAndImportImage(t *testing.T) {
 	deleteImages("repo/testexp:v1")
 
 	os.Remove("/tmp/testexp.tar")
-
-	logDone("export - export a container")
-	logDone("import - import an image")
 }
@@ -61,8 +61,6 @@ RUN echo "Z"`,
 			t.Fatalf("Expected layer \"%s\", but was: %s", expectedValues[i], actualValue)
 		}
 	}
-
-	logDone("history - build history")
 }
 
 func TestHistoryExistentImage(t *testing.T) {
@@ -71,7 +69,6 @@ func TestHistoryExistentImage(t *testing.T) {
 	if err != nil || exitCode != 0 {
 		t.Fatal("failed to get image history")
 	}
-	logDone("history - history on existent image must not fail")
 }
 
 func TestHistoryNonExistentImage(t *testing.T) {
@@ -80,5 +77,4 @@ func TestHistoryNonExistentImage(t *testing.T) {
 	if err == nil || exitCode == 0 {
 		t.Fatal("history on a non-existent image didn't result in a non-zero exit status")
 	}
-	logDone("history - history on non-existent image must fail")
 }
@@ -20,8 +20,6 @@ func TestImagesEnsureImageIsListed(t *testing.T) {
 	if !strings.Contains(out, "busybox") {
 		t.Fatal("images should've listed busybox")
 	}
-
-	logDone("images - busybox should be listed")
 }
 
 func TestImagesOrderedByCreationDate(t *testing.T) {
@@ -63,8 +61,6 @@ func TestImagesOrderedByCreationDate(t *testing.T) {
 	if imgs[2] != id1 {
 		t.Fatalf("Third image must be %s, got %s", id1, imgs[2])
 	}
-
-	logDone("images - ordering by creation date")
 }
 
 func TestImagesErrorWithInvalidFilterNameTest(t *testing.T) {
@@ -73,8 +69,6 @@ func TestImagesErrorWithInvalidFilterNameTest(t *testing.T) {
 	if !strings.Contains(out, "Invalid filter") {
 		t.Fatalf("error should occur when listing images with invalid filter name FOO, %s, %v", out, err)
 	}
-
-	logDone("images - invalid filter name check working")
 }
 
 func TestImagesFilterWhiteSpaceTrimmingAndLowerCasingWorking(t *testing.T) {
@@ -119,6 +113,4 @@ func TestImagesFilterWhiteSpaceTrimmingAndLowerCasingWorking(t *testing.T) {
 			t.Fatalf("All output must be the same")
 		}
 	}
-
-	logDone("images - white space trimming and lower casing")
 }
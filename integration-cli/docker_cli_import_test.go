@@ -27,6 +27,4 @@ func TestImportDisplay(t *testing.T) {
 	}
 
 	deleteImages("cirros")
-
-	logDone("import - cirros was imported and display is fine")
 }
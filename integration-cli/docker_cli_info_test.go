@@ -21,6 +21,4 @@ func TestInfoEnsureSucceeds(t *testing.T) {
 			t.Errorf("couldn't find string %v in output", linePrefix)
 		}
 	}
-
-	logDone("info - verify that it works")
 }
@@ -18,8 +18,6 @@ func TestInspectImage(t *testing.T) {
 	if id := strings.TrimSuffix(out, "\n"); id != imageTestID {
 		t.Fatalf("Expected id: %s for image: %s but received id: %s", imageTestID, imageTest, id)
 	}
-
-	logDone("inspect - inspect an image")
 }
 
 func TestInspectExecID(t *testing.T) {
@@ -53,6 +51,4 @@ func TestInspectExecID(t *testing.T) {
 	if out == "[]" || out == "<no value>" {
 		t.Fatalf("ExecIDs should not be empty, got: %s", out)
 	}
-
-	logDone("inspect - inspect a container with ExecIDs")
 }
@@ -36,8 +36,6 @@ func TestKillContainer(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("kill - kill container running sleep 10")
 }
 
 func TestKillDifferentUserContainer(t *testing.T) {
@@ -70,6 +68,4 @@ func TestKillDifferentUserContainer(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("kill - kill container running sleep 10 from a different user")
 }
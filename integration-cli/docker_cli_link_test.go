@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinkAddRemoveRunning(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "web", "busybox", "top")
+	ip, err := inspectField("db", "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dockerCmd(t, "link", "add", "web", "db:database")
+	out, _, _ := dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if !strings.Contains(out, ip+"\tdatabase db\n") {
+		t.Fatalf("Expected database in /etc/hosts of web:\n%s", out)
+	}
+	links, err := inspectField("web", "HostConfig.Links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links != "[/db:/web/database]" {
+		t.Fatalf("Expected the link in the host config, got %s", links)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "link", "add", "web", "db:database")); err == nil {
+		t.Fatalf("Expected adding the link again to fail:\n%s", out)
+	}
+
+	dockerCmd(t, "link", "rm", "web", "db:database")
+	out, _, _ = dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if strings.Contains(out, "database") {
+		t.Fatalf("Expected database to be removed from /etc/hosts of web:\n%s", out)
+	}
+	if links, _ := inspectField("web", "HostConfig.Links"); strings.Contains(links, "database") {
+		t.Fatalf("Expected no link in the host config, got %s", links)
+	}
+}
+
+func TestLinkAddStopped(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "create", "--name", "web", "busybox", "sh", "-c", "echo $DATABASE_NAME; grep database /etc/hosts")
+
+	dockerCmd(t, "link", "add", "web", "db:database")
+	out, _, _ := dockerCmd(t, "start", "-a", "web")
+	if !strings.Contains(out, "/web/database") || !strings.Contains(out, "\tdatabase") {
+		t.Fatalf("Expected the link to be set up at start:\n%s", out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "link", "rm", "web", "web:database")); err == nil {
+		t.Fatalf("Expected removing a link to another container to fail:\n%s", out)
+	}
+}
+
+func TestLinkAddBothWays(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "web", "busybox", "top")
+
+	// linking the containers to each other at the same time locks them in
+	// both orders
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, _, err := runCommandWithOutput(exec.Command(dockerBinary, "link", "add", "web", "db:database"))
+			if err == nil {
+				_, _, err = runCommandWithOutput(exec.Command(dockerBinary, "link", "rm", "web", "db:database"))
+			}
+			done <- err
+		}()
+		go func() {
+			_, _, err := runCommandWithOutput(exec.Command(dockerBinary, "link", "add", "db", "web:frontend"))
+			if err == nil {
+				_, _, err = runCommandWithOutput(exec.Command(dockerBinary, "link", "rm", "db", "web:frontend"))
+			}
+			done <- err
+		}()
+		for j := 0; j < 2; j++ {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(30 * time.Second):
+				t.Fatal("Timeout linking the containers to each other, the daemon deadlocked")
+			}
+		}
+	}
+}
@@ -26,8 +26,6 @@ func TestLinksEtcHostsRegularFile(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("link - /etc/hosts is a regular file")
 }
 
 func TestLinksEtcHostsContentMatch(t *testing.T) {
@@ -47,8 +45,6 @@ func TestLinksEtcHostsContentMatch(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("link - /etc/hosts matches hosts copy")
 }
 
 func TestLinksPingUnlinkedContainers(t *testing.T) {
@@ -60,8 +56,6 @@ func TestLinksPingUnlinkedContainers(t *testing.T) {
 	} else if exitCode != 1 {
 		t.Fatalf("run ping failed with errors: %v", err)
 	}
-
-	logDone("links - ping unlinked container")
 }
 
 func TestLinksPingLinkedContainers(t *testing.T) {
@@ -74,8 +68,6 @@ func TestLinksPingLinkedContainers(t *testing.T) {
 	dockerCmd(t, "kill", idA)
 	dockerCmd(t, "kill", idB)
 	deleteAllContainers()
-
-	logDone("links - ping linked container")
 }
 
 func TestLinksPingLinkedContainersAfterRename(t *testing.T) {
@@ -88,8 +80,6 @@ func TestLinksPingLinkedContainersAfterRename(t *testing.T) {
 	dockerCmd(t, "kill", idA)
 	dockerCmd(t, "kill", idB)
 	deleteAllContainers()
-
-	logDone("links - ping linked container after rename")
 }
 
 func TestLinksPingLinkedContainersOnRename(t *testing.T) {
@@ -120,8 +110,6 @@ func TestLinksPingLinkedContainersOnRename(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("links - ping linked container upon rename")
 }
 
 func TestLinksIpTablesRulesWhenLinkAndUnlink(t *testing.T) {
@@ -131,7 +119,7 @@ func TestLinksIpTablesRulesWhenLinkAndUnlink(t *testing.T) {
 	childIP := findContainerIP(t, "child")
 	parentIP := findContainerIP(t, "parent")
 
-	sourceRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-p", "tcp", "-s", childIP, "--sport", "80", "-d", parentIP, "-j", "ACCEPT"}
+	sourceRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-p", "tcp", "-s", childIP, "--sport", "80", "-d", parentIP, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}
 	destinationRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-p", "tcp", "-s", parentIP, "--dport", "80", "-d", childIP, "-j", "ACCEPT"}
 	if !iptables.Exists(sourceRule...) || !iptables.Exists(destinationRule...) {
 		t.Fatal("Iptables rules not found")
@@ -145,8 +133,48 @@ func TestLinksIpTablesRulesWhenLinkAndUnlink(t *testing.T) {
 	dockerCmd(t, "kill", "child")
 	dockerCmd(t, "kill", "parent")
 	deleteAllContainers()
+}
+
+func TestLinksRejectNonExposedPorts(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--icc=false", "--link-reject"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "child", "--expose", "80", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run child: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "parent", "--link", "child:http", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run parent: err=%v\n%s", err, out)
+	}
+	var ips []string
+	for _, name := range []string{"child", "parent"} {
+		out, err := d.Cmd("inspect", "-f", "{{.NetworkSettings.IPAddress}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		ips = append(ips, strings.TrimSpace(out))
+	}
+	childIP, parentIP := ips[0], ips[1]
+
+	rejectRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-s", parentIP, "-d", childIP, "-p", "tcp", "-j", "REJECT", "--reject-with", "tcp-reset"}
+	if !iptables.Exists(rejectRule...) {
+		t.Fatal("Iptables reject rule not found")
+	}
+
+	// refused right away instead of timing out
+	out, err := d.Cmd("exec", "parent", "nc", "-w", "5", childIP, "81")
+	if err == nil || !strings.Contains(out, "refused") {
+		t.Fatalf("Expected the connection to a non exposed port to be refused: err=%v\n%s", err, out)
+	}
 
-	logDone("link - verify iptables when link and unlink")
+	if out, err := d.Cmd("rm", "--link", "parent/http"); err != nil {
+		t.Fatalf("Could not remove the link: err=%v\n%s", err, out)
+	}
+	if iptables.Exists(rejectRule...) {
+		t.Fatal("Iptables reject rule should be removed when unlink")
+	}
 }
 
 func TestLinksInspectLinksStarted(t *testing.T) {
@@ -175,7 +203,6 @@ func TestLinksInspectLinksStarted(t *testing.T) {
 	if !equal {
 		t.Fatalf("Links %s, expected %s", result, expected)
 	}
-	logDone("link - links in started container inspect")
 }
 
 func TestLinksInspectLinksStopped(t *testing.T) {
@@ -204,8 +231,6 @@ func TestLinksInspectLinksStopped(t *testing.T) {
 	if !equal {
 		t.Fatalf("Links %s, but expected %s", result, expected)
 	}
-
-	logDone("link - links in stopped container inspect")
 }
 
 func TestLinksNotStartedParentNotFail(t *testing.T) {
@@ -225,7 +250,6 @@ func TestLinksNotStartedParentNotFail(t *testing.T) {
 	if err != nil {
 		t.Fatal(out, err)
 	}
-	logDone("link - container start not failing on updating stopped parent links")
 }
 
 func TestLinksHostsFilesInject(t *testing.T) {
@@ -260,8 +284,6 @@ func TestLinksHostsFilesInject(t *testing.T) {
 	if !strings.Contains(string(contentTwo), "onetwo") {
 		t.Fatal("Host is not present in updated hosts file", string(contentTwo))
 	}
-
-	logDone("link - ensure containers hosts files are updated with the link alias.")
 }
 
 func TestLinksNetworkHostContainer(t *testing.T) {
@@ -276,8 +298,6 @@ func TestLinksNetworkHostContainer(t *testing.T) {
 	if err == nil || !strings.Contains(out, "--net=host can't be used with links. This would result in undefined behavior.") {
 		t.Fatalf("Running container linking to a container with --net host should have failed: %s", out)
 	}
-
-	logDone("link - error thrown when linking to container with --net host")
 }
 
 func TestLinksUpdateOnRestart(t *testing.T) {
@@ -331,5 +351,138 @@ func TestLinksUpdateOnRestart(t *testing.T) {
 	if ip := getIP(content, "onetwo"); ip != realIP {
 		t.Fatalf("For 'onetwo' alias expected IP: %s, got: %s", realIP, ip)
 	}
-	logDone("link - ensure containers hosts files are updated on restart")
+}
+
+func TestLinksUpdateOnRestartOfNetworkSharer(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "sidecar", "--net", "container:db", "busybox", "top")
+	out, _, _ := dockerCmd(t, "run", "-d", "--name", "web", "--link", "sidecar:side", "busybox", "top")
+	id := strings.TrimSpace(out)
+
+	checkIP := func() {
+		realIP, err := inspectField("db", "NetworkSettings.IPAddress")
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := readContainerFile(id, "hosts")
+		if err != nil {
+			t.Fatal(err, string(content))
+		}
+		if !strings.Contains(string(content), realIP+"\tside sidecar\n") {
+			t.Fatalf("Expected side to have the IP of db %s:\n%s", realIP, content)
+		}
+	}
+	checkIP()
+
+	// sidecar joins the new network of db when it restarts
+	dockerCmd(t, "restart", "db")
+	dockerCmd(t, "restart", "sidecar")
+	checkIP()
+}
+
+func TestLinksIp6TablesRulesWhenLinkAndUnlink(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--ipv6", "--fixed-cidr-v6=2001:db8:1::/64"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "child", "--expose", "80", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run child: err=%v\n%s", err, out)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "parent", "--link", "child:http", "busybox", "top"); err != nil {
+		t.Fatalf("Could not run parent: err=%v\n%s", err, out)
+	}
+
+	var ips []string
+	for _, name := range []string{"child", "parent"} {
+		out, err := d.Cmd("inspect", "-f", "{{.NetworkSettings.GlobalIPv6Address}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: err=%v\n%s", name, err, out)
+		}
+		ips = append(ips, strings.TrimSpace(out))
+	}
+	childIP, parentIP := ips[0], ips[1]
+
+	sourceRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-p", "tcp", "-s", childIP, "--sport", "80", "-d", parentIP, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"}
+	destinationRule := []string{"DOCKER", "-i", "docker0", "-o", "docker0", "-p", "tcp", "-s", parentIP, "--dport", "80", "-d", childIP, "-j", "ACCEPT"}
+	if !iptables.Exists6(sourceRule...) || !iptables.Exists6(destinationRule...) {
+		t.Fatal("Ip6tables rules not found")
+	}
+
+	out, err := d.Cmd("exec", "parent", "cat", "/etc/hosts")
+	if err != nil {
+		t.Fatalf("Could not read /etc/hosts: err=%v\n%s", err, out)
+	}
+	if !strings.Contains(out, childIP+"\thttp child\n") {
+		t.Fatalf("Expected the IPv6 address of child in /etc/hosts:\n%s", out)
+	}
+
+	if out, err := d.Cmd("rm", "--link", "parent/http"); err != nil {
+		t.Fatalf("Could not remove the link: err=%v\n%s", err, out)
+	}
+	if iptables.Exists6(sourceRule...) || iptables.Exists6(destinationRule...) {
+		t.Fatal("Ip6tables rules should be removed when unlink")
+	}
+}
+
+func TestLinksSeveralAndSharedAliases(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "web1", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "web2", "busybox", "top")
+	ip1, ip2 := findContainerIP(t, "web1"), findContainerIP(t, "web2")
+
+	out, _, _ := dockerCmd(t, "run", "--name", "lb", "--link", "web1:web,front", "--link", "web2:web", "busybox", "cat", "/etc/hosts")
+	for _, expected := range []string{ip1 + "\tweb web1\n", ip2 + "\tweb web2\n", ip1 + "\tfront web1\n"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("Expected %q in /etc/hosts:\n%s", expected, out)
+		}
+	}
+
+	links, err := inspectField("lb", "HostConfig.Links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"/web1:/lb/web", "/web1:/lb/front", "/web2:/lb/web"} {
+		if !strings.Contains(links, expected) {
+			t.Fatalf("Expected %s in the links of lb, got %s", expected, links)
+		}
+	}
+}
+
+func TestLinksHiddenWhileStopped(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db1", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "db2", "busybox", "top")
+	ip1, ip2 := findContainerIP(t, "db1"), findContainerIP(t, "db2")
+	dockerCmd(t, "run", "-d", "--name", "web", "--link", "db1:db:hide", "--link", "db2:db", "busybox", "top")
+
+	dockerCmd(t, "stop", "db1")
+	dockerCmd(t, "stop", "db2")
+	out, _, _ := dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if strings.Contains(out, ip1+"\tdb db1\n") {
+		t.Fatalf("Expected the hidden link to be removed from /etc/hosts:\n%s", out)
+	}
+	if !strings.Contains(out, ip2+"\tdb db2\n") {
+		t.Fatalf("Expected the link which is not hidden to be kept in /etc/hosts:\n%s", out)
+	}
+
+	dockerCmd(t, "start", "db1")
+	ip1 = findContainerIP(t, "db1")
+	out, _, _ = dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if !strings.Contains(out, ip1+"\tdb db1\n") {
+		t.Fatalf("Expected the hidden link to be back in /etc/hosts:\n%s", out)
+	}
+
+	links, err := inspectField("web", "HostConfig.Links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(links, "/db1:/web/db:hide") {
+		t.Fatalf("Expected the hidden link in the links of web, got %s", links)
+	}
 }
@@ -16,6 +16,4 @@ func TestLoginWithoutTTY(t *testing.T) {
 	if err := cmd.Run(); err == nil {
 		t.Fatal("Expected non nil err when loginning in & TTY not available")
 	}
-
-	logDone("login - login without TTY")
 }
@@ -34,8 +34,6 @@ func TestLogsContainerSmallerThanPage(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - logs container running echo smaller than page size")
 }
 
 // Regression test: When going over the PageSize, it used to panic (gh#4851)
@@ -61,8 +59,6 @@ func TestLogsContainerBiggerThanPage(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - logs container running echo bigger than page size")
 }
 
 // Regression test: When going much over the PageSize, it used to block (gh#4851)
@@ -88,8 +84,6 @@ func TestLogsContainerMuchBiggerThanPage(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - logs container running echo much bigger than page size")
 }
 
 func TestLogsTimestamps(t *testing.T) {
@@ -131,8 +125,6 @@ func TestLogsTimestamps(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - logs with timestamps")
 }
 
 func TestLogsSeparateStderr(t *testing.T) {
@@ -163,8 +155,6 @@ func TestLogsSeparateStderr(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - separate stderr (without pseudo-tty)")
 }
 
 func TestLogsStderrInStdout(t *testing.T) {
@@ -195,8 +185,6 @@ func TestLogsStderrInStdout(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("logs - stderr in stdout (with pseudo-tty)")
 }
 
 func TestLogsTail(t *testing.T) {
@@ -248,7 +236,6 @@ func TestLogsTail(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-	logDone("logs - logs tail")
 }
 
 func TestLogsFollowStopped(t *testing.T) {
@@ -282,7 +269,6 @@ func TestLogsFollowStopped(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-	logDone("logs - logs follow stopped container")
 }
 
 // Regression test for #8832
@@ -332,6 +318,4 @@ func TestLogsFollowSlowStdoutConsumer(t *testing.T) {
 	if actual != expected {
 		t.Fatalf("Invalid bytes read: %d, expected %d", actual, expected)
 	}
-
-	logDone("logs - follow slow consumer")
 }
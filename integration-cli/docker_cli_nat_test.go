@@ -55,6 +55,4 @@ func TestNetworkNat(t *testing.T) {
 		t.Fatalf("failed to kill container: %s, %v", out, err)
 	}
 	deleteAllContainers()
-
-	logDone("network - make sure nat works through the host")
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNetworkCreateAndRemove(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, _ := dockerCmd(t, "network", "create", "--subnet", "10.99.0.0/24", "testnet")
+	id := strings.TrimSpace(out)
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	out, _, _ = dockerCmd(t, "network", "ls", "--no-trunc")
+	if !strings.Contains(out, id) || !strings.Contains(out, "10.99.0.1/24") || !strings.Contains(out, "br-"+id[:12]) {
+		t.Fatalf("Expected testnet in the networks:\n%s", out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "create", "testnet")); err == nil {
+		t.Fatalf("Expected creating testnet again to fail:\n%s", out)
+	}
+
+	dockerCmd(t, "run", "--net", "testnet", "--name", "first", "busybox", "true")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "testnet")); err == nil || !strings.Contains(out, "first") {
+		t.Fatalf("Expected removing testnet to fail while first uses it: err=%v\n%s", err, out)
+	}
+
+	dockerCmd(t, "network", "disconnect", "testnet", "first")
+	mode, err := inspectField("first", "HostConfig.NetworkMode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "bridge" {
+		t.Fatalf("Expected first back on the bridge, got %s", mode)
+	}
+
+	dockerCmd(t, "network", "rm", "testnet")
+	out, _, _ = dockerCmd(t, "network", "ls", "-q")
+	if strings.Contains(out, id[:12]) {
+		t.Fatalf("Expected testnet to be removed:\n%s", out)
+	}
+}
+
+func TestNetworkResolvesAndIsolates(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "testnet")
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	dockerCmd(t, "run", "-d", "--net", "testnet", "--name", "db", "busybox", "top")
+	ip, err := inspectField("db", "NetworkSettings.IPAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, _ := dockerCmd(t, "run", "--net", "testnet", "busybox", "sh", "-c", "nslookup db && ping -c 1 -W 2 db")
+	if !strings.Contains(out, ip) {
+		t.Fatalf("Expected db to resolve to %s:\n%s", ip, out)
+	}
+
+	// docker0 is isolated from the network, by name and by address
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "busybox", "nslookup", "db")); err == nil && strings.Contains(out, ip) {
+		t.Fatalf("Expected db not to resolve from docker0:\n%s", out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "busybox", "ping", "-c", "1", "-W", "2", ip)); err == nil {
+		t.Fatalf("Expected db not to be reachable from docker0:\n%s", out)
+	}
+
+	// a stopped container joins the network
+	dockerCmd(t, "create", "--name", "web", "busybox", "ping", "-c", "1", "-W", "2", "db")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "connect", "testnet", "db")); err == nil {
+		t.Fatalf("Expected connecting the running db to fail:\n%s", out)
+	}
+	dockerCmd(t, "network", "connect", "testnet", "web")
+	dockerCmd(t, "start", "-a", "web")
+	if code, err := inspectField("web", "State.ExitCode"); err != nil || code != "0" {
+		t.Fatalf("Expected web to reach db, exit code %s: %v", code, err)
+	}
+}
+
+func TestNetworkRunUnknown(t *testing.T) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--net", "nosuchnet", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "network nosuchnet does not exist") {
+		t.Fatalf("Expected running on an unknown network to fail: err=%v\n%s", err, out)
+	}
+}
+
+func TestNetworkRejectsPublishedPorts(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "network", "create", "testnet")
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	for _, publish := range []string{"-p=8080:80", "-P"} {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", publish, "--net", "testnet", "busybox", "true"))
+		if err == nil || !strings.Contains(out, "ports are only published on the default bridge") {
+			t.Fatalf("Expected %s on testnet to be rejected at create: err=%v\n%s", publish, err, out)
+		}
+	}
+
+	dockerCmd(t, "create", "-p=8080:80", "--name", "web", "busybox", "true")
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "connect", "testnet", "web"))
+	if err == nil || !strings.Contains(out, "publishes ports") {
+		t.Fatalf("Expected connecting a container publishing ports to fail: err=%v\n%s", err, out)
+	}
+}
+
+func TestNetworkStaticAddressReserved(t *testing.T) {
+	defer deleteAllContainers()
+
+	// the addresses of the containers are 10.98.0.2 to 10.98.0.6
+	dockerCmd(t, "network", "create", "--subnet", "10.98.0.0/29", "testnet")
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	dockerCmd(t, "create", "--net", "testnet", "--ip", "10.98.0.2", "--name", "static", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--net", "testnet", "--name", "dynamic", "busybox", "top")
+	if ip, err := inspectField("dynamic", "NetworkSettings.IPAddress"); err != nil || ip == "10.98.0.2" {
+		t.Fatalf("Expected the static address of the stopped container to be kept, got %s: %v", ip, err)
+	}
+
+	dockerCmd(t, "start", "static")
+	if ip, err := inspectField("static", "NetworkSettings.IPAddress"); err != nil || ip != "10.98.0.2" {
+		t.Fatalf("Expected the static address 10.98.0.2, got %s: %v", ip, err)
+	}
+}
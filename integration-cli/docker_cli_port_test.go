@@ -122,8 +122,39 @@ func TestPortList(t *testing.T) {
 	}
 
 	deleteAllContainers()
+}
+
+func TestPortListRange(t *testing.T) {
+	defer deleteAllContainers()
 
-	logDone("port - test port list")
+	runCmd := exec.Command(dockerBinary, "run", "-d", "-p", "9876-9878:8000-8002/udp", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	ID := stripTrailingCharacters(out)
+
+	runCmd = exec.Command(dockerBinary, "port", ID)
+	out, _, err = runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	if !assertPortList(t, out, []string{
+		"8000/udp -> 0.0.0.0:9876",
+		"8001/udp -> 0.0.0.0:9877",
+		"8002/udp -> 0.0.0.0:9878"}) {
+		t.Error("Port list is not correct\n", out)
+	}
+
+	// the range is bound as a whole
+	bindings, err := inspectField(ID, "HostConfig.PortBindings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(bindings, "8000-8002/udp") {
+		t.Fatalf("Expected a single binding for the range, got %s", bindings)
+	}
 }
 
 func assertPortList(t *testing.T, out string, expected []string) bool {
@@ -221,8 +221,6 @@ func TestPsListContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("ps - test ps options")
 }
 
 func assertContainerList(out string, expected []string) bool {
@@ -282,7 +280,6 @@ func TestPsListContainersSize(t *testing.T) {
 	}
 
 	deleteAllContainers()
-	logDone("ps - test ps size")
 }
 
 func TestPsListContainersFilterStatus(t *testing.T) {
@@ -333,8 +330,6 @@ func TestPsListContainersFilterStatus(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("ps - test ps filter status")
 }
 
 func TestPsListContainersFilterID(t *testing.T) {
@@ -363,8 +358,6 @@ func TestPsListContainersFilterID(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("ps - test ps filter id")
 }
 
 func TestPsListContainersFilterName(t *testing.T) {
@@ -393,8 +386,6 @@ func TestPsListContainersFilterName(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("ps - test ps filter name")
 }
 
 func TestPsListContainersFilterExited(t *testing.T) {
@@ -473,6 +464,4 @@ func TestPsListContainersFilterExited(t *testing.T) {
 	if ids[1] != firstNonZero {
 		t.Fatalf("Second in list should be %q, got %q", firstNonZero, ids[1])
 	}
-
-	logDone("ps - test ps filter exited")
 }
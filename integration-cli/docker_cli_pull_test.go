@@ -49,8 +49,6 @@ func TestPullImageWithAliases(t *testing.T) {
 			t.Fatalf("Image %v shouldn't have been pulled down", repo)
 		}
 	}
-
-	logDone("pull - image with aliases")
 }
 
 // pulling busybox should show verified message
@@ -87,8 +85,6 @@ func TestPullVerified(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pullCmd); err != nil || !strings.Contains(out, expected) {
 		t.Fatalf("pulling a verified image failed. expected: %s\ngot: %s, %v", expected, out, err)
 	}
-
-	logDone("pull - pull verified")
 }
 
 // pulling an image from the central registry should work
@@ -97,7 +93,6 @@ func TestPullImageFromCentralRegistry(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pullCmd); err != nil {
 		t.Fatalf("pulling the hello-world image from the registry has failed: %s, %v", out, err)
 	}
-	logDone("pull - pull hello-world")
 }
 
 // pulling a non-existing image from the central registry should return a non-zero exit code
@@ -106,7 +101,6 @@ func TestPullNonExistingImage(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pullCmd); err == nil {
 		t.Fatalf("expected non-zero exit status when pulling non-existing image: %s", out)
 	}
-	logDone("pull - pull fooblahblah1234 (non-existing image)")
 }
 
 // pulling an image from the central registry using official names should work
@@ -136,5 +130,4 @@ func TestPullImageOfficialNames(t *testing.T) {
 			t.Errorf("images should not have listed '%s'", name)
 		}
 	}
-	logDone("pull - pull official names")
 }
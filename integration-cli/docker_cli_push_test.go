@@ -28,7 +28,6 @@ func TestPushBusyboxImage(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pushCmd); err != nil {
 		t.Fatalf("pushing the image to the private registry has failed: %s, %v", out, err)
 	}
-	logDone("push - busybox to private registry")
 }
 
 // pushing an image without a prefix should throw an error
@@ -37,7 +36,6 @@ func TestPushUnprefixedRepo(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pushCmd); err == nil {
 		t.Fatalf("pushing an unprefixed repo didn't result in a non-zero exit status: %s", out)
 	}
-	logDone("push - unprefixed busybox repo must fail")
 }
 
 func TestPushUntagged(t *testing.T) {
@@ -52,7 +50,6 @@ func TestPushUntagged(t *testing.T) {
 	} else if !strings.Contains(out, expected) {
 		t.Fatalf("pushing the image failed with an unexpected message: expected %q, got %q", expected, out)
 	}
-	logDone("push - untagged image")
 }
 
 func TestPushBadTag(t *testing.T) {
@@ -67,7 +64,6 @@ func TestPushBadTag(t *testing.T) {
 	} else if !strings.Contains(out, expected) {
 		t.Fatalf("pushing the image failed with an unexpected message: expected %q, got %q", expected, out)
 	}
-	logDone("push - image with bad tag")
 }
 
 func TestPushMultipleTags(t *testing.T) {
@@ -92,7 +88,6 @@ func TestPushMultipleTags(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pushCmd); err != nil {
 		t.Fatalf("pushing the image to the private registry has failed: %s, %v", out, err)
 	}
-	logDone("push - multiple tags to private registry")
 }
 
 func TestPushInterrupt(t *testing.T) {
@@ -121,8 +116,6 @@ func TestPushInterrupt(t *testing.T) {
 	if err := pushCmd.Start(); err != nil {
 		t.Fatalf("Failed to start pushing to private registry: %v", err)
 	}
-
-	logDone("push - interrupted")
 }
 
 func TestPushEmptyLayer(t *testing.T) {
@@ -154,5 +147,4 @@ func TestPushEmptyLayer(t *testing.T) {
 	if out, _, err := runCommandWithOutput(pushCmd); err != nil {
 		t.Fatalf("pushing the image to the private registry has failed: %s, %v", out, err)
 	}
-	logDone("push - empty layer config to private registry")
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRenameStoppedContainer(t *testing.T) {
@@ -33,12 +35,10 @@ func TestRenameStoppedContainer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if name != "new_name" {
+	if name != "/new_name" {
 		t.Fatal("Failed to rename container ", name)
 	}
 	deleteAllContainers()
-
-	logDone("rename - stopped container")
 }
 
 func TestRenameRunningContainer(t *testing.T) {
@@ -59,12 +59,10 @@ func TestRenameRunningContainer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if name != "new_name" {
+	if name != "/new_name" {
 		t.Fatal("Failed to rename container ")
 	}
 	deleteAllContainers()
-
-	logDone("rename - running container")
 }
 
 func TestRenameCheckNames(t *testing.T) {
@@ -84,7 +82,7 @@ func TestRenameCheckNames(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if name != "new_name" {
+	if name != "/new_name" {
 		t.Fatal("Failed to rename container ")
 	}
 
@@ -94,8 +92,6 @@ func TestRenameCheckNames(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rename - running container")
 }
 
 func TestRenameInvalidName(t *testing.T) {
@@ -114,6 +110,32 @@ func TestRenameInvalidName(t *testing.T) {
 	if out, _, err := runCommandWithOutput(runCmd); err != nil || !strings.Contains(out, "myname") {
 		t.Fatalf("Output of docker ps should have included 'myname': %s\n%v", out, err)
 	}
+}
 
-	logDone("rename - invalid container name")
+func TestRenameLinkedContainer(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "web", "--link", "db:database", "busybox", "top")
+	since := time.Now().Unix()
+	dockerCmd(t, "rename", "db", "newdb")
+
+	out, _, _ := dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if !strings.Contains(out, "\tdatabase newdb\n") {
+		t.Fatalf("Expected the new name in /etc/hosts of web:\n%s", out)
+	}
+	dockerCmd(t, "exec", "web", "ping", "-c", "1", "-W", "1", "newdb")
+
+	links, err := inspectField("web", "HostConfig.Links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links != "[/newdb:/web/database]" {
+		t.Fatalf("Expected the new name in the links of web, got %s", links)
+	}
+
+	out, _, _ = dockerCmd(t, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", time.Now().Unix()+1))
+	if !strings.Contains(out, " rename\n") {
+		t.Fatalf("Expected a rename event:\n%s", out)
+	}
 }
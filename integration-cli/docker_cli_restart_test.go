@@ -47,8 +47,6 @@ func TestRestartStoppedContainer(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("restart - echo foobar for stopped container")
 }
 
 func TestRestartRunningContainer(t *testing.T) {
@@ -90,8 +88,6 @@ func TestRestartRunningContainer(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("restart - echo foobar for running container")
 }
 
 // Test that restarting a container with a volume does not create a new volume on restart. Regression test for #819.
@@ -148,8 +144,6 @@ func TestRestartWithVolumes(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("restart - does not create a new volume on restart")
 }
 
 func TestRestartPolicyNO(t *testing.T) {
@@ -169,8 +163,6 @@ func TestRestartPolicyNO(t *testing.T) {
 	if name != "no" {
 		t.Fatalf("Container restart policy name is %s, expected %s", name, "no")
 	}
-
-	logDone("restart - recording restart policy name for --restart=no")
 }
 
 func TestRestartPolicyAlways(t *testing.T) {
@@ -190,8 +182,6 @@ func TestRestartPolicyAlways(t *testing.T) {
 	if name != "always" {
 		t.Fatalf("Container restart policy name is %s, expected %s", name, "always")
 	}
-
-	logDone("restart - recording restart policy name for --restart=always")
 }
 
 func TestRestartPolicyOnFailure(t *testing.T) {
@@ -211,6 +201,48 @@ func TestRestartPolicyOnFailure(t *testing.T) {
 	if name != "on-failure" {
 		t.Fatalf("Container restart policy name is %s, expected %s", name, "on-failure")
 	}
+}
+
+func TestRestartPolicyDelays(t *testing.T) {
+	defer deleteAllContainers()
 
-	logDone("restart - recording restart policy name for --restart=on-failure")
+	cmd := exec.Command(dockerBinary, "run", "-d", "--restart=always", "--restart-delay=2s", "--restart-max-delay=1m", "busybox", "false")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+
+	id := strings.TrimSpace(string(out))
+	for field, expected := range map[string]string{
+		"HostConfig.RestartPolicy.Delay":      "2000",
+		"HostConfig.RestartPolicy.MaxDelay":   "60000",
+		"HostConfig.RestartPolicy.Multiplier": "2",
+	} {
+		value, err := inspectField(id, field)
+		if err != nil {
+			t.Fatal(err, out)
+		}
+		if value != expected {
+			t.Fatalf("Container %s is %s, expected %s", field, value, expected)
+		}
+	}
+}
+
+func TestRestartPolicyStabilizeDelay(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "--restart=on-failure:1", "--restart-stabilize-delay=1s", "busybox", "sh", "-c", "exit 3")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(string(out))
+
+	delay, err := inspectField(id, "HostConfig.RestartPolicy.StabilizeDelay")
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if delay != "1000" {
+		t.Fatalf("Container restart stabilize delay is %s, expected 1000", delay)
+	}
 }
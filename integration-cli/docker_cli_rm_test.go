@@ -23,8 +23,6 @@ func TestRmContainerWithRemovedVolume(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rm - removed volume")
 }
 
 func TestRmContainerWithVolume(t *testing.T) {
@@ -39,8 +37,6 @@ func TestRmContainerWithVolume(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rm - volume")
 }
 
 func TestRmRunningContainer(t *testing.T) {
@@ -53,8 +49,6 @@ func TestRmRunningContainer(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rm - running container")
 }
 
 func TestRmRunningContainerCheckError409(t *testing.T) {
@@ -71,8 +65,6 @@ func TestRmRunningContainerCheckError409(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rm - running container")
 }
 
 func TestRmForceRemoveRunningContainer(t *testing.T) {
@@ -85,8 +77,6 @@ func TestRmForceRemoveRunningContainer(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rm - running container with --force=true")
 }
 
 func TestRmContainerOrphaning(t *testing.T) {
@@ -125,8 +115,6 @@ func TestRmContainerOrphaning(t *testing.T) {
 
 	deleteAllContainers()
 	deleteImages(img1)
-
-	logDone("rm - container orphaning")
 }
 
 func TestRmInvalidContainer(t *testing.T) {
@@ -135,8 +123,6 @@ func TestRmInvalidContainer(t *testing.T) {
 	} else if !strings.Contains(out, "failed to remove one or more containers") {
 		t.Fatalf("Expected output to contain 'failed to remove one or more containers', got %q", out)
 	}
-
-	logDone("rm - delete unknown container")
 }
 
 func createRunningContainer(t *testing.T, name string) {
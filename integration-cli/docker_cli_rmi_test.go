@@ -35,8 +35,6 @@ func TestRmiWithContainerFails(t *testing.T) {
 	}
 
 	deleteContainer(cleanedContainerID)
-
-	logDone("rmi- container using image while rmi, should not remove image name")
 }
 
 func TestRmiTag(t *testing.T) {
@@ -74,7 +72,6 @@ func TestRmiTag(t *testing.T) {
 		}
 
 	}
-	logDone("rmi - tag,rmi- tagging the same images multiple times then removing tags")
 }
 
 func TestRmiTagWithExistingContainers(t *testing.T) {
@@ -96,8 +93,6 @@ func TestRmiTagWithExistingContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rmi - delete tag with existing containers")
 }
 
 func TestRmiForceWithExistingContainers(t *testing.T) {
@@ -116,6 +111,4 @@ func TestRmiForceWithExistingContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("rmi - force delete with existing containers")
 }
@@ -36,8 +36,6 @@ func TestRunEchoStdout(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - echo test123")
 }
 
 // "test" should be printed
@@ -56,8 +54,6 @@ func TestRunEchoStdoutWithMemoryLimit(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - echo with memory limit")
 }
 
 // "test" should be printed
@@ -73,8 +69,6 @@ func TestRunEchoStdoutWitCPULimit(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - echo with CPU limit")
 }
 
 // "test" should be printed
@@ -90,8 +84,6 @@ func TestRunEchoStdoutWithCPUAndMemoryLimit(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - echo with CPU and memory limit")
 }
 
 // "test" should be printed
@@ -111,8 +103,6 @@ func TestRunEchoNamedContainer(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - echo with named container")
 }
 
 // docker run should not leak file descriptors
@@ -129,8 +119,6 @@ func TestRunLeakyFileDescriptors(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - check file descriptor leakage")
 }
 
 // it should be possible to ping Google DNS resolver
@@ -143,8 +131,6 @@ func TestRunPingGoogle(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - ping 8.8.8.8")
 }
 
 // the exit code should be 0
@@ -156,8 +142,6 @@ func TestRunExitCodeZero(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - exit with 0")
 }
 
 // the exit code should be 1
@@ -173,8 +157,6 @@ func TestRunExitCodeOne(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - exit with 1")
 }
 
 // it should be possible to pipe in data via stdin to a process running in a container
@@ -216,8 +198,6 @@ func TestRunStdinPipe(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - pipe in with -i -a stdin")
 }
 
 // the container's ID should be printed when starting a container in detached mode
@@ -252,8 +232,6 @@ func TestRunDetachedContainerIDPrinting(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - print container ID in detached mode")
 }
 
 // the working directory should be set correctly
@@ -283,9 +261,6 @@ func TestRunWorkingDirectory(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - run with working directory set by -w")
-	logDone("run - run with working directory set by --workdir")
 }
 
 // pinging Google's DNS resolver should fail when we disable the networking
@@ -309,9 +284,6 @@ func TestRunWithoutNetworking(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - disable networking with --net=none")
-	logDone("run - disable networking with -n=false")
 }
 
 //test --link use container name to link target
@@ -336,8 +308,6 @@ func TestRunLinksContainerWithContainerName(t *testing.T) {
 		t.Fatalf("use a container name to link target failed")
 	}
 	deleteAllContainers()
-
-	logDone("run - use a container name to link target work")
 }
 
 //test --link use container id to link target
@@ -364,8 +334,6 @@ func TestRunLinksContainerWithContainerId(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - use a container id to link target work")
 }
 
 // Regression test for #4741
@@ -382,8 +350,6 @@ func TestRunWithVolumesAsFiles(t *testing.T) {
 		t.Fatal("2", out, stderr, err)
 	}
 	deleteAllContainers()
-
-	logDone("run - regression test for #4741 - volumes from as files")
 }
 
 // Regression test for #4979
@@ -400,8 +366,6 @@ func TestRunWithVolumesFromExited(t *testing.T) {
 		t.Fatal("2", out, stderr, err)
 	}
 	deleteAllContainers()
-
-	logDone("run - regression test for #4979 - volumes-from on exited container")
 }
 
 // Regression test for #4830
@@ -412,8 +376,6 @@ func TestRunWithRelativePath(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - volume with relative path")
 }
 
 func TestRunVolumesMountedAsReadonly(t *testing.T) {
@@ -423,8 +385,6 @@ func TestRunVolumesMountedAsReadonly(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - volumes as readonly mount")
 }
 
 func TestRunVolumesFromInReadonlyMode(t *testing.T) {
@@ -438,8 +398,6 @@ func TestRunVolumesFromInReadonlyMode(t *testing.T) {
 	if code, err := runCommand(cmd); err == nil || code == 0 {
 		t.Fatalf("run should fail because volume is ro: exit code %d", code)
 	}
-
-	logDone("run - volumes from as readonly mount")
 }
 
 // Regression test for #1201
@@ -464,8 +422,6 @@ func TestRunVolumesFromInReadWriteMode(t *testing.T) {
 	if out, _, err := runCommandWithOutput(cmd); err != nil {
 		t.Fatalf("running --volumes-from parent failed with output: %q\nerror: %v", out, err)
 	}
-
-	logDone("run - volumes from as read write mount")
 }
 
 func TestVolumesFromGetsProperMode(t *testing.T) {
@@ -489,8 +445,6 @@ func TestVolumesFromGetsProperMode(t *testing.T) {
 	if _, err := runCommand(cmd); err == nil {
 		t.Fatal("Expected volumes-from to inherit read-only volume even when passing in `ro`")
 	}
-
-	logDone("run - volumes from ignores `rw` if inherrited volume is `ro`")
 }
 
 // Test for #1351
@@ -506,8 +460,6 @@ func TestRunApplyVolumesFromBeforeVolumes(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - volumes from mounted first")
 }
 
 func TestRunMultipleVolumesFrom(t *testing.T) {
@@ -528,8 +480,6 @@ func TestRunMultipleVolumesFrom(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - multiple volumes from")
 }
 
 // this tests verifies the ID format for the container
@@ -551,8 +501,6 @@ func TestRunVerifyContainerID(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - verify container ID")
 }
 
 // Test that creating a container with a volume doesn't crash. Regression test for #995.
@@ -563,8 +511,6 @@ func TestRunCreateVolume(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - create docker managed volume")
 }
 
 // Test that creating a volume with a symlink in its path works correctly. Test for #5152.
@@ -606,8 +552,6 @@ func TestRunCreateVolumeWithSymlink(t *testing.T) {
 
 	deleteImages("docker-test-createvolumewithsymlink")
 	deleteAllContainers()
-
-	logDone("run - create volume with symlink")
 }
 
 // Tests that a volume path that has a symlink exists in a container mounting it with `--volumes-from`.
@@ -637,8 +581,6 @@ func TestRunVolumesFromSymlinkPath(t *testing.T) {
 
 	deleteAllContainers()
 	deleteImages(name)
-
-	logDone("run - volumes-from symlink path")
 }
 
 func TestRunExitCode(t *testing.T) {
@@ -653,8 +595,6 @@ func TestRunExitCode(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - correct exit code")
 }
 
 func TestRunUserDefaultsToRoot(t *testing.T) {
@@ -668,8 +608,6 @@ func TestRunUserDefaultsToRoot(t *testing.T) {
 		t.Fatalf("expected root user got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - default user")
 }
 
 func TestRunUserByName(t *testing.T) {
@@ -683,8 +621,6 @@ func TestRunUserByName(t *testing.T) {
 		t.Fatalf("expected root user got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - user by name")
 }
 
 func TestRunUserByID(t *testing.T) {
@@ -698,8 +634,6 @@ func TestRunUserByID(t *testing.T) {
 		t.Fatalf("expected daemon user got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - user by id")
 }
 
 func TestRunUserByIDBig(t *testing.T) {
@@ -713,8 +647,6 @@ func TestRunUserByIDBig(t *testing.T) {
 		t.Fatalf("expected error about uids range, got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - user by id, id too big")
 }
 
 func TestRunUserByIDNegative(t *testing.T) {
@@ -728,8 +660,6 @@ func TestRunUserByIDNegative(t *testing.T) {
 		t.Fatalf("expected error about uids range, got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - user by id, id negative")
 }
 
 func TestRunUserByIDZero(t *testing.T) {
@@ -743,8 +673,6 @@ func TestRunUserByIDZero(t *testing.T) {
 		t.Fatalf("expected daemon user got %s", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - user by id, zero uid")
 }
 
 func TestRunUserNotFound(t *testing.T) {
@@ -755,8 +683,6 @@ func TestRunUserNotFound(t *testing.T) {
 		t.Fatal("unknown user should cause container to fail")
 	}
 	deleteAllContainers()
-
-	logDone("run - user not found")
 }
 
 func TestRunTwoConcurrentContainers(t *testing.T) {
@@ -776,8 +702,6 @@ func TestRunTwoConcurrentContainers(t *testing.T) {
 	group.Wait()
 
 	deleteAllContainers()
-
-	logDone("run - two concurrent containers")
 }
 
 func TestRunEnvironment(t *testing.T) {
@@ -816,8 +740,6 @@ func TestRunEnvironment(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - verify environment")
 }
 
 func TestRunEnvironmentErase(t *testing.T) {
@@ -849,8 +771,6 @@ func TestRunEnvironmentErase(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - verify environment erase")
 }
 
 func TestRunEnvironmentOverride(t *testing.T) {
@@ -882,8 +802,6 @@ func TestRunEnvironmentOverride(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - verify environment override")
 }
 
 func TestRunContainerNetwork(t *testing.T) {
@@ -893,8 +811,6 @@ func TestRunContainerNetwork(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - test container network via ping")
 }
 
 // Issue #4681
@@ -905,8 +821,6 @@ func TestRunLoopbackWhenNetworkDisabled(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - test container loopback when networking disabled")
 }
 
 func TestRunNetHostNotAllowedWithLinks(t *testing.T) {
@@ -919,8 +833,6 @@ func TestRunNetHostNotAllowedWithLinks(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - don't allow --net=host to be used with links")
 }
 
 func TestRunLoopbackOnlyExistsWhenNetworkingDisabled(t *testing.T) {
@@ -950,8 +862,6 @@ func TestRunLoopbackOnlyExistsWhenNetworkingDisabled(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - test loopback only exists when networking disabled")
 }
 
 // #7851 hostname outside container shows FQDN, inside only shortname
@@ -970,8 +880,6 @@ func TestRunFullHostnameSet(t *testing.T) {
 		t.Fatalf("expected hostname 'foo.bar.baz', received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test fully qualified hostname set with -h")
 }
 
 func TestRunPrivilegedCanMknod(t *testing.T) {
@@ -985,8 +893,6 @@ func TestRunPrivilegedCanMknod(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test privileged can mknod")
 }
 
 func TestRunUnPrivilegedCanMknod(t *testing.T) {
@@ -1000,8 +906,6 @@ func TestRunUnPrivilegedCanMknod(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test un-privileged can mknod")
 }
 
 func TestRunCapDropInvalid(t *testing.T) {
@@ -1011,8 +915,6 @@ func TestRunCapDropInvalid(t *testing.T) {
 	if err == nil {
 		t.Fatal(err, out)
 	}
-
-	logDone("run - test --cap-drop=CHPASS invalid")
 }
 
 func TestRunCapDropCannotMknod(t *testing.T) {
@@ -1026,8 +928,6 @@ func TestRunCapDropCannotMknod(t *testing.T) {
 		t.Fatalf("expected output not ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-drop=MKNOD cannot mknod")
 }
 
 func TestRunCapDropCannotMknodLowerCase(t *testing.T) {
@@ -1041,8 +941,6 @@ func TestRunCapDropCannotMknodLowerCase(t *testing.T) {
 		t.Fatalf("expected output not ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-drop=mknod cannot mknod lowercase")
 }
 
 func TestRunCapDropALLCannotMknod(t *testing.T) {
@@ -1056,8 +954,6 @@ func TestRunCapDropALLCannotMknod(t *testing.T) {
 		t.Fatalf("expected output not ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-drop=ALL cannot mknod")
 }
 
 func TestRunCapDropALLAddMknodCanMknod(t *testing.T) {
@@ -1071,8 +967,6 @@ func TestRunCapDropALLAddMknodCanMknod(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-drop=ALL --cap-add=MKNOD can mknod")
 }
 
 func TestRunCapAddInvalid(t *testing.T) {
@@ -1083,8 +977,6 @@ func TestRunCapAddInvalid(t *testing.T) {
 	if err == nil {
 		t.Fatal(err, out)
 	}
-
-	logDone("run - test --cap-add=CHPASS invalid")
 }
 
 func TestRunCapAddCanDownInterface(t *testing.T) {
@@ -1098,8 +990,6 @@ func TestRunCapAddCanDownInterface(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-add=NET_ADMIN can set eth0 down")
 }
 
 func TestRunCapAddALLCanDownInterface(t *testing.T) {
@@ -1113,8 +1003,6 @@ func TestRunCapAddALLCanDownInterface(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-add=ALL can set eth0 down")
 }
 
 func TestRunCapAddALLDropNetAdminCanDownInterface(t *testing.T) {
@@ -1128,8 +1016,6 @@ func TestRunCapAddALLDropNetAdminCanDownInterface(t *testing.T) {
 		t.Fatalf("expected output not ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --cap-add=ALL --cap-drop=NET_ADMIN cannot set eth0 down")
 }
 
 func TestRunPrivilegedCanMount(t *testing.T) {
@@ -1144,8 +1030,6 @@ func TestRunPrivilegedCanMount(t *testing.T) {
 		t.Fatalf("expected output ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test privileged can mount")
 }
 
 func TestRunUnPrivilegedCannotMount(t *testing.T) {
@@ -1160,8 +1044,6 @@ func TestRunUnPrivilegedCannotMount(t *testing.T) {
 		t.Fatalf("expected output not ok received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test un-privileged cannot mount")
 }
 
 func TestRunSysNotWritableInNonPrivilegedContainers(t *testing.T) {
@@ -1171,8 +1053,6 @@ func TestRunSysNotWritableInNonPrivilegedContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - sys not writable in non privileged container")
 }
 
 func TestRunSysWritableInPrivilegedContainers(t *testing.T) {
@@ -1182,8 +1062,6 @@ func TestRunSysWritableInPrivilegedContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - sys writable in privileged container")
 }
 
 func TestRunProcNotWritableInNonPrivilegedContainers(t *testing.T) {
@@ -1193,8 +1071,6 @@ func TestRunProcNotWritableInNonPrivilegedContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - proc not writable in non privileged container")
 }
 
 func TestRunProcWritableInPrivilegedContainers(t *testing.T) {
@@ -1204,8 +1080,6 @@ func TestRunProcWritableInPrivilegedContainers(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - proc writable in privileged container")
 }
 
 func TestRunWithCpuset(t *testing.T) {
@@ -1215,8 +1089,6 @@ func TestRunWithCpuset(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - cpuset 0")
 }
 
 func TestRunDeviceNumbers(t *testing.T) {
@@ -1236,8 +1108,6 @@ func TestRunDeviceNumbers(t *testing.T) {
 		t.Fatalf("expected output\ncrw-rw-rw- 1 root root 1, 3 May 24 13:29 /dev/null\n received\n %s\n", out)
 	}
 	deleteAllContainers()
-
-	logDone("run - test device numbers")
 }
 
 func TestRunThatCharacterDevicesActLikeCharacterDevices(t *testing.T) {
@@ -1252,8 +1122,6 @@ func TestRunThatCharacterDevicesActLikeCharacterDevices(t *testing.T) {
 		t.Fatalf("expected a new file called /zero to be create that is greater than 0 bytes long, but du says: %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test that character devices work.")
 }
 
 func TestRunUnprivilegedWithChroot(t *testing.T) {
@@ -1264,8 +1132,6 @@ func TestRunUnprivilegedWithChroot(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - unprivileged with chroot")
 }
 
 func TestRunAddingOptionalDevices(t *testing.T) {
@@ -1280,8 +1146,6 @@ func TestRunAddingOptionalDevices(t *testing.T) {
 		t.Fatalf("expected output /dev/nulo, received %s", actual)
 	}
 	deleteAllContainers()
-
-	logDone("run - test --device argument")
 }
 
 func TestRunModeHostname(t *testing.T) {
@@ -1311,8 +1175,6 @@ func TestRunModeHostname(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - hostname and several network modes")
 }
 
 func TestRunRootWorkdir(t *testing.T) {
@@ -1325,8 +1187,6 @@ func TestRunRootWorkdir(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - workdir /")
 }
 
 func TestRunAllowBindMountingRoot(t *testing.T) {
@@ -1336,8 +1196,6 @@ func TestRunAllowBindMountingRoot(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - bind mount / as volume")
 }
 
 func TestRunDisallowBindMountingRootToRoot(t *testing.T) {
@@ -1348,8 +1206,6 @@ func TestRunDisallowBindMountingRootToRoot(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - bind mount /:/ as volume should fail")
 }
 
 // Verify that a container gets default DNS when only localhost resolvers exist
@@ -1391,8 +1247,6 @@ func TestRunDnsDefaultOptions(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - dns default options")
 }
 
 func TestRunDnsOptions(t *testing.T) {
@@ -1419,8 +1273,6 @@ func TestRunDnsOptions(t *testing.T) {
 	if actual != "nameserver 127.0.0.1" {
 		t.Fatalf("expected 'nameserver 127.0.0.1', but says: %q", actual)
 	}
-
-	logDone("run - dns options")
 }
 
 func TestRunDnsOptionsBasedOnHostResolvConf(t *testing.T) {
@@ -1515,8 +1367,6 @@ func TestRunDnsOptionsBasedOnHostResolvConf(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - dns options based on host resolv.conf")
 }
 
 // Test the file watch notifier on docker host's /etc/resolv.conf
@@ -1667,7 +1517,6 @@ func TestRunResolvconfUpdater(t *testing.T) {
 	}
 
 	//cleanup, restore original resolv.conf happens in defer func()
-	logDone("run - resolv.conf updater")
 }
 
 func TestRunAddHost(t *testing.T) {
@@ -1683,8 +1532,6 @@ func TestRunAddHost(t *testing.T) {
 	if actual != "86.75.30.9\textra" {
 		t.Fatalf("expected '86.75.30.9\textra', but says: %q", actual)
 	}
-
-	logDone("run - add-host option")
 }
 
 // Regression test for #6983
@@ -1699,8 +1546,6 @@ func TestRunAttachStdErrOnlyTTYMode(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - Attach stderr only with -t")
 }
 
 // Regression test for #6983
@@ -1715,8 +1560,6 @@ func TestRunAttachStdOutOnlyTTYMode(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - Attach stdout only with -t")
 }
 
 // Regression test for #6983
@@ -1731,8 +1574,6 @@ func TestRunAttachStdOutAndErrTTYMode(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - Attach stderr and stdout with -t")
 }
 
 func TestRunState(t *testing.T) {
@@ -1798,7 +1639,6 @@ func TestRunState(t *testing.T) {
 	if pid3 == pid1 {
 		t.Fatalf("Container state Pid %s, but expected %s", pid2, pid1)
 	}
-	logDone("run - test container state.")
 }
 
 // Test for #1737
@@ -1826,8 +1666,6 @@ func TestRunCopyVolumeUidGid(t *testing.T) {
 	if out != "dockerio:dockerio" {
 		t.Fatalf("Wrong /hello ownership: %s, expected dockerio:dockerio", out)
 	}
-
-	logDone("run - copy uid/gid for volume")
 }
 
 // Test for #1582
@@ -1852,7 +1690,6 @@ func TestRunCopyVolumeContent(t *testing.T) {
 	if !(strings.Contains(out, "/hello/local/world") && strings.Contains(out, "/hello/local")) {
 		t.Fatal("Container failed to transfer content to volume")
 	}
-	logDone("run - copy volume content")
 }
 
 func TestRunCleanupCmdOnEntrypoint(t *testing.T) {
@@ -1878,7 +1715,6 @@ func TestRunCleanupCmdOnEntrypoint(t *testing.T) {
 	if out != "root" {
 		t.Fatalf("Expected output root, got %q", out)
 	}
-	logDone("run - cleanup cmd on --entrypoint")
 }
 
 // TestRunWorkdirExistsAndIsFile checks that if 'docker run -w' with existing file can be detected
@@ -1889,7 +1725,6 @@ func TestRunWorkdirExistsAndIsFile(t *testing.T) {
 	if !(err != nil && exit == 1 && strings.Contains(out, "Cannot mkdir: /bin/cat is not a directory")) {
 		t.Fatalf("Docker must complains about making dir, but we got out: %s, exit: %d, err: %s", out, exit, err)
 	}
-	logDone("run - error on existing file for workdir")
 }
 
 func TestRunExitOnStdinClose(t *testing.T) {
@@ -1944,7 +1779,6 @@ func TestRunExitOnStdinClose(t *testing.T) {
 	if state != "false" {
 		t.Fatal("Container must be stopped after stdin closing")
 	}
-	logDone("run - exit on stdin closing")
 }
 
 // Test for #2267
@@ -1972,8 +1806,6 @@ func TestRunWriteHostsFileAndNotCommit(t *testing.T) {
 	if len(strings.Trim(out, "\r\n")) != 0 {
 		t.Fatal("diff should be empty")
 	}
-
-	logDone("run - write to /etc/hosts and not commited")
 }
 
 // Test for #2267
@@ -2001,8 +1833,6 @@ func TestRunWriteHostnameFileAndNotCommit(t *testing.T) {
 	if len(strings.Trim(out, "\r\n")) != 0 {
 		t.Fatal("diff should be empty")
 	}
-
-	logDone("run - write to /etc/hostname and not commited")
 }
 
 // Test for #2267
@@ -2030,8 +1860,6 @@ func TestRunWriteResolvFileAndNotCommit(t *testing.T) {
 	if len(strings.Trim(out, "\r\n")) != 0 {
 		t.Fatal("diff should be empty")
 	}
-
-	logDone("run - write to /etc/resolv.conf and not commited")
 }
 
 func TestRunWithBadDevice(t *testing.T) {
@@ -2047,7 +1875,6 @@ func TestRunWithBadDevice(t *testing.T) {
 	if !strings.Contains(out, expected) {
 		t.Fatalf("Output should contain %q, actual out: %q", expected, out)
 	}
-	logDone("run - error with bad device")
 }
 
 func TestRunEntrypoint(t *testing.T) {
@@ -2063,10 +1890,10 @@ func TestRunEntrypoint(t *testing.T) {
 	if out != expected {
 		t.Fatalf("Output should be %q, actual out: %q", expected, out)
 	}
-	logDone("run - entrypoint")
 }
 
 func TestRunBindMounts(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	defer deleteAllContainers()
 
 	tmpDir, err := ioutil.TempDir("", "docker-test-container")
@@ -2113,8 +1940,6 @@ func TestRunBindMounts(t *testing.T) {
 	if content != expected {
 		t.Fatalf("Output should be %q, actual out: %q", expected, content)
 	}
-
-	logDone("run - bind mounts")
 }
 
 func TestRunMutableNetworkFiles(t *testing.T) {
@@ -2170,7 +1995,6 @@ func TestRunMutableNetworkFiles(t *testing.T) {
 			t.Fatalf("Expected content of %s: %q, got: %q", fn, "success2\n", res)
 		}
 	}
-	logDone("run - mutable network files")
 }
 
 // Ensure that CIDFile gets deleted if it's empty
@@ -2193,7 +2017,6 @@ func TestRunCidFileCleanupIfEmpty(t *testing.T) {
 		t.Fatalf("empty CIDFile %q should've been deleted", tmpCidFile)
 	}
 	deleteAllContainers()
-	logDone("run - cleanup empty cidfile on fail")
 }
 
 // #2098 - Docker cidFiles only contain short version of the containerId
@@ -2224,7 +2047,6 @@ func TestRunCidFileCheckIDLength(t *testing.T) {
 		t.Fatalf("cid must be equal to %s, got %s", id, cid)
 	}
 	deleteAllContainers()
-	logDone("run - cidfile contains long id")
 }
 
 func TestRunNetworkNotInitializedNoneMode(t *testing.T) {
@@ -2242,7 +2064,6 @@ func TestRunNetworkNotInitializedNoneMode(t *testing.T) {
 		t.Fatalf("For 'none' mode network must not be initialized, but container got IP: %s", res)
 	}
 	deleteAllContainers()
-	logDone("run - network must not be initialized in 'none' mode")
 }
 
 func TestRunSetMacAddress(t *testing.T) {
@@ -2258,7 +2079,6 @@ func TestRunSetMacAddress(t *testing.T) {
 	}
 
 	deleteAllContainers()
-	logDone("run - setting MAC address with --mac-address")
 }
 
 func TestRunInspectMacAddress(t *testing.T) {
@@ -2277,7 +2097,25 @@ func TestRunInspectMacAddress(t *testing.T) {
 		t.Fatalf("docker inspect outputs wrong MAC address: %q, should be: %q", inspectedMac, mac)
 	}
 	deleteAllContainers()
-	logDone("run - inspecting MAC address")
+}
+
+func TestRunMacAddressInUse(t *testing.T) {
+	defer deleteAllContainers()
+	mac := "12:34:56:78:9a:bc"
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "--mac-address="+mac, "busybox", "top")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--mac-address="+mac, "busybox", "true")
+	out, _, err := runCommandWithOutput(cmd)
+	if err == nil {
+		t.Fatal("Expected the container with a MAC address in use to fail to start")
+	}
+	if !strings.Contains(out, "is already used by container") {
+		t.Fatalf("Expected the MAC address collision in the output: %s", out)
+	}
 }
 
 func TestRunDeallocatePortOnMissingIptablesRule(t *testing.T) {
@@ -2306,7 +2144,6 @@ func TestRunDeallocatePortOnMissingIptablesRule(t *testing.T) {
 		t.Fatal(err, out)
 	}
 	deleteAllContainers()
-	logDone("run - port should be deallocated even on iptables error")
 }
 
 func TestRunPortInUse(t *testing.T) {
@@ -2326,7 +2163,6 @@ func TestRunPortInUse(t *testing.T) {
 	}
 
 	deleteAllContainers()
-	logDone("run - fail if port already in use")
 }
 
 // https://github.com/docker/docker/issues/8428
@@ -2358,12 +2194,11 @@ func TestRunPortProxy(t *testing.T) {
 	if !strings.Contains(out, "docker-proxy -proto tcp -host-ip 0.0.0.0 -host-port 12345") {
 		t.Errorf("Failed to find docker-proxy process, got %s", out)
 	}
-
-	logDone("run - proxy should work with unavailable port")
 }
 
 // Regression test for #7792
 func TestRunMountOrdering(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	tmpDir, err := ioutil.TempDir("", "docker_nested_mount_test")
 	if err != nil {
 		t.Fatal(err)
@@ -2401,10 +2236,10 @@ func TestRunMountOrdering(t *testing.T) {
 	}
 
 	deleteAllContainers()
-	logDone("run - volumes are mounted in the correct order")
 }
 
 func TestRunExecDir(t *testing.T) {
+	testRequires(t, SameHostDaemon, NativeExecDriver)
 	cmd := exec.Command(dockerBinary, "run", "-d", "busybox", "top")
 	out, _, err := runCommandWithOutput(cmd)
 	if err != nil {
@@ -2500,12 +2335,11 @@ func TestRunExecDir(t *testing.T) {
 			t.Fatalf("Error should be about non-existing, got %s", err)
 		}
 	}
-
-	logDone("run - check execdriver dir behavior")
 }
 
 // Regression test for https://github.com/docker/docker/issues/8259
 func TestRunReuseBindVolumeThatIsSymlink(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	tmpDir, err := ioutil.TempDir(os.TempDir(), "testlink")
 	if err != nil {
 		t.Fatal(err)
@@ -2532,10 +2366,10 @@ func TestRunReuseBindVolumeThatIsSymlink(t *testing.T) {
 	}
 
 	deleteAllContainers()
-	logDone("run - can remount old bindmount volume")
 }
 
 func TestVolumesNoCopyData(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	defer deleteImages("dataimage")
 	defer deleteAllContainers()
 	if _, err := buildImage("dataimage",
@@ -2567,11 +2401,10 @@ func TestVolumesNoCopyData(t *testing.T) {
 	if out, _, err := runCommandWithOutput(cmd); err == nil || !strings.Contains(out, "No such file or directory") {
 		t.Fatalf("Data was copied on bind-mount but shouldn't be:\n%q", out)
 	}
-
-	logDone("run - volumes do not copy data for volumes-from and bindmounts")
 }
 
 func TestRunVolumesNotRecreatedOnStart(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	// Clear out any remnants from other tests
 	deleteAllContainers()
 	info, err := ioutil.ReadDir(volumesConfigPath)
@@ -2604,8 +2437,6 @@ func TestRunVolumesNotRecreatedOnStart(t *testing.T) {
 	if len(info) != 1 {
 		t.Fatalf("Expected only 1 volume have %v", len(info))
 	}
-
-	logDone("run - volumes not recreated on start")
 }
 
 func TestRunNoOutputFromPullInStdout(t *testing.T) {
@@ -2620,7 +2451,6 @@ func TestRunNoOutputFromPullInStdout(t *testing.T) {
 	if stdout.Len() != 0 {
 		t.Fatalf("Stdout contains output from pull: %s", stdout)
 	}
-	logDone("run - no output from pull in stdout")
 }
 
 func TestRunVolumesCleanPaths(t *testing.T) {
@@ -2668,8 +2498,6 @@ func TestRunVolumesCleanPaths(t *testing.T) {
 	if !strings.Contains(out, volumesStoragePath) {
 		t.Fatalf("Volume was not defined for /bar\n%q", out)
 	}
-
-	logDone("run - volume paths are cleaned")
 }
 
 // Regression test for #3631
@@ -2695,8 +2523,6 @@ func TestRunSlowStdoutConsumer(t *testing.T) {
 	if n != expected {
 		t.Fatalf("Expected %d, got %d", expected, n)
 	}
-
-	logDone("run - slow consumer")
 }
 
 func TestRunAllowPortRangeThroughExpose(t *testing.T) {
@@ -2724,7 +2550,6 @@ func TestRunAllowPortRangeThroughExpose(t *testing.T) {
 	if err := deleteContainer(id); err != nil {
 		t.Fatal(err)
 	}
-	logDone("run - allow port range through --expose flag")
 }
 
 func TestRunUnknownCommand(t *testing.T) {
@@ -2753,8 +2578,6 @@ func TestRunUnknownCommand(t *testing.T) {
 	if rc != "-1" {
 		t.Fatalf("ExitCode(%v) was supposed to be -1", rc)
 	}
-
-	logDone("run - Unknown Command")
 }
 
 func TestRunModeIpcHost(t *testing.T) {
@@ -2785,8 +2608,6 @@ func TestRunModeIpcHost(t *testing.T) {
 		t.Fatalf("IPC should be different without --ipc=host %s == %s\n", hostIpc, out2)
 	}
 	deleteAllContainers()
-
-	logDone("run - ipc host mode")
 }
 
 func TestRunModeIpcContainer(t *testing.T) {
@@ -2823,8 +2644,6 @@ func TestRunModeIpcContainer(t *testing.T) {
 		t.Fatalf("IPC different with --ipc=container:%s %s != %s\n", id, parentContainerIpc, out2)
 	}
 	deleteAllContainers()
-
-	logDone("run - ipc container mode")
 }
 
 func TestContainerNetworkMode(t *testing.T) {
@@ -2857,8 +2676,6 @@ func TestContainerNetworkMode(t *testing.T) {
 		t.Fatalf("NET different with --net=container:%s %s != %s\n", id, parentContainerNet, out2)
 	}
 	deleteAllContainers()
-
-	logDone("run - container shared network namespace")
 }
 
 func TestRunModePidHost(t *testing.T) {
@@ -2889,8 +2706,6 @@ func TestRunModePidHost(t *testing.T) {
 		t.Fatalf("PID should be different without --pid=host %s == %s\n", hostPid, out2)
 	}
 	deleteAllContainers()
-
-	logDone("run - pid host mode")
 }
 
 func TestRunTLSverify(t *testing.T) {
@@ -2914,8 +2729,6 @@ func TestRunTLSverify(t *testing.T) {
 	if err == nil || ec == 0 || !strings.Contains(out, "cert") {
 		t.Fatalf("Should have failed: \nec:%v\nout:%v\nerr:%v", ec, out, err)
 	}
-
-	logDone("run - verify tls is set for --tlsverify")
 }
 
 func TestRunPortFromDockerRangeInUse(t *testing.T) {
@@ -2955,8 +2768,6 @@ func TestRunPortFromDockerRangeInUse(t *testing.T) {
 	if err != nil {
 		t.Fatal(out, err)
 	}
-
-	logDone("run - find another port if port from autorange already bound")
 }
 
 func TestRunTtyWithPipe(t *testing.T) {
@@ -2984,8 +2795,6 @@ func TestRunTtyWithPipe(t *testing.T) {
 	case <-time.After(3 * time.Second):
 		t.Fatal("container is running but should have failed")
 	}
-
-	logDone("run - forbid piped stdin with tty")
 }
 
 func TestRunNonLocalMacAddress(t *testing.T) {
@@ -2996,8 +2805,6 @@ func TestRunNonLocalMacAddress(t *testing.T) {
 	if out, _, err := runCommandWithOutput(cmd); err != nil || !strings.Contains(out, addr) {
 		t.Fatalf("Output should have contained %q: %s, %v", addr, out, err)
 	}
-
-	logDone("run - use non-local mac-address")
 }
 
 func TestRunNetHost(t *testing.T) {
@@ -3028,8 +2835,6 @@ func TestRunNetHost(t *testing.T) {
 	if hostNet == out2 {
 		t.Fatalf("Net namespace should be different without --net=host %s == %s\n", hostNet, out2)
 	}
-
-	logDone("run - net host mode")
 }
 
 func TestRunAllowPortRangeThroughPublish(t *testing.T) {
@@ -3053,7 +2858,6 @@ func TestRunAllowPortRangeThroughPublish(t *testing.T) {
 			t.Fatal("Port is not mapped for the port "+port, out)
 		}
 	}
-	logDone("run - allow port range through --expose flag")
 }
 
 func TestRunOOMExitCode(t *testing.T) {
@@ -3075,8 +2879,6 @@ func TestRunOOMExitCode(t *testing.T) {
 	case <-time.After(3 * time.Second):
 		t.Fatal("Timeout waiting for container to die on OOM")
 	}
-
-	logDone("run - exit code on oom")
 }
 
 func TestRunRestartMaxRetries(t *testing.T) {
@@ -3096,7 +2898,6 @@ func TestRunRestartMaxRetries(t *testing.T) {
 	if count != "3" {
 		t.Fatalf("Container was restarted %s times, expected %d", count, 3)
 	}
-	logDone("run - test max-retries for --restart")
 }
 
 func TestRunContainerWithWritableRootfs(t *testing.T) {
@@ -3105,7 +2906,6 @@ func TestRunContainerWithWritableRootfs(t *testing.T) {
 	if err != nil {
 		t.Fatal(string(out), err)
 	}
-	logDone("run - writable rootfs")
 }
 
 func TestRunContainerWithReadonlyRootfs(t *testing.T) {
@@ -3118,7 +2918,6 @@ func TestRunContainerWithReadonlyRootfs(t *testing.T) {
 	if !strings.Contains(string(out), expected) {
 		t.Fatalf("expected output from failure to contain %s but contains %s", expected, out)
 	}
-	logDone("run - read only rootfs")
 }
 
 func TestRunVolumesFromRestartAfterRemoved(t *testing.T) {
@@ -3145,6 +2944,4 @@ func TestRunVolumesFromRestartAfterRemoved(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected container to restart successfully: %v\n%s", err, out)
 	}
-
-	logDone("run - can restart a volumes-from container after producer is removed")
 }
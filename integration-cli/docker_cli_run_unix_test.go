@@ -50,12 +50,11 @@ func TestRunRedirectStdout(t *testing.T) {
 
 	checkRedirect(dockerBinary + " run -i busybox cat /etc/passwd | grep -q root")
 	checkRedirect(dockerBinary + " run busybox cat /etc/passwd | grep -q root")
-
-	logDone("run - redirect stdout")
 }
 
 // Test recursive bind mount works by default
 func TestRunWithVolumesIsRecursive(t *testing.T) {
+	testRequires(t, SameHostDaemon)
 	tmpDir, err := ioutil.TempDir("", "docker_recursive_mount_test")
 	if err != nil {
 		t.Fatal(err)
@@ -88,6 +87,4 @@ func TestRunWithVolumesIsRecursive(t *testing.T) {
 	}
 
 	deleteAllContainers()
-
-	logDone("run - volumes are bind mounted recursively")
 }
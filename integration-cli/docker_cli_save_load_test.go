@@ -75,8 +75,6 @@ func TestSaveXzAndLoadRepoStdout(t *testing.T) {
 
 	deleteContainer(cleanedContainerID)
 	deleteImages(repoName)
-
-	logDone("load - save a repo with xz compression & load it using stdout")
 }
 
 // save a repo using xz+gz compression and try to load it using stdout
@@ -142,8 +140,6 @@ func TestSaveXzGzAndLoadRepoStdout(t *testing.T) {
 
 	deleteContainer(cleanedContainerID)
 	deleteImages(repoName)
-
-	logDone("load - save a repo with xz+gz compression & load it using stdout")
 }
 
 func TestSaveSingleTag(t *testing.T) {
@@ -171,8 +167,6 @@ func TestSaveSingleTag(t *testing.T) {
 	}
 
 	deleteImages(repoName)
-
-	logDone("save - save a specific image:tag")
 }
 
 func TestSaveImageId(t *testing.T) {
@@ -209,8 +203,6 @@ func TestSaveImageId(t *testing.T) {
 	}
 
 	deleteImages(repoName)
-
-	logDone("save - save a image by ID")
 }
 
 // save a repo and try to load it using flags
@@ -270,8 +262,6 @@ func TestSaveAndLoadRepoFlags(t *testing.T) {
 	deleteImages(repoName)
 
 	os.Remove("/tmp/foobar-save-load-test.tar")
-
-	logDone("save - save a repo using -o && load a repo using -i")
 }
 
 func TestSaveMultipleNames(t *testing.T) {
@@ -300,8 +290,6 @@ func TestSaveMultipleNames(t *testing.T) {
 	}
 
 	deleteImages(repoName)
-
-	logDone("save - save by multiple names")
 }
 
 func TestSaveRepoWithMultipleImages(t *testing.T) {
@@ -360,8 +348,6 @@ func TestSaveRepoWithMultipleImages(t *testing.T) {
 	if !reflect.DeepEqual(expected, actual) {
 		t.Fatalf("achive does not contains the right layers: got %v, expected %v", actual, expected)
 	}
-
-	logDone("save - save repository with multiple images")
 }
 
 // Issue #6722 #5892 ensure directories are included in changes
@@ -424,6 +410,4 @@ func TestSaveDirectoryPermissions(t *testing.T) {
 	if !found {
 		t.Fatalf("failed to find the layer with the right content listing")
 	}
-
-	logDone("save - ensure directories exist in exported layers")
 }
@@ -70,7 +70,6 @@ func TestSaveAndLoadRepoStdout(t *testing.T) {
 
 	os.Remove("/tmp/foobar-save-load-test.tar")
 
-	logDone("save - save/load a repo using stdout")
 
 	pty, tty, err := pty.Open()
 	if err != nil {
@@ -97,6 +96,4 @@ func TestSaveAndLoadRepoStdout(t *testing.T) {
 	if !bytes.Contains(buf[:n], []byte("Cowardly refusing")) {
 		t.Fatal("help output is not being yielded", out)
 	}
-
-	logDone("save - do not save to a tty")
 }
@@ -17,6 +17,4 @@ func TestSearchOnCentralRegistry(t *testing.T) {
 	if !strings.Contains(out, "Busybox base image.") {
 		t.Fatal("couldn't find any repository named (or containing) 'Busybox base image.'")
 	}
-
-	logDone("search - search for repositories named (or containing) 'Busybox base image.'")
 }
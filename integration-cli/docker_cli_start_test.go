@@ -35,8 +35,6 @@ func TestStartAttachReturnsOnError(t *testing.T) {
 	case <-time.After(time.Second):
 		t.Fatalf("Attach did not exit properly")
 	}
-
-	logDone("start - error on start with attach exits")
 }
 
 // gh#8555: Exit code should be passed through when using start -a
@@ -65,8 +63,6 @@ func TestStartAttachCorrectExitCode(t *testing.T) {
 	if exitCode != 1 {
 		t.Fatalf("start -a did not respond with proper exit code: expected 1, got %d", exitCode)
 	}
-
-	logDone("start - correct exit code returned with -a")
 }
 
 func TestStartSilentAttach(t *testing.T) {
@@ -93,8 +89,6 @@ func TestStartSilentAttach(t *testing.T) {
 	if expected := "test\n"; startOut != expected {
 		t.Fatalf("start -a produced unexpected output: expected %q, got %q", expected, startOut)
 	}
-
-	logDone("start - don't echo container ID when attaching")
 }
 
 func TestStartRecordError(t *testing.T) {
@@ -134,8 +128,6 @@ func TestStartRecordError(t *testing.T) {
 	if stateErr != "" {
 		t.Fatalf("Expected to not have state error but got state.Error(%q)", stateErr)
 	}
-
-	logDone("start - set state error when start fails")
 }
 
 // gh#8726: a failed Start() breaks --volumes-from on subsequent Start()'s
@@ -162,8 +154,6 @@ func TestStartVolumesFromFailsCleanly(t *testing.T) {
 	if n_volumes != "2" {
 		t.Fatalf("Missing volumes: expected 2, got %s", n_volumes)
 	}
-
-	logDone("start - missing containers in --volumes-from did not affect subsequent runs")
 }
 
 func TestStartPausedContainer(t *testing.T) {
@@ -184,6 +174,4 @@ func TestStartPausedContainer(t *testing.T) {
 	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Cannot start a paused container, try unpause instead.") {
 		t.Fatalf("an error should have been shown that you cannot start paused container: %s\n%v", out, err)
 	}
-
-	logDone("start - error should show if trying to start paused container")
 }
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
@@ -19,8 +18,6 @@ func TestTagUnprefixedRepoByName(t *testing.T) {
 	}
 
 	deleteImages("testfoobarbaz")
-
-	logDone("tag - busybox -> testfoobarbaz")
 }
 
 // tagging an image by ID in a new unprefixed repo should work
@@ -38,8 +35,6 @@ func TestTagUnprefixedRepoByID(t *testing.T) {
 	}
 
 	deleteImages("testfoobarbaz")
-
-	logDone("tag - busybox's image ID -> testfoobarbaz")
 }
 
 // ensure we don't allow the use of invalid repository names; these tag operations should fail
@@ -54,7 +49,6 @@ func TestTagInvalidUnprefixedRepo(t *testing.T) {
 			t.Fatalf("tag busybox %v should have failed", repo)
 		}
 	}
-	logDone("tag - busybox invalid repo names --> must fail")
 }
 
 // ensure we don't allow the use of invalid tags; these tag operations should fail
@@ -70,7 +64,6 @@ func TestTagInvalidPrefixedRepo(t *testing.T) {
 			t.Fatalf("tag busybox %v should have failed", repotag)
 		}
 	}
-	logDone("tag - busybox with invalid repo:tagnames --> must fail")
 }
 
 // ensure we allow the use of valid tags
@@ -89,8 +82,6 @@ func TestTagValidPrefixedRepo(t *testing.T) {
 			continue
 		}
 		deleteImages(repo)
-		logMessage := fmt.Sprintf("tag - busybox %v", repo)
-		logDone(logMessage)
 	}
 }
 
@@ -110,8 +101,6 @@ func TestTagExistedNameWithoutForce(t *testing.T) {
 		t.Fatal("tag busybox busybox:test should have failed,because busybox:test is existed")
 	}
 	deleteImages("busybox:test")
-
-	logDone("tag - busybox with an existed tag name without -f option --> must fail")
 }
 
 // tag an image with an existed tag name with -f option should work
@@ -129,8 +118,6 @@ func TestTagExistedNameWithForce(t *testing.T) {
 		t.Fatal(out, err)
 	}
 	deleteImages("busybox:test")
-
-	logDone("tag - busybox with an existed tag name with -f option work")
 }
 
 // ensure tagging using official names works
@@ -160,9 +147,6 @@ func TestTagOfficialNames(t *testing.T) {
 		} else if strings.Contains(out, name) {
 			t.Errorf("images should not have listed '%s'", name)
 			deleteImages(name + ":latest")
-		} else {
-			logMessage := fmt.Sprintf("tag official name - busybox %v", name)
-			logDone(logMessage)
 		}
 	}
 
@@ -174,7 +158,5 @@ func TestTagOfficialNames(t *testing.T) {
 			continue
 		}
 		deleteImages("fooo/bar:latest")
-		logMessage := fmt.Sprintf("tag official name - %v fooo/bar", name)
-		logDone(logMessage)
 	}
 }
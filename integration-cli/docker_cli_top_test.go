@@ -25,8 +25,6 @@ func TestTopMultipleArgs(t *testing.T) {
 	if !strings.Contains(out, "PID") {
 		t.Fatalf("did not see PID after top -o pid: %s", out)
 	}
-
-	logDone("top - multiple arguments")
 }
 
 func TestTopNonPrivileged(t *testing.T) {
@@ -64,8 +62,6 @@ func TestTopNonPrivileged(t *testing.T) {
 	} else if !strings.Contains(out2, "sleep 20") {
 		t.Fatal("top should've listed `sleep 20` in the process list, but failed the second itime")
 	}
-
-	logDone("top - sleep process should be listed in non privileged mode")
 }
 
 func TestTopPrivileged(t *testing.T) {
@@ -103,6 +99,37 @@ func TestTopPrivileged(t *testing.T) {
 	} else if !strings.Contains(out2, "sleep 20") {
 		t.Fatal("top should've listed `sleep 20` in the process list, but failed the second itime")
 	}
+}
+
+func TestTopExecProcesses(t *testing.T) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "sleep", "20")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to start the container: %s, %v", out, err)
+	}
+
+	cleanedContainerID := stripTrailingCharacters(out)
+	defer deleteContainer(cleanedContainerID)
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "exec", "-d", cleanedContainerID, "sh", "-c", "sleep 19; true"))
+	if err != nil {
+		t.Fatalf("failed to exec: %s, %v", out, err)
+	}
+	execID := stripTrailingCharacters(out)[:12]
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "top", cleanedContainerID))
+	if err != nil {
+		t.Fatalf("failed to run top: %s, %v", out, err)
+	}
 
-	logDone("top - sleep process should be listed in privileged mode")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if !strings.HasPrefix(lines[0], "EXEC") {
+		t.Fatalf("expected an EXEC column: %s", out)
+	}
+	for _, line := range lines[1:] {
+		fromExec := strings.HasPrefix(line, execID)
+		if strings.Contains(line, "sleep 20") == fromExec || strings.Contains(line, "sleep 19") != fromExec {
+			t.Fatalf("expected only the processes of the exec to show its ID: %s", out)
+		}
+	}
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpdateAddHostRunning(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "db", "busybox", "top")
+	dockerCmd(t, "run", "-d", "--name", "web", "--link", "db:database", "--add-host", "cache:10.0.0.13", "busybox", "top")
+
+	dockerCmd(t, "update", "--add-host", "cache:10.0.0.14", "--add-host", "extra:10.0.0.15", "web")
+	out, _, _ := dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	for _, expected := range []string{"10.0.0.14\tcache\n", "10.0.0.15\textra\n", "\tdatabase db\n"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("Expected %q in /etc/hosts of web:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "10.0.0.13") {
+		t.Fatalf("Expected the old mapping of cache to be replaced:\n%s", out)
+	}
+
+	hosts, err := inspectField("web", "HostConfig.ExtraHosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hosts != "[cache:10.0.0.14 extra:10.0.0.15]" {
+		t.Fatalf("Expected the mappings in the host config, got %s", hosts)
+	}
+
+	// the mappings are kept at the next start
+	dockerCmd(t, "restart", "web")
+	out, _, _ = dockerCmd(t, "exec", "web", "cat", "/etc/hosts")
+	if !strings.Contains(out, "10.0.0.15\textra\n") {
+		t.Fatalf("Expected extra in /etc/hosts of web after restart:\n%s", out)
+	}
+}
+
+func TestUpdateAddHostInvalid(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "create", "--name", "web", "busybox", "true")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "update", "--add-host", "extra", "web")); err == nil {
+		t.Fatalf("Expected an invalid mapping to fail:\n%s", out)
+	}
+
+	dockerCmd(t, "create", "--name", "hostnet", "--net", "host", "busybox", "true")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "update", "--add-host", "extra:10.0.0.15", "hostnet")); err == nil {
+		t.Fatalf("Expected changing the hosts of a host network container to fail:\n%s", out)
+	}
+}
+
+func TestUpdateRestartPolicy(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "run", "-d", "--name", "web", "--restart-delay", "200ms", "busybox", "sh", "-c", "sleep 2; exit 1")
+	dockerCmd(t, "update", "--restart", "on-failure:1", "--restart-max-delay", "1s", "web")
+
+	for field, expected := range map[string]string{
+		"HostConfig.RestartPolicy.Name":              "on-failure",
+		"HostConfig.RestartPolicy.MaximumRetryCount": "1",
+		"HostConfig.RestartPolicy.MaxDelay":          "1000",
+		// the settings which aren't given are kept
+		"HostConfig.RestartPolicy.Delay": "200",
+	} {
+		value, err := inspectField("web", field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Fatalf("Expected %s to be %s, got %s", field, expected, value)
+		}
+	}
+
+	// the running container gets the new policy at its next exit
+	dockerCmd(t, "wait", "web")
+	count, err := inspectField("web", "RestartCount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != "1" {
+		t.Fatalf("Expected the container to be restarted once by the new policy, got %s restarts", count)
+	}
+}
+
+func TestUpdateRestartPolicyInvalid(t *testing.T) {
+	defer deleteAllContainers()
+
+	dockerCmd(t, "create", "--name", "web", "busybox", "true")
+	for _, args := range [][]string{
+		{"--restart", "sometimes"},
+		{"--restart", "always:3"},
+		{"--restart-jitter", "2"},
+	} {
+		args = append(append([]string{"update"}, args...), "web")
+		if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, args...)); err == nil {
+			t.Fatalf("Expected %v to fail:\n%s", args, out)
+		}
+	}
+}
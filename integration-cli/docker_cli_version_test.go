@@ -30,6 +30,4 @@ func TestVersionEnsureSucceeds(t *testing.T) {
 			t.Errorf("couldn't find string %v in output", linePrefix)
 		}
 	}
-
-	logDone("version - verify that it works and that the output is properly formatted")
 }
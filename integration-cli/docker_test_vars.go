@@ -41,5 +41,9 @@ func init() {
 	if registry := os.Getenv("REGISTRY_URL"); registry != "" {
 		privateRegistryURL = registry
 	}
+	// for the docker commands of the tests to use the daemon under test
+	if host := os.Getenv("DOCKER_TEST_HOST"); host != "" {
+		os.Setenv("DOCKER_HOST", host)
+	}
 	workingDirectory, _ = os.Getwd()
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,6 +24,10 @@ import (
 	"time"
 )
 
+// daemonLogTailLines is how many lines of the end of its log a daemon shows
+// when the test which started it fails
+const daemonLogTailLines = 50
+
 // Daemon represents a Docker daemon for the testing framework.
 type Daemon struct {
 	t              *testing.T
@@ -38,7 +44,12 @@ type Daemon struct {
 // NewDaemon returns a Daemon instance to be used for testing.
 // This will create a directory such as daemon123456789 in the folder specified by $DEST.
 // The daemon will not automatically start.
+// It uses the storage and exec drivers set in $DOCKER_GRAPHDRIVER and
+// $DOCKER_EXECDRIVER, if any. The daemon runs on this machine, so the test is
+// skipped when the suite is pointed at a remote daemon.
 func NewDaemon(t *testing.T) *Daemon {
+	testRequires(t, SameHostDaemon)
+
 	dest := os.Getenv("DEST")
 	if dest == "" {
 		t.Fatal("Please set the DEST environment variable")
@@ -98,6 +109,7 @@ func (d *Daemon) Start(arg ...string) error {
 
 	args = append(args, arg...)
 	d.cmd = exec.Command(dockerBinary, args...)
+	d.cmd.Env = localEnv()
 
 	d.logFile, err = os.OpenFile(filepath.Join(d.folder, "docker.log"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
@@ -162,28 +174,32 @@ func (d *Daemon) Start(arg ...string) error {
 }
 
 // StartWithBusybox will first start the daemon with Daemon.Start()
-// then save the busybox image from the main daemon and load it into this Daemon instance.
+// then load the busybox image into this Daemon instance from the fixtures cache.
 func (d *Daemon) StartWithBusybox(arg ...string) error {
+	return d.StartWithImages([]string{busyboxFixture}, arg...)
+}
+
+// StartWithImages will first start the daemon with the given flags, and then
+// load the images into it from the fixtures cache.
+func (d *Daemon) StartWithImages(images []string, arg ...string) error {
 	if err := d.Start(arg...); err != nil {
 		return err
 	}
-	bb := filepath.Join(d.folder, "busybox.tar")
-	if _, err := os.Stat(bb); err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("unexpected error on busybox.tar stat: %v", err)
+	return d.LoadImages(images...)
+}
+
+// LoadImages loads the images into the running daemon from the fixtures
+// cache, which they are saved to first if needed.
+func (d *Daemon) LoadImages(images ...string) error {
+	for _, image := range images {
+		tar, err := fixtureTar(d, image)
+		if err != nil {
+			return err
 		}
-		// saving busybox image from main daemon
-		if err := exec.Command(dockerBinary, "save", "--output", bb, "busybox:latest").Run(); err != nil {
-			return fmt.Errorf("could not save busybox image: %v", err)
+		if out, err := d.Cmd("load", "--input", tar); err != nil {
+			return fmt.Errorf("could not load %s: %v\n%s", image, err, out)
 		}
 	}
-	// loading busybox image to this daemon
-	if _, err := d.Cmd("load", "--input", bb); err != nil {
-		return fmt.Errorf("could not load busybox image: %v", err)
-	}
-	if err := os.Remove(bb); err != nil {
-		d.t.Logf("Could not remove %s: %v", bb, err)
-	}
 	return nil
 }
 
@@ -197,6 +213,9 @@ func (d *Daemon) Stop() error {
 	}
 
 	defer func() {
+		if d.t.Failed() {
+			d.logTail()
+		}
 		d.logFile.Close()
 		d.cmd = nil
 	}()
@@ -245,6 +264,21 @@ out2:
 	return nil
 }
 
+// logTail logs the end of the log of the daemon to the test, for the output
+// and the report of a failed test to show what the daemon did.
+func (d *Daemon) logTail() {
+	b, err := ioutil.ReadFile(filepath.Join(d.folder, "docker.log"))
+	if err != nil {
+		d.t.Logf("Could not read the daemon log: %v", err)
+		return
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	if len(lines) > daemonLogTailLines {
+		lines = lines[len(lines)-daemonLogTailLines:]
+	}
+	d.t.Logf("End of %s/docker.log:\n%s", d.folder, strings.Join(lines, "\n"))
+}
+
 // Restart will restart the daemon by first stopping it and then starting it.
 func (d *Daemon) Restart(arg ...string) error {
 	d.Stop()
@@ -261,18 +295,68 @@ func (d *Daemon) Cmd(name string, arg ...string) (string, error) {
 	args := []string{"--host", d.sock(), name}
 	args = append(args, arg...)
 	c := exec.Command(dockerBinary, args...)
+	c.Env = localEnv()
 	b, err := c.CombinedOutput()
 	return string(b), err
 }
 
+// localEnv returns the environment without the TLS settings of the daemon
+// under test, which the daemons started by the tests don't use
+func localEnv() []string {
+	var env []string
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, "DOCKER_TLS_VERIFY=") && !strings.HasPrefix(e, "DOCKER_CERT_PATH=") {
+			env = append(env, e)
+		}
+	}
+	return env
+}
+
+// daemonHost returns the address of the daemon under test, from
+// $DOCKER_TEST_HOST or else $DOCKER_HOST
 func daemonHost() string {
 	daemonUrlStr := "unix:///var/run/docker.sock"
 	if daemonHostVar := os.Getenv("DOCKER_TEST_HOST"); daemonHostVar != "" {
 		daemonUrlStr = daemonHostVar
+	} else if daemonHostVar := os.Getenv("DOCKER_HOST"); daemonHostVar != "" {
+		daemonUrlStr = daemonHostVar
 	}
 	return daemonUrlStr
 }
 
+// daemonTLSConfig returns the TLS configuration the docker client uses for
+// the daemon under test with $DOCKER_TLS_VERIFY and $DOCKER_CERT_PATH, nil if
+// it doesn't use TLS
+func daemonTLSConfig(host string) (*tls.Config, error) {
+	if os.Getenv("DOCKER_TLS_VERIFY") == "" {
+		return nil, nil
+	}
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	if certPath == "" {
+		certPath = filepath.Join(os.Getenv("HOME"), ".docker")
+	}
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("could not load the client certificate: %v", err)
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(certPath, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   host,
+	}, nil
+}
+
 func sockRequest(method, endpoint string, data interface{}) ([]byte, error) {
 	jsonData := bytes.NewBuffer(nil)
 	if err := json.NewEncoder(jsonData).Encode(data); err != nil {
@@ -294,7 +378,19 @@ func sockRequestRaw(method, endpoint string, data io.Reader, ct string) ([]byte,
 	case "unix":
 		c, err = net.DialTimeout(daemonUrl.Scheme, daemonUrl.Path, time.Duration(10*time.Second))
 	case "tcp":
+		var config *tls.Config
+		if config, err = daemonTLSConfig(daemonUrl.Host); err != nil {
+			return nil, err
+		}
 		c, err = net.DialTimeout(daemonUrl.Scheme, daemonUrl.Host, time.Duration(10*time.Second))
+		if err == nil && config != nil {
+			tlsConn := tls.Client(c, config)
+			if err = tlsConn.Handshake(); err != nil {
+				c.Close()
+			} else {
+				c = tlsConn
+			}
+		}
 	default:
 		err = fmt.Errorf("unknown scheme %v", daemonUrl.Scheme)
 	}
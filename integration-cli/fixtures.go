@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// busyboxFixture is the image most tests run their containers from
+const busyboxFixture = "busybox:latest"
+
+// fixturesDir returns the folder the fixture images are cached in as tars,
+// $DOCKER_TEST_FIXTURES or else the fixtures folder in $DEST. Point
+// $DOCKER_TEST_FIXTURES to a lasting folder to keep them between runs.
+func fixturesDir() (string, error) {
+	dir := os.Getenv("DOCKER_TEST_FIXTURES")
+	if dir == "" {
+		dest := os.Getenv("DEST")
+		if dest == "" {
+			return "", fmt.Errorf("Please set the DEST or the DOCKER_TEST_FIXTURES environment variable")
+		}
+		dir = filepath.Join(dest, "fixtures")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create the fixtures folder %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// fixtureTar returns the path of the tar of image in the fixtures cache,
+// saving it to the cache first if needed. The image is saved from the
+// daemon under test if it has it, else pulled with d and saved from it.
+func fixtureTar(d *Daemon, image string) (string, error) {
+	dir, err := fixturesDir()
+	if err != nil {
+		return "", err
+	}
+	tar := filepath.Join(dir, strings.NewReplacer("/", "_", ":", "_").Replace(image)+".tar")
+	if _, err := os.Stat(tar); err == nil {
+		return tar, nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("unexpected error on %s stat: %v", tar, err)
+	}
+
+	// saved aside first, for an interrupted save not to leave a broken tar
+	tmp := tar + ".tmp"
+	defer os.Remove(tmp)
+	if err := exec.Command(dockerBinary, "inspect", image).Run(); err == nil {
+		if out, err := exec.Command(dockerBinary, "save", "--output", tmp, image).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("could not save %s: %v\n%s", image, err, out)
+		}
+	} else {
+		if out, err := d.Cmd("pull", image); err != nil {
+			return "", fmt.Errorf("could not pull %s: %v\n%s", image, err, out)
+		}
+		if out, err := d.Cmd("save", "--output", tmp, image); err != nil {
+			return "", fmt.Errorf("could not save %s: %v\n%s", image, err, out)
+		}
+	}
+	if err := os.Rename(tmp, tar); err != nil {
+		return "", err
+	}
+	return tar, nil
+}
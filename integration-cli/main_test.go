@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests verbosely for their results to be read from their
+// output, which is still written to stdout, and writes the JSON and JUnit
+// reports of the results to $DEST
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if v := flag.Lookup("test.v"); v != nil && v.Value.String() == "false" {
+		v.Value.Set("true")
+	}
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not read the output of the tests: %v\n", err)
+		os.Exit(m.Run())
+	}
+	os.Stdout = w
+	parsed := make(chan struct{})
+	go func() {
+		reporter.parse(io.TeeReader(r, stdout))
+		close(parsed)
+	}()
+
+	code := m.Run()
+
+	w.Close()
+	<-parsed
+	os.Stdout = stdout
+	if dest := os.Getenv("DEST"); dest != "" {
+		if err := reporter.write(dest); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write the test reports: %v\n", err)
+		}
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// testResult is the outcome of a test in the reports
+type testResult struct {
+	Name string `json:"name"`
+	// Area is the part of docker the test covers, e.g. restart, from its name
+	Area string `json:"area"`
+	// Message is the first line a failed or skipped test logged
+	Message string `json:"message,omitempty"`
+	// Status is pass, fail or skip
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	// Output is what a failed or skipped test logged, with the end of the
+	// log of the daemons it started if it failed
+	Output string `json:"output,omitempty"`
+}
+
+// testReporter collects the results of the tests from their verbose output
+type testReporter struct {
+	sync.Mutex
+	results []*testResult
+}
+
+var reporter = &testReporter{}
+
+var (
+	testRunLine    = regexp.MustCompile(`^=== RUN\s+(\S+)`)
+	testResultLine = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([0-9.]+)\s?s(?:econds)?\)`)
+	// the summary go test prints after the last test
+	testSummaryLine = regexp.MustCompile(`^(PASS|FAIL|ok\s|exit status )`)
+	// what t.Error, t.Fatal or t.Skip logged, after the file and line
+	testLogLine = regexp.MustCompile(`^\s*\S+\.go:[0-9]+: (.*)`)
+)
+
+// parse reads the results of the tests from their verbose output. The lines
+// which aren't the result of a test belong to the test which ran last, some
+// versions of go print the output of the failures after their result.
+func (r *testReporter) parse(output io.Reader) {
+	var (
+		current *testResult
+		lines   []string
+	)
+	flush := func() {
+		if current == nil || current.Status == "" {
+			return
+		}
+		if current.Status != "pass" {
+			current.Output = strings.Join(lines, "\n")
+			current.Message = logMessage(lines)
+		}
+		r.Lock()
+		r.results = append(r.results, current)
+		r.Unlock()
+	}
+
+	reader := bufio.NewReader(output)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			break
+		}
+		line = strings.TrimRight(line, "\n")
+		if m := testRunLine.FindStringSubmatch(line); m != nil {
+			flush()
+			current, lines = &testResult{Name: m[1], Area: testArea(m[1])}, nil
+		} else if m := testResultLine.FindStringSubmatch(line); m != nil && current != nil {
+			current.Status = strings.ToLower(m[1])
+			current.Duration, _ = strconv.ParseFloat(m[3], 64)
+		} else if current != nil && !testSummaryLine.MatchString(line) {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	flush()
+}
+
+// logMessage returns the first message the test logged in lines, or the
+// first line if it logged none
+func logMessage(lines []string) string {
+	for _, line := range lines {
+		if m := testLogLine.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// testArea returns the first word of the name of a test after Test, in
+// lower case, e.g. restart for TestRestartPolicyDelays
+func testArea(name string) string {
+	name = strings.TrimPrefix(name, "Test")
+	for i, c := range name {
+		if i > 0 && unicode.IsUpper(c) {
+			return strings.ToLower(name[:i])
+		}
+	}
+	return strings.ToLower(name)
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// write writes the results to integration-cli-report.json and, in the JUnit
+// format, to integration-cli-report.xml in dir
+func (r *testReporter) write(dir string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	b, err := json.MarshalIndent(r.results, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "integration-cli-report.json"), b, 0644); err != nil {
+		return err
+	}
+
+	suite := junitSuite{Name: "integration-cli", Tests: len(r.results)}
+	var total float64
+	for _, result := range r.results {
+		total += result.Duration
+		c := junitCase{
+			Classname: "integration-cli." + result.Area,
+			Name:      result.Name,
+			Time:      fmt.Sprintf("%.3f", result.Duration),
+		}
+		switch result.Status {
+		case "fail":
+			suite.Failures++
+			c.Failure = &junitMessage{Message: result.Message, Output: result.Output}
+		case "skip":
+			suite.Skipped++
+			c.Skipped = &junitMessage{Message: result.Message, Output: result.Output}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+
+	if b, err = xml.MarshalIndent(suite, "", "\t"); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "integration-cli-report.xml"), append([]byte(xml.Header), b...), 0644)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRequirement is a condition a test needs to hold to be run, it is
+// skipped with SkipMessage otherwise
+type TestRequirement struct {
+	Condition   func() bool
+	SkipMessage string
+}
+
+var (
+	SameHostDaemon = TestRequirement{
+		func() bool { return strings.HasPrefix(daemonHost(), "unix://") },
+		"Test requires the daemon to run on the same machine as the tests",
+	}
+	NativeExecDriver = TestRequirement{
+		func() bool { return daemonDriver("Execution Driver") == "native" },
+		"Test requires the native exec driver",
+	}
+)
+
+// testRequires skips the test unless all the requirements are met
+func testRequires(t *testing.T, requirements ...TestRequirement) {
+	for _, r := range requirements {
+		if !r.Condition() {
+			t.Skip(r.SkipMessage)
+		}
+	}
+}
+
+var (
+	daemonInfoOnce sync.Once
+	daemonInfo     = map[string]string{}
+)
+
+// daemonDriver returns the name, without its version, of the driver the
+// daemon under test shows as key in docker info. With make, the daemons
+// started by the tests with NewDaemon use the same drivers, from
+// $DOCKER_GRAPHDRIVER and $DOCKER_EXECDRIVER
+func daemonDriver(key string) string {
+	daemonInfoOnce.Do(func() {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "info"))
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+				daemonInfo[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+	})
+	// the exec drivers are shown with their version, as in native-0.2
+	return strings.SplitN(daemonInfo[key], "-", 2)[0]
+}
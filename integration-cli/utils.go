@@ -95,10 +95,6 @@ func runCommand(cmd *exec.Cmd) (exitCode int, err error) {
 	return
 }
 
-func logDone(message string) {
-	fmt.Printf("[PASSED]: %s\n", message)
-}
-
 func stripTrailingCharacters(target string) string {
 	return strings.TrimSpace(target)
 }
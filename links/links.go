@@ -11,6 +11,8 @@ import (
 type Link struct {
 	ParentIP         string
 	ChildIP          string
+	ParentIPv6       string // the global IPv6 addresses, if the daemon has IPv6 enabled
+	ChildIPv6        string
 	Name             string
 	ChildEnvironment []string
 	Ports            []nat.Port
@@ -43,8 +45,14 @@ func NewLink(parentIP, childIP, name string, env []string, exposedPorts map[nat.
 }
 
 func (l *Link) Alias() string {
-	_, alias := path.Split(l.Name)
-	return alias
+	return Alias(l.Name)
+}
+
+// Alias returns the alias of the link named name, without the ID of the
+// linked container which tells apart the links of the containers sharing it
+func Alias(name string) string {
+	_, alias := path.Split(name)
+	return strings.SplitN(alias, "@", 2)[0]
 }
 
 func nextContiguous(ports []nat.Port, value int, index int) int {
@@ -162,6 +170,8 @@ func (l *Link) toggle(action string, ignoreErrors bool) error {
 
 	job.Setenv("ParentIP", l.ParentIP)
 	job.Setenv("ChildIP", l.ChildIP)
+	job.Setenv("ParentIPv6", l.ParentIPv6)
+	job.Setenv("ChildIPv6", l.ChildIPv6)
 	job.SetenvBool("IgnoreErrors", ignoreErrors)
 
 	out := make([]string, len(l.Ports))
@@ -225,3 +225,26 @@ func TestLinkPortRangeEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestLinkSharedAlias(t *testing.T) {
+	ports := make(nat.PortSet)
+	ports[nat.Port("6379/tcp")] = struct{}{}
+
+	link, err := NewLink("172.0.17.3", "172.0.17.2", "/web/db@0123456789ab", nil, ports, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alias := link.Alias(); alias != "db" {
+		t.Fatalf("Expected the alias db, got %s", alias)
+	}
+
+	rawEnv := link.ToEnv()
+	env := make(map[string]string, len(rawEnv))
+	for _, e := range rawEnv {
+		parts := strings.Split(e, "=")
+		env[parts[0]] = parts[1]
+	}
+	if env["DB_PORT"] != "tcp://172.0.17.2:6379" {
+		t.Fatalf("Expected tcp://172.0.17.2:6379 for DB_PORT, got %s", env["DB_PORT"])
+	}
+}
@@ -59,6 +59,21 @@ func (p Port) Int() int {
 	return port
 }
 
+// Range returns the first and last ports of a port, or of a range of ports
+// published as a whole, as in "8000-8100/udp"
+func (p Port) Range() (int, int, error) {
+	start, end, err := parsers.ParsePortRange(p.Port())
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(start), int(end), nil
+}
+
+// IsRange returns whether the port is a range of ports
+func (p Port) IsRange() bool {
+	return strings.Contains(p.Port(), "-")
+}
+
 // Splits a port in the format of proto/port
 func SplitProtoPort(rawPort string) (string, string) {
 	parts := strings.Split(rawPort, "/")
@@ -85,7 +100,9 @@ func validateProto(proto string) bool {
 }
 
 // We will receive port specs in the format of ip:public:private/proto and these need to be
-// parsed in the internal types
+// parsed in the internal types. The ports of a range are exposed one by one,
+// but the range is bound as a whole, under a single port such as "8000-8100/udp",
+// to the host range or to dynamic host ports when none is given.
 func ParsePortSpecs(ports []string) (map[Port]struct{}, map[Port][]PortBinding, error) {
 	var (
 		exposedPorts = make(map[Port]struct{}, len(ports))
@@ -145,25 +162,32 @@ func ParsePortSpecs(ports []string) (map[Port]struct{}, map[Port][]PortBinding,
 		}
 
 		for i := uint64(0); i <= (endPort - startPort); i++ {
-			containerPort = strconv.FormatUint(startPort+i, 10)
-			if len(hostPort) > 0 {
-				hostPort = strconv.FormatUint(startHostPort+i, 10)
-			}
-			port := NewPort(proto, containerPort)
+			port := NewPort(proto, strconv.FormatUint(startPort+i, 10))
 			if _, exists := exposedPorts[port]; !exists {
 				exposedPorts[port] = struct{}{}
 			}
+		}
 
-			binding := PortBinding{
-				HostIp:   rawIp,
-				HostPort: hostPort,
-			}
-			bslice, exists := bindings[port]
-			if !exists {
-				bslice = []PortBinding{}
-			}
-			bindings[port] = append(bslice, binding)
+		port := NewPort(proto, formatPortRange(startPort, endPort))
+		if hostPort != "" {
+			hostPort = formatPortRange(startHostPort, endHostPort)
+		}
+		binding := PortBinding{
+			HostIp:   rawIp,
+			HostPort: hostPort,
+		}
+		bslice, exists := bindings[port]
+		if !exists {
+			bslice = []PortBinding{}
 		}
+		bindings[port] = append(bslice, binding)
 	}
 	return exposedPorts, bindings, nil
 }
+
+func formatPortRange(start, end uint64) string {
+	if start == end {
+		return strconv.FormatUint(start, 10)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
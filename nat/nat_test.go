@@ -200,6 +200,19 @@ func TestParsePortSpecs(t *testing.T) {
 	}
 }
 
+func TestPortRange(t *testing.T) {
+	start, end, err := Port("8000-8100/udp").Range()
+	if err != nil || start != 8000 || end != 8100 {
+		t.Fatalf("Expected the range 8000-8100, got %d-%d: %v", start, end, err)
+	}
+	if !Port("8000-8100/udp").IsRange() || Port("8000/udp").IsRange() {
+		t.Fatal("Expected only 8000-8100/udp to be a range")
+	}
+	if start, end, err = Port("80/tcp").Range(); err != nil || start != 80 || end != 80 {
+		t.Fatalf("Expected the range 80-80, got %d-%d: %v", start, end, err)
+	}
+}
+
 func TestParsePortSpecsWithRange(t *testing.T) {
 	var (
 		portMap    map[Port]struct{}
@@ -285,6 +298,20 @@ func TestParsePortSpecsWithRange(t *testing.T) {
 		}
 	}
 
+	portMap, bindingMap, err = ParsePortSpecs([]string{"9000-9100:8000-8100/udp"})
+
+	if err != nil {
+		t.Fatalf("Error while processing ParsePortSpecs: %s", err)
+	}
+
+	if len(portMap) != 101 {
+		t.Fatalf("Expected the 101 ports of the range to be exposed, got %d", len(portMap))
+	}
+
+	if bindings := bindingMap[Port("8000-8100/udp")]; len(bindingMap) != 1 || len(bindings) != 1 || bindings[0].HostPort != "9000-9100" {
+		t.Fatalf("Expected the range to be bound as a whole to 9000-9100, got %v", bindingMap)
+	}
+
 	_, _, err = ParsePortSpecs([]string{"localhost:1234-1236:1234-1236/tcp"})
 
 	if err == nil {
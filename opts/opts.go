@@ -17,6 +17,8 @@ import (
 var (
 	alphaRegexp  = regexp.MustCompile(`[a-zA-Z]`)
 	domainRegexp = regexp.MustCompile(`^(:?(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9]))(:?\.(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])))*)\.?\s*$`)
+	// DeviceCgroupRuleRegexp matches a devices cgroup rule: type major:minor access
+	DeviceCgroupRuleRegexp = regexp.MustCompile(`^([acb]) ([0-9]+|\*):([0-9]+|\*) ([rwm]{1,3})$`)
 )
 
 func ListVar(values *[]string, names []string, usage string) {
@@ -136,7 +138,7 @@ func ValidateAttach(val string) (string, error) {
 }
 
 func ValidateLink(val string) (string, error) {
-	if _, err := parsers.PartParser("name:alias", val); err != nil {
+	if _, _, _, err := parsers.ParseLink(val); err != nil {
 		return val, err
 	}
 	return val, nil
@@ -183,6 +185,19 @@ func ValidateIPAddress(val string) (string, error) {
 	return "", fmt.Errorf("%s is not an ip address", val)
 }
 
+// ValidateMACAddress validates the unicast Ethernet address of a container,
+// e.g. "92:d0:c6:0a:29:33"
+func ValidateMACAddress(val string) (string, error) {
+	mac, err := net.ParseMAC(strings.TrimSpace(val))
+	if err != nil || len(mac) != 6 {
+		return "", fmt.Errorf("%s is not a MAC address", val)
+	}
+	if mac[0]&0x1 != 0 {
+		return "", fmt.Errorf("%s is a multicast MAC address", val)
+	}
+	return mac.String(), nil
+}
+
 // Validates domain for resolvconf search configuration.
 // A zero length domain is represented by .
 func ValidateDnsSearch(val string) (string, error) {
@@ -220,3 +235,11 @@ func ValidateLabel(val string) (string, error) {
 	}
 	return val, nil
 }
+
+// ValidateDeviceCgroupRule validates a devices cgroup rule, e.g. "c 189:* rwm"
+func ValidateDeviceCgroupRule(val string) (string, error) {
+	if DeviceCgroupRuleRegexp.MatchString(val) {
+		return val, nil
+	}
+	return val, fmt.Errorf("invalid device cgroup rule format: '%s'", val)
+}
@@ -27,6 +27,18 @@ func TestValidateIPAddress(t *testing.T) {
 
 }
 
+func TestValidateMACAddress(t *testing.T) {
+	if ret, err := ValidateMACAddress(`92:D0:C6:0A:29:33`); err != nil || ret != "92:d0:c6:0a:29:33" {
+		t.Fatalf("ValidateMACAddress(`92:D0:C6:0A:29:33`) got %s %s", ret, err)
+	}
+
+	for _, invalid := range []string{`92:d0:c6:0a:29`, `01:00:5e:00:00:01`, `random invalid string`} {
+		if ret, err := ValidateMACAddress(invalid); err == nil || ret != "" {
+			t.Fatalf("ValidateMACAddress(`%s`) got %s %s", invalid, ret, err)
+		}
+	}
+}
+
 func TestListOpts(t *testing.T) {
 	o := NewListOpts(nil)
 	o.Set("foo")
@@ -104,3 +116,31 @@ func TestValidateDnsSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateDeviceCgroupRule(t *testing.T) {
+	valid := []string{
+		"c 189:* rwm",
+		"b 8:0 r",
+		"c *:* m",
+		"a *:* rwm",
+	}
+	invalid := []string{
+		"",
+		"c 189",
+		"c 189:*",
+		"x 189:* rwm",
+		"c 189:* rwmx",
+		"c189:* rwm",
+	}
+
+	for _, rule := range valid {
+		if _, err := ValidateDeviceCgroupRule(rule); err != nil {
+			t.Fatalf("ValidateDeviceCgroupRule(`%s`) got %s", rule, err)
+		}
+	}
+	for _, rule := range invalid {
+		if _, err := ValidateDeviceCgroupRule(rule); err == nil {
+			t.Fatalf("ValidateDeviceCgroupRule(`%s`) should have failed", rule)
+		}
+	}
+}
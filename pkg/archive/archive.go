@@ -5,8 +5,11 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"os"
@@ -35,6 +38,40 @@ type (
 		Compression     Compression
 		NoLchown        bool
 		Name            string
+		// Progress, if set, is called by Unpack after each entry with the
+		// number of content bytes and entries extracted so far
+		Progress func(bytes, entries int64) `json:"-"`
+		// MaxSize and MaxEntries, if set, bound the content bytes and the
+		// number of entries Unpack extracts before giving up. MaxSize,
+		// MaxEntries, Digest and Cancel are honored by UnpackLayer too.
+		MaxSize    int64
+		MaxEntries int64
+		// PreserveXattrs records the user extended attributes and the POSIX
+		// ACLs of the files in the archive, file capabilities always are.
+		// Unpack restores all the extended attributes of the archive.
+		PreserveXattrs bool
+		// Workers, if more than 1, is the number of goroutines Unpack
+		// writes small regular files with
+		Workers int
+		// UIDMaps and GIDMaps, if set, remap the ownership of the entries
+		// from container ids to host ids as they are unpacked
+		UIDMaps []IDMap
+		GIDMaps []IDMap
+		// Digest, if set, is the expected "sha256:<hex>" digest of the
+		// uncompressed archive. Unpack removes the paths it created and
+		// returns ErrDigestMismatch if the archive doesn't match it.
+		Digest string
+		// Cancel, if set, aborts Unpack once closed: it removes the paths
+		// it created and returns ErrCanceled
+		Cancel <-chan struct{} `json:"-"`
+	}
+
+	// IDMap maps Size container ids starting at ContainerID to the host
+	// ids starting at HostID
+	IDMap struct {
+		ContainerID int
+		HostID      int
+		Size        int
 	}
 
 	// Archiver allows the reuse of most utility functions of this package
@@ -50,8 +87,12 @@ type (
 )
 
 var (
-	ErrNotImplemented = errors.New("Function not implemented")
-	defaultArchiver   = &Archiver{Untar}
+	ErrNotImplemented     = errors.New("Function not implemented")
+	ErrMaxSizeExceeded    = errors.New("Archive exceeds the maximum extracted size")
+	ErrMaxEntriesExceeded = errors.New("Archive exceeds the maximum number of entries")
+	ErrDigestMismatch     = errors.New("Archive doesn't match its expected digest")
+	ErrCanceled           = errors.New("Unpacking the archive was canceled")
+	defaultArchiver       = &Archiver{Untar}
 )
 
 const (
@@ -59,6 +100,7 @@ const (
 	Bzip2
 	Gzip
 	Xz
+	Zstd
 )
 
 func IsArchive(header []byte) bool {
@@ -76,6 +118,7 @@ func DetectCompression(source []byte) Compression {
 		Bzip2: {0x42, 0x5A, 0x68},
 		Gzip:  {0x1F, 0x8B, 0x08},
 		Xz:    {0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00},
+		Zstd:  {0x28, 0xB5, 0x2F, 0xFD},
 	} {
 		if len(source) < len(m) {
 			log.Debugf("Len too short")
@@ -88,8 +131,18 @@ func DetectCompression(source []byte) Compression {
 	return Uncompressed
 }
 
+// decompressMemoryLimit bounds the memory the external xz and zstd
+// decompressors may use, archives needing more are rejected
+const decompressMemoryLimit = "128MiB"
+
 func xzDecompress(archive io.Reader) (io.ReadCloser, error) {
-	args := []string{"xz", "-d", "-c", "-q"}
+	args := []string{"xz", "-d", "-c", "-q", "--memlimit-decompress=" + decompressMemoryLimit}
+
+	return CmdStream(exec.Command(args[0], args[1:]...), archive)
+}
+
+func zstdDecompress(archive io.Reader) (io.ReadCloser, error) {
+	args := []string{"zstd", "-d", "-c", "-q", "--memory=" + decompressMemoryLimit}
 
 	return CmdStream(exec.Command(args[0], args[1:]...), archive)
 }
@@ -125,6 +178,13 @@ func DecompressStream(archive io.Reader) (io.ReadCloser, error) {
 		}
 		readBufWrapper := p.NewReadCloserWrapper(buf, xzReader)
 		return readBufWrapper, nil
+	case Zstd:
+		zstdReader, err := zstdDecompress(buf)
+		if err != nil {
+			return nil, err
+		}
+		readBufWrapper := p.NewReadCloserWrapper(buf, zstdReader)
+		return readBufWrapper, nil
 	default:
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
 	}
@@ -141,8 +201,8 @@ func CompressStream(dest io.WriteCloser, compression Compression) (io.WriteClose
 		gzWriter := gzip.NewWriter(dest)
 		writeBufWrapper := p.NewWriteCloserWrapper(buf, gzWriter)
 		return writeBufWrapper, nil
-	case Bzip2, Xz:
-		// archive/bzip2 does not support writing, and there is no xz support at all
+	case Bzip2, Xz, Zstd:
+		// archive/bzip2 does not support writing, and there is no xz or zstd support at all
 		// However, this is not a problem as docker only currently generates gzipped tars
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
 	default:
@@ -160,6 +220,8 @@ func (compression *Compression) Extension() string {
 		return "tar.gz"
 	case Xz:
 		return "tar.xz"
+	case Zstd:
+		return "tar.zst"
 	}
 	return ""
 }
@@ -170,6 +232,10 @@ type tarAppender struct {
 
 	// for hardlink mapping
 	SeenFiles map[uint64]string
+
+	// PreserveXattrs records the user extended attributes and POSIX ACLs
+	// next to the file capabilities
+	PreserveXattrs bool
 }
 
 func (ta *tarAppender) addTarFile(path, name string) error {
@@ -220,6 +286,11 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 		hdr.Xattrs = make(map[string]string)
 		hdr.Xattrs["security.capability"] = string(capability)
 	}
+	if ta.PreserveXattrs {
+		if err := addXattrs(hdr, path); err != nil {
+			return err
+		}
+	}
 
 	if err := ta.TarWriter.WriteHeader(hdr); err != nil {
 		return err
@@ -247,6 +318,153 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 	return nil
 }
 
+// canceled reports whether cancel is closed
+func canceled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkLimits returns the error for options.MaxSize or options.MaxEntries
+// if extracting hdr would exceed it, the size in the header is the expanded
+// size even for sparse files
+func checkLimits(options *TarOptions, hdr *tar.Header, size, entries int64) error {
+	if options.MaxEntries > 0 && entries+1 > options.MaxEntries {
+		return ErrMaxEntriesExceeded
+	}
+	if options.MaxSize > 0 && size+hdr.Size > options.MaxSize {
+		return ErrMaxSizeExceeded
+	}
+	return nil
+}
+
+// digestReader tees the archive through sha256 when an expected digest is
+// given, so that it can be verified once the archive is unpacked
+type digestReader struct {
+	io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func newDigestReader(r io.Reader, digest string) (*digestReader, error) {
+	if digest == "" {
+		return &digestReader{Reader: r}, nil
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("Unsupported digest %s", digest)
+	}
+	h := sha256.New()
+	return &digestReader{
+		Reader:   io.TeeReader(r, h),
+		hash:     h,
+		expected: strings.TrimPrefix(digest, "sha256:"),
+	}, nil
+}
+
+// verify returns ErrDigestMismatch if the archive doesn't match the expected
+// digest. The digest covers the padding after the end of the archive too,
+// which is read first.
+func (d *digestReader) verify() error {
+	if d.hash == nil {
+		return nil
+	}
+	if _, err := io.Copy(ioutil.Discard, d.Reader); err != nil {
+		return err
+	}
+	if hex.EncodeToString(d.hash.Sum(nil)) != d.expected {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// createdPaths are the paths an unpack created in its destination, which
+// are removed when it is canceled or the archive doesn't match its digest.
+// Paths which existed before are never removed: those the archive replaced
+// or merged into keep what was unpacked.
+type createdPaths []string
+
+// add records path if it doesn't exist yet
+func (c *createdPaths) add(path string) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		*c = append(*c, path)
+	}
+}
+
+// mkdirAll is os.MkdirAll, recording the directories it creates
+func (c *createdPaths) mkdirAll(path string, perm os.FileMode) error {
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			break
+		}
+		missing = append(missing, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
+	if err := os.MkdirAll(path, perm); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		*c = append(*c, missing[i])
+	}
+	return nil
+}
+
+// remove removes the created paths in reverse order, a directory is only
+// removed once what was created in it is
+func (c createdPaths) remove() {
+	for i := len(c) - 1; i >= 0; i-- {
+		os.Remove(c[i])
+	}
+}
+
+// toHostID returns the host id the container id is mapped to, ids are
+// kept as is without maps
+func toHostID(id int, maps []IDMap) (int, error) {
+	if len(maps) == 0 {
+		return id, nil
+	}
+	for _, m := range maps {
+		if id >= m.ContainerID && id < m.ContainerID+m.Size {
+			return m.HostID + id - m.ContainerID, nil
+		}
+	}
+	return -1, fmt.Errorf("Container ID %d cannot be mapped to a host ID", id)
+}
+
+// addXattrs records the user extended attributes and the POSIX ACLs of the
+// file at path in hdr
+func addXattrs(hdr *tar.Header, path string) error {
+	keys, err := system.Llistxattr(path)
+	if err != nil {
+		if err == syscall.ENOTSUP || err == system.ErrNotSupportedPlatform {
+			return nil
+		}
+		return err
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "user.") && key != "system.posix_acl_access" && key != "system.posix_acl_default" {
+			continue
+		}
+		value, err := system.Lgetxattr(path, key)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if hdr.Xattrs == nil {
+			hdr.Xattrs = make(map[string]string)
+		}
+		hdr.Xattrs[key] = string(value)
+	}
+	return nil
+}
+
 func createTarFile(path, extractDir string, hdr *tar.Header, reader io.Reader, Lchown bool) error {
 	// hdr.Mode is in linux format, which we can use for sycalls,
 	// but for os.Foo() calls we need the mode converted to os.FileMode,
@@ -388,9 +606,10 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 
 	go func() {
 		ta := &tarAppender{
-			TarWriter: tar.NewWriter(compressWriter),
-			Buffer:    pools.BufioWriter32KPool.Get(nil),
-			SeenFiles: make(map[uint64]string),
+			TarWriter:      tar.NewWriter(compressWriter),
+			Buffer:         pools.BufioWriter32KPool.Get(nil),
+			SeenFiles:      make(map[uint64]string),
+			PreserveXattrs: options.PreserveXattrs,
 		}
 		// this buffer is needed for the duration of this piped stream
 		defer pools.BufioWriter32KPool.Put(ta.Buffer)
@@ -479,16 +698,40 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 	return pipeReader, nil
 }
 
-func Unpack(decompressedArchive io.Reader, dest string, options *TarOptions) error {
-	tr := tar.NewReader(decompressedArchive)
+func Unpack(decompressedArchive io.Reader, dest string, options *TarOptions) (err error) {
+	digested, err := newDigestReader(decompressedArchive, options.Digest)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(digested)
 	trBuf := pools.BufioReader32KPool.Get(nil)
 	defer pools.BufioReader32KPool.Put(trBuf)
 
-	var dirs []*tar.Header
+	var (
+		dirs    []*tar.Header
+		created createdPaths
+		size    int64
+		entries int64
+		pool    *unpackPool
+	)
+	// registered before the pool is closed, so that it runs after it
+	defer func() {
+		if err != nil && canceled(options.Cancel) {
+			created.remove()
+			err = ErrCanceled
+		}
+	}()
+	if options.Workers > 1 {
+		pool = newUnpackPool(options.Workers, dest, !options.NoLchown)
+		defer pool.close()
+	}
 
 	// Iterate through the files in the archive.
 loop:
 	for {
+		if canceled(options.Cancel) {
+			return ErrCanceled
+		}
 		hdr, err := tr.Next()
 		if err == io.EOF {
 			// end of tar archive
@@ -513,7 +756,7 @@ loop:
 			parent := filepath.Dir(hdr.Name)
 			parentPath := filepath.Join(dest, parent)
 			if _, err := os.Lstat(parentPath); err != nil && os.IsNotExist(err) {
-				err = os.MkdirAll(parentPath, 0777)
+				err = created.mkdirAll(parentPath, 0777)
 				if err != nil {
 					return err
 				}
@@ -529,30 +772,92 @@ loop:
 			return breakoutError(fmt.Errorf("%q is outside of %q", hdr.Name, dest))
 		}
 
+		// A path written by the pool must be complete before it is looked at
+		if pool != nil && pool.busy(path) {
+			if err := pool.wait(); err != nil {
+				return err
+			}
+		}
+
 		// If path exits we almost always just want to remove and replace it
 		// The only exception is when it is a directory *and* the file from
 		// the layer is also a directory. Then we want to merge them (i.e.
 		// just apply the metadata from the layer).
-		if fi, err := os.Lstat(path); err == nil {
+		fi, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			created = append(created, path)
+		}
+		if err == nil {
 			if fi.IsDir() && hdr.Name == "." {
 				continue
 			}
 			if !(fi.IsDir() && hdr.Typeflag == tar.TypeDir) {
+				// the pool may be writing below a directory being replaced
+				if pool != nil {
+					if err := pool.wait(); err != nil {
+						return err
+					}
+				}
 				if err := os.RemoveAll(path); err != nil {
 					return err
 				}
 			}
 		}
-		trBuf.Reset(tr)
-		if err := createTarFile(path, dest, hdr, trBuf, !options.NoLchown); err != nil {
+		// Check the limits before anything is written
+		if err := checkLimits(options, hdr, size, entries); err != nil {
 			return err
 		}
 
+		if hdr.Uid, err = toHostID(hdr.Uid, options.UIDMaps); err != nil {
+			return err
+		}
+		if hdr.Gid, err = toHostID(hdr.Gid, options.GIDMaps); err != nil {
+			return err
+		}
+
+		switch {
+		case pool != nil && pool.accepts(hdr):
+			if err := pool.add(path, hdr, tr); err != nil {
+				return err
+			}
+		default:
+			// Directories don't depend on the files being written, anything
+			// else, e.g. hard links, is written once the pool is done
+			if pool != nil && hdr.Typeflag != tar.TypeDir {
+				if err := pool.wait(); err != nil {
+					return err
+				}
+			}
+			trBuf.Reset(tr)
+			if err := createTarFile(path, dest, hdr, trBuf, !options.NoLchown); err != nil {
+				return err
+			}
+		}
+
 		// Directory mtimes must be handled at the end to avoid further
 		// file creation in them to modify the directory mtime
 		if hdr.Typeflag == tar.TypeDir {
 			dirs = append(dirs, hdr)
 		}
+
+		size += hdr.Size
+		entries++
+		if options.Progress != nil {
+			options.Progress(size, entries)
+		}
+	}
+
+	if pool != nil {
+		if err := pool.wait(); err != nil {
+			return err
+		}
+	}
+
+	if err := digested.verify(); err != nil {
+		if err == ErrDigestMismatch {
+			created.remove()
+		}
+		return err
 	}
 
 	for _, hdr := range dirs {
@@ -568,7 +873,7 @@ loop:
 // Untar reads a stream of bytes from `archive`, parses it as a tar archive,
 // and unpacks it into the directory at `dest`.
 // The archive may be compressed with one of the following algorithms:
-//  identity (uncompressed), gzip, bzip2, xz.
+//  identity (uncompressed), gzip, bzip2, xz, zstd.
 // FIXME: specify behavior when target path exists vs. doesn't exist.
 func Untar(archive io.Reader, dest string, options *TarOptions) error {
 	if archive == nil {
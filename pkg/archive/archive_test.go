@@ -2,6 +2,7 @@ package archive
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
 )
 
@@ -66,6 +68,31 @@ func TestCmdStreamGood(t *testing.T) {
 	}
 }
 
+func TestDecompressStreamZstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not found in PATH")
+	}
+	compressed, err := exec.Command("/bin/sh", "-c", "printf hello | zstd -q -c").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := DetectCompression(compressed); c != Zstd {
+		t.Fatalf("expected zstd compression to be detected, got %s", c.Extension())
+	}
+	r, err := DecompressStream(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Fatalf("expected hello, got %q", out)
+	}
+}
+
 func TestTarFiles(t *testing.T) {
 	// try without hardlinks
 	if err := checkNoChanges(1000, false); err != nil {
@@ -623,3 +650,247 @@ func TestTempArchiveCloseMultipleTimes(t *testing.T) {
 		}
 	}
 }
+
+func TestUntarLimits(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-untar-limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	if err := ioutil.WriteFile(path.Join(origin, "1"), []byte("hello world"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(origin, "2"), []byte("welcome!"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		options *TarOptions
+		err     error
+	}{
+		{&TarOptions{MaxSize: 10}, ErrMaxSizeExceeded},
+		{&TarOptions{MaxEntries: 1}, ErrMaxEntriesExceeded},
+		{&TarOptions{MaxSize: 19, MaxEntries: 3}, nil},
+	} {
+		archive, err := Tar(origin, Uncompressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dest, err := ioutil.TempDir("", "docker-test-untar-limits-dest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dest)
+		if err := Untar(archive, dest, c.options); err != c.err {
+			t.Fatalf("expected %v for %+v, got %v", c.err, c.options, err)
+		}
+	}
+}
+
+func TestUntarXattrs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "file",
+		Mode:     0644,
+		Typeflag: tar.TypeReg,
+		Xattrs:   map[string]string{"user.docker": "hello"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := ioutil.TempDir("", "docker-test-untar-xattrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := system.Lsetxattr(dest, "user.docker", []byte("probe"), 0); err != nil {
+		t.Skipf("user extended attributes are not supported: %s", err)
+	}
+
+	if err := Untar(bytes.NewReader(buf.Bytes()), dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	value, err := system.Lgetxattr(filepath.Join(dest, "file"), "user.docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != "hello" {
+		t.Fatalf("expected user.docker to be restored, got %q", value)
+	}
+}
+
+func TestTarPreserveXattrs(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-tar-xattrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	if err := ioutil.WriteFile(filepath.Join(origin, "file"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Lsetxattr(filepath.Join(origin, "file"), "user.docker", []byte("hello"), 0); err != nil {
+		t.Skipf("user extended attributes are not supported: %s", err)
+	}
+
+	for _, preserve := range []bool{false, true} {
+		archive, err := TarWithOptions(origin, &TarOptions{PreserveXattrs: preserve})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(archive)
+		var xattrs map[string]string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hdr.Name == "file" {
+				xattrs = hdr.Xattrs
+			}
+		}
+		archive.Close()
+
+		if preserve && xattrs["user.docker"] != "hello" {
+			t.Fatalf("expected user.docker to be recorded, got %v", xattrs)
+		}
+		if _, exists := xattrs["user.docker"]; !preserve && exists {
+			t.Fatalf("expected user.docker not to be recorded, got %v", xattrs)
+		}
+	}
+}
+
+func TestUntarWorkers(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-untar-workers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	for i := 0; i < 10; i++ {
+		dir := path.Join(origin, fmt.Sprintf("dir%d", i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 20; j++ {
+			if err := ioutil.WriteFile(path.Join(dir, fmt.Sprintf("file%d", j)), []byte(fmt.Sprintf("%d-%d", i, j)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.Link(path.Join(origin, "dir0", "file0"), path.Join(origin, "hardlink")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(origin, "big"), bytes.Repeat([]byte("a"), maxPooledFileSize+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Tar(origin, Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest, err := ioutil.TempDir("", "docker-test-untar-workers-dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := Untar(archive, dest, &TarOptions{Workers: 4}); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := ChangesDirs(dest, origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes after a parallel untar, got %v", changes)
+	}
+}
+
+func TestUntarIDMaps(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Typeflag: tar.TypeReg, Uid: 1, Gid: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dest, err := ioutil.TempDir("", "docker-test-untar-idmaps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	options := &TarOptions{
+		UIDMaps: []IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMaps: []IDMap{{ContainerID: 0, HostID: 200000, Size: 65536}},
+	}
+	if err := Untar(bytes.NewReader(buf.Bytes()), dest, options); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Lstat(filepath.Join(dest, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := fi.Sys().(*syscall.Stat_t)
+	if stat.Uid != 100001 || stat.Gid != 200002 {
+		t.Fatalf("expected ownership 100001:200002, got %d:%d", stat.Uid, stat.Gid)
+	}
+
+	options.UIDMaps = []IDMap{{ContainerID: 1000, HostID: 100000, Size: 1}}
+	if err := Untar(bytes.NewReader(buf.Bytes()), dest, options); err == nil {
+		t.Fatal("expected an error for an unmapped id")
+	}
+}
+
+func TestUntarDigest(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "dir", Mode: 0755, Typeflag: tar.TypeDir},
+		{Name: "dir/file", Mode: 0644, Typeflag: tar.TypeReg, Size: 5},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tw.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(buf.Bytes()))
+
+	for _, c := range []struct {
+		digest string
+		err    error
+	}{
+		{digest, nil},
+		{"sha256:" + strings.Repeat("0", 64), ErrDigestMismatch},
+	} {
+		dest, err := ioutil.TempDir("", "docker-test-untar-digest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dest)
+		if err := Untar(bytes.NewReader(buf.Bytes()), dest, &TarOptions{Digest: c.digest}); err != c.err {
+			t.Fatalf("expected %v, got %v", c.err, err)
+		}
+		files, err := ioutil.ReadDir(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.err != nil && len(files) != 0 {
+			t.Fatalf("expected the unpacked files to be removed, found %d", len(files))
+		}
+		if c.err == nil && len(files) != 1 {
+			t.Fatalf("expected dir to be unpacked, found %d files", len(files))
+		}
+	}
+}
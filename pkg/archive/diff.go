@@ -15,18 +15,42 @@ import (
 	"github.com/docker/docker/pkg/system"
 )
 
-func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
-	tr := tar.NewReader(layer)
+// UnpackLayer applies the uncompressed layer to dest, honoring the MaxSize,
+// MaxEntries, Digest and Cancel options. When it is canceled or the layer
+// doesn't match its digest, the paths it created are removed, the paths it
+// replaced or whited out can't be restored.
+func UnpackLayer(dest string, layer ArchiveReader, options *TarOptions) (size int64, err error) {
+	if options == nil {
+		options = &TarOptions{}
+	}
+	digested, err := newDigestReader(layer, options.Digest)
+	if err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(digested)
 	trBuf := pools.BufioReader32KPool.Get(tr)
 	defer pools.BufioReader32KPool.Put(trBuf)
 
-	var dirs []*tar.Header
+	var (
+		dirs    []*tar.Header
+		created createdPaths
+		entries int64
+	)
+	defer func() {
+		if err != nil && canceled(options.Cancel) {
+			created.remove()
+			size, err = 0, ErrCanceled
+		}
+	}()
 
 	aufsTempdir := ""
 	aufsHardlinks := make(map[string]*tar.Header)
 
 	// Iterate through the files in the archive.
 	for {
+		if canceled(options.Cancel) {
+			return 0, ErrCanceled
+		}
 		hdr, err := tr.Next()
 		if err == io.EOF {
 			// end of tar archive
@@ -36,7 +60,12 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 			return 0, err
 		}
 
+		// Check the limits before anything is written
+		if err := checkLimits(options, hdr, size, entries); err != nil {
+			return 0, err
+		}
 		size += hdr.Size
+		entries++
 
 		// Normalize name, for safety and for a simple is-root check
 		hdr.Name = filepath.Clean(hdr.Name)
@@ -48,7 +77,7 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 			parent := filepath.Dir(hdr.Name)
 			parentPath := filepath.Join(dest, parent)
 			if _, err := os.Lstat(parentPath); err != nil && os.IsNotExist(err) {
-				err = os.MkdirAll(parentPath, 0600)
+				err = created.mkdirAll(parentPath, 0600)
 				if err != nil {
 					return 0, err
 				}
@@ -103,6 +132,8 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 						return 0, err
 					}
 				}
+			} else if os.IsNotExist(err) {
+				created = append(created, path)
 			}
 
 			trBuf.Reset(tr)
@@ -137,6 +168,13 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 		}
 	}
 
+	if err := digested.verify(); err != nil {
+		if err == ErrDigestMismatch {
+			created.remove()
+		}
+		return 0, err
+	}
+
 	for _, hdr := range dirs {
 		path := filepath.Join(dest, hdr.Name)
 		ts := []syscall.Timespec{timeToTimespec(hdr.AccessTime), timeToTimespec(hdr.ModTime)}
@@ -150,8 +188,9 @@ func UnpackLayer(dest string, layer ArchiveReader) (size int64, err error) {
 
 // ApplyLayer parses a diff in the standard layer format from `layer`, and
 // applies it to the directory `dest`. Returns the size in bytes of the
-// contents of the layer.
-func ApplyLayer(dest string, layer ArchiveReader) (int64, error) {
+// contents of the layer. options may be nil, see UnpackLayer for the ones
+// honored.
+func ApplyLayer(dest string, layer ArchiveReader, options *TarOptions) (int64, error) {
 	dest = filepath.Clean(dest)
 
 	// We need to be able to set any perms
@@ -165,5 +204,5 @@ func ApplyLayer(dest string, layer ArchiveReader) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return UnpackLayer(dest, layer)
+	return UnpackLayer(dest, layer, options)
 }
@@ -1,6 +1,11 @@
 package archive
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
@@ -189,3 +194,83 @@ func TestApplyLayerInvalidSymlink(t *testing.T) {
 		}
 	}
 }
+
+// testLayer returns an uncompressed layer made of headers, regular files
+// hold their name
+func testLayer(t *testing.T, headers []*tar.Header) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, hdr := range headers {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(hdr.Name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestApplyLayerDigestMismatch(t *testing.T) {
+	dest, err := ioutil.TempDir("", "docker-TestApplyLayerDigestMismatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := os.Mkdir(filepath.Join(dest, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dest, "dir", "existing"), []byte("existing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	layer := testLayer(t, []*tar.Header{
+		{Name: "dir", Mode: 0755, Typeflag: tar.TypeDir},
+		{Name: "dir/added", Mode: 0644, Typeflag: tar.TypeReg},
+		{Name: "parent/missing/added", Mode: 0644, Typeflag: tar.TypeReg},
+	})
+	options := &TarOptions{Digest: "sha256:" + strings.Repeat("0", 64)}
+	if _, err := ApplyLayer(dest, bytes.NewReader(layer), options); err != ErrDigestMismatch {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+	for _, p := range []string{"dir/added", "parent"} {
+		if _, err := os.Lstat(filepath.Join(dest, p)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", p, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "dir", "existing")); err != nil {
+		t.Fatalf("expected dir/existing to be kept, got %v", err)
+	}
+}
+
+func TestApplyLayerLimits(t *testing.T) {
+	layer := testLayer(t, []*tar.Header{
+		{Name: "first", Mode: 0644, Typeflag: tar.TypeReg},
+		{Name: "second", Mode: 0644, Typeflag: tar.TypeReg},
+	})
+	for _, c := range []struct {
+		options *TarOptions
+		err     error
+	}{
+		{&TarOptions{MaxEntries: 1}, ErrMaxEntriesExceeded},
+		{&TarOptions{MaxSize: 8}, ErrMaxSizeExceeded},
+		{&TarOptions{MaxEntries: 2, MaxSize: 11}, nil},
+	} {
+		dest, err := ioutil.TempDir("", "docker-TestApplyLayerLimits")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dest)
+		if _, err := ApplyLayer(dest, bytes.NewReader(layer), c.options); err != c.err {
+			t.Fatalf("expected %v, got %v", c.err, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+)
+
+// maxPooledFileSize is the size up to which regular files are read in memory
+// and written by the pool's workers, larger files are written in order by
+// Unpack itself.
+const maxPooledFileSize = 1 << 20
+
+// unpackPool writes the small regular files of an archive with a few workers
+// while Unpack keeps reading the tar stream. Entries which depend on files
+// written before them (hard links, replaced paths) must wait for the pool
+// first.
+type unpackPool struct {
+	dest   string
+	lchown bool
+
+	files chan *pooledFile
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	err     error
+	pending map[string]bool
+}
+
+type pooledFile struct {
+	path string
+	hdr  *tar.Header
+	data []byte
+}
+
+func newUnpackPool(workers int, dest string, lchown bool) *unpackPool {
+	p := &unpackPool{
+		dest:    dest,
+		lchown:  lchown,
+		files:   make(chan *pooledFile, workers),
+		pending: make(map[string]bool),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *unpackPool) work() {
+	for f := range p.files {
+		err := createTarFile(f.path, p.dest, f.hdr, bytes.NewReader(f.data), p.lchown)
+		p.mu.Lock()
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		delete(p.pending, f.path)
+		p.mu.Unlock()
+		p.wg.Done()
+	}
+}
+
+// accepts reports whether the entry can be written by the pool
+func (p *unpackPool) accepts(hdr *tar.Header) bool {
+	return (hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA) && hdr.Size <= maxPooledFileSize
+}
+
+// busy reports whether a worker is still writing path
+func (p *unpackPool) busy(path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending[path]
+}
+
+// add reads the content of the entry from r and queues it for the workers
+func (p *unpackPool) add(path string, hdr *tar.Header, r io.Reader) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r, hdr.Size))
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if err := p.err; err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	p.pending[path] = true
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	p.files <- &pooledFile{path: path, hdr: hdr, data: data}
+	return nil
+}
+
+// wait waits for the queued files to be written and returns the first error
+// the workers ran into
+func (p *unpackPool) wait() error {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// close waits for the queued files and stops the workers
+func (p *unpackPool) close() error {
+	err := p.wait()
+	close(p.files)
+	return err
+}
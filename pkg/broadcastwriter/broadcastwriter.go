@@ -2,100 +2,675 @@ package broadcastwriter
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/docker/docker/pkg/jsonlog"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// DefaultMaxBuffered is the default size up to which a BroadcastWriter
+// buffers the partial line it has been written
+const DefaultMaxBuffered = 1 << 20
+
+// DefaultQueueSize is the default number of writes queued for each writer
+const DefaultQueueSize = 256
+
+// ErrQueueFull is the reason given for the writers evicted because their
+// queue was full, with the DisconnectWhenFull policy
+var ErrQueueFull = errors.New("Writer queue is full")
+
+// EvictHandler is called with the writers evicted from a BroadcastWriter and
+// the reason they were evicted, before they are closed
+type EvictHandler func(writer io.WriteCloser, stream string, err error)
+
+// FullPolicy is what a BroadcastWriter does when the queue of a writer
+// which doesn't keep up is full, it is given for each writer
+type FullPolicy int
+
+const (
+	// BlockWhenFull waits for the writer to make room in its queue, nothing
+	// is lost but the writes of the BroadcastWriter are held back
+	BlockWhenFull FullPolicy = iota
+	// DropWhenFull drops the writes the writer can't keep up with
+	DropWhenFull
+	// DisconnectWhenFull evicts and closes the writer
+	DisconnectWhenFull
 )
 
 // BroadcastWriter accumulate multiple io.WriteCloser by stream.
+// The embedded lock guards the partial line and the settings, every stream
+// has its own lock guarding its writers, so that the writers of one stream
+// don't hold back the others.
 type BroadcastWriter struct {
 	sync.Mutex
-	buf      *bytes.Buffer
+	buf         *bytes.Buffer
+	streamsLock sync.RWMutex
+	streams     map[string]*stream
+	// lineWriters is the number of writers of every stream but "", lines
+	// aren't split when there are none
+	lineWriters int32
+	maxBuffered int
+	queueSize   int
+	dropped     int64
+	evicted     int64
+	onEvict     atomic.Value
+	// partialTimeout is how long a partial line is buffered without any
+	// write before it is sent, partialTimer sends it
+	partialTimeout time.Duration
+	partialTimer   *time.Timer
+	lastWrite      time.Time
+	// replaySize is the size of the output replayed to the writers added,
+	// replayLines holds the last lines and the stream "" the last bytes
+	replaySize  int64
+	replayLines []Line
+	replayLen   int
+	// seq is the sequence number of the last line sent, partialCreated the
+	// time of the write the partial line started in
+	seq            uint64
+	partialCreated time.Time
+}
+
+// Line is a line sent to the writers of a BroadcastWriter
+type Line struct {
+	// Text ends with a newline, unless it is a partial line sent by Flush
+	// or after the partial timeout
+	Text string
+	// Created is the time of the write the line started in, it never goes
+	// backwards
+	Created time.Time
+	// Seq numbers the lines sent by the BroadcastWriter from 1
+	Seq uint64
+}
+
+// stream holds the writers of a stream
+type stream struct {
+	sync.Mutex
+	name    string
+	writers map[*queuedWriter]struct{}
+	// count is the number of writers, read without the lock to skip
+	// streams without any
+	count    int32
 	jsLogBuf *bytes.Buffer
-	streams  map[string](map[io.WriteCloser]struct{})
+	// tail is the output replayed to the writers added to the stream ""
+	tail []byte
+}
+
+// queuedWriter writes to a WriteCloser from its own goroutine, so that a
+// slow writer doesn't hold back the others
+type queuedWriter struct {
+	io.WriteCloser
+	stream    *stream
+	formatter Formatter
+	filter    Filter
+	// header is the stdcopy header framing the writes of a multiplexed
+	// writer of the stream ""
+	header *stdcopy.StdType
+	policy FullPolicy
+	queue  chan []byte
+	// queued and done count the writes queued and the ones done with,
+	// written is signaled when they are done with
+	countLock sync.Mutex
+	queued    uint64
+	done      uint64
+	written   *sync.Cond
+	// evicted is set once the writer failed, the rest of its queue is
+	// discarded
+	evicted int32
+	// evictErr is the reason the writer was evicted, and onEvict the
+	// handler it is reported to
+	evictErr error
+	onEvict  EvictHandler
+}
+
+// SetMaxBuffered sets the size up to which the partial line is buffered,
+// the oldest bytes of longer lines are dropped. A size of 0 means no limit.
+func (w *BroadcastWriter) SetMaxBuffered(size int) {
+	w.Lock()
+	w.maxBuffered = size
+	w.Unlock()
+}
+
+// SetPartialTimeout sets how long the partial line is buffered without any
+// write before it is sent to the writers of every stream but "", as a line
+// without a trailing newline. A timeout of 0 means it is buffered until the
+// line is terminated or flushed.
+func (w *BroadcastWriter) SetPartialTimeout(timeout time.Duration) {
+	w.Lock()
+	w.partialTimeout = timeout
+	w.Unlock()
+}
+
+// SetReplaySize sets the size of the last output replayed to the writers
+// added from now on, so that they don't miss what was written just before.
+// Writers of the stream "" get the last bytes written, the others the last
+// lines. A size of 0 disables the replay.
+func (w *BroadcastWriter) SetReplaySize(size int) {
+	w.Lock()
+	atomic.StoreInt64(&w.replaySize, int64(size))
+	w.trimReplay()
+	w.Unlock()
+	// the stream "" keeps the last bytes even without writers
+	s := w.getStream("")
+	s.Lock()
+	s.tail = appendTail(s.tail, nil, size)
+	s.Unlock()
 }
 
-// AddWriter adds new io.WriteCloser for stream.
+// SetQueue sets the number of writes queued for the writers added from now
+// on
+func (w *BroadcastWriter) SetQueue(size int) {
+	w.Lock()
+	w.queueSize = size
+	w.Unlock()
+}
+
+// SetEvictHandler sets the handler called with the writers evicted from now
+// on. It is called from the goroutine of the writer, without the lock held.
+func (w *BroadcastWriter) SetEvictHandler(handler EvictHandler) {
+	w.onEvict.Store(handler)
+}
+
+// Evicted returns the number of writers evicted, because they failed or their
+// queue was full
+func (w *BroadcastWriter) Evicted() int64 {
+	return atomic.LoadInt64(&w.evicted)
+}
+
+// Dropped returns the number of bytes dropped, from partial lines longer
+// than the maximum buffered size or for writers whose queue was full
+func (w *BroadcastWriter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// AddWriter adds new io.WriteCloser for stream, policy is what happens to
+// it when its queue is full.
 // If stream is "", then all writes proceed as is. Otherwise every line from
 // input will be packed to serialized jsonlog.JSONLog.
-func (w *BroadcastWriter) AddWriter(writer io.WriteCloser, stream string) {
+func (w *BroadcastWriter) AddWriter(writer io.WriteCloser, stream string, policy FullPolicy) {
+	w.AddFormattedWriter(writer, stream, JSONLog, policy)
+}
+
+// AddFormattedWriter adds new io.WriteCloser for stream, every line from
+// input is written to it formatted by formatter. If stream is "", then all
+// writes proceed as is and formatter is ignored.
+func (w *BroadcastWriter) AddFormattedWriter(writer io.WriteCloser, stream string, formatter Formatter, policy FullPolicy) {
+	w.AddFilteredWriter(writer, stream, formatter, nil, policy)
+}
+
+// AddFilteredWriter adds new io.WriteCloser for stream, every line from input
+// accepted by filter is written to it formatted by formatter. If stream is
+// "", then all writes proceed as is and formatter and filter are ignored.
+func (w *BroadcastWriter) AddFilteredWriter(writer io.WriteCloser, stream string, formatter Formatter, filter Filter, policy FullPolicy) {
+	w.add(&queuedWriter{WriteCloser: writer, formatter: formatter, filter: filter, policy: policy}, stream)
+}
+
+// AddStdWriter adds new io.WriteCloser to which all writes proceed framed in
+// the stdcopy format as the stream t, so that the writers of stdout and stderr
+// can share a connection. Each write is framed and written at once.
+func (w *BroadcastWriter) AddStdWriter(writer io.WriteCloser, t stdcopy.StdType, policy FullPolicy) {
+	w.add(&queuedWriter{WriteCloser: writer, header: &t, policy: policy}, "")
+}
+
+// add adds qw to the writers of the stream named name and starts writing its
+// queue
+func (w *BroadcastWriter) add(qw *queuedWriter, name string) {
+	// the lock keeps lines from being sent until qw is added after their
+	// replay, and the lock of the stream the bytes of the stream ""
 	w.Lock()
-	if _, ok := w.streams[stream]; !ok {
-		w.streams[stream] = make(map[io.WriteCloser]struct{})
+	defer w.Unlock()
+	qw.queue = make(chan []byte, w.queueSize)
+	qw.written = sync.NewCond(&qw.countLock)
+	s := w.getStream(name)
+	qw.stream = s
+	go w.run(qw)
+
+	s.Lock()
+	defer s.Unlock()
+	if atomic.LoadInt64(&w.replaySize) > 0 {
+		if b := w.replay(qw); len(b) > 0 {
+			w.send(qw, b)
+		}
 	}
-	w.streams[stream][writer] = struct{}{}
-	w.Unlock()
+	s.writers[qw] = struct{}{}
+	w.countWriter(s, 1)
 }
 
-// Write writes bytes to all writers. Failed writers will be evicted during
-// this call.
-func (w *BroadcastWriter) Write(p []byte) (n int, err error) {
-	created := time.Now().UTC()
-	w.Lock()
-	if writers, ok := w.streams[""]; ok {
-		for sw := range writers {
-			if n, err := sw.Write(p); err != nil || n != len(p) {
-				// On error, evict the writer
-				delete(writers, sw)
+// replay returns the last output for qw, the lock and the lock of the stream
+// of qw must be held
+func (w *BroadcastWriter) replay(qw *queuedWriter) []byte {
+	s := qw.stream
+	if s.name == "" {
+		if len(s.tail) == 0 {
+			return nil
+		}
+		if qw.header != nil {
+			return frame(*qw.header, s.tail)
+		}
+		return append([]byte(nil), s.tail...)
+	}
+	lines := w.replayLines
+	if qw.filter != nil {
+		lines = qw.filter.apply(lines, s.name)
+	}
+	return w.format(s, qw.formatter, lines)
+}
+
+// trimReplay drops the oldest lines replayed beyond the replay size, the lock
+// must be held
+func (w *BroadcastWriter) trimReplay() {
+	size := int(atomic.LoadInt64(&w.replaySize))
+	for len(w.replayLines) > 0 && w.replayLen > size {
+		w.replayLen -= len(w.replayLines[0].Text)
+		w.replayLines[0] = Line{}
+		w.replayLines = w.replayLines[1:]
+	}
+}
+
+// appendTail returns the last size bytes of tail followed by p
+func appendTail(tail, p []byte, size int) []byte {
+	if len(p) >= size {
+		return append(tail[:0], p[len(p)-size:]...)
+	}
+	if over := len(tail) + len(p) - size; over > 0 {
+		tail = tail[:copy(tail, tail[over:])]
+	}
+	return append(tail, p...)
+}
+
+// getStream returns the stream named name, which is created if no writer was
+// ever added for it
+func (w *BroadcastWriter) getStream(name string) *stream {
+	w.streamsLock.Lock()
+	defer w.streamsLock.Unlock()
+	s, ok := w.streams[name]
+	if !ok {
+		s = &stream{name: name, writers: make(map[*queuedWriter]struct{})}
+		w.streams[name] = s
+	}
+	return s
+}
+
+// stream returns the stream named name, or nil if no writer was ever added
+// for it
+func (w *BroadcastWriter) stream(name string) *stream {
+	w.streamsLock.RLock()
+	defer w.streamsLock.RUnlock()
+	return w.streams[name]
+}
+
+// countWriter adds delta to the writers counted for s, the lock of s must be
+// held
+func (w *BroadcastWriter) countWriter(s *stream, delta int32) {
+	atomic.AddInt32(&s.count, delta)
+	if s.name != "" {
+		atomic.AddInt32(&w.lineWriters, delta)
+	}
+}
+
+// RemoveWriter removes writer from the writers of stream, leaving the other
+// writers attached. The writer is closed once its queued writes are written.
+func (w *BroadcastWriter) RemoveWriter(writer io.WriteCloser, name string) {
+	s := w.stream(name)
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	for qw := range s.writers {
+		if qw.WriteCloser == writer {
+			delete(s.writers, qw)
+			w.countWriter(s, -1)
+			close(qw.queue)
+			return
+		}
+	}
+}
+
+// run writes the queue of qw until it is closed, then closes the writer
+func (w *BroadcastWriter) run(qw *queuedWriter) {
+	for b := range qw.queue {
+		if atomic.LoadInt32(&qw.evicted) == 0 {
+			n, err := qw.Write(b)
+			if err == nil && n != len(b) {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				// On error, evict the writer. A write blocked on its full
+				// queue holds the lock of the stream, the queue is drained
+				// until the writer is evicted.
+				atomic.StoreInt32(&qw.evicted, 1)
+				go func(err error) {
+					qw.stream.Lock()
+					w.evict(qw, err)
+					qw.stream.Unlock()
+				}(err)
 			}
 		}
+		qw.markDone()
+	}
+	// the queue is closed, evictErr is set if it was closed by evict
+	if qw.evictErr != nil && qw.onEvict != nil {
+		qw.onEvict(qw.WriteCloser, qw.stream.name, qw.evictErr)
+	}
+	qw.Close()
+}
+
+// evict removes qw from the writers of its stream because of err and closes
+// its queue, the lock of the stream must be held
+func (w *BroadcastWriter) evict(qw *queuedWriter, err error) {
+	s := qw.stream
+	if _, ok := s.writers[qw]; !ok {
+		return
 	}
-	if w.jsLogBuf == nil {
-		w.jsLogBuf = new(bytes.Buffer)
-		w.jsLogBuf.Grow(1024)
+	atomic.StoreInt32(&qw.evicted, 1)
+	qw.evictErr = err
+	qw.onEvict, _ = w.onEvict.Load().(EvictHandler)
+	atomic.AddInt64(&w.evicted, 1)
+	delete(s.writers, qw)
+	w.countWriter(s, -1)
+	close(qw.queue)
+}
+
+// markDone counts a write of qw as done with, written or not
+func (qw *queuedWriter) markDone() {
+	qw.countLock.Lock()
+	qw.done++
+	qw.written.Broadcast()
+	qw.countLock.Unlock()
+}
+
+// wait waits for the writes queued before it is called to be done with,
+// the ones queued meanwhile aren't waited for
+func (qw *queuedWriter) wait() {
+	qw.countLock.Lock()
+	for queued := qw.queued; qw.done < queued; {
+		qw.written.Wait()
 	}
-	w.buf.Write(p)
-	for {
-		line, err := w.buf.ReadString('\n')
-		if err != nil {
-			w.buf.Write([]byte(line))
-			break
+	qw.countLock.Unlock()
+}
+
+// send queues b for qw, the lock of its stream must be held
+func (w *BroadcastWriter) send(qw *queuedWriter, b []byte) {
+	qw.countLock.Lock()
+	qw.queued++
+	qw.countLock.Unlock()
+	if qw.policy == BlockWhenFull {
+		qw.queue <- b
+		return
+	}
+	select {
+	case qw.queue <- b:
+	default:
+		qw.markDone()
+		atomic.AddInt64(&w.dropped, int64(len(b)))
+		if qw.policy == DisconnectWhenFull {
+			w.evict(qw, ErrQueueFull)
 		}
-		for stream, writers := range w.streams {
-			if stream == "" {
+	}
+}
+
+// Write writes bytes to all writers. Failed writers will be evicted,
+// writes are queued for each writer and written from its own goroutine.
+func (w *BroadcastWriter) Write(p []byte) (n int, err error) {
+	replaySize := atomic.LoadInt64(&w.replaySize)
+	if s := w.stream(""); s != nil && (atomic.LoadInt32(&s.count) > 0 || replaySize > 0) {
+		b := append([]byte(nil), p...)
+		var framed map[stdcopy.StdType][]byte
+		s.Lock()
+		if replaySize > 0 {
+			s.tail = appendTail(s.tail, p, int(replaySize))
+		}
+		for qw := range s.writers {
+			if qw.header == nil {
+				w.send(qw, b)
 				continue
 			}
-			jsonLog := jsonlog.JSONLog{Log: line, Stream: stream, Created: created}
-			err = jsonLog.MarshalJSONBuf(w.jsLogBuf)
+			if framed == nil {
+				framed = make(map[stdcopy.StdType][]byte)
+			}
+			f, ok := framed[*qw.header]
+			if !ok {
+				f = frame(*qw.header, p)
+				framed[*qw.header] = f
+			}
+			w.send(qw, f)
+		}
+		s.Unlock()
+	}
+	w.Lock()
+	created := time.Now().UTC()
+	if created.Before(w.lastWrite) {
+		created = w.lastWrite
+	}
+	// the partial line keeps the time of the write it started in
+	partialCreated := w.partialCreated
+	if w.buf.Len() == 0 {
+		partialCreated = created
+	}
+	if atomic.LoadInt32(&w.lineWriters) == 0 && replaySize == 0 {
+		// nobody gets the lines, only the partial line is kept
+		if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+			w.buf.Reset()
+			w.buf.Write(p[i+1:])
+			partialCreated = created
+		} else {
+			w.buf.Write(p)
+		}
+	} else {
+		w.buf.Write(p)
+		var lines []Line
+		for {
+			line, err := w.buf.ReadString('\n')
 			if err != nil {
-				log.Errorf("Error making JSON log line: %s", err)
-				continue
+				w.buf.Write([]byte(line))
+				break
 			}
-			w.jsLogBuf.WriteByte('\n')
-			b := w.jsLogBuf.Bytes()
-			for sw := range writers {
-				if _, err := sw.Write(b); err != nil {
-					delete(writers, sw)
+			lines = append(lines, Line{Text: line, Created: partialCreated})
+			partialCreated = created
+		}
+		w.sendLines(lines)
+	}
+	w.partialCreated = partialCreated
+	if w.maxBuffered > 0 {
+		// overwrite the oldest bytes of the partial line
+		if over := w.buf.Len() - w.maxBuffered; over > 0 {
+			w.buf.Next(over)
+			atomic.AddInt64(&w.dropped, int64(over))
+		}
+		// don't keep a buffer sized for the longest write or line ever seen
+		if w.buf.Cap() > 2*w.maxBuffered {
+			w.buf = bytes.NewBuffer(append([]byte(nil), w.buf.Bytes()...))
+		}
+	}
+	w.lastWrite = created
+	if w.partialTimeout > 0 && w.buf.Len() > 0 {
+		if w.partialTimer == nil {
+			w.partialTimer = time.AfterFunc(w.partialTimeout, w.partialTimedOut)
+		} else {
+			w.partialTimer.Reset(w.partialTimeout)
+		}
+	} else if w.partialTimer != nil {
+		w.partialTimer.Stop()
+	}
+	w.Unlock()
+	return len(p), nil
+}
+
+// frame returns p framed in the stdcopy format as the stream t
+func frame(t stdcopy.StdType, p []byte) []byte {
+	b := make([]byte, stdcopy.StdWriterPrefixLen+len(p))
+	copy(b, t[:])
+	binary.BigEndian.PutUint32(b[stdcopy.StdWriterSizeIndex:], uint32(len(p)))
+	copy(b[stdcopy.StdWriterPrefixLen:], p)
+	return b
+}
+
+// partialTimedOut sends the partial line once no write happened for the
+// partial timeout
+func (w *BroadcastWriter) partialTimedOut() {
+	w.Lock()
+	defer w.Unlock()
+	// a write may have raced with the timer, which it then reset
+	if w.partialTimeout == 0 || time.Since(w.lastWrite) < w.partialTimeout {
+		return
+	}
+	w.sendPartial()
+}
+
+// sendPartial sends the partial line to the writers of every stream but "",
+// the lock must be held
+func (w *BroadcastWriter) sendPartial() {
+	if w.buf.Len() > 0 {
+		w.sendLines([]Line{{Text: w.buf.String(), Created: w.partialCreated}})
+		w.buf.Reset()
+	}
+}
+
+// sendLines queues lines for the writers of every stream but "", they are
+// formatted once for each formatter of a stream and queued at once for each
+// writer. The lines are numbered, the lock must be held so that they are
+// numbered and queued in order.
+func (w *BroadcastWriter) sendLines(lines []Line) {
+	if len(lines) == 0 {
+		return
+	}
+	for i := range lines {
+		w.seq++
+		lines[i].Seq = w.seq
+	}
+	if atomic.LoadInt64(&w.replaySize) > 0 {
+		for _, line := range lines {
+			w.replayLines = append(w.replayLines, line)
+			w.replayLen += len(line.Text)
+		}
+		w.trimReplay()
+	}
+	w.streamsLock.RLock()
+	defer w.streamsLock.RUnlock()
+	for name, s := range w.streams {
+		if name == "" || atomic.LoadInt32(&s.count) == 0 {
+			continue
+		}
+		s.Lock()
+		formatted := make(map[Formatter][]byte)
+		for qw := range s.writers {
+			if qw.filter != nil {
+				// filtered writers get their own lines
+				if b := w.format(s, qw.formatter, qw.filter.apply(lines, name)); len(b) > 0 {
+					w.send(qw, b)
 				}
+				continue
+			}
+			b, ok := formatted[qw.formatter]
+			if !ok {
+				b = w.format(s, qw.formatter, lines)
+				formatted[qw.formatter] = b
 			}
+			w.send(qw, b)
+		}
+		// don't keep a buffer sized for the longest line ever seen
+		if w.maxBuffered > 0 && s.jsLogBuf != nil && s.jsLogBuf.Cap() > 2*w.maxBuffered {
+			s.jsLogBuf = nil
+		}
+		s.Unlock()
+	}
+}
+
+// format returns lines of s formatted by formatter, the lock of s must be
+// held
+func (w *BroadcastWriter) format(s *stream, formatter Formatter, lines []Line) []byte {
+	if s.jsLogBuf == nil {
+		s.jsLogBuf = new(bytes.Buffer)
+		s.jsLogBuf.Grow(1024)
+	}
+	for i := range lines {
+		if err := formatter.Format(s.jsLogBuf, &lines[i], s.name); err != nil {
+			log.Errorf("Error formatting log line: %s", err)
 		}
-		w.jsLogBuf.Reset()
 	}
-	w.jsLogBuf.Reset()
+	b := append([]byte(nil), s.jsLogBuf.Bytes()...)
+	s.jsLogBuf.Reset()
+	return b
+}
+
+// Flush sends the buffered partial line, without a trailing newline, to the
+// writers of every stream but "", which already got it, and waits up to
+// timeout for all the queued writes to be written. A timeout of 0 waits
+// until they are.
+func (w *BroadcastWriter) Flush(timeout time.Duration) error {
+	w.Lock()
+	w.sendPartial()
 	w.Unlock()
-	return len(p), nil
+
+	done := make(chan struct{})
+	go func() {
+		w.wait()
+		close(done)
+	}()
+	if timeout == 0 {
+		<-done
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("Timeout flushing the log writers after %s", timeout)
+	}
 }
 
-// Clean closes and removes all writers. Last non-eol-terminated part of data
-// will be saved.
+// wait waits for the writes queued so far to be written
+func (w *BroadcastWriter) wait() {
+	var writers []*queuedWriter
+	w.streamsLock.RLock()
+	for _, s := range w.streams {
+		s.Lock()
+		for qw := range s.writers {
+			writers = append(writers, qw)
+		}
+		s.Unlock()
+	}
+	w.streamsLock.RUnlock()
+	for _, qw := range writers {
+		qw.wait()
+	}
+}
+
+// Clean closes and removes all writers, once their queued writes are
+// written. Last non-eol-terminated part of data will be saved.
 func (w *BroadcastWriter) Clean() error {
-	w.Lock()
-	for _, writers := range w.streams {
-		for w := range writers {
-			w.Close()
+	w.streamsLock.RLock()
+	for _, s := range w.streams {
+		s.Lock()
+		for qw := range s.writers {
+			delete(s.writers, qw)
+			w.countWriter(s, -1)
+			close(qw.queue)
 		}
+		s.tail = s.tail[:0]
+		s.Unlock()
+	}
+	w.streamsLock.RUnlock()
+	w.Lock()
+	w.replayLines, w.replayLen = nil, 0
+	if w.partialTimer != nil {
+		w.partialTimer.Stop()
 	}
-	w.streams = make(map[string](map[io.WriteCloser]struct{}))
 	w.Unlock()
 	return nil
 }
 
 func New() *BroadcastWriter {
 	return &BroadcastWriter{
-		streams: make(map[string](map[io.WriteCloser]struct{})),
-		buf:     bytes.NewBuffer(nil),
+		streams:     make(map[string]*stream),
+		buf:         bytes.NewBuffer(nil),
+		maxBuffered: DefaultMaxBuffered,
+		queueSize:   DefaultQueueSize,
 	}
 }
@@ -3,8 +3,14 @@ package broadcastwriter
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 type dummyWriter struct {
@@ -32,10 +38,11 @@ func TestBroadcastWriter(t *testing.T) {
 
 	// Test 1: Both bufferA and bufferB should contain "foo"
 	bufferA := &dummyWriter{}
-	writer.AddWriter(bufferA, "")
+	writer.AddWriter(bufferA, "", BlockWhenFull)
 	bufferB := &dummyWriter{}
-	writer.AddWriter(bufferB, "")
+	writer.AddWriter(bufferB, "", BlockWhenFull)
 	writer.Write([]byte("foo"))
+	writer.wait()
 
 	if bufferA.String() != "foo" {
 		t.Errorf("Buffer contains %v", bufferA.String())
@@ -48,8 +55,9 @@ func TestBroadcastWriter(t *testing.T) {
 	// Test2: bufferA and bufferB should contain "foobar",
 	// while bufferC should only contain "bar"
 	bufferC := &dummyWriter{}
-	writer.AddWriter(bufferC, "")
+	writer.AddWriter(bufferC, "", BlockWhenFull)
 	writer.Write([]byte("bar"))
+	writer.wait()
 
 	if bufferA.String() != "foobar" {
 		t.Errorf("Buffer contains %v", bufferA.String())
@@ -66,6 +74,7 @@ func TestBroadcastWriter(t *testing.T) {
 	// Test3: Test eviction on failure
 	bufferA.failOnWrite = true
 	writer.Write([]byte("fail"))
+	writer.wait()
 	if bufferA.String() != "foobar" {
 		t.Errorf("Buffer contains %v", bufferA.String())
 	}
@@ -75,6 +84,7 @@ func TestBroadcastWriter(t *testing.T) {
 	// Even though we reset the flag, no more writes should go in there
 	bufferA.failOnWrite = false
 	writer.Write([]byte("test"))
+	writer.wait()
 	if bufferA.String() != "foobar" {
 		t.Errorf("Buffer contains %v", bufferA.String())
 	}
@@ -100,7 +110,7 @@ func TestRaceBroadcastWriter(t *testing.T) {
 	writer := New()
 	c := make(chan bool)
 	go func() {
-		writer.AddWriter(devNullCloser(0), "")
+		writer.AddWriter(devNullCloser(0), "", BlockWhenFull)
 		c <- true
 	}()
 	writer.Write([]byte("hello"))
@@ -111,9 +121,9 @@ func BenchmarkBroadcastWriter(b *testing.B) {
 	writer := New()
 	setUpWriter := func() {
 		for i := 0; i < 100; i++ {
-			writer.AddWriter(devNullCloser(0), "stdout")
-			writer.AddWriter(devNullCloser(0), "stderr")
-			writer.AddWriter(devNullCloser(0), "")
+			writer.AddWriter(devNullCloser(0), "stdout", BlockWhenFull)
+			writer.AddWriter(devNullCloser(0), "stderr", BlockWhenFull)
+			writer.AddWriter(devNullCloser(0), "", BlockWhenFull)
 		}
 	}
 	testLine := "Line that thinks that it is log line from docker"
@@ -142,3 +152,430 @@ func BenchmarkBroadcastWriter(b *testing.B) {
 		b.StartTimer()
 	}
 }
+
+func TestBroadcastWriterMaxBuffered(t *testing.T) {
+	writer := New()
+	writer.SetMaxBuffered(4)
+	buffer := &dummyWriter{}
+	writer.AddWriter(buffer, "stdout", BlockWhenFull)
+
+	writer.Write([]byte("abcdefgh"))
+	if dropped := writer.Dropped(); dropped != 4 {
+		t.Fatalf("Expected 4 dropped bytes, got %d", dropped)
+	}
+	writer.Write([]byte("\n"))
+	writer.wait()
+	if !strings.Contains(buffer.String(), `"log":"efgh\n"`) {
+		t.Fatalf("Expected the last 4 bytes of the line, got %s", buffer.String())
+	}
+
+	// complete lines are never dropped
+	writer.Write([]byte("0123456789\n"))
+	writer.wait()
+	if !strings.Contains(buffer.String(), `"log":"0123456789\n"`) {
+		t.Fatalf("Expected the whole line, got %s", buffer.String())
+	}
+	if dropped := writer.Dropped(); dropped != 4 {
+		t.Fatalf("Expected 4 dropped bytes, got %d", dropped)
+	}
+}
+
+// blockingWriter blocks its writes until unblock is closed
+type blockingWriter struct {
+	dummyWriter
+	unblock chan struct{}
+	closed  chan struct{}
+}
+
+func (bw *blockingWriter) Write(p []byte) (int, error) {
+	<-bw.unblock
+	return bw.dummyWriter.Write(p)
+}
+
+func (bw *blockingWriter) Close() error {
+	close(bw.closed)
+	return nil
+}
+
+func TestBroadcastWriterBlockWhenFull(t *testing.T) {
+	// a writer failing while a write waits for it must not hold it back
+	for _, fail := range []bool{false, true} {
+		writer := New()
+		writer.SetQueue(1)
+		slow := &blockingWriter{dummyWriter: dummyWriter{failOnWrite: fail}, unblock: make(chan struct{}), closed: make(chan struct{})}
+		writer.AddWriter(slow, "", BlockWhenFull)
+
+		// the slow writer holds the first write and queues the second one,
+		// the third one waits for room in its queue
+		writer.Write([]byte("a"))
+		time.Sleep(10 * time.Millisecond)
+		writer.Write([]byte("b"))
+		written := make(chan struct{})
+		go func() {
+			writer.Write([]byte("c"))
+			close(written)
+		}()
+		select {
+		case <-written:
+			t.Fatal("Expected the write to wait for the slow writer")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(slow.unblock)
+		select {
+		case <-written:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the write to go through once the slow writer caught up")
+		}
+
+		if fail {
+			<-slow.closed
+		} else {
+			writer.wait()
+			if slow.String() != "abc" {
+				t.Fatalf("Expected the slow writer to get everything, got %q", slow.String())
+			}
+		}
+		if writer.Dropped() != 0 {
+			t.Fatalf("Expected nothing dropped, got %d bytes", writer.Dropped())
+		}
+		writer.Clean()
+	}
+}
+
+func TestBroadcastWriterSlowWriter(t *testing.T) {
+	for _, policy := range []FullPolicy{DropWhenFull, DisconnectWhenFull} {
+		writer := New()
+		writer.SetQueue(1)
+		slow := &blockingWriter{unblock: make(chan struct{}), closed: make(chan struct{})}
+		writer.AddWriter(slow, "", policy)
+		// the policy of the slow writer doesn't apply to the others
+		fast := &dummyWriter{}
+		writer.AddWriter(fast, "", BlockWhenFull)
+
+		// the slow writer holds the first write, queues the second one
+		// and can't take the third one
+		for _, s := range []string{"a", "b", "c"} {
+			writer.Write([]byte(s))
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(slow.unblock)
+		writer.wait()
+		if fast.String() != "abc" {
+			t.Fatalf("Expected the fast writer to get everything, got %q", fast.String())
+		}
+		if writer.Dropped() != 1 {
+			t.Fatalf("Expected 1 dropped byte, got %d", writer.Dropped())
+		}
+
+		writer.Write([]byte("d"))
+		writer.wait()
+		switch policy {
+		case DropWhenFull:
+			if slow.String() != "abd" {
+				t.Fatalf("Expected the slow writer to miss c, got %q", slow.String())
+			}
+		case DisconnectWhenFull:
+			<-slow.closed
+			// what was still queued is discarded
+			if slow.String() != "a" {
+				t.Fatalf("Expected the slow writer to be disconnected after a, got %q", slow.String())
+			}
+		}
+		writer.Clean()
+	}
+}
+
+func TestBroadcastWriterFlush(t *testing.T) {
+	writer := New()
+	raw := &dummyWriter{}
+	writer.AddWriter(raw, "", BlockWhenFull)
+	jsonLog := &dummyWriter{}
+	writer.AddWriter(jsonLog, "stdout", BlockWhenFull)
+
+	writer.Write([]byte("foo\nbar"))
+	if err := writer.Flush(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if raw.String() != "foo\nbar" {
+		t.Fatalf("Buffer contains %q", raw.String())
+	}
+	if !strings.Contains(jsonLog.String(), `"log":"bar"`) {
+		t.Fatalf("Expected the partial line, got %s", jsonLog.String())
+	}
+	// the partial line is only flushed once
+	writer.Write([]byte("\n"))
+	writer.wait()
+	if strings.Count(jsonLog.String(), "bar") != 1 {
+		t.Fatalf("Expected bar once, got %s", jsonLog.String())
+	}
+
+	slow := &blockingWriter{unblock: make(chan struct{}), closed: make(chan struct{})}
+	writer.AddWriter(slow, "", BlockWhenFull)
+	writer.Write([]byte("baz"))
+	if err := writer.Flush(10 * time.Millisecond); err == nil {
+		t.Fatal("Expected the flush to time out")
+	}
+	close(slow.unblock)
+	if err := writer.Flush(0); err != nil {
+		t.Fatal(err)
+	}
+	writer.Clean()
+}
+
+func TestBroadcastWriterFlushWhileWriting(t *testing.T) {
+	writer := New()
+	writer.AddWriter(devNullCloser(0), "", BlockWhenFull)
+	writer.AddWriter(devNullCloser(0), "stdout", BlockWhenFull)
+
+	// the writes keep being queued while the flushes wait
+	stop := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				writer.Write([]byte("line\n"))
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := writer.Flush(5 * time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-written
+	writer.Clean()
+}
+
+func TestBroadcastWriterEvictHandler(t *testing.T) {
+	writer := New()
+	type eviction struct {
+		writer io.WriteCloser
+		stream string
+		err    error
+	}
+	evictions := make(chan eviction, 2)
+	writer.SetEvictHandler(func(w io.WriteCloser, stream string, err error) {
+		evictions <- eviction{w, stream, err}
+	})
+	failing := &dummyWriter{failOnWrite: true}
+	writer.AddWriter(failing, "stdout", BlockWhenFull)
+	writer.Write([]byte("foo\n"))
+	e := <-evictions
+	if e.writer != failing || e.stream != "stdout" || e.err == nil || e.err.Error() != "Fake fail" {
+		t.Fatalf("Unexpected eviction %v", e)
+	}
+
+	writer.SetQueue(1)
+	slow := &blockingWriter{unblock: make(chan struct{}), closed: make(chan struct{})}
+	writer.AddWriter(slow, "", DisconnectWhenFull)
+	writer.Write([]byte("a"))
+	writer.Write([]byte("b"))
+	writer.Write([]byte("c"))
+	close(slow.unblock)
+	if e := <-evictions; e.writer != slow || e.err != ErrQueueFull {
+		t.Fatalf("Unexpected eviction %v", e)
+	}
+	if evicted := writer.Evicted(); evicted != 2 {
+		t.Fatalf("Expected 2 evicted writers, got %d", evicted)
+	}
+	writer.Clean()
+}
+
+func TestBroadcastWriterRemoveWriter(t *testing.T) {
+	writer := New()
+	bufferA := &blockingWriter{unblock: make(chan struct{}), closed: make(chan struct{})}
+	close(bufferA.unblock)
+	writer.AddWriter(bufferA, "stdout", BlockWhenFull)
+	bufferB := &dummyWriter{}
+	writer.AddWriter(bufferB, "stdout", BlockWhenFull)
+
+	writer.Write([]byte("foo\n"))
+	writer.RemoveWriter(bufferA, "stdout")
+	<-bufferA.closed
+	writer.Write([]byte("bar\n"))
+	writer.wait()
+	if !strings.Contains(bufferA.String(), "foo") || strings.Contains(bufferA.String(), "bar") {
+		t.Fatalf("Expected only foo in the removed writer, got %s", bufferA.String())
+	}
+	if !strings.Contains(bufferB.String(), "bar") {
+		t.Fatalf("Expected bar in the remaining writer, got %s", bufferB.String())
+	}
+
+	// removing it again is a no-op
+	writer.RemoveWriter(bufferA, "stdout")
+	writer.Clean()
+}
+
+// notifyingWriter signals its writes on written
+type notifyingWriter struct {
+	dummyWriter
+	written chan struct{}
+}
+
+func (nw *notifyingWriter) Write(p []byte) (int, error) {
+	n, err := nw.dummyWriter.Write(p)
+	nw.written <- struct{}{}
+	return n, err
+}
+
+func TestBroadcastWriterPartialTimeout(t *testing.T) {
+	writer := New()
+	writer.SetPartialTimeout(10 * time.Millisecond)
+	buffer := &notifyingWriter{written: make(chan struct{}, 2)}
+	writer.AddFormattedWriter(buffer, "stdout", Raw, BlockWhenFull)
+
+	writer.Write([]byte("10%\r"))
+	writer.Write([]byte("50%\r"))
+	select {
+	case <-buffer.written:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the partial line to be sent")
+	}
+	if buffer.String() != "10%\r50%\r" {
+		t.Fatalf("Buffer contains %q", buffer.String())
+	}
+	writer.Write([]byte("100%\n"))
+	writer.wait()
+	if buffer.String() != "10%\r50%\r100%\n" {
+		t.Fatalf("Buffer contains %q", buffer.String())
+	}
+	writer.Clean()
+}
+
+func TestBroadcastWriterWithoutLineWriters(t *testing.T) {
+	writer := New()
+	raw := &dummyWriter{}
+	writer.AddWriter(raw, "", BlockWhenFull)
+	// the lines aren't split, but the partial line is kept
+	writer.Write([]byte("foo\nba"))
+	buffer := &dummyWriter{}
+	writer.AddFormattedWriter(buffer, "stdout", Raw, BlockWhenFull)
+	writer.Write([]byte("r\n"))
+	writer.wait()
+	if raw.String() != "foo\nbar\n" {
+		t.Fatalf("Buffer contains %q", raw.String())
+	}
+	if buffer.String() != "bar\n" {
+		t.Fatalf("Buffer contains %q", buffer.String())
+	}
+	writer.Clean()
+}
+
+func TestBroadcastWriterStdWriter(t *testing.T) {
+	writer := New()
+	muxed := &dummyWriter{}
+	writer.AddStdWriter(muxed, stdcopy.Stderr, BlockWhenFull)
+	raw := &dummyWriter{}
+	writer.AddWriter(raw, "", BlockWhenFull)
+
+	writer.Write([]byte("foo\nba"))
+	writer.Write([]byte("r\n"))
+	writer.wait()
+	if raw.String() != "foo\nbar\n" {
+		t.Fatalf("Buffer contains %q", raw.String())
+	}
+	if muxed.buffer.Len() != 2*stdcopy.StdWriterPrefixLen+len("foo\nbar\n") {
+		t.Fatalf("Expected 2 frames, got %q", muxed.String())
+	}
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, &muxed.buffer); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 || stderr.String() != "foo\nbar\n" {
+		t.Fatalf("Expected the output on stderr, got %q and %q", stdout.String(), stderr.String())
+	}
+	writer.Clean()
+}
+
+func TestBroadcastWriterReplay(t *testing.T) {
+	writer := New()
+	writer.SetReplaySize(8)
+	writer.Write([]byte("one\ntwo\nthree\n"))
+
+	raw := &dummyWriter{}
+	writer.AddWriter(raw, "", BlockWhenFull)
+	lines := &dummyWriter{}
+	writer.AddFormattedWriter(lines, "stdout", Raw, BlockWhenFull)
+	muxed := &dummyWriter{}
+	writer.AddStdWriter(muxed, stdcopy.Stdout, BlockWhenFull)
+	writer.Write([]byte("four\n"))
+	writer.wait()
+	if raw.String() != "o\nthree\nfour\n" {
+		t.Fatalf("Buffer contains %q", raw.String())
+	}
+	if lines.String() != "three\nfour\n" {
+		t.Fatalf("Buffer contains %q", lines.String())
+	}
+	var stdout bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, nil, &muxed.buffer); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "o\nthree\nfour\n" {
+		t.Fatalf("Expected the replay on stdout, got %q", stdout.String())
+	}
+
+	// nothing is replayed after Clean
+	writer.Clean()
+	late := &dummyWriter{}
+	writer.AddWriter(late, "", BlockWhenFull)
+	writer.wait()
+	if late.String() != "" {
+		t.Fatalf("Buffer contains %q", late.String())
+	}
+	writer.Clean()
+}
+
+// recordFormatter writes the sequence number and time of every line
+type recordFormatter struct{}
+
+func (recordFormatter) Format(buf *bytes.Buffer, line *Line, stream string) error {
+	fmt.Fprintf(buf, "%d %d %s", line.Seq, line.Created.UnixNano(), line.Text)
+	return nil
+}
+
+func TestBroadcastWriterLineSeqAndTime(t *testing.T) {
+	writer := New()
+	buffer := &dummyWriter{}
+	writer.AddFormattedWriter(buffer, "stdout", recordFormatter{}, BlockWhenFull)
+
+	writer.Write([]byte("foo\nba"))
+	time.Sleep(time.Millisecond)
+	writer.Write([]byte("r\nbaz\n"))
+	writer.wait()
+
+	var (
+		seqs    []uint64
+		created []int64
+		texts   []string
+	)
+	for _, record := range strings.SplitAfter(buffer.String(), "\n") {
+		if record == "" {
+			continue
+		}
+		var (
+			seq  uint64
+			nano int64
+			text string
+		)
+		if _, err := fmt.Sscanf(record, "%d %d %s", &seq, &nano, &text); err != nil {
+			t.Fatalf("%q: %v", record, err)
+		}
+		seqs, created, texts = append(seqs, seq), append(created, nano), append(texts, text)
+	}
+	if strings.Join(texts, " ") != "foo bar baz" {
+		t.Fatalf("Unexpected lines %v", texts)
+	}
+	if seqs[0] != 1 || seqs[1] != 2 || seqs[2] != 3 {
+		t.Fatalf("Unexpected sequence numbers %v", seqs)
+	}
+	// bar started in the first write, baz in the second
+	if created[1] != created[0] || created[2] <= created[1] {
+		t.Fatalf("Unexpected times %v", created)
+	}
+	writer.Clean()
+}
@@ -0,0 +1,70 @@
+package broadcastwriter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Filter is evaluated on every line sent to a writer before it is formatted.
+// It returns the line to write, or false to drop it. Lines end with a newline,
+// unless they are a partial line sent by Flush.
+type Filter func(line, stream string) (string, bool)
+
+// apply returns the lines of stream accepted by f
+func (f Filter) apply(lines []Line, stream string) []Line {
+	var filtered []Line
+	for _, line := range lines {
+		if text, ok := f(line.Text, stream); ok {
+			line.Text = text
+			filtered = append(filtered, line)
+		}
+	}
+	return filtered
+}
+
+// StreamFilter accepts the lines of streams
+func StreamFilter(streams ...string) Filter {
+	return func(line, stream string) (string, bool) {
+		for _, s := range streams {
+			if s == stream {
+				return line, true
+			}
+		}
+		return "", false
+	}
+}
+
+// MatchFilter accepts the lines matching re
+func MatchFilter(re *regexp.Regexp) Filter {
+	return func(line, stream string) (string, bool) {
+		return line, re.MatchString(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// TruncateFilter truncates the lines longer than max bytes, not counting
+// their newline
+func TruncateFilter(max int) Filter {
+	return func(line, stream string) (string, bool) {
+		if len(strings.TrimSuffix(line, "\n")) <= max {
+			return line, true
+		}
+		if strings.HasSuffix(line, "\n") {
+			return line[:max] + "\n", true
+		}
+		return line[:max], true
+	}
+}
+
+// ChainFilters returns a Filter accepting the lines accepted by all filters,
+// transformed by each of them in order
+func ChainFilters(filters ...Filter) Filter {
+	return func(line, stream string) (string, bool) {
+		for _, f := range filters {
+			var ok bool
+			if line, ok = f(line, stream); !ok {
+				return "", false
+			}
+		}
+		return line, true
+	}
+}
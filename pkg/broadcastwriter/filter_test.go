@@ -0,0 +1,45 @@
+package broadcastwriter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	filter := ChainFilters(StreamFilter("stderr"), MatchFilter(regexp.MustCompile("^err")), TruncateFilter(5))
+	for _, tc := range []struct {
+		line, stream string
+		expected     string
+		ok           bool
+	}{
+		{"error: oops\n", "stderr", "error\n", true},
+		{"err\n", "stderr", "err\n", true},
+		{"error: partial", "stderr", "error", true},
+		{"error: oops\n", "stdout", "", false},
+		{"warning\n", "stderr", "", false},
+	} {
+		line, ok := filter(tc.line, tc.stream)
+		if line != tc.expected || ok != tc.ok {
+			t.Errorf("%q on %s: expected %q %v, got %q %v", tc.line, tc.stream, tc.expected, tc.ok, line, ok)
+		}
+	}
+}
+
+func TestBroadcastWriterFilteredWriters(t *testing.T) {
+	writer := New()
+	all := &dummyWriter{}
+	writer.AddFormattedWriter(all, "stdout", Raw, BlockWhenFull)
+	filtered := &dummyWriter{}
+	writer.AddFilteredWriter(filtered, "stdout", Raw, MatchFilter(regexp.MustCompile("b")), BlockWhenFull)
+
+	writer.Write([]byte("foo\nbar\nbaz\n"))
+	writer.Write([]byte("qux\n"))
+	writer.wait()
+	if all.String() != "foo\nbar\nbaz\nqux\n" {
+		t.Errorf("Buffer contains %q", all.String())
+	}
+	if filtered.String() != "bar\nbaz\n" {
+		t.Errorf("Buffer contains %q", filtered.String())
+	}
+	writer.Clean()
+}
@@ -0,0 +1,134 @@
+package broadcastwriter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonlog"
+)
+
+// Formatter formats the lines a BroadcastWriter sends to a writer. Each line
+// is formatted once for all the writers of a stream sharing an equal
+// Formatter, so Formatters must be comparable.
+type Formatter interface {
+	// Format appends line, written to stream, to buf
+	Format(buf *bytes.Buffer, line *Line, stream string) error
+}
+
+var (
+	// Raw writes the lines as they are
+	Raw Formatter = rawFormatter{}
+	// JSONLog writes every line as a serialized jsonlog.JSONLog with its
+	// sequence number
+	JSONLog Formatter = jsonLogFormatter{}
+	// Logfmt writes every line as logfmt key=value pairs with its
+	// sequence number, partial lines are marked with partial=true
+	Logfmt Formatter = logfmtFormatter{}
+)
+
+type rawFormatter struct{}
+
+func (rawFormatter) Format(buf *bytes.Buffer, line *Line, stream string) error {
+	buf.WriteString(line.Text)
+	return nil
+}
+
+type jsonLogFormatter struct{}
+
+func (jsonLogFormatter) Format(buf *bytes.Buffer, line *Line, stream string) error {
+	jsonLog := jsonlog.JSONLog{Log: line.Text, Stream: stream, Created: line.Created, Seq: line.Seq}
+	if err := jsonLog.MarshalJSONBuf(buf); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+type logfmtFormatter struct{}
+
+func (logfmtFormatter) Format(buf *bytes.Buffer, line *Line, stream string) error {
+	fmt.Fprintf(buf, "time=%s stream=%s seq=%d msg=%s",
+		line.Created.Format(time.RFC3339Nano), logfmtValue(stream), line.Seq, logfmtValue(strings.TrimSuffix(line.Text, "\n")))
+	if !strings.HasSuffix(line.Text, "\n") {
+		buf.WriteString(" partial=true")
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// logfmtValue quotes value when it is empty or holds spaces, quotes, equal
+// signs or non printable characters
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, c := range value {
+		if c <= ' ' || c == '"' || c == '=' || c == '\\' || !strconv.IsPrint(c) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
+
+// Syslog severities of the lines of the stdout and stderr streams
+const (
+	syslogErr  = 3
+	syslogInfo = 6
+)
+
+// syslogMaxSequenceID is the largest sequenceId of a syslog message
+const syslogMaxSequenceID = 2147483647
+
+// SyslogFormatter writes every line as a RFC 5424 syslog message, with the
+// stream as MSGID and the sequence number as the sequenceId of the meta
+// structured data. Lines of stderr get the err severity, the others info.
+// Empty Hostname, AppName and ProcID are written as the nil value.
+type SyslogFormatter struct {
+	Facility int
+	Hostname string
+	AppName  string
+	ProcID   string
+}
+
+func (f SyslogFormatter) Format(buf *bytes.Buffer, line *Line, stream string) error {
+	if f.Facility < 0 || f.Facility > 23 {
+		return fmt.Errorf("Invalid syslog facility %d", f.Facility)
+	}
+	severity := syslogInfo
+	if stream == "stderr" {
+		severity = syslogErr
+	}
+	data := "-"
+	if line.Seq > 0 {
+		// sequenceId wraps after 2147483647
+		data = fmt.Sprintf(`[meta sequenceId="%d"]`, (line.Seq-1)%syslogMaxSequenceID+1)
+	}
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %s %s %s %s\n",
+		f.Facility*8+severity,
+		line.Created.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogValue(f.Hostname, 255),
+		syslogValue(f.AppName, 48),
+		syslogValue(f.ProcID, 128),
+		syslogValue(stream, 32),
+		data,
+		strings.TrimSuffix(line.Text, "\n"))
+	return nil
+}
+
+// syslogValue returns value as a header field of at most max printable ASCII
+// characters, or the nil value "-" when it is empty
+func syslogValue(value string, max int) string {
+	field := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(field) < max; i++ {
+		if c := value[i]; c > ' ' && c < 127 {
+			field = append(field, c)
+		}
+	}
+	if len(field) == 0 {
+		return "-"
+	}
+	return string(field)
+}
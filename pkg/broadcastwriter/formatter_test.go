@@ -0,0 +1,63 @@
+package broadcastwriter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFormatters(t *testing.T) {
+	line := &Line{
+		Text:    "hello world\n",
+		Created: time.Date(2015, 2, 10, 12, 30, 5, 123456789, time.UTC),
+		Seq:     42,
+	}
+	for _, tc := range []struct {
+		formatter Formatter
+		stream    string
+		expected  string
+	}{
+		{Raw, "stdout", "hello world\n"},
+		{JSONLog, "stdout", `{"log":"hello world\n","stream":"stdout","time":"2015-02-10T12:30:05.123456789Z","seq":42}` + "\n"},
+		{Logfmt, "stdout", `time=2015-02-10T12:30:05.123456789Z stream=stdout seq=42 msg="hello world"` + "\n"},
+		{SyslogFormatter{Facility: 1, Hostname: "host", AppName: "app name"}, "stderr", "<11>1 2015-02-10T12:30:05.123456Z host appname - stderr [meta sequenceId=\"42\"] hello world\n"},
+		{SyslogFormatter{Facility: 1}, "stdout", "<14>1 2015-02-10T12:30:05.123456Z - - - stdout [meta sequenceId=\"42\"] hello world\n"},
+	} {
+		var buf bytes.Buffer
+		if err := tc.formatter.Format(&buf, line, tc.stream); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := (SyslogFormatter{Facility: 24}).Format(&buf, line, "stdout"); err == nil {
+		t.Fatal("Expected an invalid facility to fail")
+	}
+}
+
+func TestBroadcastWriterFormattedWriters(t *testing.T) {
+	writer := New()
+	raw := &dummyWriter{}
+	writer.AddFormattedWriter(raw, "stdout", Raw, BlockWhenFull)
+	logfmt := &dummyWriter{}
+	writer.AddFormattedWriter(logfmt, "stdout", Logfmt, BlockWhenFull)
+	jsonLog := &dummyWriter{}
+	writer.AddWriter(jsonLog, "stdout", BlockWhenFull)
+
+	writer.Write([]byte("foo\nba"))
+	writer.Write([]byte("r\n"))
+	writer.wait()
+	if raw.String() != "foo\nbar\n" {
+		t.Errorf("Buffer contains %q", raw.String())
+	}
+	if !bytes.Contains(logfmt.buffer.Bytes(), []byte("msg=bar\n")) {
+		t.Errorf("Buffer contains %q", logfmt.String())
+	}
+	if !bytes.Contains(jsonLog.buffer.Bytes(), []byte(`"log":"bar\n"`)) {
+		t.Errorf("Buffer contains %q", jsonLog.String())
+	}
+	writer.Clean()
+}
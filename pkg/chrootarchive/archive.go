@@ -1,49 +1,95 @@
 package chrootarchive
 
 import (
-	"bytes"
-	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strings"
 	"syscall"
 
 	"github.com/docker/docker/pkg/archive"
-	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/ulimit"
 )
 
 var chrootArchiver = &archive.Archiver{Untar: Untar}
 
-func chroot(path string) error {
-	if err := syscall.Chroot(path); err != nil {
-		return err
-	}
-	return syscall.Chdir("/")
+// Limits bound the resources of the docker-untar helper
+type Limits struct {
+	// Rlimits are set on the helper before it reads the archive
+	Rlimits []*ulimit.Rlimit
+	// Memory, if set, is the limit in bytes of a memory cgroup the helper
+	// is moved into
+	Memory int64
 }
 
-func untar() {
-	runtime.LockOSThread()
-	flag.Parse()
-	if err := chroot(flag.Arg(0)); err != nil {
-		fatal(err)
-	}
-	var options *archive.TarOptions
-	if err := json.NewDecoder(strings.NewReader(flag.Arg(1))).Decode(&options); err != nil {
-		fatal(err)
+// HelperLimits are applied to every docker-untar helper, so that a
+// decompression bomb or a pathological archive can't exhaust the memory
+// or the file descriptors of the host. They are only enforced on Linux.
+var HelperLimits = Limits{
+	Rlimits: []*ulimit.Rlimit{
+		{Type: ulimit.RLIMIT_NOFILE, Soft: 1024, Hard: 1024},
+		{Type: ulimit.RLIMIT_DATA, Soft: 2 << 30, Hard: 2 << 30},
+		{Type: ulimit.RLIMIT_CORE, Soft: 0, Hard: 0},
+	},
+}
+
+var (
+	ErrNoSpace        = errors.New("No space left to unpack the archive")
+	ErrPermission     = errors.New("Permission denied unpacking the archive")
+	ErrCorruptArchive = errors.New("Archive is corrupt or truncated")
+)
+
+// UntarError is returned by Untar when the helper reports why unpacking
+// failed. Err is ErrNoSpace, ErrPermission or ErrCorruptArchive when the
+// failure is one of these, so callers can tell e.g. whether retrying a pull
+// makes sense, the underlying error otherwise.
+type UntarError struct {
+	Op    string
+	Path  string
+	Errno syscall.Errno
+	Err   error
+}
+
+func (e *UntarError) Error() string {
+	msg := e.Err.Error()
+	if e.Errno != 0 && e.Err != e.Errno {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Errno)
 	}
-	if err := archive.Unpack(os.Stdin, "/", options); err != nil {
-		fatal(err)
+	switch {
+	case e.Path != "":
+		return fmt.Sprintf("Untar %s %s: %s", e.Op, e.Path, msg)
+	case e.Op != "":
+		return fmt.Sprintf("Untar %s: %s", e.Op, msg)
 	}
-	// fully consume stdin in case it is zero padded
-	flush(os.Stdin)
-	os.Exit(0)
+	return "Untar " + msg
 }
 
+// chrootDisabled reports whether DOCKER_CHROOT_DISABLE asks for archives to
+// be unpacked without chroot, e.g. for tests running as an unprivileged user
+func chrootDisabled() bool {
+	return os.Getenv("DOCKER_CHROOT_DISABLE") != ""
+}
+
+// Untar reads a stream of bytes from `tarArchive`, parses it as a tar archive,
+// and unpacks it into the directory at `dest` from within a chroot.
+// options.Progress, if set, is called as the extraction proceeds.
 func Untar(tarArchive io.Reader, dest string, options *archive.TarOptions) error {
+	var progress func(bytes, entries int64)
+	if options != nil {
+		progress = options.Progress
+	}
+	return UntarWithProgress(tarArchive, dest, options, progress)
+}
+
+// UntarWithProgress is like Untar, calling progress with the number of
+// content bytes and entries extracted so far while the archive is unpacked.
+// The records are streamed back from the helper process over a pipe.
+//
+// Archives are unpacked from within a chroot on Linux only, on other
+// platforms and when DOCKER_CHROOT_DISABLE is set they are unpacked with
+// archive.Untar, which still refuses entries breaking out of dest.
+func UntarWithProgress(tarArchive io.Reader, dest string, options *archive.TarOptions, progress func(bytes, entries int64)) error {
 	if tarArchive == nil {
 		return fmt.Errorf("Empty archive")
 	}
@@ -54,32 +100,39 @@ func Untar(tarArchive io.Reader, dest string, options *archive.TarOptions) error
 		options.ExcludePatterns = []string{}
 	}
 
-	var (
-		buf bytes.Buffer
-		enc = json.NewEncoder(&buf)
-	)
-	if err := enc.Encode(options); err != nil {
-		return fmt.Errorf("Untar json encode: %v", err)
-	}
 	if _, err := os.Stat(dest); os.IsNotExist(err) {
 		if err := os.MkdirAll(dest, 0777); err != nil {
 			return err
 		}
 	}
 	dest = filepath.Clean(dest)
-	decompressedArchive, err := archive.DecompressStream(tarArchive)
-	if err != nil {
-		return err
+	if chrootDisabled() {
+		return untarUnchrooted(tarArchive, dest, options, progress)
 	}
-	defer decompressedArchive.Close()
+	return untarChrooted(tarArchive, dest, options, progress)
+}
 
-	cmd := reexec.Command("docker-untar", dest, buf.String())
-	cmd.Stdin = decompressedArchive
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("Untar %s %s", err, out)
+// Tar creates an archive of the directory at `src` from within a chroot,
+// so that symlinks in an untrusted filesystem, e.g. a container's, can't
+// lead it to read files of the host. It takes the same options as
+// archive.TarWithOptions, errors of the helper are returned when the archive
+// is read.
+func Tar(src string, options *archive.TarOptions) (io.ReadCloser, error) {
+	if options == nil {
+		options = &archive.TarOptions{}
+	}
+	src = filepath.Clean(src)
+	if chrootDisabled() {
+		return archive.TarWithOptions(src, options)
 	}
-	return nil
+	return tarChrooted(src, options)
+}
+
+// untarUnchrooted unpacks the archive in the daemon's own root
+func untarUnchrooted(tarArchive io.Reader, dest string, options *archive.TarOptions, progress func(bytes, entries int64)) error {
+	opts := *options
+	opts.Progress = progress
+	return archive.Untar(tarArchive, dest, &opts)
 }
 
 func TarUntar(src, dst string) error {
@@ -0,0 +1,392 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+)
+
+// untarResult is a record sent by the docker-untar helper over its results
+// pipe: progress records while the archive is unpacked, when requested, then
+// a final record carrying the error, if any. Op, Path and Errno describe the
+// error when it comes from a system call, Op is "read" with no Errno when the
+// archive itself couldn't be parsed.
+type untarResult struct {
+	Bytes   int64  `json:"bytes"`
+	Entries int64  `json:"entries"`
+	Done    bool   `json:"done,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Op      string `json:"op,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Errno   int    `json:"errno,omitempty"`
+}
+
+// progressInterval is the minimum delay between two progress records
+const progressInterval = 100 * time.Millisecond
+
+// The docker-untar helper reads its options from fd 3 and writes its results
+// to fd 4, keeping them out of its command line
+const (
+	optionsFd = 3
+	resultsFd = 4
+)
+
+// unpackWorkers is the number of goroutines the helper writes files with when
+// the caller didn't ask for a given number
+func unpackWorkers() int {
+	if n := runtime.NumCPU(); n < 4 {
+		return n
+	}
+	return 4
+}
+
+// archiveErrors are reported by the helper with their message and turned
+// back into the typed errors for callers
+var archiveErrors = []error{
+	archive.ErrMaxSizeExceeded,
+	archive.ErrMaxEntriesExceeded,
+	archive.ErrDigestMismatch,
+	archive.ErrCanceled,
+}
+
+// setError fills the error fields of the final record of the helper
+func (r *untarResult) setError(err error) {
+	r.Error = err.Error()
+	switch e := err.(type) {
+	case *os.PathError:
+		r.Op, r.Path, err = e.Op, e.Path, e.Err
+	case *os.LinkError:
+		r.Op, r.Path, err = e.Op, e.New, e.Err
+	case *os.SyscallError:
+		r.Op, err = e.Syscall, e.Err
+	}
+	switch err {
+	case tar.ErrHeader, io.ErrUnexpectedEOF:
+		r.Op = "read"
+	}
+	if errno, ok := err.(syscall.Errno); ok {
+		r.Errno = int(errno)
+	}
+}
+
+// untarError returns the error reported in the final record of the helper,
+// paths are reported relative to the chroot at dest
+func untarError(result untarResult, dest string) error {
+	for _, archiveErr := range archiveErrors {
+		if result.Error == archiveErr.Error() {
+			return archiveErr
+		}
+	}
+	if result.Errno == 0 && result.Op != "read" {
+		return fmt.Errorf("Untar %s", result.Error)
+	}
+	e := &UntarError{
+		Op:    result.Op,
+		Errno: syscall.Errno(result.Errno),
+	}
+	if result.Path != "" {
+		e.Path = filepath.Join(dest, result.Path)
+	}
+	switch e.Errno {
+	case 0:
+		e.Err = ErrCorruptArchive
+	case syscall.ENOSPC, syscall.EDQUOT:
+		e.Err = ErrNoSpace
+	case syscall.EPERM, syscall.EACCES, syscall.EROFS:
+		e.Err = ErrPermission
+	default:
+		e.Err = e.Errno
+	}
+	return e
+}
+
+func untar() {
+	runtime.LockOSThread()
+	flag.Parse()
+	var options *archive.TarOptions
+	if err := json.NewDecoder(os.NewFile(optionsFd, "options")).Decode(&options); err != nil {
+		fatal(err)
+	}
+	// SIGTERM cancels the unpack, reading the archive from a non-blocking
+	// stdin lets a pending read be interrupted too
+	if err := syscall.SetNonblock(0, true); err != nil {
+		fatal(err)
+	}
+	var (
+		stdin  = os.NewFile(0, "stdin")
+		cancel = make(chan struct{})
+		sigc   = make(chan os.Signal, 1)
+	)
+	options.Cancel = cancel
+	signal.Notify(sigc, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		close(cancel)
+		stdin.SetReadDeadline(time.Now())
+	}()
+	if err := chroot(flag.Arg(0)); err != nil {
+		fatal(err)
+	}
+	if options.Workers == 0 {
+		options.Workers = unpackWorkers()
+	}
+	allThreads, err := restrictSyscalls()
+	if err != nil {
+		fatal(err)
+	}
+	if !allThreads {
+		// only this thread is confined, unpack on it
+		options.Workers = 1
+	}
+	var (
+		results = json.NewEncoder(os.NewFile(resultsFd, "results"))
+		last    untarResult
+		sent    time.Time
+	)
+	if flag.Arg(1) == "progress" {
+		options.Progress = func(bytes, entries int64) {
+			last = untarResult{Bytes: bytes, Entries: entries}
+			if time.Since(sent) >= progressInterval {
+				results.Encode(last)
+				sent = time.Now()
+			}
+		}
+	}
+	err = archive.Unpack(stdin, "/", options)
+	last.Done = true
+	if err != nil {
+		last.setError(err)
+		results.Encode(last)
+		os.Exit(1)
+	}
+	results.Encode(last)
+	// fully consume stdin in case it is zero padded
+	flush(stdin)
+	os.Exit(0)
+}
+
+// untarChrooted unpacks the archive from the docker-untar helper, chrooted
+// into dest. Closing options.Cancel sends SIGTERM to the helper, which
+// removes what it unpacked and exits.
+func untarChrooted(tarArchive io.Reader, dest string, options *archive.TarOptions, progress func(bytes, entries int64)) error {
+	var (
+		buf bytes.Buffer
+		enc = json.NewEncoder(&buf)
+	)
+	if err := enc.Encode(options); err != nil {
+		return fmt.Errorf("Untar json encode: %v", err)
+	}
+	args := []string{"docker-untar", dest}
+	if progress != nil {
+		args = append(args, "progress")
+	}
+	cmd := command(args...)
+	// the archive is copied by hand, so that a stalled stream doesn't keep
+	// Wait from returning once the helper is gone
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+
+	optionsR, optionsW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer optionsW.Close()
+	resultsR, resultsW, err := os.Pipe()
+	if err != nil {
+		optionsR.Close()
+		return err
+	}
+	defer resultsR.Close()
+	cmd.ExtraFiles = []*os.File{optionsR, resultsW}
+
+	err = cmd.Start()
+	// only the helper holds these ends now
+	optionsR.Close()
+	resultsW.Close()
+	if err != nil {
+		return err
+	}
+
+	decompressedArchive, err := archive.DecompressStream(tarArchive)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	// the archive is closed by the copy, which may outlive this function if
+	// the stream stalls. Its error is sent before stdin is closed: a helper
+	// which succeeded read stdin to the end, so the error is known by then.
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(stdin, decompressedArchive)
+		copyErr <- err
+		stdin.Close()
+		decompressedArchive.Close()
+	}()
+
+	// the helper waits for its options, it is limited before it reads the
+	// archive
+	cgroup, err := applyLimits(cmd.Process.Pid)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if cgroup != "" {
+		defer os.Remove(cgroup)
+	}
+
+	// the helper reads its options before touching the archive
+	if _, err := optionsW.Write(buf.Bytes()); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("Untar sending options: %v %s", err, out.Bytes())
+	}
+	optionsW.Close()
+
+	if options.Cancel != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-options.Cancel:
+				cmd.Process.Signal(syscall.SIGTERM)
+			case <-done:
+			}
+		}()
+	}
+
+	var (
+		result untarResult
+		dec    = json.NewDecoder(resultsR)
+	)
+	for {
+		var r untarResult
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		if r.Done {
+			result = r
+			continue
+		}
+		if progress != nil {
+			progress(r.Bytes, r.Entries)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if result.Done && result.Error != "" {
+			return untarError(result, dest)
+		}
+		return fmt.Errorf("Untar %s %s", err, out.Bytes())
+	}
+	// e.g. a truncated stream or a corrupted checksum at the end of the
+	// compressed archive, which the helper can't tell from its end
+	select {
+	case err := <-copyErr:
+		if err != nil {
+			return fmt.Errorf("Untar reading the archive: %v", err)
+		}
+	default:
+	}
+	if progress != nil {
+		progress(result.Bytes, result.Entries)
+	}
+	return nil
+}
+
+// packArchive is the docker-tar helper, it writes the archive of the
+// directory it chroots into to its stdout
+func packArchive() {
+	runtime.LockOSThread()
+	flag.Parse()
+	var options *archive.TarOptions
+	if err := json.NewDecoder(os.NewFile(optionsFd, "options")).Decode(&options); err != nil {
+		fatal(err)
+	}
+	// stdout carries the archive
+	log.SetOutput(os.Stderr)
+	if err := chroot(flag.Arg(0)); err != nil {
+		fatal(err)
+	}
+	// the archive is written from another goroutine, which is only
+	// confined on kernels applying the filter to all threads
+	if _, err := restrictSyscalls(); err != nil {
+		fatal(err)
+	}
+	rc, err := archive.TarWithOptions("/", options)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := io.Copy(os.Stdout, rc); err != nil {
+		fatal(err)
+	}
+	os.Exit(0)
+}
+
+// tarChrooted streams the archive of src from the docker-tar helper,
+// chrooted into src. The helper is killed if the archive is closed before
+// it is fully read.
+func tarChrooted(src string, options *archive.TarOptions) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(options); err != nil {
+		return nil, fmt.Errorf("Tar json encode: %v", err)
+	}
+
+	cmd := command("docker-tar", src)
+	var errBuf bytes.Buffer
+	cmd.Stderr = &errBuf
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	optionsR, optionsW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer optionsW.Close()
+	cmd.ExtraFiles = []*os.File{optionsR}
+
+	err = cmd.Start()
+	optionsR.Close()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := optionsW.Write(buf.Bytes()); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("Tar sending options: %v %s", err, errBuf.Bytes())
+	}
+	optionsW.Close()
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		if _, err := io.Copy(pipeWriter, stdout); err != nil {
+			// the reader went away
+			cmd.Process.Kill()
+		}
+		if err := cmd.Wait(); err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("Tar %s %s", err, errBuf.Bytes()))
+			return
+		}
+		pipeWriter.Close()
+	}()
+	return pipeReader, nil
+}
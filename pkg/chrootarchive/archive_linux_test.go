@@ -0,0 +1,287 @@
+package chrootarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/ulimit"
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+	"github.com/docker/libcontainer/cgroups"
+)
+
+func TestChrootUntarTruncatedArchive(t *testing.T) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Typeflag: tar.TypeReg, Size: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("truncated")); err != nil {
+		t.Fatal(err)
+	}
+	dest, err := ioutil.TempDir("", "docker-TestChrootUntarTruncatedArchive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	err = Untar(buf, dest, nil)
+	e, ok := err.(*UntarError)
+	if !ok {
+		t.Fatalf("expected an UntarError, got %v", err)
+	}
+	if e.Err != ErrCorruptArchive {
+		t.Fatalf("expected ErrCorruptArchive, got %v", e.Err)
+	}
+}
+
+func TestChrootUntarCorruptedGzip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Typeflag: tar.TypeReg, Size: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the checksum of the content is in the trailer, after the whole archive
+	data := buf.Bytes()
+	data[len(data)-8] ^= 0xff
+
+	dest, err := ioutil.TempDir("", "docker-TestChrootUntarCorruptedGzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := Untar(bytes.NewReader(data), dest, nil); err == nil {
+		t.Fatal("expected an error for an archive with a bad checksum")
+	}
+}
+
+func TestUntarErrorFromResult(t *testing.T) {
+	var result untarResult
+	result.setError(&os.PathError{Op: "open", Path: "/dir/file", Err: syscall.ENOSPC})
+	err := untarError(result, "/dest")
+	e, ok := err.(*UntarError)
+	if !ok {
+		t.Fatalf("expected an UntarError, got %v", err)
+	}
+	if e.Err != ErrNoSpace || e.Op != "open" || e.Path != "/dest/dir/file" || e.Errno != syscall.ENOSPC {
+		t.Fatalf("unexpected error %#v", e)
+	}
+	if expected := "Untar open /dest/dir/file: No space left to unpack the archive (no space left on device)"; e.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, e.Error())
+	}
+
+	result = untarResult{}
+	result.setError(&os.LinkError{Op: "link", Old: "/a", New: "/b", Err: syscall.EACCES})
+	if e, ok := untarError(result, "/dest").(*UntarError); !ok || e.Err != ErrPermission {
+		t.Fatalf("expected ErrPermission, got %v", e)
+	}
+}
+
+func TestChrootTarDoesNotFollowSymlinksOut(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootTarDoesNotFollowSymlinksOut")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	outside := filepath.Join(tmpdir, "outside")
+	src := filepath.Join(tmpdir, "src")
+	for _, dir := range []string{outside, src} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := Tar(src, &archive.TarOptions{IncludeFiles: []string{"toto", "link/secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var names []string
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if len(names) != 1 || names[0] != "toto" {
+		t.Fatalf("expected only toto in the archive, got %v", names)
+	}
+}
+
+func TestChrootTarPreserveXattrs(t *testing.T) {
+	src, err := ioutil.TempDir("", "docker-TestChrootTarPreserveXattrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	file := filepath.Join(src, "file")
+	if err := ioutil.WriteFile(file, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Lsetxattr(file, "user.test", []byte("value"), 0); err != nil {
+		t.Skipf("extended attributes are not supported: %v", err)
+	}
+
+	stream, err := Tar(src, &archive.TarOptions{PreserveXattrs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	found := false
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "file" {
+			found = true
+			if v := hdr.Xattrs["user.test"]; v != "value" {
+				t.Fatalf("expected the extended attribute of the file in the archive, got %q", v)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected the file in the archive")
+	}
+}
+
+func TestChrootUntarHelperLimits(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarHelperLimits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(limits Limits) { HelperLimits = limits }(HelperLimits)
+	HelperLimits.Rlimits = append(HelperLimits.Rlimits, &ulimit.Rlimit{Type: ulimit.RLIMIT_FSIZE, Soft: 4, Hard: 4})
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Untar(stream, filepath.Join(tmpdir, "dest"), nil); err == nil {
+		t.Fatal("expected the file size limit to fail the untar")
+	}
+
+	if _, err := cgroups.FindCgroupMountpoint("memory"); err != nil {
+		t.Skip("the memory cgroup is not mounted")
+	}
+	HelperLimits = Limits{Memory: 64 << 20}
+	stream, err = archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Untar(stream, filepath.Join(tmpdir, "dest"), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChrootUntarCancel(t *testing.T) {
+	dest, err := ioutil.TempDir("", "docker-TestChrootUntarCancel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	// the stream stalls after its first entry
+	r, w := io.Pipe()
+	defer w.Close()
+	go func() {
+		tw := tar.NewWriter(w)
+		tw.WriteHeader(&tar.Header{Name: "toto", Mode: 0644, Typeflag: tar.TypeReg, Size: 10})
+		tw.Write([]byte("hello toto"))
+		tw.Flush()
+	}()
+
+	cancel := make(chan struct{})
+	options := &archive.TarOptions{
+		Cancel: cancel,
+		Progress: func(bytes, entries int64) {
+			if entries == 1 {
+				close(cancel)
+			}
+		},
+	}
+	if err := Untar(r, dest, options); err != archive.ErrCanceled {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "toto")); !os.IsNotExist(err) {
+		t.Fatalf("expected toto to be removed, got %v", err)
+	}
+}
+
+func TestChrootApplyLayerCancel(t *testing.T) {
+	dest, err := ioutil.TempDir("", "docker-TestChrootApplyLayerCancel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	// the stream stalls after its first entry
+	r, w := io.Pipe()
+	defer w.Close()
+	go func() {
+		tw := tar.NewWriter(w)
+		tw.WriteHeader(&tar.Header{Name: "toto", Mode: 0644, Typeflag: tar.TypeReg, Size: 10})
+		tw.Write([]byte("hello toto"))
+		tw.Flush()
+	}()
+
+	cancel := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := os.Lstat(filepath.Join(dest, "toto")); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		close(cancel)
+	}()
+	if _, err := ApplyLayer(dest, r, &archive.TarOptions{Cancel: cancel}); err != archive.ErrCanceled {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "toto")); !os.IsNotExist(err) {
+		t.Fatalf("expected toto to be removed, got %v", err)
+	}
+}
@@ -1,10 +1,12 @@
 package chrootarchive
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -45,6 +47,73 @@ func TestChrootTarUntar(t *testing.T) {
 	}
 }
 
+func TestChrootUntarWithProgress(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarWithProgress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "lolo"), []byte("hello lolo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	var bytes, entries int64
+	if err := UntarWithProgress(stream, dest, nil, func(b, e int64) {
+		bytes, entries = b, e
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes != 20 {
+		t.Fatalf("expected 20 bytes to be reported, got %d", bytes)
+	}
+	// the root directory of the archive already exists and is skipped
+	if entries != 2 {
+		t.Fatalf("expected 2 entries to be reported, got %d", entries)
+	}
+}
+
+func TestChrootUntarWithHugeExcludesList(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarHugeExcludes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	// 65536 patterns take well over ARG_MAX once JSON encoded
+	excludes := make([]string, 65536)
+	for i := range excludes {
+		excludes[i] = fmt.Sprintf("/does/not/exist/excluded-%d", i)
+	}
+	if err := Untar(stream, dest, &archive.TarOptions{ExcludePatterns: excludes}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "toto")); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type slowEmptyTarReader struct {
 	size      int
 	offset    int
@@ -95,7 +164,218 @@ func TestChrootApplyEmptyArchiveFromSlowReader(t *testing.T) {
 		t.Fatal(err)
 	}
 	stream := &slowEmptyTarReader{size: 10240, chunkSize: 1024}
-	if _, err := ApplyLayer(dest, stream); err != nil {
+	if _, err := ApplyLayer(dest, stream, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChrootApplyLayerWhiteout(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootApplyLayerWhiteout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	dest := filepath.Join(tmpdir, "dest")
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dest, "removed"), []byte("removed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	layer := filepath.Join(tmpdir, "layer")
+	if err := os.MkdirAll(layer, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(layer, ".wh.removed"), nil, 0644); err != nil {
 		t.Fatal(err)
 	}
+	if err := ioutil.WriteFile(filepath.Join(layer, "added"), []byte("added"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(layer, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyLayer(dest, stream, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "removed")); !os.IsNotExist(err) {
+		t.Fatalf("expected whiteout to remove the file, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "added")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChrootUntarMaxSize(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarMaxSize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	if err := Untar(stream, dest, &archive.TarOptions{MaxSize: 5}); err != archive.ErrMaxSizeExceeded {
+		t.Fatalf("expected ErrMaxSizeExceeded, got %v", err)
+	}
+}
+
+func TestChrootApplyLayerMaxEntries(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootApplyLayerMaxEntries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"toto", "titi"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte("hello "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyLayer(dest, stream, &archive.TarOptions{MaxEntries: 1}); err != archive.ErrMaxEntriesExceeded {
+		t.Fatalf("expected ErrMaxEntriesExceeded, got %v", err)
+	}
+}
+
+func TestUntarChrootDisabled(t *testing.T) {
+	os.Setenv("DOCKER_CHROOT_DISABLE", "1")
+	defer os.Unsetenv("DOCKER_CHROOT_DISABLE")
+
+	tmpdir, err := ioutil.TempDir("", "docker-TestUntarChrootDisabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	var entries int64
+	if err := UntarWithProgress(stream, dest, nil, func(b, e int64) { entries = e }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "toto")); err != nil {
+		t.Fatal(err)
+	}
+	if entries != 1 {
+		t.Fatalf("expected 1 entry to be reported, got %d", entries)
+	}
+}
+
+func TestChrootUntarLeavesNoPivotRoot(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarLeavesNoPivotRoot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	if err := TarUntar(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "toto" {
+		t.Fatalf("expected only toto to be unpacked, got %v", files)
+	}
+}
+
+func TestChrootUntarDigestMismatch(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootUntarDigestMismatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	options := &archive.TarOptions{Digest: "sha256:" + strings.Repeat("0", 64)}
+	if err := Untar(stream, dest, options); err != archive.ErrDigestMismatch {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "toto")); !os.IsNotExist(err) {
+		t.Fatalf("expected toto to be removed, got %v", err)
+	}
+}
+
+func TestChrootApplyLayerDigestMismatch(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "docker-TestChrootApplyLayerDigestMismatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(src, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "toto"), []byte("hello toto"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(tmpdir, "dest")
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dest, "existing"), []byte("existing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := &archive.TarOptions{Digest: "sha256:" + strings.Repeat("0", 64)}
+	if _, err := ApplyLayer(dest, stream, options); err != archive.ErrDigestMismatch {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "toto")); !os.IsNotExist(err) {
+		t.Fatalf("expected toto to be removed, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "existing")); err != nil {
+		t.Fatalf("expected existing to be kept, got %v", err)
+	}
 }
@@ -0,0 +1,17 @@
+// +build !linux
+
+package chrootarchive
+
+import (
+	"io"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+func untarChrooted(tarArchive io.Reader, dest string, options *archive.TarOptions, progress func(bytes, entries int64)) error {
+	return untarUnchrooted(tarArchive, dest, options, progress)
+}
+
+func tarChrooted(src string, options *archive.TarOptions) (io.ReadCloser, error) {
+	return archive.TarWithOptions(src, options)
+}
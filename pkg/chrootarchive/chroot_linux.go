@@ -0,0 +1,89 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+// command returns the command running the helper registered as args[0],
+// started in a mount namespace of its own.
+//
+// The namespace must not be unshared by the helper itself: unsharing it
+// also unshares the root directory of the calling thread only, leaving the
+// goroutines scheduled on the other threads of the helper outside of the
+// chroot.
+func command(args ...string) *exec.Cmd {
+	cmd := reexec.Command(args...)
+	cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWNS
+	return cmd
+}
+
+// chroot confines the helper to path. When it runs in a mount namespace of
+// its own it pivot_roots into path, detaching the old root so that neither
+// the host mounts nor a way back to them remain visible; otherwise, or on
+// filesystems where this isn't possible (e.g. a rootfs root), it falls back
+// to a plain chroot. Both apply to all the threads of the helper.
+func chroot(path string) error {
+	if !ownMountNamespace() {
+		return realChroot(path)
+	}
+	return pivotRoot(path)
+}
+
+// ownMountNamespace reports whether the process runs in another mount
+// namespace than its parent
+func ownMountNamespace() bool {
+	ns, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return false
+	}
+	parentNs, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", os.Getppid()))
+	return err == nil && ns != parentNs
+}
+
+// pivotRoot falls back to realChroot as long as the root hasn't changed yet,
+// errors once it has are returned as is
+func pivotRoot(path string) error {
+	// keep the mounts below from propagating back to the host
+	if err := syscall.Mount("", "/", "", syscall.MS_PRIVATE|syscall.MS_REC, ""); err != nil {
+		return realChroot(path)
+	}
+	// pivot_root needs the new root to be a mount point
+	if err := syscall.Mount(path, path, "bind", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return realChroot(path)
+	}
+
+	oldroot, err := ioutil.TempDir(path, ".pivot_root")
+	if err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(path, oldroot); err != nil {
+		os.Remove(oldroot)
+		return realChroot(path)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+
+	// the old root is now below the new one, detach and remove it
+	oldroot = filepath.Join("/", filepath.Base(oldroot))
+	if err := syscall.Unmount(oldroot, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("Error unmounting the old root: %v", err)
+	}
+	return os.Remove(oldroot)
+}
+
+func realChroot(path string) error {
+	if err := syscall.Chroot(path); err != nil {
+		return err
+	}
+	return syscall.Chdir("/")
+}
@@ -1,82 +1,23 @@
 package chrootarchive
 
 import (
-	"bytes"
-	"encoding/json"
-	"flag"
-	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
-	"runtime"
-	"syscall"
 
 	"github.com/docker/docker/pkg/archive"
-	"github.com/docker/docker/pkg/reexec"
 )
 
-type applyLayerResponse struct {
-	LayerSize int64 `json:"layerSize"`
-}
-
-func applyLayer() {
-	runtime.LockOSThread()
-	flag.Parse()
-
-	if err := chroot(flag.Arg(0)); err != nil {
-		fatal(err)
-	}
-
-	// We need to be able to set any perms
-	oldmask := syscall.Umask(0)
-	defer syscall.Umask(oldmask)
-	tmpDir, err := ioutil.TempDir("/", "temp-docker-extract")
-	if err != nil {
-		fatal(err)
+// ApplyLayer parses a diff in the standard layer format from `layer`, and
+// applies it to the directory `dest` from within a chroot. As for Untar, the
+// layer is applied with archive.ApplyLayer on other platforms than Linux and
+// when DOCKER_CHROOT_DISABLE is set. options may be nil, MaxSize, MaxEntries,
+// Digest and Cancel are honored as by archive.UnpackLayer.
+func ApplyLayer(dest string, layer archive.ArchiveReader, options *archive.TarOptions) (size int64, err error) {
+	if options == nil {
+		options = &archive.TarOptions{}
 	}
-
-	os.Setenv("TMPDIR", tmpDir)
-	size, err := archive.UnpackLayer("/", os.Stdin)
-	os.RemoveAll(tmpDir)
-	if err != nil {
-		fatal(err)
-	}
-
-	encoder := json.NewEncoder(os.Stdout)
-	if err := encoder.Encode(applyLayerResponse{size}); err != nil {
-		fatal(fmt.Errorf("unable to encode layerSize JSON: %s", err))
-	}
-
-	flush(os.Stdout)
-	flush(os.Stdin)
-	os.Exit(0)
-}
-
-func ApplyLayer(dest string, layer archive.ArchiveReader) (size int64, err error) {
 	dest = filepath.Clean(dest)
-	decompressed, err := archive.DecompressStream(layer)
-	if err != nil {
-		return 0, err
-	}
-
-	defer decompressed.Close()
-
-	cmd := reexec.Command("docker-applyLayer", dest)
-	cmd.Stdin = decompressed
-
-	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
-	cmd.Stdout, cmd.Stderr = outBuf, errBuf
-
-	if err = cmd.Run(); err != nil {
-		return 0, fmt.Errorf("ApplyLayer %s stdout: %s stderr: %s", err, outBuf, errBuf)
+	if chrootDisabled() {
+		return archive.ApplyLayer(dest, layer, options)
 	}
-
-	// Stdout should be a valid JSON struct representing an applyLayerResponse.
-	response := applyLayerResponse{}
-	decoder := json.NewDecoder(outBuf)
-	if err = decoder.Decode(&response); err != nil {
-		return 0, fmt.Errorf("unable to decode ApplyLayer JSON response: %s", err)
-	}
-
-	return response.LayerSize, nil
+	return applyLayerChrooted(dest, layer, options)
 }
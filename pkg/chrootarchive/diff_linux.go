@@ -0,0 +1,174 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+// applyLayerResponse is written by the docker-applyLayer helper to its
+// stdout, Error is set when the layer couldn't be applied
+type applyLayerResponse struct {
+	LayerSize int64  `json:"layerSize"`
+	Error     string `json:"error,omitempty"`
+}
+
+func applyLayer() {
+	runtime.LockOSThread()
+	flag.Parse()
+	var options *archive.TarOptions
+	if err := json.NewDecoder(os.NewFile(optionsFd, "options")).Decode(&options); err != nil {
+		fatal(err)
+	}
+	// SIGTERM cancels the unpack, as for docker-untar
+	if err := syscall.SetNonblock(0, true); err != nil {
+		fatal(err)
+	}
+	var (
+		stdin  = os.NewFile(0, "stdin")
+		cancel = make(chan struct{})
+		sigc   = make(chan os.Signal, 1)
+	)
+	options.Cancel = cancel
+	signal.Notify(sigc, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		close(cancel)
+		stdin.SetReadDeadline(time.Now())
+	}()
+
+	if err := chroot(flag.Arg(0)); err != nil {
+		fatal(err)
+	}
+
+	// We need to be able to set any perms
+	oldmask := syscall.Umask(0)
+	defer syscall.Umask(oldmask)
+	tmpDir, err := ioutil.TempDir("/", "temp-docker-extract")
+	if err != nil {
+		fatal(err)
+	}
+
+	os.Setenv("TMPDIR", tmpDir)
+	if _, err := restrictSyscalls(); err != nil {
+		fatal(err)
+	}
+	size, err := archive.UnpackLayer("/", stdin, options)
+	os.RemoveAll(tmpDir)
+
+	encoder := json.NewEncoder(os.Stdout)
+	if err != nil {
+		encoder.Encode(applyLayerResponse{Error: err.Error()})
+		os.Exit(1)
+	}
+	if err := encoder.Encode(applyLayerResponse{LayerSize: size}); err != nil {
+		fatal(fmt.Errorf("unable to encode layerSize JSON: %s", err))
+	}
+
+	flush(os.Stdout)
+	flush(stdin)
+	os.Exit(0)
+}
+
+// applyLayerError returns the error reported by the helper, the typed
+// errors of the archive package are returned as is
+func applyLayerError(response applyLayerResponse) error {
+	for _, archiveErr := range archiveErrors {
+		if response.Error == archiveErr.Error() {
+			return archiveErr
+		}
+	}
+	return fmt.Errorf("ApplyLayer %s", response.Error)
+}
+
+// applyLayerChrooted applies the layer from the docker-applyLayer helper,
+// chrooted into dest. Closing options.Cancel sends SIGTERM to the helper,
+// which removes the paths it created and exits.
+func applyLayerChrooted(dest string, layer archive.ArchiveReader, options *archive.TarOptions) (size int64, err error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(options); err != nil {
+		return 0, fmt.Errorf("ApplyLayer json encode: %v", err)
+	}
+
+	cmd := command("docker-applyLayer", dest)
+	// the layer is copied by hand, so that a stalled stream doesn't keep
+	// Wait from returning once the helper is gone
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = outBuf, errBuf
+
+	optionsR, optionsW, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer optionsW.Close()
+	cmd.ExtraFiles = []*os.File{optionsR}
+
+	err = cmd.Start()
+	optionsR.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	decompressed, err := archive.DecompressStream(layer)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, err
+	}
+	go func() {
+		io.Copy(stdin, decompressed)
+		stdin.Close()
+		decompressed.Close()
+	}()
+
+	if _, err := optionsW.Write(buf.Bytes()); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, fmt.Errorf("ApplyLayer sending options: %v %s", err, errBuf)
+	}
+	optionsW.Close()
+
+	if options.Cancel != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-options.Cancel:
+				cmd.Process.Signal(syscall.SIGTERM)
+			case <-done:
+			}
+		}()
+	}
+
+	// Stdout should be a valid JSON struct representing an applyLayerResponse.
+	response := applyLayerResponse{}
+	if err = cmd.Wait(); err != nil {
+		if json.NewDecoder(outBuf).Decode(&response) == nil && response.Error != "" {
+			return 0, applyLayerError(response)
+		}
+		return 0, fmt.Errorf("ApplyLayer %s stdout: %s stderr: %s", err, outBuf, errBuf)
+	}
+
+	decoder := json.NewDecoder(outBuf)
+	if err = decoder.Decode(&response); err != nil {
+		return 0, fmt.Errorf("unable to decode ApplyLayer JSON response: %s", err)
+	}
+
+	return response.LayerSize, nil
+}
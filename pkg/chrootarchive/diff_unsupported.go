@@ -0,0 +1,9 @@
+// +build !linux
+
+package chrootarchive
+
+import "github.com/docker/docker/pkg/archive"
+
+func applyLayerChrooted(dest string, layer archive.ArchiveReader, options *archive.TarOptions) (size int64, err error) {
+	return archive.ApplyLayer(dest, layer, options)
+}
@@ -1,3 +1,5 @@
+// +build linux
+
 package chrootarchive
 
 import (
@@ -11,6 +13,7 @@ import (
 
 func init() {
 	reexec.Register("docker-untar", untar)
+	reexec.Register("docker-tar", packArchive)
 	reexec.Register("docker-applyLayer", applyLayer)
 }
 
@@ -0,0 +1,63 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/libcontainer/cgroups"
+)
+
+// applyLimits applies HelperLimits to the started helper pid. It returns
+// the memory cgroup the helper was moved into, if any, to be removed once
+// the helper exited.
+func applyLimits(pid int) (string, error) {
+	for _, rlimit := range HelperLimits.Rlimits {
+		if err := prlimit(pid, rlimit.Type, &syscall.Rlimit{Cur: rlimit.Soft, Max: rlimit.Hard}); err != nil {
+			return "", fmt.Errorf("Error setting the %s limit of the helper: %v", rlimit.Name(), err)
+		}
+	}
+	if HelperLimits.Memory == 0 {
+		return "", nil
+	}
+	return memoryCgroup(pid, HelperLimits.Memory)
+}
+
+func prlimit(pid, resource int, rlimit *syscall.Rlimit) error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(rlimit)), 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// memoryCgroup creates a memory cgroup limited to limit bytes below the
+// daemon's own and moves pid into it
+func memoryCgroup(pid int, limit int64) (string, error) {
+	mnt, err := cgroups.FindCgroupMountpoint("memory")
+	if err != nil {
+		return "", err
+	}
+	parent, err := cgroups.GetThisCgroupDir("memory")
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(mnt, parent, fmt.Sprintf("docker-untar-%d", pid))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "memory.limit_in_bytes"), []byte(strconv.FormatInt(limit, 10)), 0700); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0700); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,128 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	auditArchX8664 = 0xc000003e
+
+	sysSeccomp   = 317
+	sysGetrandom = 318
+	sysRseq      = 334
+	sysClone3    = 435
+
+	prSetSeccomp          = 22
+	prSetNoNewPrivs       = 38
+	seccompModeFilter     = 2
+	seccompSetModeFilter  = 1
+	seccompFilterFlagSync = 1
+
+	seccompRetAllow = 0x7fff0000
+	seccompRetErrno = 0x00050000
+
+	// offsets in struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+
+	// x32 system calls have this bit set on x86_64
+	x32SyscallBit = 0x40000000
+)
+
+// helperSyscalls are the system calls the helpers need to unpack an archive,
+// along with the ones the Go runtime uses. clone is handled separately.
+var helperSyscalls = []uintptr{
+	// files
+	syscall.SYS_READ, syscall.SYS_WRITE, syscall.SYS_PREAD64, syscall.SYS_PWRITE64,
+	syscall.SYS_OPEN, syscall.SYS_OPENAT, syscall.SYS_CLOSE, syscall.SYS_LSEEK,
+	syscall.SYS_FSTAT, syscall.SYS_LSTAT, syscall.SYS_STAT, syscall.SYS_NEWFSTATAT,
+	syscall.SYS_FCNTL, syscall.SYS_FTRUNCATE, syscall.SYS_FSYNC, syscall.SYS_GETDENTS64,
+	syscall.SYS_ACCESS, syscall.SYS_FACCESSAT, syscall.SYS_GETCWD, syscall.SYS_UMASK,
+	// creating and removing files
+	syscall.SYS_MKDIR, syscall.SYS_MKDIRAT, syscall.SYS_RMDIR,
+	syscall.SYS_UNLINK, syscall.SYS_UNLINKAT, syscall.SYS_RENAME, syscall.SYS_RENAMEAT,
+	syscall.SYS_LINK, syscall.SYS_LINKAT, syscall.SYS_SYMLINK, syscall.SYS_SYMLINKAT,
+	syscall.SYS_READLINK, syscall.SYS_READLINKAT, syscall.SYS_MKNOD, syscall.SYS_MKNODAT,
+	// metadata
+	syscall.SYS_CHOWN, syscall.SYS_FCHOWN, syscall.SYS_LCHOWN, syscall.SYS_FCHOWNAT,
+	syscall.SYS_CHMOD, syscall.SYS_FCHMOD, syscall.SYS_FCHMODAT,
+	syscall.SYS_UTIMES, syscall.SYS_UTIMENSAT, syscall.SYS_FUTIMESAT,
+	syscall.SYS_LSETXATTR, syscall.SYS_LGETXATTR, syscall.SYS_LLISTXATTR, syscall.SYS_LISTXATTR,
+	// Go runtime
+	syscall.SYS_MMAP, syscall.SYS_MUNMAP, syscall.SYS_MPROTECT, syscall.SYS_MADVISE, syscall.SYS_BRK,
+	syscall.SYS_RT_SIGACTION, syscall.SYS_RT_SIGPROCMASK, syscall.SYS_RT_SIGRETURN, syscall.SYS_SIGALTSTACK,
+	syscall.SYS_FUTEX, syscall.SYS_SCHED_YIELD, syscall.SYS_SCHED_GETAFFINITY, syscall.SYS_NANOSLEEP,
+	syscall.SYS_CLOCK_GETTIME, syscall.SYS_GETTID, syscall.SYS_GETPID, syscall.SYS_TGKILL,
+	syscall.SYS_EPOLL_CREATE1, syscall.SYS_EPOLL_CTL, syscall.SYS_EPOLL_WAIT, syscall.SYS_EPOLL_PWAIT, syscall.SYS_EVENTFD2,
+	syscall.SYS_PIPE2, syscall.SYS_GETRLIMIT, syscall.SYS_PRLIMIT64, sysGetrandom,
+	syscall.SYS_EXIT, syscall.SYS_EXIT_GROUP,
+	// threads started by the C library in cgo binaries
+	syscall.SYS_SET_ROBUST_LIST, sysRseq,
+}
+
+// seccompFilter returns a filter allowing helperSyscalls, clone for new
+// threads only, and failing any other system call with EPERM. clone3 fails
+// with ENOSYS instead, its flags can't be checked and the C library falls
+// back to clone then.
+func seccompFilter() []syscall.SockFilter {
+	deny := syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: seccompRetErrno | uint32(syscall.EPERM)}
+	noClone3 := syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: seccompRetErrno | uint32(syscall.ENOSYS)}
+	allow := syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: seccompRetAllow}
+
+	filter := []syscall.SockFilter{
+		// system calls of other ABIs have different numbers
+		{Code: syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS, K: seccompDataArch},
+		{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, K: auditArchX8664, Jt: 1},
+		deny,
+		{Code: syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS, K: seccompDataNr},
+		{Code: syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K, K: x32SyscallBit, Jf: 1},
+		deny,
+	}
+	for _, nr := range helperSyscalls {
+		filter = append(filter,
+			syscall.SockFilter{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, K: uint32(nr), Jf: 1},
+			allow,
+		)
+	}
+	// the runtime starts threads with clone, processes must not be forked
+	filter = append(filter,
+		syscall.SockFilter{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, K: sysClone3, Jf: 1},
+		noClone3,
+		syscall.SockFilter{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, K: syscall.SYS_CLONE, Jf: 3},
+		syscall.SockFilter{Code: syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS, K: seccompDataArg0},
+		syscall.SockFilter{Code: syscall.BPF_JMP | syscall.BPF_JSET | syscall.BPF_K, K: syscall.CLONE_THREAD, Jf: 1},
+		allow,
+		deny,
+	)
+	return filter
+}
+
+// restrictSyscalls installs the seccomp filter of the helpers. It returns
+// false if the filter could only be installed on the calling thread, on
+// kernels older than 3.17, in which case the caller must keep processing the
+// archive on its locked thread.
+func restrictSyscalls() (allThreads bool, err error) {
+	filter := seccompFilter()
+	prog := syscall.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return false, errno
+	}
+	_, _, errno := syscall.RawSyscall(sysSeccomp, seccompSetModeFilter, seccompFilterFlagSync, uintptr(unsafe.Pointer(&prog)))
+	if errno == 0 {
+		return true, nil
+	}
+	if errno != syscall.ENOSYS && errno != syscall.EINVAL {
+		return false, errno
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return false, errno
+	}
+	return false, nil
+}
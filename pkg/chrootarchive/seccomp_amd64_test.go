@@ -0,0 +1,41 @@
+// +build linux
+
+package chrootarchive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Register("docker-seccomp-test", func() {
+		if _, err := restrictSyscalls(); err != nil {
+			fatal(err)
+		}
+		if _, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0); err != syscall.EPERM {
+			fatal(fmt.Errorf("socket: %v", err))
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_FORK, 0, 0, 0); errno != syscall.EPERM {
+			fatal(fmt.Errorf("fork: %v", errno))
+		}
+		os.Stdout.WriteString("denied")
+		os.Exit(0)
+	})
+	// archive_test.go's init may have run before this helper was registered
+	reexec.Init()
+}
+
+func TestRestrictSyscalls(t *testing.T) {
+	out, err := reexec.Command("docker-seccomp-test").CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if !strings.Contains(string(out), "denied") {
+		t.Fatalf("Expected socket and fork to be denied, got %q", out)
+	}
+}
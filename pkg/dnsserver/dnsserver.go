@@ -0,0 +1,404 @@
+// Package dnsserver implements a minimal DNS server over UDP and TCP, which
+// answers the address queries it knows about and forwards the other queries
+// to upstream nameservers.
+package dnsserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	TypeA    uint16 = 1
+	TypeAAAA uint16 = 28
+
+	classIN = 1
+
+	headerLen = 12
+	// maxUDPSize is the size of the messages over UDP, the larger
+	// responses are truncated for the client to ask again over TCP
+	maxUDPSize = 512
+	// maxMsgSize is the size of the messages over TCP, and of the
+	// responses of the upstream nameservers over UDP
+	maxMsgSize = 65535
+
+	flagQR     = 1 << 15
+	flagAA     = 1 << 10
+	flagTC     = 1 << 9
+	flagRD     = 1 << 8
+	flagRA     = 1 << 7
+	opcodeMask = 0xf << 11
+
+	rcodeServFail = 2
+
+	// ForwardTimeout is how long an upstream nameserver has to answer
+	ForwardTimeout = 2 * time.Second
+	// TCPIdleTimeout is how long a client connected over TCP has to send
+	// its next query
+	TCPIdleTimeout = 5 * time.Second
+	// MaxHandlers is the number of queries over UDP handled at the same
+	// time, the next ones wait
+	MaxHandlers = 128
+	// MaxTCPHandlers is the number of connections over TCP handled at the
+	// same time, the next ones wait. The connections don't take the slots
+	// of the queries over UDP, clients keeping them open can't hold back
+	// the resolution of the others.
+	MaxTCPHandlers = 16
+)
+
+var ErrInvalidQuery = errors.New("invalid DNS query")
+
+// LookupFunc returns the addresses of name, without its trailing dot, for
+// the client. ok is false when the name is unknown and the query has to be
+// forwarded.
+type LookupFunc func(client net.IP, name string) (ips []net.IP, ok bool)
+
+// UpstreamFunc returns the addresses (host:port) of the nameservers the
+// unknown queries of the client are forwarded to.
+type UpstreamFunc func(client net.IP) []string
+
+type Server struct {
+	conn     *net.UDPConn
+	listener *net.TCPListener
+	lookup   LookupFunc
+	upstream UpstreamFunc
+	// handlers has a slot for each query over UDP being handled, and
+	// tcpHandlers for each connection over TCP
+	handlers    chan struct{}
+	tcpHandlers chan struct{}
+}
+
+// New listens on the UDP and TCP address addr. Serve has to be called to
+// answer the queries.
+func New(addr string, lookup LookupFunc, upstream UpstreamFunc) (*Server, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	// on the same port when addr lets the system choose one
+	udpAddr = conn.LocalAddr().(*net.UDPAddr)
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: udpAddr.IP, Port: udpAddr.Port, Zone: udpAddr.Zone})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Server{
+		conn:        conn,
+		listener:    listener,
+		lookup:      lookup,
+		upstream:    upstream,
+		handlers:    make(chan struct{}, MaxHandlers),
+		tcpHandlers: make(chan struct{}, MaxTCPHandlers),
+	}, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Serve answers the queries until the server is closed.
+func (s *Server) Serve() error {
+	errs := make(chan error, 2)
+	go func() { errs <- s.serveUDP() }()
+	go func() { errs <- s.serveTCP() }()
+	err := <-errs
+	if err != nil {
+		s.Close()
+	}
+	<-errs
+	return err
+}
+
+func (s *Server) Close() error {
+	err := s.conn.Close()
+	if lerr := s.listener.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+// spawn runs f once handlers has a free slot, which it takes until f returns
+func spawn(handlers chan struct{}, f func()) {
+	handlers <- struct{}{}
+	go func() {
+		defer func() { <-handlers }()
+		f()
+	}()
+}
+
+func isClosed(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
+
+func (s *Server) serveUDP() error {
+	for {
+		buf := make([]byte, maxUDPSize)
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if isClosed(err) {
+				return nil
+			}
+			return err
+		}
+		spawn(s.handlers, func() { s.handleUDP(buf[:n], from) })
+	}
+}
+
+func (s *Server) serveTCP() error {
+	for {
+		conn, err := s.listener.AcceptTCP()
+		if err != nil {
+			if isClosed(err) {
+				return nil
+			}
+			return err
+		}
+		spawn(s.tcpHandlers, func() { s.handleTCP(conn) })
+	}
+}
+
+func (s *Server) handleUDP(query []byte, from *net.UDPAddr) {
+	resp, err := s.resolve(from.IP, query, "udp")
+	if err != nil {
+		log.Debugf("DNS query from %s: %s", from, err)
+		return
+	}
+	if _, err := s.conn.WriteToUDP(resp, from); err != nil {
+		log.Debugf("DNS response to %s: %s", from, err)
+	}
+}
+
+// handleTCP answers the queries of conn until the client closes it, or
+// sends no query for TCPIdleTimeout
+func (s *Server) handleTCP(conn *net.TCPConn) {
+	defer conn.Close()
+	client := conn.RemoteAddr().(*net.TCPAddr).IP
+	for {
+		conn.SetDeadline(time.Now().Add(TCPIdleTimeout))
+		query, err := readTCP(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Debugf("DNS query from %s: %s", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		resp, err := s.resolve(client, query, "tcp")
+		if err != nil {
+			log.Debugf("DNS query from %s: %s", conn.RemoteAddr(), err)
+			return
+		}
+		conn.SetDeadline(time.Now().Add(TCPIdleTimeout))
+		if err := writeTCP(conn, resp); err != nil {
+			log.Debugf("DNS response to %s: %s", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// resolve returns the response to query received over network, truncated
+// to maxUDPSize over UDP
+func (s *Server) resolve(client net.IP, query []byte, network string) ([]byte, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var resp []byte
+	if ips, ok := s.lookup(client, q.name); ok {
+		resp = q.answer(ips)
+	} else if resp, err = forward(query, network, s.upstream(client)); err != nil {
+		log.Debugf("Forwarding DNS query for %s: %s", q.name, err)
+		return q.reply(rcodeServFail, nil), nil
+	}
+	if network == "udp" && len(resp) > maxUDPSize {
+		return q.truncated(resp), nil
+	}
+	return resp, nil
+}
+
+// forward sends query to each of the upstream nameservers in turn over
+// network, and returns the first response.
+func forward(query []byte, network string, upstream []string) ([]byte, error) {
+	err := errors.New("no upstream nameserver")
+	for _, addr := range upstream {
+		var resp []byte
+		if resp, err = exchange(query, network, addr); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func exchange(query []byte, network, addr string) ([]byte, error) {
+	conn, err := net.DialTimeout(network, addr, ForwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ForwardTimeout))
+	if network == "tcp" {
+		if err := writeTCP(conn, query); err != nil {
+			return nil, err
+		}
+		resp, err := readTCP(conn)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) < headerLen || resp[0] != query[0] || resp[1] != query[1] {
+			return nil, errors.New("invalid DNS response")
+		}
+		return resp, nil
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	// the response may be larger than maxUDPSize, with EDNS
+	buf := make([]byte, maxMsgSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// skip stray responses to other queries
+		if n >= headerLen && buf[0] == query[0] && buf[1] == query[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+// readTCP reads a message over TCP, after its length on 2 bytes
+func readTCP(r io.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeTCP writes msg over TCP, after its length on 2 bytes
+func writeTCP(w io.Writer, msg []byte) error {
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	_, err := w.Write(append(buf, msg...))
+	return err
+}
+
+type query struct {
+	id       uint16
+	flags    uint16
+	name     string
+	qtype    uint16
+	question []byte // the question section, as received
+}
+
+func parseQuery(msg []byte) (*query, error) {
+	if len(msg) < headerLen {
+		return nil, ErrInvalidQuery
+	}
+	q := &query{
+		id:    binary.BigEndian.Uint16(msg[0:]),
+		flags: binary.BigEndian.Uint16(msg[2:]),
+	}
+	if q.flags&flagQR != 0 || q.flags&opcodeMask != 0 || binary.BigEndian.Uint16(msg[4:]) != 1 {
+		return nil, ErrInvalidQuery
+	}
+
+	var (
+		labels []string
+		off    = headerLen
+	)
+	for {
+		if off >= len(msg) {
+			return nil, ErrInvalidQuery
+		}
+		l := int(msg[off])
+		off++
+		if l == 0 {
+			break
+		}
+		// names of queries are not compressed
+		if l > 63 || off+l > len(msg) {
+			return nil, ErrInvalidQuery
+		}
+		labels = append(labels, string(msg[off:off+l]))
+		off += l
+	}
+	if off+4 > len(msg) {
+		return nil, ErrInvalidQuery
+	}
+	q.name = strings.ToLower(strings.Join(labels, "."))
+	q.qtype = binary.BigEndian.Uint16(msg[off:])
+	q.question = msg[headerLen : off+4]
+	return q, nil
+}
+
+// answer returns the response to q with the addresses of ips matching its
+// type. There is no answer for the other types, but the name exists.
+func (q *query) answer(ips []net.IP) []byte {
+	var answers [][]byte
+	for _, ip := range ips {
+		var data []byte
+		switch q.qtype {
+		case TypeA:
+			data = ip.To4()
+		case TypeAAAA:
+			if ip.To4() == nil {
+				data = ip.To16()
+			}
+		}
+		if data == nil {
+			continue
+		}
+		rr := make([]byte, 12, 12+len(data))
+		// pointer to the name of the question
+		binary.BigEndian.PutUint16(rr[0:], 0xc000|headerLen)
+		binary.BigEndian.PutUint16(rr[2:], q.qtype)
+		binary.BigEndian.PutUint16(rr[4:], classIN)
+		// TTL 0, the addresses change when containers restart
+		binary.BigEndian.PutUint32(rr[6:], 0)
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(data)))
+		answers = append(answers, append(rr, data...))
+	}
+	return q.reply(0, answers)
+}
+
+// truncated returns the header of resp with the TC flag set and the
+// question of q, without the records which don't fit over UDP
+func (q *query) truncated(resp []byte) []byte {
+	msg := make([]byte, headerLen, headerLen+len(q.question))
+	copy(msg, resp[:4])
+	msg[2] |= flagTC >> 8
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	return append(msg, q.question...)
+}
+
+func (q *query) reply(rcode uint16, answers [][]byte) []byte {
+	msg := make([]byte, headerLen, maxUDPSize)
+	binary.BigEndian.PutUint16(msg[0:], q.id)
+	flags := uint16(flagQR|flagRA) | q.flags&flagRD | rcode
+	if rcode == 0 {
+		flags |= flagAA
+	}
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)))
+	msg = append(msg, q.question...)
+	for _, rr := range answers {
+		msg = append(msg, rr...)
+	}
+	return msg
+}
@@ -0,0 +1,252 @@
+package dnsserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	for _, label := range strings.Split(name, ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0, byte(qtype>>8), byte(qtype), 0, classIN)
+}
+
+func startServer(t *testing.T, lookup LookupFunc, upstream UpstreamFunc) *Server {
+	s, err := New("127.0.0.1:0", lookup, upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve()
+	return s
+}
+
+func ask(t *testing.T, s *Server, network string, msg []byte) []byte {
+	resp, err := exchange(msg, network, s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestServerAnswers(t *testing.T) {
+	lookupClients := make(chan net.IP, 3)
+	s := startServer(t, func(client net.IP, name string) ([]net.IP, bool) {
+		lookupClients <- client
+		if name == "db" {
+			return []net.IP{net.ParseIP("172.17.0.5"), net.ParseIP("2001:db8::5")}, true
+		}
+		return nil, false
+	}, func(net.IP) []string { return nil })
+	defer s.Close()
+
+	resp := ask(t, s, "udp", buildQuery(42, "DB", TypeA))
+	if id := binary.BigEndian.Uint16(resp[0:]); id != 42 {
+		t.Fatalf("Expected the ID of the query, got %d", id)
+	}
+	if flags := binary.BigEndian.Uint16(resp[2:]); flags&flagQR == 0 || flags&0xf != 0 {
+		t.Fatalf("Expected a successful response, got flags %x", flags)
+	}
+	if n := binary.BigEndian.Uint16(resp[6:]); n != 1 {
+		t.Fatalf("Expected 1 answer, got %d", n)
+	}
+	if ip := resp[len(resp)-4:]; !bytes.Equal(ip, []byte{172, 17, 0, 5}) {
+		t.Fatalf("Expected 172.17.0.5, got %v", net.IP(ip))
+	}
+	if lookupClient := <-lookupClients; !lookupClient.Equal(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("Expected the lookup for client 127.0.0.1, got %s", lookupClient)
+	}
+
+	resp = ask(t, s, "udp", buildQuery(43, "db", TypeAAAA))
+	if ip := net.IP(resp[len(resp)-16:]); !ip.Equal(net.ParseIP("2001:db8::5")) {
+		t.Fatalf("Expected 2001:db8::5, got %s", ip)
+	}
+
+	// no upstream nameserver for the unknown names
+	resp = ask(t, s, "udp", buildQuery(44, "example.com", TypeA))
+	if rcode := binary.BigEndian.Uint16(resp[2:]) & 0xf; rcode != rcodeServFail {
+		t.Fatalf("Expected SERVFAIL, got %d", rcode)
+	}
+}
+
+func TestServerForwards(t *testing.T) {
+	upstream := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return []net.IP{net.ParseIP("93.184.216.34")}, true
+	}, nil)
+	defer upstream.Close()
+
+	s := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return nil, false
+	}, func(net.IP) []string {
+		// the first nameserver does not answer
+		return []string{"127.0.0.1:1", upstream.Addr().String()}
+	})
+	defer s.Close()
+
+	start := time.Now()
+	resp := ask(t, s, "udp", buildQuery(7, "example.com", TypeA))
+	if ip := resp[len(resp)-4:]; !bytes.Equal(ip, []byte{93, 184, 216, 34}) {
+		t.Fatalf("Expected the answer of the upstream nameserver, got %v", net.IP(ip))
+	}
+	if elapsed := time.Since(start); elapsed > 2*ForwardTimeout {
+		t.Fatalf("Forwarding took %s", elapsed)
+	}
+}
+
+func manyIPs(n int) []net.IP {
+	var ips []net.IP
+	for i := 0; i < n; i++ {
+		ips = append(ips, net.IPv4(172, 17, 1, byte(i)))
+	}
+	return ips
+}
+
+func TestServerTruncatesOverUDP(t *testing.T) {
+	s := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return manyIPs(40), true
+	}, nil)
+	defer s.Close()
+
+	resp := ask(t, s, "udp", buildQuery(8, "web", TypeA))
+	if flags := binary.BigEndian.Uint16(resp[2:]); flags&flagTC == 0 {
+		t.Fatalf("Expected a truncated response over UDP, got flags %x", flags)
+	}
+	if n := binary.BigEndian.Uint16(resp[6:]); n != 0 || len(resp) > maxUDPSize {
+		t.Fatalf("Expected no answer in %d bytes over UDP, got %d answers", len(resp), n)
+	}
+
+	resp = ask(t, s, "tcp", buildQuery(9, "web", TypeA))
+	if flags := binary.BigEndian.Uint16(resp[2:]); flags&flagTC != 0 {
+		t.Fatalf("Expected a complete response over TCP, got flags %x", flags)
+	}
+	if n := binary.BigEndian.Uint16(resp[6:]); n != 40 {
+		t.Fatalf("Expected 40 answers over TCP, got %d", n)
+	}
+}
+
+func TestServerForwardsOverTCP(t *testing.T) {
+	upstream := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return manyIPs(40), true
+	}, nil)
+	defer upstream.Close()
+
+	s := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return nil, false
+	}, func(net.IP) []string {
+		return []string{upstream.Addr().String()}
+	})
+	defer s.Close()
+
+	resp := ask(t, s, "tcp", buildQuery(10, "example.com", TypeA))
+	if n := binary.BigEndian.Uint16(resp[6:]); n != 40 {
+		t.Fatalf("Expected the 40 answers of the upstream nameserver, got %d", n)
+	}
+}
+
+func TestExchangeLargeResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, maxUDPSize)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		// an EDNS response larger than maxUDPSize
+		resp := make([]byte, 4000)
+		copy(resp, buf[:n])
+		conn.WriteToUDP(resp, from)
+	}()
+
+	resp, err := exchange(buildQuery(11, "example.com", TypeA), "udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 4000 {
+		t.Fatalf("Expected the response of 4000 bytes, got %d bytes", len(resp))
+	}
+}
+
+func TestServerBoundsHandlers(t *testing.T) {
+	var running int32
+	release := make(chan struct{})
+	s := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		atomic.AddInt32(&running, 1)
+		<-release
+		return nil, true
+	}, nil)
+	defer s.Close()
+	defer close(release)
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for i := 0; i < MaxHandlers+10; i++ {
+		if _, err := conn.Write(buildQuery(uint16(i), "db", TypeA)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; atomic.LoadInt32(&running) < MaxHandlers; i++ {
+		if i == 500 {
+			t.Fatalf("Expected %d queries to be handled, got %d", MaxHandlers, atomic.LoadInt32(&running))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&running); n != MaxHandlers {
+		t.Fatalf("Expected at most %d queries to be handled at the same time, got %d", MaxHandlers, n)
+	}
+}
+
+func TestServerTCPDoesNotHoldBackUDP(t *testing.T) {
+	s := startServer(t, func(net.IP, string) ([]net.IP, bool) {
+		return []net.IP{net.ParseIP("172.17.0.5")}, true
+	}, nil)
+	defer s.Close()
+
+	// idle connections hold all the TCP slots, as many as there are UDP
+	// slots wait
+	for i := 0; i < MaxTCPHandlers+MaxHandlers; i++ {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+
+	resp := ask(t, s, "udp", buildQuery(1, "db", TypeA))
+	if n := binary.BigEndian.Uint16(resp[6:]); n != 1 {
+		t.Fatalf("Expected the query over UDP to be answered, got %d answers", n)
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	response := buildQuery(1, "db", TypeA)
+	response[2] |= flagQR >> 8
+	for _, msg := range [][]byte{
+		nil,
+		make([]byte, headerLen),
+		buildQuery(1, "db", TypeA)[:headerLen+3],
+		response,
+	} {
+		if _, err := parseQuery(msg); err != ErrInvalidQuery {
+			t.Fatalf("Expected %v to be invalid, got %v", msg, err)
+		}
+	}
+}
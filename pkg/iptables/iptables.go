@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -24,15 +25,19 @@ const (
 )
 
 var (
-	iptablesPath        string
-	supportsXlock       = false
-	ErrIptablesNotFound = errors.New("Iptables not found")
+	iptablesPath         string
+	ip6tablesPath        string
+	supportsXlock        = false
+	supportsXlock6       = false
+	ErrIptablesNotFound  = errors.New("Iptables not found")
+	ErrIp6tablesNotFound = errors.New("Ip6tables not found")
 )
 
 type Chain struct {
 	Name   string
 	Bridge string
 	Table  Table
+	IPv6   bool // the chain is set with ip6tables
 }
 
 type ChainError struct {
@@ -57,20 +62,46 @@ func initCheck() error {
 	return nil
 }
 
+func initCheck6() error {
+	if ip6tablesPath == "" {
+		path, err := exec.LookPath("ip6tables")
+		if err != nil {
+			return ErrIp6tablesNotFound
+		}
+		ip6tablesPath = path
+		supportsXlock6 = exec.Command(ip6tablesPath, "--wait", "-L", "-n").Run() == nil
+	}
+	return nil
+}
+
 func NewChain(name, bridge string, table Table) (*Chain, error) {
-	c := &Chain{
+	return newChain(&Chain{
 		Name:   name,
 		Bridge: bridge,
 		Table:  table,
-	}
+	})
+}
 
+// NewChain6 is NewChain for the ip6tables filter table, the only one IPv6
+// is set up in
+func NewChain6(name, bridge string) (*Chain, error) {
+	return newChain(&Chain{
+		Name:   name,
+		Bridge: bridge,
+		Table:  Filter,
+		IPv6:   true,
+	})
+}
+
+func newChain(c *Chain) (*Chain, error) {
+	table := c.Table
 	if string(c.Table) == "" {
 		c.Table = Filter
 	}
 
 	// Add chain if it doesn't exist
-	if _, err := Raw("-t", string(c.Table), "-n", "-L", c.Name); err != nil {
-		if output, err := Raw("-t", string(c.Table), "-N", c.Name); err != nil {
+	if _, err := c.raw("-t", string(c.Table), "-n", "-L", c.Name); err != nil {
+		if output, err := c.raw("-t", string(c.Table), "-N", c.Name); err != nil {
 			return nil, err
 		} else if len(output) != 0 {
 			return nil, fmt.Errorf("Could not create %s/%s chain: %s", c.Table, c.Name, output)
@@ -100,9 +131,9 @@ func NewChain(name, bridge string, table Table) (*Chain, error) {
 		link := []string{"FORWARD",
 			"-o", c.Bridge,
 			"-j", c.Name}
-		if !Exists(link...) {
+		if !c.exists(link...) {
 			insert := append([]string{string(Insert)}, link...)
-			if output, err := Raw(insert...); err != nil {
+			if output, err := c.raw(insert...); err != nil {
 				return nil, err
 			} else if len(output) != 0 {
 				return nil, fmt.Errorf("Could not create linking rule to %s/%s: %s", c.Table, c.Name, output)
@@ -125,6 +156,13 @@ func RemoveExistingChain(name string, table Table) error {
 
 // Add forwarding rule to 'filter' table and corresponding nat rule to 'nat' table
 func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr string, destPort int) error {
+	return c.ForwardRange(action, ip, port, 1, proto, destAddr, destPort)
+}
+
+// ForwardRange forwards count contiguous ports from port to the ports from
+// destPort. The ports are matched by a single rule when they are the same on
+// both sides, since the DNAT target can't shift a range of ports.
+func (c *Chain) ForwardRange(action Action, ip net.IP, port, count int, proto, destAddr string, destPort int) error {
 	daddr := ip.String()
 	if ip.IsUnspecified() {
 		// iptables interprets "0.0.0.0" as "0.0.0.0/32", whereas we
@@ -132,16 +170,30 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		// value" by both iptables and ip6tables.
 		daddr = "0/0"
 	}
-	if output, err := Raw("-t", string(Nat), string(action), c.Name,
-		"-p", proto,
-		"-d", daddr,
-		"--dport", strconv.Itoa(port),
-		"!", "-i", c.Bridge,
-		"-j", "DNAT",
-		"--to-destination", net.JoinHostPort(destAddr, strconv.Itoa(destPort))); err != nil {
-		return err
-	} else if len(output) != 0 {
-		return &ChainError{Chain: "FORWARD", Output: output}
+
+	dnats := [][2]string{}
+	switch {
+	case count <= 1:
+		dnats = append(dnats, [2]string{strconv.Itoa(port), net.JoinHostPort(destAddr, strconv.Itoa(destPort))})
+	case port == destPort:
+		dnats = append(dnats, [2]string{portRange(port, count), destAddr})
+	default:
+		for i := 0; i < count; i++ {
+			dnats = append(dnats, [2]string{strconv.Itoa(port + i), net.JoinHostPort(destAddr, strconv.Itoa(destPort+i))})
+		}
+	}
+	for _, dnat := range dnats {
+		if output, err := Raw("-t", string(Nat), string(action), c.Name,
+			"-p", proto,
+			"-d", daddr,
+			"--dport", dnat[0],
+			"!", "-i", c.Bridge,
+			"-j", "DNAT",
+			"--to-destination", dnat[1]); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return &ChainError{Chain: "FORWARD", Output: output}
+		}
 	}
 
 	if output, err := Raw("-t", string(Filter), string(action), c.Name,
@@ -149,7 +201,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		"-o", c.Bridge,
 		"-p", proto,
 		"-d", destAddr,
-		"--dport", strconv.Itoa(destPort),
+		"--dport", portRange(destPort, count),
 		"-j", "ACCEPT"); err != nil {
 		return err
 	} else if len(output) != 0 {
@@ -160,7 +212,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		"-p", proto,
 		"-s", destAddr,
 		"-d", destAddr,
-		"--dport", strconv.Itoa(destPort),
+		"--dport", portRange(destPort, count),
 		"-j", "MASQUERADE"); err != nil {
 		return err
 	} else if len(output) != 0 {
@@ -170,10 +222,21 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 	return nil
 }
 
+// portRange returns the --dport argument matching count ports from port
+func portRange(port, count int) string {
+	if count <= 1 {
+		return strconv.Itoa(port)
+	}
+	return fmt.Sprintf("%d:%d", port, port+count-1)
+}
+
 // Add reciprocal ACCEPT rule for two supplied IP addresses.
 // Traffic is allowed from ip1 to ip2 and vice-versa
 func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) error {
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if (ip1.To4() == nil) != c.IPv6 {
+		return fmt.Errorf("Cannot link %s in the %s chain of the other IP version", ip1, c.Name)
+	}
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip1.String(),
@@ -184,12 +247,14 @@ func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) err
 	} else if len(output) != 0 {
 		return fmt.Errorf("Error iptables forward: %s", output)
 	}
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	// only the replies, ip2 can't open connections from port to any port of ip1
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip2.String(),
 		"-d", ip1.String(),
 		"--sport", strconv.Itoa(port),
+		"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED",
 		"-j", "ACCEPT"); err != nil {
 		return err
 	} else if len(output) != 0 {
@@ -198,6 +263,45 @@ func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) err
 	return nil
 }
 
+// Reject rejects the packets from ip1 to ip2 which are not accepted by the
+// rules of Link before it in the chain, with a TCP reset or an ICMP port
+// unreachable instead of dropping them
+func (c *Chain) Reject(action Action, ip1, ip2 net.IP) error {
+	if (ip1.To4() == nil) != c.IPv6 {
+		return fmt.Errorf("Cannot reject %s in the %s chain of the other IP version", ip1, c.Name)
+	}
+	for _, reject := range [][]string{
+		{"-p", "tcp", "-j", "REJECT", "--reject-with", "tcp-reset"},
+		{"-j", "REJECT"},
+	} {
+		args := append([]string{"-t", string(Filter), string(action), c.Name,
+			"-i", c.Bridge, "-o", c.Bridge,
+			"-s", ip1.String(),
+			"-d", ip2.String()}, reject...)
+		if output, err := c.raw(args...); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return fmt.Errorf("Error iptables reject: %s", output)
+		}
+	}
+	return nil
+}
+
+// raw calls iptables or ip6tables depending on the chain
+func (c *Chain) raw(args ...string) ([]byte, error) {
+	if c.IPv6 {
+		return Raw6(args...)
+	}
+	return Raw(args...)
+}
+
+func (c *Chain) exists(args ...string) bool {
+	if c.IPv6 {
+		return Exists6(args...)
+	}
+	return Exists(args...)
+}
+
 // Add linking rule to nat/PREROUTING chain.
 func (c *Chain) Prerouting(action Action, args ...string) error {
 	a := []string{"-t", string(Nat), string(action), "PREROUTING"}
@@ -236,8 +340,8 @@ func (c *Chain) Remove() error {
 		c.Prerouting(Delete)
 		c.Output(Delete)
 	}
-	Raw("-t", string(c.Table), "-F", c.Name)
-	Raw("-t", string(c.Table), "-X", c.Name)
+	c.raw("-t", string(c.Table), "-F", c.Name)
+	c.raw("-t", string(c.Table), "-X", c.Name)
 	return nil
 }
 
@@ -266,21 +370,45 @@ func Exists(args ...string) bool {
 	)
 }
 
+// Check if a rule exists in ip6tables
+func Exists6(args ...string) bool {
+	if _, err := Raw6(append([]string{"-C"}, args...)...); err == nil {
+		return true
+	}
+
+	// parse ip6tables-save for the rule, without the -C option
+	rule := strings.Replace(strings.Join(args, " "), "-t nat ", "", -1)
+	existingRules, _ := exec.Command("ip6tables-save").Output()
+	return strings.Contains(string(existingRules), rule)
+}
+
 // Call 'iptables' system command, passing supplied arguments
 func Raw(args ...string) ([]byte, error) {
 
 	if err := initCheck(); err != nil {
 		return nil, err
 	}
-	if supportsXlock {
+	return raw(iptablesPath, supportsXlock, args...)
+}
+
+// Call 'ip6tables' system command, passing supplied arguments
+func Raw6(args ...string) ([]byte, error) {
+	if err := initCheck6(); err != nil {
+		return nil, err
+	}
+	return raw(ip6tablesPath, supportsXlock6, args...)
+}
+
+func raw(path string, xlock bool, args ...string) ([]byte, error) {
+	if xlock {
 		args = append([]string{"--wait"}, args...)
 	}
 
-	log.Debugf("%s, %v", iptablesPath, args)
+	log.Debugf("%s, %v", path, args)
 
-	output, err := exec.Command(iptablesPath, args...).CombinedOutput()
+	output, err := exec.Command(path, args...).CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("iptables failed: iptables %v: %s (%s)", strings.Join(args, " "), output, err)
+		return nil, fmt.Errorf("iptables failed: %s %v: %s (%s)", filepath.Base(path), strings.Join(args, " "), output, err)
 	}
 
 	// ignore iptables' message about xtables lock
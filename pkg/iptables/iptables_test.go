@@ -81,6 +81,43 @@ func TestForward(t *testing.T) {
 	}
 }
 
+func TestForwardRange(t *testing.T) {
+	ip := net.ParseIP("192.168.1.1")
+	dstAddr := "172.17.0.1"
+
+	if err := natChain.ForwardRange(Insert, ip, 8000, 101, "udp", dstAddr, 8000); err != nil {
+		t.Fatal(err)
+	}
+
+	dnatRule := []string{natChain.Name,
+		"-t", string(natChain.Table),
+		"!", "-i", filterChain.Bridge,
+		"-d", ip.String(),
+		"-p", "udp",
+		"--dport", "8000:8100",
+		"-j", "DNAT",
+		"--to-destination", dstAddr,
+	}
+
+	if !Exists(dnatRule...) {
+		t.Fatalf("DNAT rule of the range does not exist")
+	}
+
+	filterRule := []string{filterChain.Name,
+		"-t", string(filterChain.Table),
+		"!", "-i", filterChain.Bridge,
+		"-o", filterChain.Bridge,
+		"-d", dstAddr,
+		"-p", "udp",
+		"--dport", "8000:8100",
+		"-j", "ACCEPT",
+	}
+
+	if !Exists(filterRule...) {
+		t.Fatalf("filter rule of the range does not exist")
+	}
+}
+
 func TestLink(t *testing.T) {
 	var err error
 
@@ -116,6 +153,7 @@ func TestLink(t *testing.T) {
 		"-s", ip2.String(),
 		"-d", ip1.String(),
 		"--sport", strconv.Itoa(port),
+		"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED",
 		"-j", "ACCEPT"}
 
 	if !Exists(rule2...) {
@@ -123,6 +161,47 @@ func TestLink(t *testing.T) {
 	}
 }
 
+func TestReject(t *testing.T) {
+	ip1 := net.ParseIP("192.168.1.1")
+	ip2 := net.ParseIP("192.168.1.2")
+
+	if err := filterChain.Reject(Append, ip1, ip2); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := []string{filterChain.Name,
+		"-t", string(filterChain.Table),
+		"-i", filterChain.Bridge,
+		"-o", filterChain.Bridge,
+		"-s", ip1.String(),
+		"-d", ip2.String(),
+		"-p", "tcp",
+		"-j", "REJECT", "--reject-with", "tcp-reset"}
+
+	if !Exists(rule...) {
+		t.Fatalf("rule does not exist")
+	}
+
+	if err := filterChain.Reject(Delete, ip1, ip2); err != nil {
+		t.Fatal(err)
+	}
+	if Exists(rule...) {
+		t.Fatalf("rule still exists")
+	}
+}
+
+func TestLinkIPVersionMismatch(t *testing.T) {
+	chain := &Chain{Name: chainName, Bridge: "lo", Table: Filter}
+	if err := chain.Link(Append, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 1234, "tcp"); err == nil {
+		t.Fatal("Expected linking IPv6 addresses in an iptables chain to fail")
+	}
+
+	chain6 := &Chain{Name: chainName, Bridge: "lo", Table: Filter, IPv6: true}
+	if err := chain6.Link(Append, net.ParseIP("192.168.1.1"), net.ParseIP("192.168.1.2"), 1234, "tcp"); err == nil {
+		t.Fatal("Expected linking IPv4 addresses in an ip6tables chain to fail")
+	}
+}
+
 func TestPrerouting(t *testing.T) {
 	args := []string{
 		"-i", "lo",
@@ -13,6 +13,8 @@ type JSONLog struct {
 	Log     string    `json:"log,omitempty"`
 	Stream  string    `json:"stream,omitempty"`
 	Created time.Time `json:"time"`
+	// Seq numbers the lines of a stream, when it is set
+	Seq uint64 `json:"seq,omitempty"`
 }
 
 func (jl *JSONLog) Format(format string) (string, error) {
@@ -30,6 +32,7 @@ func (jl *JSONLog) Reset() {
 	jl.Log = ""
 	jl.Stream = ""
 	jl.Created = time.Time{}
+	jl.Seq = 0
 }
 
 func WriteLog(src io.Reader, dst io.Writer, format string) error {
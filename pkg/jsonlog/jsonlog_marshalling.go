@@ -57,6 +57,7 @@ package jsonlog
 
 import (
 	"bytes"
+	"strconv"
 	"unicode/utf8"
 
 	"github.com/docker/docker/pkg/timeutils"
@@ -108,6 +109,10 @@ func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 		return err
 	}
 	buf.WriteString(timestamp)
+	if mj.Seq != 0 {
+		buf.WriteString(`,"seq":`)
+		buf.WriteString(strconv.FormatUint(mj.Seq, 10))
+	}
 	buf.WriteString(`}`)
 	return nil
 }
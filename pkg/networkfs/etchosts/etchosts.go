@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"regexp"
 )
 
@@ -65,3 +66,40 @@ func Update(path, IP, hostname string) error {
 	var re = regexp.MustCompile(fmt.Sprintf("(\\S*)(\\t%s)", regexp.QuoteMeta(hostname)))
 	return ioutil.WriteFile(path, re.ReplaceAll(old, []byte(IP+"$2")), 0644)
 }
+
+// Add appends a record for hostname with IP to the hosts file at path
+func Add(path, IP, hostname string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = Record{Hosts: hostname, IP: IP}.WriteTo(f)
+	return err
+}
+
+// Delete removes the records of hostname from the hosts file at path
+func Delete(path, hostname string) error {
+	return Replace(path, []string{hostname}, nil)
+}
+
+// Replace removes the records of the hostnames in remove, with the aliases
+// which follow them, from the hosts file at path and appends the records of
+// add, in a single write
+func Replace(path string, remove []string, add []Record) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, hostname := range remove {
+		var re = regexp.MustCompile(fmt.Sprintf("(?m)^\\S*\\t%s( .*)?\\n", regexp.QuoteMeta(hostname)))
+		content = re.ReplaceAll(content, nil)
+	}
+	buf := bytes.NewBuffer(content)
+	for _, r := range add {
+		if _, err := r.WriteTo(buf); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -132,3 +132,99 @@ func TestUpdate(t *testing.T) {
 		t.Fatalf("Expected to find '%s' got '%s'", expected, content)
 	}
 }
+
+func TestAddDelete(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), "10.11.12.13", "testhostname", "", []Record{{Hosts: "db2", IP: "2.2.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Add(file.Name(), "1.1.1.1", "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "2.2.2.2\tdb2\n1.1.1.1\tdb\n"; !bytes.HasSuffix(content, []byte(expected)) {
+		t.Fatalf("Expected to end with '%s' got '%s'", expected, content)
+	}
+
+	if err := Delete(file.Name(), "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err = ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unexpected := "\tdb\n"; bytes.Contains(content, []byte(unexpected)) {
+		t.Fatalf("Expected not to find '%s' got '%s'", unexpected, content)
+	}
+	for _, expected := range []string{"10.11.12.13\ttesthostname\n", "2.2.2.2\tdb2\n"} {
+		if !bytes.Contains(content, []byte(expected)) {
+			t.Fatalf("Expected to find '%s' got '%s'", expected, content)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), "10.11.12.13", "testhostname", "", []Record{{Hosts: "db", IP: "1.1.1.1"}, {Hosts: "db2", IP: "2.2.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(file.Name(), []string{"db", "db2"}, []Record{{Hosts: "db2", IP: "3.3.3.3"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "10.11.12.13\ttesthostname\n"; !bytes.HasPrefix(content, []byte(expected)) {
+		t.Fatalf("Expected to start with '%s' got '%s'", expected, content)
+	}
+	if expected := "ff02::2\tip6-allrouters\n3.3.3.3\tdb2\n"; !bytes.HasSuffix(content, []byte(expected)) {
+		t.Fatalf("Expected to end with '%s' got '%s'", expected, content)
+	}
+}
+
+func TestReplaceWithAliases(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), "10.11.12.13", "testhostname", "", []Record{{Hosts: "db olddb", IP: "1.1.1.1"}, {Hosts: "dbx", IP: "2.2.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(file.Name(), []string{"db"}, []Record{{Hosts: "db newdb", IP: "1.1.1.1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "ff02::2\tip6-allrouters\n2.2.2.2\tdbx\n1.1.1.1\tdb newdb\n"; !bytes.HasSuffix(content, []byte(expected)) {
+		t.Fatalf("Expected to end with '%s' got '%s'", expected, content)
+	}
+}
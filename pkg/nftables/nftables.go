@@ -0,0 +1,55 @@
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+var (
+	nftPath        string
+	ErrNftNotFound = errors.New("Nft not found")
+)
+
+func initCheck() error {
+	if nftPath == "" {
+		path, err := exec.LookPath("nft")
+		if err != nil {
+			return ErrNftNotFound
+		}
+		nftPath = path
+	}
+	return nil
+}
+
+// Raw calls the nft binary with args, which it joins as a single command
+func Raw(args ...string) ([]byte, error) {
+	if err := initCheck(); err != nil {
+		return nil, err
+	}
+
+	log.Debugf("%s, %v", nftPath, args)
+
+	output, err := exec.Command(nftPath, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("nft failed: nft %v: %s (%s)", strings.Join(args, " "), output, err)
+	}
+	return output, nil
+}
+
+// Handle returns the handle of the first rule with comment in the listing of
+// a chain with handles (nft -a list chain), or 0 if there is none
+func Handle(listing []byte, comment string) int {
+	re := regexp.MustCompile(fmt.Sprintf(`comment "%s" # handle (\d+)`, regexp.QuoteMeta(comment)))
+	matches := re.FindSubmatch(listing)
+	if matches == nil {
+		return 0
+	}
+	handle, _ := strconv.Atoi(string(matches[1]))
+	return handle
+}
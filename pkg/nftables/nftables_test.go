@@ -0,0 +1,21 @@
+package nftables
+
+import (
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	listing := []byte(`table ip docker {
+	chain containers {
+		iifname "docker0" oifname "docker0" ip saddr 172.17.0.3 ip daddr 172.17.0.2 tcp dport 80 accept comment "2d1f8a0b" # handle 7
+		iifname "docker0" oifname "docker0" ip saddr 172.17.0.2 ip daddr 172.17.0.3 tcp sport 80 ct state established,related accept comment "9e44c7d1" # handle 8
+	}
+}
+`)
+	if handle := Handle(listing, "9e44c7d1"); handle != 8 {
+		t.Fatalf("Expected handle 8, got %d", handle)
+	}
+	if handle := Handle(listing, "ffffffff"); handle != 0 {
+		t.Fatalf("Expected no handle, got %d", handle)
+	}
+}
@@ -97,6 +97,23 @@ func PartParser(template, data string) (map[string]string, error) {
 	return out, nil
 }
 
+// ParseLink parses a link in the form name:alias, followed by ":hide" to hide
+// the alias of the link while the container is not running. It returns the
+// name, the alias and whether the alias is hidden.
+func ParseLink(link string) (string, string, bool, error) {
+	parts := strings.Split(link, ":")
+	if len(parts) == 3 {
+		if parts[2] != "hide" {
+			return "", "", false, fmt.Errorf("Invalid link option %s in %s, only hide is allowed", parts[2], link)
+		}
+		return parts[0], parts[1], true, nil
+	}
+	if len(parts) != 2 {
+		return "", "", false, fmt.Errorf("Invalid format to parse.  %s should match template name:alias[:hide]", link)
+	}
+	return parts[0], parts[1], false, nil
+}
+
 func ParseKeyValueOpt(opt string) (string, string, error) {
 	parts := strings.SplitN(opt, "=", 2)
 	if len(parts) != 2 {
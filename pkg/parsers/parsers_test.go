@@ -114,3 +114,17 @@ func TestParsePortRangeIncorrectStartRange(t *testing.T) {
 		t.Fatalf("Expecting error 'Invalid range specified for the Port' but received %s.", err)
 	}
 }
+
+func TestParseLink(t *testing.T) {
+	if name, alias, hide, err := ParseLink("db:database"); err != nil || name != "db" || alias != "database" || hide {
+		t.Fatalf("Expected db, database and no hide, got %s, %s, %t (%v)", name, alias, hide, err)
+	}
+	if name, alias, hide, err := ParseLink("db:database:hide"); err != nil || name != "db" || alias != "database" || !hide {
+		t.Fatalf("Expected db, database and hide, got %s, %s, %t (%v)", name, alias, hide, err)
+	}
+	for _, invalid := range []string{"db", "db:database:show", "db:database:hide:hide"} {
+		if _, _, _, err := ParseLink(invalid); err == nil {
+			t.Fatalf("Expected %q to be invalid", invalid)
+		}
+	}
+}
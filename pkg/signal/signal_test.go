@@ -0,0 +1,26 @@
+package signal
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, raw := range []string{"SIGTERM", "TERM", "term", "15"} {
+		sig, err := ParseSignal(raw)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %s", raw, err)
+		}
+		if sig != syscall.SIGTERM {
+			t.Fatalf("expected %q to be parsed as SIGTERM, got %d", raw, sig)
+		}
+	}
+}
+
+func TestParseSignalInvalid(t *testing.T) {
+	for _, raw := range []string{"", "0", "32", "SIGNOTREAL"} {
+		if _, err := ParseSignal(raw); err == nil {
+			t.Fatalf("expected an error parsing %q", raw)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"bytes"
 	"syscall"
 	"unsafe"
 )
@@ -34,6 +35,35 @@ func Lgetxattr(path string, attr string) ([]byte, error) {
 	return dest[:sz], nil
 }
 
+// Returns the names of the xattrs set on path
+func Llistxattr(path string) ([]string, error) {
+	pathBytes, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sz, _, errno := syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(pathBytes)), 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	if sz == 0 {
+		return nil, nil
+	}
+	dest := make([]byte, sz)
+	sz, _, errno = syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(pathBytes)), uintptr(unsafe.Pointer(&dest[0])), uintptr(len(dest)))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	var names []string
+	for _, name := range bytes.Split(dest[:sz], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
 var _zero uintptr
 
 func Lsetxattr(path string, attr string, data []byte, flags int) error {
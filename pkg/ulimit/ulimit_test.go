@@ -0,0 +1,66 @@
+package ulimit
+
+import "testing"
+
+func TestParseInvalidLimitType(t *testing.T) {
+	if _, err := Parse("notarealtype=1024:1024"); err == nil {
+		t.Fatalf("expected error on invalid ulimit type")
+	}
+}
+
+func TestParseBadFormat(t *testing.T) {
+	if _, err := Parse("nofile:1024:1024"); err == nil {
+		t.Fatal("expected error on bad syntax")
+	}
+
+	if _, err := Parse("nofile"); err == nil {
+		t.Fatal("expected error on bad syntax")
+	}
+
+	if _, err := Parse("nofile="); err == nil {
+		t.Fatal("expected error on bad syntax")
+	}
+	if _, err := Parse("nofile=:"); err == nil {
+		t.Fatal("expected error on bad syntax")
+	}
+	if _, err := Parse("nofile=:1024"); err == nil {
+		t.Fatal("expected error on bad syntax")
+	}
+}
+
+func TestParseHardLessThanSoft(t *testing.T) {
+	if _, err := Parse("nofile=1024:1"); err == nil {
+		t.Fatal("expected error on hard limit less than soft limit")
+	}
+}
+
+func TestParseSoftOnly(t *testing.T) {
+	u, err := Parse("nofile=1024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Soft != 1024 || u.Hard != 1024 {
+		t.Fatalf("expected soft and hard to be 1024, got %d:%d", u.Soft, u.Hard)
+	}
+}
+
+func TestStringOutput(t *testing.T) {
+	u := &Ulimit{Name: "nofile", Hard: 1024, Soft: 512}
+	if s := u.String(); s != "nofile=512:1024" {
+		t.Fatal("expected String to return nofile=512:1024, but got", s)
+	}
+}
+
+func TestRlimitName(t *testing.T) {
+	u := &Ulimit{Name: "memlock", Hard: 64, Soft: 64}
+	r, err := u.GetRlimit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Type != RLIMIT_MEMLOCK {
+		t.Fatalf("expected type %d, got %d", RLIMIT_MEMLOCK, r.Type)
+	}
+	if name := r.Name(); name != "memlock" {
+		t.Fatalf("expected name memlock, got %s", name)
+	}
+}
@@ -5,6 +5,14 @@ import (
 	"github.com/docker/docker/nat"
 )
 
+const (
+	// DefaultStopSignal is sent to the container's process when it is stopped
+	DefaultStopSignal = "SIGTERM"
+	// DefaultStopTimeout is the number of seconds a container is given to
+	// exit after the stop signal before it is killed
+	DefaultStopTimeout = 10
+)
+
 // Note: the Config structure should hold only portable information about the container.
 // Here, "portable" means "independent from the host we are running on".
 // Non-portable information *should* appear in HostConfig.
@@ -16,6 +24,7 @@ type Config struct {
 	MemorySwap      int64  // Total memory usage (memory + swap); set `-1' to disable swap
 	CpuShares       int64  // CPU shares (relative weight vs. other containers)
 	Cpuset          string // Cpuset 0-2, 0,1
+	CpusetMems      string // Memory nodes for Cpuset 0-2, 0,1
 	AttachStdin     bool
 	AttachStdout    bool
 	AttachStderr    bool
@@ -32,6 +41,9 @@ type Config struct {
 	Entrypoint      []string
 	NetworkDisabled bool
 	MacAddress      string
+	IPAddress       string // IPv4 address of the container on its network, allocated otherwise
+	StopSignal      string // Signal to stop the container with, SIGTERM by default
+	StopTimeout     int    // Seconds to wait for the container to stop before it is killed
 	OnBuild         []string
 }
 
@@ -44,6 +56,7 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 		MemorySwap:      job.GetenvInt64("MemorySwap"),
 		CpuShares:       job.GetenvInt64("CpuShares"),
 		Cpuset:          job.Getenv("Cpuset"),
+		CpusetMems:      job.Getenv("CpusetMems"),
 		AttachStdin:     job.GetenvBool("AttachStdin"),
 		AttachStdout:    job.GetenvBool("AttachStdout"),
 		AttachStderr:    job.GetenvBool("AttachStderr"),
@@ -54,6 +67,9 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 		WorkingDir:      job.Getenv("WorkingDir"),
 		NetworkDisabled: job.GetenvBool("NetworkDisabled"),
 		MacAddress:      job.Getenv("MacAddress"),
+		IPAddress:       job.Getenv("IPAddress"),
+		StopSignal:      job.Getenv("StopSignal"),
+		StopTimeout:     job.GetenvInt("StopTimeout"),
 	}
 	job.GetenvJson("ExposedPorts", &config.ExposedPorts)
 	job.GetenvJson("Volumes", &config.Volumes)
@@ -2,9 +2,13 @@ package runconfig
 
 import (
 	"fmt"
+	"path"
+	"time"
 
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/utils"
 )
 
@@ -17,19 +21,32 @@ type ExecConfig struct {
 	AttachStderr bool
 	AttachStdout bool
 	Detach       bool
+	Env          []string
+	WorkingDir   string
+	WaitUnpause  int
+	Memory       int64 // Memory limit (in bytes)
+	CpuShares    int64 // CPU shares (relative weight vs. other execs of the container)
+	Timeout      int   // Seconds after which the command is killed, 0 for none
 	Cmd          []string
 }
 
 func ExecConfigFromJob(job *engine.Job) (*ExecConfig, error) {
 	execConfig := &ExecConfig{
-		// TODO(vishh): Expose 'User' once it is supported.
-		//User:         job.Getenv("User"),
-		// TODO(vishh): Expose 'Privileged' once it is supported.
-		//Privileged:   job.GetenvBool("Privileged"),
+		User:         job.Getenv("User"),
+		Privileged:   job.GetenvBool("Privileged"),
 		Tty:          job.GetenvBool("Tty"),
 		AttachStdin:  job.GetenvBool("AttachStdin"),
 		AttachStderr: job.GetenvBool("AttachStderr"),
 		AttachStdout: job.GetenvBool("AttachStdout"),
+		Env:          job.GetenvList("Env"),
+		WorkingDir:   job.Getenv("WorkingDir"),
+		WaitUnpause:  job.GetenvInt("WaitUnpause"),
+		Memory:       job.GetenvInt64("Memory"),
+		CpuShares:    job.GetenvInt64("CpuShares"),
+		Timeout:      job.GetenvInt("Timeout"),
+	}
+	if execConfig.WorkingDir != "" && !path.IsAbs(execConfig.WorkingDir) {
+		return nil, ErrInvalidWorkingDirectory
 	}
 	cmd := job.GetenvList("Cmd")
 	if len(cmd) == 0 {
@@ -43,38 +60,64 @@ func ExecConfigFromJob(job *engine.Job) (*ExecConfig, error) {
 
 func ParseExec(cmd *flag.FlagSet, args []string) (*ExecConfig, error) {
 	var (
-		flStdin   = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
-		flTty     = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
-		flDetach  = cmd.Bool([]string{"d", "-detach"}, false, "Detached mode: run command in the background")
-		execCmd   []string
-		container string
+		flStdin       = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
+		flTty         = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
+		flDetach      = cmd.Bool([]string{"d", "-detach"}, false, "Detached mode: run command in the background")
+		flUser        = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
+		flEnv         = opts.NewListOpts(opts.ValidateEnv)
+		flWorkDir     = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
+		flPrivileged  = cmd.Bool([]string{"-privileged"}, false, "Give extended privileges to the command")
+		flWaitUnpause = cmd.Int([]string{"-wait-unpause"}, 0, "Seconds to wait for a paused container to be unpaused, 0 to fail right away")
+		flMemory      = cmd.String([]string{"m", "-memory"}, "", "Memory limit of the command within the container's (format: <number><optional unit>, where unit = b, k, m or g)")
+		flCpuShares   = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares of the command within the container's (relative weight)")
+		flTimeout     = cmd.Duration([]string{"-timeout"}, 0, "Kill the command if it runs longer than this duration (e.g. 300s), 0 for no limit")
+		execCmd       []string
+		container     string
 	)
+	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
 	cmd.Require(flag.Min, 2)
 	if err := utils.ParseFlags(cmd, args, true); err != nil {
 		return nil, err
 	}
+	if *flWorkDir != "" && !path.IsAbs(*flWorkDir) {
+		return nil, ErrInvalidWorkingDirectory
+	}
+	var memory int64
+	if *flMemory != "" {
+		parsedMemory, err := units.RAMInBytes(*flMemory)
+		if err != nil {
+			return nil, err
+		}
+		memory = parsedMemory
+	}
 	container = cmd.Arg(0)
 	parsedArgs := cmd.Args()
 	execCmd = parsedArgs[1:]
 
 	execConfig := &ExecConfig{
-		// TODO(vishh): Expose '-u' flag once it is supported.
-		User: "",
-		// TODO(vishh): Expose '-p' flag once it is supported.
-		Privileged: false,
-		Tty:        *flTty,
-		Cmd:        execCmd,
-		Container:  container,
-		Detach:     *flDetach,
+		User:        *flUser,
+		Privileged:  *flPrivileged,
+		Tty:         *flTty,
+		Cmd:         execCmd,
+		Container:   container,
+		Detach:      *flDetach,
+		Env:         flEnv.GetAll(),
+		WorkingDir:  *flWorkDir,
+		WaitUnpause: *flWaitUnpause,
+		Memory:      memory,
+		CpuShares:   *flCpuShares,
+		// the API takes whole seconds
+		Timeout: int((*flTimeout + time.Second - 1) / time.Second),
 	}
 
 	// If -d is not set, attach to everything by default
 	if !*flDetach {
 		execConfig.AttachStdout = true
 		execConfig.AttachStderr = true
-		if *flStdin {
-			execConfig.AttachStdin = true
-		}
+	}
+	// With -d, STDIN is kept open for `docker exec attach`
+	if *flStdin {
+		execConfig.AttachStdin = true
 	}
 
 	return execConfig, nil
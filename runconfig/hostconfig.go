@@ -1,15 +1,30 @@
 package runconfig
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/utils"
 )
 
 type NetworkMode string
 
+var validNetworkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// ValidNetworkName indicates whether name can name a user-defined network,
+// which must not be one of the other network modes
+func ValidNetworkName(name string) bool {
+	switch name {
+	case "bridge", "host", "none", "container":
+		return false
+	}
+	return validNetworkNamePattern.MatchString(name)
+}
+
 // IsPrivate indicates whether container use it's private network stack
 func (n NetworkMode) IsPrivate() bool {
 	return !(n.IsHost() || n.IsContainer() || n.IsNone())
@@ -28,6 +43,23 @@ func (n NetworkMode) IsNone() bool {
 	return n == "none"
 }
 
+// IsBridge indicates whether the container is on the default bridge
+func (n NetworkMode) IsBridge() bool {
+	return n == "bridge" || n == "" // empty string to support existing containers
+}
+
+// IsUserDefined indicates whether the container is on a network created
+// with `docker network create`, the mode being the name of the network
+func (n NetworkMode) IsUserDefined() bool {
+	return n.IsPrivate() && !n.IsBridge()
+}
+
+// PublishesPorts returns whether the ports of the container are published on
+// the host, which is only supported on the default bridge
+func (c *HostConfig) PublishesPorts() bool {
+	return len(c.PortBindings) > 0 || c.PublishAllPorts
+}
+
 type IpcMode string
 
 // IsPrivate indicates whether container use it's private ipc stack
@@ -87,6 +119,27 @@ func (n PidMode) Valid() bool {
 	return true
 }
 
+type UTSMode string
+
+// IsPrivate indicates whether container use it's private UTS namespace
+func (n UTSMode) IsPrivate() bool {
+	return !(n.IsHost())
+}
+
+func (n UTSMode) IsHost() bool {
+	return n == "host"
+}
+
+func (n UTSMode) Valid() bool {
+	parts := strings.Split(string(n), ":")
+	switch mode := parts[0]; mode {
+	case "", "host":
+	default:
+		return false
+	}
+	return true
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
@@ -96,29 +149,80 @@ type DeviceMapping struct {
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+	// Delay is the delay before the first restart, in milliseconds.
+	// Multiplier multiplies it after each restart following a short run, up
+	// to MaxDelay milliseconds if it is set, and Jitter is the fraction of
+	// the delay randomly added or removed. The ones left to 0 are set to the
+	// defaults of the daemon.
+	Delay      int
+	MaxDelay   int
+	Multiplier float64
+	Jitter     float64
+	// ResetWindow is how long the container has to run, in milliseconds,
+	// for its failures to be counted again from 0 by on-failure, 0 to only
+	// count them again after it exits successfully
+	ResetWindow int
+	// StabilizeDelay is a fixed delay added before each restart, in
+	// milliseconds
+	StabilizeDelay int
+}
+
+// ValidateBackoff returns an error if the delay settings or the reset window
+// of the policy are out of range
+func (rp RestartPolicy) ValidateBackoff() error {
+	switch {
+	case rp.Delay < 0 || rp.MaxDelay < 0 || rp.StabilizeDelay < 0:
+		return fmt.Errorf("Invalid restart delay: it must not be negative")
+	case rp.ResetWindow < 0:
+		return fmt.Errorf("Invalid restart reset window: it must not be negative")
+	case rp.Multiplier != 0 && rp.Multiplier < 1:
+		return fmt.Errorf("Invalid restart multiplier %g: it must be at least 1", rp.Multiplier)
+	case rp.Jitter < 0 || rp.Jitter > 1:
+		return fmt.Errorf("Invalid restart jitter %g: it must be between 0 and 1", rp.Jitter)
+	}
+	return nil
+}
+
+// Validate returns an error if the policy is unknown, if it has a maximum
+// restart count without being on-failure, or if its backoff is out of range
+func (rp RestartPolicy) Validate() error {
+	switch rp.Name {
+	case "", "no", "always", "unless-stopped", "on-failure":
+	default:
+		return fmt.Errorf("invalid restart policy %s", rp.Name)
+	}
+	if rp.MaximumRetryCount != 0 && rp.Name != "on-failure" {
+		return fmt.Errorf("maximum restart count not valid with restart policy of \"%s\"", rp.Name)
+	}
+	return rp.ValidateBackoff()
 }
 
 type HostConfig struct {
-	Binds           []string
-	ContainerIDFile string
-	LxcConf         []utils.KeyValuePair
-	Privileged      bool
-	PortBindings    nat.PortMap
-	Links           []string
-	PublishAllPorts bool
-	Dns             []string
-	DnsSearch       []string
-	ExtraHosts      []string
-	VolumesFrom     []string
-	Devices         []DeviceMapping
-	NetworkMode     NetworkMode
-	IpcMode         IpcMode
-	PidMode         PidMode
-	CapAdd          []string
-	CapDrop         []string
-	RestartPolicy   RestartPolicy
-	SecurityOpt     []string
-	ReadonlyRootfs  bool
+	Binds             []string
+	ContainerIDFile   string
+	LxcConf           []utils.KeyValuePair
+	Privileged        bool
+	PortBindings      nat.PortMap
+	Links             []string
+	PublishAllPorts   bool
+	Dns               []string
+	DnsSearch         []string
+	ExtraHosts        []string
+	VolumesFrom       []string
+	Devices           []DeviceMapping
+	DeviceCgroupRules []string
+	Tmpfs             map[string]string
+	Gpus              bool
+	NetworkMode       NetworkMode
+	IpcMode           IpcMode
+	PidMode           PidMode
+	UTSMode           UTSMode
+	CapAdd            []string
+	CapDrop           []string
+	RestartPolicy     RestartPolicy
+	SecurityOpt       []string
+	ReadonlyRootfs    bool
+	Ulimits           []*ulimit.Ulimit
 }
 
 // This is used by the create command when you want to set both the
@@ -149,14 +253,19 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 		NetworkMode:     NetworkMode(job.Getenv("NetworkMode")),
 		IpcMode:         IpcMode(job.Getenv("IpcMode")),
 		PidMode:         PidMode(job.Getenv("PidMode")),
+		UTSMode:         UTSMode(job.Getenv("UTSMode")),
 		ReadonlyRootfs:  job.GetenvBool("ReadonlyRootfs"),
+		Gpus:            job.GetenvBool("Gpus"),
 	}
 
 	job.GetenvJson("LxcConf", &hostConfig.LxcConf)
 	job.GetenvJson("PortBindings", &hostConfig.PortBindings)
 	job.GetenvJson("Devices", &hostConfig.Devices)
 	job.GetenvJson("RestartPolicy", &hostConfig.RestartPolicy)
+	job.GetenvJson("Ulimits", &hostConfig.Ulimits)
+	job.GetenvJson("Tmpfs", &hostConfig.Tmpfs)
 	hostConfig.SecurityOpt = job.GetenvList("SecurityOpt")
+	hostConfig.DeviceCgroupRules = job.GetenvList("DeviceCgroupRules")
 	if Binds := job.GetenvList("Binds"); Binds != nil {
 		hostConfig.Binds = Binds
 	}
@@ -2,14 +2,18 @@ package runconfig
 
 import (
 	"fmt"
+	"net"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/signal"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/utils"
 )
@@ -21,6 +25,11 @@ var (
 	ErrConflictNetworkHostname          = fmt.Errorf("Conflicting options: -h and the network mode (--net)")
 	ErrConflictHostNetworkAndDns        = fmt.Errorf("Conflicting options: --net=host can't be used with --dns. This configuration is invalid.")
 	ErrConflictHostNetworkAndLinks      = fmt.Errorf("Conflicting options: --net=host can't be used with links. This would result in undefined behavior.")
+	ErrConflictUTSHostname              = fmt.Errorf("Conflicting options: -h and the UTS mode (--uts)")
+	ErrConflictNetworkAndIP             = fmt.Errorf("Conflicting options: --ip and the network mode (--net)")
+	ErrConflictNetworkAndMac            = fmt.Errorf("Conflicting options: --mac-address and the network mode (--net)")
+	ErrConflictNetworkAndPublish        = fmt.Errorf("Conflicting options: -p, -P and a user-defined network (--net), ports are only published on the default bridge")
+	ErrInvalidStopTimeout               = fmt.Errorf("Invalid value for --stop-timeout: it must be a positive number of seconds")
 )
 
 func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSet, error) {
@@ -32,6 +41,9 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flEnv     = opts.NewListOpts(opts.ValidateEnv)
 		flDevices = opts.NewListOpts(opts.ValidatePath)
 
+		flDeviceCgroupRules = opts.NewListOpts(opts.ValidateDeviceCgroupRule)
+		flTmpfs             = opts.NewListOpts(nil)
+
 		flPublish     = opts.NewListOpts(nil)
 		flExpose      = opts.NewListOpts(nil)
 		flDns         = opts.NewListOpts(opts.ValidateIPAddress)
@@ -44,9 +56,13 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flCapDrop     = opts.NewListOpts(nil)
 		flSecurityOpt = opts.NewListOpts(nil)
 
+		ulimits   = make(map[string]*ulimit.Ulimit)
+		flUlimits = opts.NewUlimitOpt(ulimits)
+
 		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
 		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
 		flPidMode         = cmd.String([]string{"-pid"}, "", "Default is to create a private PID namespace for the container\n'host': use the host PID namespace inside the container.  Note: the host mode gives the container full access to processes on the system and is therefore considered insecure.")
+		flUTSMode         = cmd.String([]string{"-uts"}, "", "Default is to create a private UTS namespace for the container\n'host': use the host UTS namespace inside the container.  Note: the host mode gives the container the ability to change the host's hostname and is therefore considered insecure.")
 		flPublishAll      = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to random ports on the host interfaces")
 		flStdin           = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty             = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
@@ -59,17 +75,31 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flWorkingDir      = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
 		flCpuShares       = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
 		flCpuset          = cmd.String([]string{"-cpuset"}, "", "CPUs in which to allow execution (0-3, 0,1)")
-		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container\n'bridge': creates a new network stack for the container on the docker bridge\n'none': no networking for this container\n'container:<name|id>': reuses another container network stack\n'host': use the host network stack inside the container.  Note: the host mode gives the container full access to local system services such as D-bus and is therefore considered insecure.")
+		flCpusetMems      = cmd.String([]string{"-cpuset-mems"}, "", "Memory nodes (MEMs) in which to allow execution (0-3, 0,1). Only effective on NUMA systems.")
+		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container\n'bridge': creates a new network stack for the container on the docker bridge\n'none': no networking for this container\n'container:<name|id>': reuses another container network stack\n'host': use the host network stack inside the container.  Note: the host mode gives the container full access to local system services such as D-bus and is therefore considered insecure.\n'<network>': connects the container to a network created with `docker network create`")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
+		flIPAddress       = cmd.String([]string{"-ip"}, "", "Container IPv4 address (e.g. 172.17.0.42), in the subnet of its network")
+		flStopSignal      = cmd.String([]string{"-stop-signal"}, DefaultStopSignal, "Signal to stop the container with")
+		flStopTimeout     = cmd.Int([]string{"-stop-timeout"}, DefaultStopTimeout, "Number of seconds to wait for the container to stop before killing it")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "Default is to create a private IPC namespace (POSIX SysV IPC) for the container\n'container:<name|id>': reuses another container shared memory, semaphores and message queues\n'host': use the host shared memory,semaphores and message queues inside the container.  Note: the host mode gives the container full access to local shared memory and is therefore considered insecure.")
-		flRestartPolicy   = cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits (no, on-failure[:max-retry], always)")
+		flRestartPolicy   = cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits (no, on-failure[:max-retry], always, unless-stopped)")
+		flRestartDelay    = cmd.Duration([]string{"-restart-delay"}, 0, "Delay before the first restart of the container (e.g. 500ms), 0 for the default of the daemon")
+		flRestartMaxDelay = cmd.Duration([]string{"-restart-max-delay"}, 0, "Maximum delay between two restarts of the container (e.g. 1m), 0 for the default of the daemon")
+		flRestartFactor   = cmd.Float64([]string{"-restart-multiplier"}, 0, "Factor the restart delay is multiplied by after each restart, 0 for the default of the daemon")
+		flRestartJitter   = cmd.Float64([]string{"-restart-jitter"}, 0, "Fraction of the restart delay randomly added or removed, from 0 to 1, 0 for the default of the daemon")
+		flRestartWindow   = cmd.Duration([]string{"-restart-reset-window"}, 0, "Run time after which the failures counted by on-failure start again from 0 (e.g. 24h)")
+		flRestartSettle   = cmd.Duration([]string{"-restart-stabilize-delay"}, 0, "Fixed delay added before each restart of the container, on top of the restart delay (e.g. 5s)")
 		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
+		flGpus            = cmd.Bool([]string{"-gpus"}, false, "(lxc exec-driver only) Give the container access to the host's NVIDIA and DRI GPU devices")
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR.")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume (e.g., from the host: -v /host:/container, from Docker: -v /container)")
-	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container in the form of <name|id>:alias")
+	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container in the form of <name|id>:alias, or <name|id>:alias1,alias2, followed by :hide to hide the alias while the container is stopped")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container (e.g. --device=/dev/sdc:/dev/xvdc:rwm)")
+	cmd.Var(&flDeviceCgroupRules, []string{"-device-cgroup-rule"}, "Add a rule to the cgroup allowed devices list (e.g. --device-cgroup-rule=\"c 189:* rwm\")")
+
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory (e.g. --tmpfs=/run:rw,size=64m)")
 
 	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
 	cmd.Var(&flEnvFile, []string{"-env-file"}, "Read in a line delimited file of environment variables")
@@ -85,6 +115,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flCapAdd, []string{"-cap-add"}, "Add Linux capabilities")
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
+	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options (e.g. --ulimit=nofile=1024:2048)")
 
 	cmd.Require(flag.Min, 1)
 
@@ -103,10 +134,46 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		attachStderr = flAttach.Get("stderr")
 	)
 
-	if *flNetMode != "bridge" && *flNetMode != "none" && *flHostname != "" {
+	if _, err := signal.ParseSignal(*flStopSignal); err != nil {
+		return nil, nil, cmd, err
+	}
+
+	if *flStopTimeout <= 0 {
+		return nil, nil, cmd, ErrInvalidStopTimeout
+	}
+
+	if (*flNetMode == "host" || strings.HasPrefix(*flNetMode, "container:")) && *flHostname != "" {
 		return nil, nil, cmd, ErrConflictNetworkHostname
 	}
 
+	if *flUTSMode == "host" && *flHostname != "" {
+		return nil, nil, cmd, ErrConflictUTSHostname
+	}
+
+	if *flIPAddress != "" {
+		if ip := net.ParseIP(*flIPAddress); ip == nil || ip.To4() == nil {
+			return nil, nil, cmd, fmt.Errorf("Invalid IPv4 address: %s", *flIPAddress)
+		}
+		if !NetworkMode(*flNetMode).IsPrivate() {
+			return nil, nil, cmd, ErrConflictNetworkAndIP
+		}
+	}
+
+	if *flMacAddress != "" {
+		mac, err := opts.ValidateMACAddress(*flMacAddress)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		*flMacAddress = mac
+		if !NetworkMode(*flNetMode).IsPrivate() {
+			return nil, nil, cmd, ErrConflictNetworkAndMac
+		}
+	}
+
+	if NetworkMode(*flNetMode).IsUserDefined() && (flPublish.Len() > 0 || *flPublishAll) {
+		return nil, nil, cmd, ErrConflictNetworkAndPublish
+	}
+
 	if *flNetMode == "host" && flLinks.Len() > 0 {
 		return nil, nil, cmd, ErrConflictHostNetworkAndLinks
 	}
@@ -236,6 +303,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
 
+	tmpfs, err := parseTmpfs(flTmpfs.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	// collect all the environment variables for the container
 	envVariables := []string{}
 	for _, ef := range flEnvFile.GetAll() {
@@ -258,15 +330,29 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, fmt.Errorf("--pid: invalid PID mode")
 	}
 
+	utsMode := UTSMode(*flUTSMode)
+	if !utsMode.Valid() {
+		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
+	}
+
 	netMode, err := parseNetMode(*flNetMode)
 	if err != nil {
 		return nil, nil, cmd, fmt.Errorf("--net: invalid net mode: %v", err)
 	}
 
-	restartPolicy, err := parseRestartPolicy(*flRestartPolicy)
+	restartPolicy, err := ParseRestartPolicy(*flRestartPolicy)
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	restartPolicy.Delay = int(*flRestartDelay / time.Millisecond)
+	restartPolicy.MaxDelay = int(*flRestartMaxDelay / time.Millisecond)
+	restartPolicy.Multiplier = *flRestartFactor
+	restartPolicy.Jitter = *flRestartJitter
+	restartPolicy.ResetWindow = int(*flRestartWindow / time.Millisecond)
+	restartPolicy.StabilizeDelay = int(*flRestartSettle / time.Millisecond)
+	if err := restartPolicy.ValidateBackoff(); err != nil {
+		return nil, nil, cmd, err
+	}
 
 	config := &Config{
 		Hostname:        hostname,
@@ -281,6 +367,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		MemorySwap:      MemorySwap,
 		CpuShares:       *flCpuShares,
 		Cpuset:          *flCpuset,
+		CpusetMems:      *flCpusetMems,
 		AttachStdin:     attachStdin,
 		AttachStdout:    attachStdout,
 		AttachStderr:    attachStderr,
@@ -289,31 +376,39 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		Image:           image,
 		Volumes:         flVolumes.GetMap(),
 		MacAddress:      *flMacAddress,
+		IPAddress:       *flIPAddress,
+		StopSignal:      *flStopSignal,
+		StopTimeout:     *flStopTimeout,
 		Entrypoint:      entrypoint,
 		WorkingDir:      *flWorkingDir,
 	}
 
 	hostConfig := &HostConfig{
-		Binds:           binds,
-		ContainerIDFile: *flContainerIDFile,
-		LxcConf:         lxcConf,
-		Privileged:      *flPrivileged,
-		PortBindings:    portBindings,
-		Links:           flLinks.GetAll(),
-		PublishAllPorts: *flPublishAll,
-		Dns:             flDns.GetAll(),
-		DnsSearch:       flDnsSearch.GetAll(),
-		ExtraHosts:      flExtraHosts.GetAll(),
-		VolumesFrom:     flVolumesFrom.GetAll(),
-		NetworkMode:     netMode,
-		IpcMode:         ipcMode,
-		PidMode:         pidMode,
-		Devices:         deviceMappings,
-		CapAdd:          flCapAdd.GetAll(),
-		CapDrop:         flCapDrop.GetAll(),
-		RestartPolicy:   restartPolicy,
-		SecurityOpt:     flSecurityOpt.GetAll(),
-		ReadonlyRootfs:  *flReadonlyRootfs,
+		Binds:             binds,
+		ContainerIDFile:   *flContainerIDFile,
+		LxcConf:           lxcConf,
+		Privileged:        *flPrivileged,
+		PortBindings:      portBindings,
+		Links:             flLinks.GetAll(),
+		PublishAllPorts:   *flPublishAll,
+		Dns:               flDns.GetAll(),
+		DnsSearch:         flDnsSearch.GetAll(),
+		ExtraHosts:        flExtraHosts.GetAll(),
+		VolumesFrom:       flVolumesFrom.GetAll(),
+		NetworkMode:       netMode,
+		IpcMode:           ipcMode,
+		PidMode:           pidMode,
+		UTSMode:           utsMode,
+		Devices:           deviceMappings,
+		DeviceCgroupRules: flDeviceCgroupRules.GetAll(),
+		Tmpfs:             tmpfs,
+		Gpus:              *flGpus,
+		CapAdd:            flCapAdd.GetAll(),
+		CapDrop:           flCapDrop.GetAll(),
+		RestartPolicy:     restartPolicy,
+		SecurityOpt:       flSecurityOpt.GetAll(),
+		ReadonlyRootfs:    *flReadonlyRootfs,
+		Ulimits:           flUlimits.GetList(),
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
@@ -323,8 +418,8 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	return config, hostConfig, cmd, nil
 }
 
-// parseRestartPolicy returns the parsed policy or an error indicating what is incorrect
-func parseRestartPolicy(policy string) (RestartPolicy, error) {
+// ParseRestartPolicy returns the parsed policy or an error indicating what is incorrect
+func ParseRestartPolicy(policy string) (RestartPolicy, error) {
 	p := RestartPolicy{}
 
 	if policy == "" {
@@ -338,9 +433,9 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 
 	p.Name = name
 	switch name {
-	case "always":
+	case "always", "unless-stopped":
 		if len(parts) == 2 {
-			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"always\"")
+			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"%s\"", name)
 		}
 	case "no":
 		// do nothing
@@ -400,7 +495,10 @@ func parseNetMode(netMode string) (NetworkMode, error) {
 			return "", fmt.Errorf("invalid container format container:<name|id>")
 		}
 	default:
-		return "", fmt.Errorf("invalid --net: %s", netMode)
+		// a user-defined network
+		if len(parts) > 1 || !ValidNetworkName(mode) {
+			return "", fmt.Errorf("invalid --net: %s", netMode)
+		}
 	}
 	return NetworkMode(netMode), nil
 }
@@ -434,3 +532,27 @@ func ParseDevice(device string) (DeviceMapping, error) {
 	}
 	return deviceMapping, nil
 }
+
+// parseTmpfs parses --tmpfs values of the form dest[:options] into a map of
+// container paths to tmpfs mount options.
+func parseTmpfs(tmpfs []string) (map[string]string, error) {
+	mounts := make(map[string]string)
+	for _, t := range tmpfs {
+		var (
+			arr  = strings.SplitN(t, ":", 2)
+			dest = path.Clean(arr[0])
+			data string
+		)
+		if len(arr) > 1 {
+			data = arr[1]
+		}
+		if !path.IsAbs(dest) {
+			return nil, fmt.Errorf("Invalid tmpfs mount: %s is not an absolute path", arr[0])
+		}
+		if dest == "/" {
+			return nil, fmt.Errorf("Invalid tmpfs mount: destination can't be '/'")
+		}
+		mounts[dest] = data
+	}
+	return mounts, nil
+}
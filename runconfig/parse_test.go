@@ -57,3 +57,163 @@ func TestNetHostname(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
 	}
 }
+
+func TestNetUserDefined(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"-h=name", "--net=backend", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !hostConfig.NetworkMode.IsUserDefined() || !hostConfig.NetworkMode.IsPrivate() {
+		t.Fatalf("Expected a user-defined network, got %s", hostConfig.NetworkMode)
+	}
+
+	for _, netMode := range []string{"bridge", "none", "host", "container:other"} {
+		if NetworkMode(netMode).IsUserDefined() {
+			t.Fatalf("Expected %s not to be a user-defined network", netMode)
+		}
+	}
+
+	for _, publish := range []string{"-p=80:80", "-P"} {
+		if _, _, _, err := parseRun([]string{publish, "--net=backend", "img", "cmd"}); err != ErrConflictNetworkAndPublish {
+			t.Fatalf("Expected error ErrConflictNetworkAndPublish for %s, got: %v", publish, err)
+		}
+	}
+
+	for _, netMode := range []string{"-backend", "back:end", "a"} {
+		if _, _, _, err := parseRun([]string{"--net=" + netMode, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected --net=%s to be invalid", netMode)
+		}
+	}
+}
+
+func TestUTSHostname(t *testing.T) {
+	if _, _, _, err := parseRun([]string{"--uts=host", "img", "cmd"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, _, _, err := parseRun([]string{"-h=name", "--uts=host", "img", "cmd"}); err != ErrConflictUTSHostname {
+		t.Fatalf("Expected error ErrConflictUTSHostname, got: %s", err)
+	}
+
+	if _, _, _, err := parseRun([]string{"--uts=container:other", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an invalid UTS mode")
+	}
+}
+
+func TestIPAddress(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--ip=172.17.0.42", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.IPAddress != "172.17.0.42" {
+		t.Fatalf("Expected the address 172.17.0.42, got %s", config.IPAddress)
+	}
+
+	for _, invalid := range []string{"172.17.0", "2001:db8::1"} {
+		if _, _, _, err := parseRun([]string{"--ip=" + invalid, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for the address %s", invalid)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--ip=172.17.0.42", "--net=host", "img", "cmd"}); err != ErrConflictNetworkAndIP {
+		t.Fatalf("Expected error ErrConflictNetworkAndIP, got: %v", err)
+	}
+}
+
+func TestMacAddress(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--mac-address=92:D0:C6:0A:29:33", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.MacAddress != "92:d0:c6:0a:29:33" {
+		t.Fatalf("Expected the MAC address 92:d0:c6:0a:29:33, got %s", config.MacAddress)
+	}
+
+	if _, _, _, err := parseRun([]string{"--mac-address=92:d0:c6", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an invalid MAC address")
+	}
+
+	if _, _, _, err := parseRun([]string{"--mac-address=92:d0:c6:0a:29:33", "--net=none", "img", "cmd"}); err != ErrConflictNetworkAndMac {
+		t.Fatalf("Expected error ErrConflictNetworkAndMac, got: %v", err)
+	}
+}
+
+func TestParseTmpfs(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--tmpfs=/run:rw,size=64m", "--tmpfs=/tmp/", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if opts, ok := hostConfig.Tmpfs["/run"]; !ok || opts != "rw,size=64m" {
+		t.Fatalf("Expected /run to be mounted with rw,size=64m, got %v", hostConfig.Tmpfs)
+	}
+	if opts, ok := hostConfig.Tmpfs["/tmp"]; !ok || opts != "" {
+		t.Fatalf("Expected /tmp to be mounted without options, got %v", hostConfig.Tmpfs)
+	}
+
+	if _, _, _, err := parseRun([]string{"--tmpfs=run", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a relative tmpfs destination")
+	}
+
+	if _, _, _, err := parseRun([]string{"--tmpfs=/", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a tmpfs mounted on /")
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, name := range []string{"always", "unless-stopped"} {
+		_, hostConfig, _, err := parseRun([]string{"--restart=" + name, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if hostConfig.RestartPolicy.Name != name {
+			t.Fatalf("Expected the restart policy %s, got %s", name, hostConfig.RestartPolicy.Name)
+		}
+		if _, _, _, err := parseRun([]string{"--restart=" + name + ":3", "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for a maximum restart count with %s", name)
+		}
+	}
+
+	_, hostConfig, _, err := parseRun([]string{"--restart=on-failure:3", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.RestartPolicy.Name != "on-failure" || hostConfig.RestartPolicy.MaximumRetryCount != 3 {
+		t.Fatalf("Expected the restart policy on-failure:3, got %v", hostConfig.RestartPolicy)
+	}
+
+	if _, _, _, err := parseRun([]string{"--restart=sometimes", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for an invalid restart policy")
+	}
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--stop-signal=SIGINT", "--stop-timeout=30", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.StopSignal != "SIGINT" || config.StopTimeout != 30 {
+		t.Fatalf("Unexpected stop signal and timeout: %s, %d", config.StopSignal, config.StopTimeout)
+	}
+
+	for _, invalid := range []string{"--stop-timeout=0", "--stop-timeout=-1", "--stop-signal=SIGFOO", "--stop-signal=0"} {
+		if _, _, _, err := parseRun([]string{invalid, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestParseRestartBackoff(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--restart=always", "--restart-delay=500ms", "--restart-max-delay=1m", "--restart-multiplier=1.5", "--restart-jitter=0.1", "--restart-reset-window=24h", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if p := hostConfig.RestartPolicy; p.Delay != 500 || p.MaxDelay != 60000 || p.Multiplier != 1.5 || p.Jitter != 0.1 || p.ResetWindow != 86400000 {
+		t.Fatalf("Unexpected restart policy: %+v", p)
+	}
+
+	for _, invalid := range []string{"--restart-delay=-1s", "--restart-multiplier=0.5", "--restart-jitter=2", "--restart-reset-window=-1h"} {
+		if _, _, _, err := parseRun([]string{"--restart=always", invalid, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}